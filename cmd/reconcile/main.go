@@ -0,0 +1,51 @@
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/joho/godotenv"
+	"github.com/padapook/bestbit-core/internal/database"
+	"github.com/padapook/bestbit-core/internal/reconciliation/model"
+	"github.com/padapook/bestbit-core/internal/reconciliation/repository"
+	"github.com/padapook/bestbit-core/internal/reconciliation/service"
+	walletRepository "github.com/padapook/bestbit-core/internal/wallet/repository"
+)
+
+// รันตรวจยอดครั้งเดียวแล้วจบ ใช้กับ cron
+// exit 0 = ไม่เจอปัญหา, 2 = เจอ discrepancy, 1 = รันไม่สำเร็จ
+func main() {
+	if err := godotenv.Load(); err != nil {
+		log.Println("no .env file, using environment")
+	}
+
+	if err := database.GormConnectDB(); err != nil {
+		log.Fatal("[postgres gorm] Error connecting to database:", err)
+	}
+
+	if err := database.AutoMigrate(database.GormDB); err != nil {
+		log.Fatal("[postgres gorm] Migration failed:", err)
+	}
+
+	reconciliationSvc := service.NewReconciliationService(
+		repository.NewReconciliationRepository(database.GormDB),
+		walletRepository.NewWalletRepository(database.GormDB),
+	)
+
+	report, err := reconciliationSvc.Run("CLI")
+	if err != nil {
+		log.Println("[reconcile] run failed:", err)
+		os.Exit(1)
+	}
+
+	log.Printf("[reconcile] report %d: status=%s wallets=%d findings=%d",
+		report.ID, report.Status, report.WalletsChecked, report.FindingsCount)
+	for _, f := range report.Findings {
+		log.Printf("[reconcile]  %s wallet=%d user=%s currency=%s ref=%s %s",
+			f.Type, f.WalletID, f.UserID, f.Currency, f.ReferenceID, f.Detail)
+	}
+
+	if report.Status != model.ReportStatusClean {
+		os.Exit(2)
+	}
+}
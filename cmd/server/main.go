@@ -10,9 +10,21 @@ import (
 
 	"log"
 	"os"
+	"strings"
 	"time"
 )
 
+// ไม่ตั้ง TRUSTED_PROXIES = ไม่เชื่อ X-Forwarded-For เลย ClientIP เป็น ip ที่ต่อเข้ามาจริง
+func trustedProxies() []string {
+	var proxies []string
+	for _, p := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			proxies = append(proxies, p)
+		}
+	}
+	return proxies
+}
+
 func main() {
 	if err := godotenv.Load(); err != nil {
 		log.Fatal("Error loading .env file")
@@ -27,6 +39,9 @@ func main() {
 	}
 
 	app := gin.Default()
+	if err := app.SetTrustedProxies(trustedProxies()); err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES:", err)
+	}
 
 	app.Use(cors.New(cors.Config{
 		AllowOrigins: []string{
@@ -35,7 +50,7 @@ func main() {
 			"http://localhost:8081",
 		},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "Idempotency-Key", "X-MFA-Code", "X-Device-ID"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
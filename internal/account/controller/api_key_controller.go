@@ -0,0 +1,85 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/padapook/bestbit-core/internal/account/service"
+	"github.com/padapook/bestbit-core/internal/utils"
+)
+
+type APIKeyController interface {
+	Create(c *gin.Context)
+	GetAPIKeys(c *gin.Context)
+	Revoke(c *gin.Context)
+}
+
+type apiKeyController struct {
+	apiKeyService service.APIKeyService
+}
+
+func NewAPIKeyController(apiKeyService service.APIKeyService) APIKeyController {
+	return &apiKeyController{apiKeyService: apiKeyService}
+}
+
+type CreateAPIKeyRequest struct {
+	Label       string     `json:"label" binding:"required"`
+	Permissions []string   `json:"permissions" binding:"required"`
+	AllowedIPs  []string   `json:"allowed_ips"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+}
+
+func (ctrl *apiKeyController) Create(c *gin.Context) {
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleError(c, utils.ErrInvalidRequest)
+		return
+	}
+
+	issued, err := ctrl.apiKeyService.Create(c.GetString("account_id"), service.CreateAPIKeyInput{
+		Label:       req.Label,
+		Permissions: req.Permissions,
+		AllowedIPs:  req.AllowedIPs,
+		ExpiresAt:   req.ExpiresAt,
+	})
+	if err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "API key created, the secret will not be shown again",
+		"data":    issued,
+	})
+}
+
+func (ctrl *apiKeyController) GetAPIKeys(c *gin.Context) {
+	keys, err := ctrl.apiKeyService.GetAPIKeys(c.GetString("account_id"))
+	if err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": keys,
+	})
+}
+
+func (ctrl *apiKeyController) Revoke(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.HandleError(c, utils.ErrInvalidRequest)
+		return
+	}
+
+	if err := ctrl.apiKeyService.Revoke(c.GetString("account_id"), id); err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "API key revoked",
+	})
+}
@@ -0,0 +1,24 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/padapook/bestbit-core/internal/utils/auth"
+)
+
+type JWKSController interface {
+	GetJWKS(c *gin.Context)
+}
+
+type jwksController struct{}
+
+func NewJWKSController() JWKSController {
+	return &jwksController{}
+}
+
+// ตอบตาม format มาตรฐานของ JWKS ไม่ห่อ message/data เพื่อให้ library ฝั่ง verifier อ่านได้ตรงๆ
+func (ctrl *jwksController) GetJWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, auth.CurrentJWKS())
+}
@@ -0,0 +1,90 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/padapook/bestbit-core/internal/account/service"
+	"github.com/padapook/bestbit-core/internal/utils"
+)
+
+type MFAController interface {
+	GetStatus(c *gin.Context)
+	Enroll(c *gin.Context)
+	Activate(c *gin.Context)
+	Disable(c *gin.Context)
+}
+
+type mfaController struct {
+	mfaService service.MFAService
+}
+
+func NewMFAController(mfaService service.MFAService) MFAController {
+	return &mfaController{mfaService: mfaService}
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+func (ctrl *mfaController) GetStatus(c *gin.Context) {
+	enabled, err := ctrl.mfaService.IsEnabled(c.GetString("account_id"))
+	if err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{"enabled": enabled},
+	})
+}
+
+// เรียกซ้ำได้จนกว่าจะ activate, secret เดิมที่ยังไม่ยืนยันจะถูกแทน
+func (ctrl *mfaController) Enroll(c *gin.Context) {
+	enrollment, err := ctrl.mfaService.Enroll(c.GetString("account_id"), c.GetString("username"))
+	if err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Scan the otpauth uri and confirm with a code to enable MFA",
+		"data":    enrollment,
+	})
+}
+
+func (ctrl *mfaController) Activate(c *gin.Context) {
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleError(c, utils.ErrInvalidRequest)
+		return
+	}
+
+	recoveryCodes, err := ctrl.mfaService.Activate(c.GetString("account_id"), req.Code)
+	if err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "MFA enabled, store the recovery codes somewhere safe",
+		"data":    gin.H{"recovery_codes": recoveryCodes},
+	})
+}
+
+func (ctrl *mfaController) Disable(c *gin.Context) {
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleError(c, utils.ErrInvalidRequest)
+		return
+	}
+
+	if err := ctrl.mfaService.Disable(c.GetString("account_id"), req.Code); err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "MFA disabled",
+	})
+}
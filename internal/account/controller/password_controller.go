@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/padapook/bestbit-core/internal/account/service"
+	"github.com/padapook/bestbit-core/internal/utils"
+)
+
+type PasswordController interface {
+	ChangePassword(c *gin.Context)
+	ForgotPassword(c *gin.Context)
+	ResetPassword(c *gin.Context)
+}
+
+type passwordController struct {
+	passwordService service.PasswordService
+	tokenService    service.TokenService
+}
+
+func NewPasswordController(passwordService service.PasswordService, tokenService service.TokenService) PasswordController {
+	return &passwordController{passwordService: passwordService, tokenService: tokenService}
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=6"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+}
+
+// session อื่นหลุดหมด ส่ง token คู่ใหม่กลับไปให้ session นี้ใช้ต่อ
+func (ctrl *passwordController) ChangePassword(c *gin.Context) {
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleError(c, utils.ErrInvalidRequest)
+		return
+	}
+
+	user, err := ctrl.passwordService.ChangePassword(c.GetString("account_id"), req.CurrentPassword, req.NewPassword)
+	if err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	tokens, err := ctrl.tokenService.IssueTokens(user, clientInfo(c))
+	if err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Password changed, other sessions were signed out",
+		"data": LoginResponse{
+			AccessToken:  tokens.AccessToken,
+			RefreshToken: tokens.RefreshToken,
+			User:         toUserResponse(user),
+		},
+	})
+}
+
+// ตอบเหมือนกันทุกกรณี ไม่บอกว่า email มีในระบบหรือไม่
+func (ctrl *passwordController) ForgotPassword(c *gin.Context) {
+	var req ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleError(c, utils.ErrInvalidRequest)
+		return
+	}
+
+	if err := ctrl.passwordService.RequestReset(req.Email); err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "If the email is registered, a reset link has been sent",
+	})
+}
+
+func (ctrl *passwordController) ResetPassword(c *gin.Context) {
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleError(c, utils.ErrInvalidRequest)
+		return
+	}
+
+	if err := ctrl.passwordService.ResetPassword(req.Token, req.NewPassword); err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Password has been reset, please log in again",
+	})
+}
@@ -0,0 +1,58 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/padapook/bestbit-core/internal/account/service"
+	"github.com/padapook/bestbit-core/internal/utils"
+)
+
+type RoleController interface {
+	GetRoles(c *gin.Context)
+	SetRoles(c *gin.Context)
+}
+
+type roleController struct {
+	roleService service.RoleService
+}
+
+func NewRoleController(roleService service.RoleService) RoleController {
+	return &roleController{roleService: roleService}
+}
+
+// ส่ง [] = ถอดทุก role
+type SetRolesRequest struct {
+	Roles []string `json:"roles" binding:"required"`
+}
+
+func (ctrl *roleController) GetRoles(c *gin.Context) {
+	roles, err := ctrl.roleService.GetRoles(c.Param("account_id"))
+	if err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": roles,
+	})
+}
+
+func (ctrl *roleController) SetRoles(c *gin.Context) {
+	var req SetRolesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleError(c, utils.ErrInvalidRequest)
+		return
+	}
+
+	roles, err := ctrl.roleService.SetRoles(c.Param("account_id"), req.Roles, c.GetString("account_id"))
+	if err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Roles updated",
+		"data":    roles,
+	})
+}
@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/padapook/bestbit-core/internal/account/service"
+	"github.com/padapook/bestbit-core/internal/utils"
+	"github.com/padapook/bestbit-core/internal/utils/auth"
+	"github.com/padapook/bestbit-core/internal/utils/crypto"
+)
+
+type SessionController interface {
+	GetSessions(c *gin.Context)
+	RevokeSession(c *gin.Context)
+}
+
+type sessionController struct {
+	tokenService service.TokenService
+}
+
+func NewSessionController(tokenService service.TokenService) SessionController {
+	return &sessionController{tokenService: tokenService}
+}
+
+const (
+	deviceCookieName = "bestbit_device"
+	deviceIDHeader   = "X-Device-ID"
+	deviceCookieAge  = 2 * 365 * 24 * 60 * 60
+)
+
+// เก็บไว้กับ session ที่กำลังจะออก token ให้
+func clientInfo(c *gin.Context) service.ClientInfo {
+	return service.ClientInfo{DeviceID: deviceID(c), UserAgent: c.Request.UserAgent(), IPAddress: c.ClientIP()}
+}
+
+// app/bot ส่ง X-Device-ID เอง, browser ใช้ cookie ที่ออกให้ครั้งแรกแล้วอยู่ข้ามการอัปเดต browser
+// device id ไม่ใช่สิทธิ์เข้าใช้ ใช้แค่ตัดสินว่าต้องแจ้งเครื่องใหม่ไหม
+func deviceID(c *gin.Context) string {
+	if id := strings.TrimSpace(c.GetHeader(deviceIDHeader)); isValidDeviceID(id) {
+		return id
+	}
+	if id, err := c.Cookie(deviceCookieName); err == nil && isValidDeviceID(id) {
+		return id
+	}
+
+	id, err := crypto.GenerateToken(16)
+	if err != nil {
+		return ""
+	}
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(deviceCookieName, id, deviceCookieAge, "/", "", c.Request.TLS != nil, true)
+	return id
+}
+
+func isValidDeviceID(id string) bool {
+	return len(id) >= 16 && len(id) <= 100
+}
+
+func (ctrl *sessionController) GetSessions(c *gin.Context) {
+	var currentSessionID string
+	if value, ok := c.Get("claims"); ok {
+		if claims, ok := value.(*auth.Claims); ok {
+			currentSessionID = claims.SessionID
+		}
+	}
+
+	sessions, err := ctrl.tokenService.GetSessions(c.GetString("account_id"), currentSessionID)
+	if err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": sessions,
+	})
+}
+
+// revoke session ตัวเองก็ได้ ผลเหมือน logout
+func (ctrl *sessionController) RevokeSession(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.HandleError(c, utils.ErrInvalidRequest)
+		return
+	}
+
+	if err := ctrl.tokenService.RevokeSession(c.GetString("account_id"), id); err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Session revoked",
+	})
+}
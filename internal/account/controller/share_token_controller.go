@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/padapook/bestbit-core/internal/account/service"
+	"github.com/padapook/bestbit-core/internal/utils"
+)
+
+type ShareTokenController interface {
+	Create(c *gin.Context)
+	GetOutstanding(c *gin.Context)
+	Revoke(c *gin.Context)
+	LoginByShareToken(c *gin.Context)
+}
+
+type shareTokenController struct {
+	shareTokenService service.ShareTokenService
+	tokenService      service.TokenService
+}
+
+func NewShareTokenController(shareTokenService service.ShareTokenService, tokenService service.TokenService) ShareTokenController {
+	return &shareTokenController{shareTokenService: shareTokenService, tokenService: tokenService}
+}
+
+type LoginByShareTokenRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+func (ctrl *shareTokenController) Create(c *gin.Context) {
+	issued, err := ctrl.shareTokenService.Create(c.GetString("account_id"))
+	if err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Share token generated successfully",
+		"share_token": issued.Token,
+		"data":        issued.Record,
+	})
+}
+
+// เฉพาะตัวที่ยังใช้ได้อยู่
+func (ctrl *shareTokenController) GetOutstanding(c *gin.Context) {
+	tokens, err := ctrl.shareTokenService.GetOutstanding(c.GetString("account_id"))
+	if err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": tokens,
+	})
+}
+
+func (ctrl *shareTokenController) Revoke(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.HandleError(c, utils.ErrInvalidRequest)
+		return
+	}
+
+	if err := ctrl.shareTokenService.Revoke(c.GetString("account_id"), id); err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Share token revoked",
+	})
+}
+
+// session ที่ได้มีสิทธิ์ตาม scope ของ share token (read-only)
+func (ctrl *shareTokenController) LoginByShareToken(c *gin.Context) {
+	var req LoginByShareTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleError(c, utils.ErrInvalidRequest)
+		return
+	}
+
+	user, scope, err := ctrl.shareTokenService.Consume(req.Token)
+	if err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	tokens, err := ctrl.tokenService.IssueScopedTokens(user, scope, clientInfo(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate session tokens"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Logged in via share token successfully",
+		"data": LoginResponse{
+			AccessToken:  tokens.AccessToken,
+			RefreshToken: tokens.RefreshToken,
+			User:         toUserResponse(user),
+		},
+	})
+}
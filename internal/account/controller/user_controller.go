@@ -1,7 +1,12 @@
 package controller
 
 import (
+	"errors"
+	"log"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/padapook/bestbit-core/internal/account/model"
@@ -15,17 +20,22 @@ type UserController interface {
 	Register(c *gin.Context)
 	GetProfile(c *gin.Context)
 	Login(c *gin.Context)
+	LoginMFA(c *gin.Context)
 	Logout(c *gin.Context)
-	LoginByShareToken(c *gin.Context)
-	GenerateShareToken(c *gin.Context)
+	LogoutAll(c *gin.Context)
+	RefreshToken(c *gin.Context)
+	SearchUsers(c *gin.Context)
 }
 
 type userController struct {
-	userService service.UserService
+	userService  service.UserService
+	tokenService service.TokenService
+	mfaService   service.MFAService
+	loginGuard   service.LoginGuard
 }
 
-func NewUserController(userService service.UserService) UserController {
-	return &userController{userService: userService}
+func NewUserController(userService service.UserService, tokenService service.TokenService, mfaService service.MFAService, loginGuard service.LoginGuard) UserController {
+	return &userController{userService: userService, tokenService: tokenService, mfaService: mfaService, loginGuard: loginGuard}
 }
 
 type UserRegisterRequest struct {
@@ -41,8 +51,21 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
-type LoginByShareTokenRequest struct {
-	Token string `json:"token" binding:"required"`
+type LoginMFARequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type LogoutAllRequest struct {
+	Before *time.Time `json:"before"`
 }
 
 type LoginResponse struct {
@@ -51,6 +74,12 @@ type LoginResponse struct {
 	User         UserResponse `json:"user"`
 }
 
+// ตอบแทน LoginResponse เมื่อ user เปิด MFA ไว้
+type MFAChallengeResponse struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+}
+
 type UserResponse struct {
 	AccountID string `json:"account_id"`
 	Username  string `json:"username"`
@@ -121,14 +150,107 @@ func (ctrl *userController) Login(c *gin.Context) {
 		return
 	}
 
+	if retryAfter, err := ctrl.loginGuard.Check(req.Username, c.ClientIP()); err != nil {
+		respondLoginThrottled(c, retryAfter, err)
+		return
+	}
+
 	user, err := ctrl.userService.Login(req.Username, req.Password)
+	if errors.Is(err, utils.ErrAccountFrozen) {
+		utils.HandleError(c, utils.ErrAccountFrozen)
+		return
+	}
 	if err != nil {
+		if err := ctrl.loginGuard.RecordFailure(req.Username, c.ClientIP()); err != nil {
+			log.Println("[login] record failure:", err)
+		}
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid username or password"})
 		return
 	}
 	// log.Println("'user",user)
 
-	tokens, err := auth.GenerateTokens(user)
+	mfaEnabled, err := ctrl.mfaService.IsEnabled(user.AccountId)
+	if err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+	// เปิด MFA ไว้ยังไม่ล้างตัวนับ รอให้ผ่าน code ก่อน ไม่งั้นคนที่ได้รหัสผ่านไปเดา code ได้ไม่จำกัด
+	if mfaEnabled {
+		mfaToken, err := auth.GenerateMFAToken(user)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate tokens"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message": "MFA code required",
+			"data":    MFAChallengeResponse{MFARequired: true, MFAToken: mfaToken},
+		})
+		return
+	}
+
+	ctrl.recordLoginSuccess(user.Username)
+	ctrl.respondLogin(c, user)
+}
+
+// ขั้นที่สองของ login, รับ mfa_token จาก /login กับ code จากแอปหรือ recovery code
+func (ctrl *userController) LoginMFA(c *gin.Context) {
+	var req LoginMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleError(c, utils.ErrInvalidRequest)
+		return
+	}
+
+	claims, err := auth.ValidateMFAToken(req.MFAToken)
+	if err != nil {
+		utils.HandleError(c, utils.ErrInvalidMFAToken)
+		return
+	}
+
+	user, err := ctrl.userService.GetByUsername(claims.Username)
+	if err != nil || user.AccountId != claims.AccountID {
+		utils.HandleError(c, utils.ErrInvalidMFAToken)
+		return
+	}
+	if !user.IsActive {
+		utils.HandleError(c, utils.ErrAccountFrozen)
+		return
+	}
+
+	if retryAfter, err := ctrl.loginGuard.Check(user.Username, c.ClientIP()); err != nil {
+		respondLoginThrottled(c, retryAfter, err)
+		return
+	}
+
+	if err := ctrl.mfaService.VerifyLogin(user.AccountId, req.Code); err != nil {
+		if errors.Is(err, utils.ErrInvalidMFACode) {
+			if err := ctrl.loginGuard.RecordFailure(user.Username, c.ClientIP()); err != nil {
+				log.Println("[login] record failure:", err)
+			}
+		}
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	ctrl.recordLoginSuccess(user.Username)
+	ctrl.respondLogin(c, user)
+}
+
+func (ctrl *userController) recordLoginSuccess(username string) {
+	if err := ctrl.loginGuard.RecordSuccess(username); err != nil {
+		log.Println("[login] reset attempts:", err)
+	}
+}
+
+func respondLoginThrottled(c *gin.Context, retryAfter time.Duration, err error) {
+	if retryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	}
+	utils.HandleServiceError(c, err)
+}
+
+func (ctrl *userController) respondLogin(c *gin.Context, user *model.User) {
+	tokens, err := ctrl.tokenService.IssueTokens(user, clientInfo(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate tokens"})
 		return
@@ -145,32 +267,71 @@ func (ctrl *userController) Login(c *gin.Context) {
 }
 
 func (ctrl *userController) Logout(c *gin.Context) {
+	value, _ := c.Get("claims")
+	claims, ok := value.(*auth.Claims)
+	if !ok {
+		utils.HandleError(c, utils.ErrUnauthorized)
+		return
+	}
+
+	// body ไม่บังคับ, ส่ง refresh_token มาด้วยจะ revoke ทั้ง family
+	var req LogoutRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.HandleError(c, utils.ErrInvalidRequest)
+			return
+		}
+	}
+
+	if err := ctrl.tokenService.Logout(claims, req.RefreshToken); err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Logged out successfully",
 	})
 }
 
-func (ctrl *userController) LoginByShareToken(c *gin.Context) {
-	var req LoginByShareTokenRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+func (ctrl *userController) LogoutAll(c *gin.Context) {
+	var req LogoutAllRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.HandleError(c, utils.ErrInvalidRequest)
+			return
+		}
+	}
+
+	before := time.Now()
+	if req.Before != nil {
+		before = *req.Before
+	}
+
+	if err := ctrl.tokenService.LogoutAll(c.GetString("account_id"), before); err != nil {
+		utils.HandleServiceError(c, err)
 		return
 	}
 
-	user, err := ctrl.userService.LoginByShareToken(req.Token)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Logged out from all sessions successfully",
+	})
+}
+
+func (ctrl *userController) RefreshToken(c *gin.Context) {
+	var req RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleError(c, utils.ErrInvalidRequest)
 		return
 	}
 
-	tokens, err := auth.GenerateTokens(user)
+	user, tokens, err := ctrl.tokenService.RefreshTokens(req.RefreshToken)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate session tokens"})
+		utils.HandleServiceError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Logged in via share token successfully",
+		"message": "Token refreshed successfully",
 		"data": LoginResponse{
 			AccessToken:  tokens.AccessToken,
 			RefreshToken: tokens.RefreshToken,
@@ -179,30 +340,19 @@ func (ctrl *userController) LoginByShareToken(c *gin.Context) {
 	})
 }
 
-func (ctrl *userController) GenerateShareToken(c *gin.Context) {
-	username, exists := c.Get("username")
-	if !exists {
-		username = c.Query("username")
-		if username == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
-			return
-		}
-	}
-
-	user, err := ctrl.userService.GetByUsername(username.(string))
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
-		return
-	}
+// admin, ?q= ค้น account id/username/email/ชื่อ
+func (ctrl *userController) SearchUsers(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	offset, _ := strconv.Atoi(c.Query("offset"))
 
-	shareTokenString, err := auth.GenerateShareToken(user)
+	page, err := ctrl.userService.SearchUsers(c.Query("q"), limit, offset)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate share token"})
+		utils.HandleServiceError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message":     "Share token generated successfully",
-		"share_token": shareTokenString,
+		"data":  page.Users,
+		"total": page.Total,
 	})
 }
@@ -0,0 +1,85 @@
+package model
+
+import (
+	"net"
+	"strings"
+	"time"
+)
+
+// สิทธิ์ของ API key แยกจาก permission ของ role, ต้องให้ตอนสร้างทีละตัว
+const (
+	APIKeyPermRead     = "read"
+	APIKeyPermTrade    = "trade"
+	APIKeyPermWithdraw = "withdraw"
+)
+
+var APIKeyPermissions = []string{APIKeyPermRead, APIKeyPermTrade, APIKeyPermWithdraw}
+
+func IsValidAPIKeyPermission(permission string) bool {
+	for _, p := range APIKeyPermissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// secret เข้ารหัสด้วย API_KEY_SECRET_KEY เหมือน TOTP secret เพราะต้องถอดกลับมาตรวจ HMAC
+// ว่าง = key ที่สร้างก่อนเข้ารหัส (เคยเก็บแค่ hash) ใช้ไม่ได้แล้ว
+type APIKey struct {
+	ID              uint64   `gorm:"primaryKey" json:"id"`
+	KeyID           string   `gorm:"size:64;not null;uniqueIndex" json:"key_id"`
+	AccountID       string   `gorm:"size:100;not null;index" json:"account_id"`
+	Label           string   `gorm:"size:100;not null" json:"label"`
+	SecretEncrypted string   `gorm:"size:255;not null;default:''" json:"-"`
+	Permissions     []string `gorm:"type:text;serializer:json;not null" json:"permissions"`
+	// IP หรือ CIDR, ว่าง = เรียกจากที่ไหนก็ได้
+	AllowedIPs []string   `gorm:"type:text;serializer:json" json:"allowed_ips"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (k *APIKey) HasPermission(permission string) bool {
+	for _, p := range k.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// ยังไม่ถูก revoke และยังไม่หมดอายุ
+func (k *APIKey) IsUsable(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || k.ExpiresAt.After(now))
+}
+
+func (k *APIKey) AllowsIP(ip string) bool {
+	if len(k.AllowedIPs) == 0 {
+		return true
+	}
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+	for _, allowed := range k.AllowedIPs {
+		if strings.Contains(allowed, "/") {
+			if _, network, err := net.ParseCIDR(allowed); err == nil && network.Contains(addr) {
+				return true
+			}
+			continue
+		}
+		if allowedIP := net.ParseIP(allowed); allowedIP != nil && allowedIP.Equal(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// signature ของ API request ที่ใช้ไปแล้ว เก็บไว้จนพ้นช่วงที่ timestamp ยังรับอยู่
+type UsedRequestSignature struct {
+	Key       string    `gorm:"primaryKey;size:200" json:"key"`
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+}
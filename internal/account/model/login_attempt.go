@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// ตัวนับ login ที่ล้มเหลวต่อ key (user:<username> หรือ ip:<address>)
+type LoginAttempt struct {
+	Key           string     `gorm:"primaryKey;size:255" json:"key"`
+	Failures      int        `gorm:"not null;default:0" json:"failures"`
+	LastFailureAt time.Time  `gorm:"not null" json:"last_failure_at"`
+	LockedUntil   *time.Time `json:"locked_until"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (a *LoginAttempt) IsLocked(now time.Time) bool {
+	return a.LockedUntil != nil && a.LockedUntil.After(now)
+}
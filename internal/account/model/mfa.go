@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// หนึ่ง row ต่อ user, Enabled = false คือ enroll แล้วแต่ยังไม่ยืนยัน code แรก
+type UserMFA struct {
+	AccountID string `gorm:"primaryKey;size:100" json:"account_id"`
+	// TOTP secret ที่เข้ารหัสด้วย MFA_SECRET_KEY แล้ว
+	Secret    string     `gorm:"size:255;not null" json:"-"`
+	Enabled   bool       `gorm:"not null;default:false" json:"enabled"`
+	EnabledAt *time.Time `json:"enabled_at"`
+	// step ล่าสุดที่ใช้ไปแล้ว กัน code เดิมถูกใช้ซ้ำในช่วง 30 วินาที
+	LastUsedStep int64     `gorm:"not null;default:0" json:"-"`
+	CreatedAt    time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// recovery code ใช้ได้ครั้งเดียว เก็บแค่ hash
+type MFARecoveryCode struct {
+	ID        uint64     `gorm:"primaryKey" json:"id"`
+	AccountID string     `gorm:"size:100;not null;index" json:"account_id"`
+	CodeHash  string     `gorm:"size:255;not null" json:"-"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
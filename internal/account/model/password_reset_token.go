@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// token reset รหัสผ่าน ใช้ได้ครั้งเดียว เก็บแค่ sha256 ของ token ที่ส่งไปทาง email
+type PasswordResetToken struct {
+	ID        uint64     `gorm:"primaryKey" json:"id"`
+	AccountID string     `gorm:"size:100;not null;index" json:"account_id"`
+	TokenHash string     `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (t *PasswordResetToken) IsUsable(now time.Time) bool {
+	return t.UsedAt == nil && t.ExpiresAt.After(now)
+}
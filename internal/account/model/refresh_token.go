@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// refresh token หนึ่ง row ต่อหนึ่ง jti, ทุกตัวที่ rotate ต่อกันมาอยู่ใน family เดียวกัน
+type RefreshToken struct {
+	ID         uint64     `gorm:"primaryKey" json:"id"`
+	TokenID    string     `gorm:"size:100;not null;uniqueIndex" json:"token_id"`
+	FamilyID   string     `gorm:"size:100;not null;index" json:"family_id"`
+	AccountID  string     `gorm:"size:100;not null;index" json:"account_id"`
+	ExpiresAt  time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt     *time.Time `json:"used_at"`
+	ReplacedBy string     `gorm:"size:100" json:"replaced_by"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (t *RefreshToken) IsRevoked() bool {
+	return t.RevokedAt != nil
+}
+
+func (t *RefreshToken) IsUsed() bool {
+	return t.UsedAt != nil
+}
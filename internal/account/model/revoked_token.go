@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// access/refresh token ที่ถูก logout ก่อนหมดอายุ, ลบทิ้งได้หลัง ExpiresAt
+type RevokedToken struct {
+	ID        uint64    `gorm:"primaryKey" json:"id"`
+	TokenID   string    `gorm:"size:100;not null;uniqueIndex" json:"token_id"`
+	AccountID string    `gorm:"size:100;not null;index" json:"account_id"`
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+	RevokedAt time.Time `gorm:"autoCreateTime" json:"revoked_at"`
+}
+
+// token ของ account ที่ออกก่อน RevokedBefore ใช้ไม่ได้ทั้งหมด (logout ทุก session)
+type TokenRevocation struct {
+	AccountID     string    `gorm:"primaryKey;size:100" json:"account_id"`
+	RevokedBefore time.Time `gorm:"not null" json:"revoked_before"`
+	UpdatedAt     time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
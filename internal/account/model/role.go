@@ -0,0 +1,82 @@
+package model
+
+import (
+	"sort"
+	"time"
+)
+
+const (
+	RoleAdmin    = "admin"
+	RoleOperator = "operator"
+	RoleSupport  = "support"
+)
+
+const (
+	PermUsersRead            = "users:read"
+	PermUsersFreeze          = "users:freeze"
+	PermRolesManage          = "roles:manage"
+	PermWalletsRead          = "wallets:read"
+	PermWalletsAdjust        = "wallets:adjust"
+	PermWithdrawalsManage    = "withdrawals:manage"
+	PermLimitsManage         = "limits:manage"
+	PermCurrenciesManage     = "currencies:manage"
+	PermMarketsManage        = "markets:manage"
+	PermLedgerRead           = "ledger:read"
+	PermReconciliationManage = "reconciliation:manage"
+)
+
+// สิทธิ์ของแต่ละ role กำหนดในโค้ด, ใน db เก็บแค่ว่า user ไหนมี role อะไร
+var RolePermissions = map[string][]string{
+	RoleAdmin: {
+		PermUsersRead, PermUsersFreeze, PermRolesManage,
+		PermWalletsRead, PermWalletsAdjust,
+		PermWithdrawalsManage, PermLimitsManage, PermCurrenciesManage, PermMarketsManage,
+		PermLedgerRead, PermReconciliationManage,
+	},
+	RoleOperator: {
+		PermUsersRead, PermUsersFreeze,
+		PermWalletsRead,
+		PermWithdrawalsManage,
+		PermLedgerRead, PermReconciliationManage,
+	},
+	RoleSupport: {
+		PermUsersRead, PermWalletsRead,
+	},
+}
+
+type UserRole struct {
+	AccountID string    `gorm:"size:100;primaryKey" json:"account_id"`
+	Role      string    `gorm:"type:varchar(30);primaryKey" json:"role"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	CreatedBy string    `gorm:"size:100" json:"created_by"`
+}
+
+func IsValidRole(role string) bool {
+	_, ok := RolePermissions[role]
+	return ok
+}
+
+func (u *User) RoleNames() []string {
+	roles := make([]string, 0, len(u.Roles))
+	for _, r := range u.Roles {
+		roles = append(roles, r.Role)
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// รวมสิทธิ์ของทุก role ไม่ซ้ำ เรียงให้ claim ออกมาเหมือนเดิมทุกครั้ง
+func PermissionsForRoles(roles []string) []string {
+	seen := make(map[string]bool)
+	permissions := []string{}
+	for _, role := range roles {
+		for _, p := range RolePermissions[role] {
+			if !seen[p] {
+				seen[p] = true
+				permissions = append(permissions, p)
+			}
+		}
+	}
+	sort.Strings(permissions)
+	return permissions
+}
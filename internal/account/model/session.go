@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// หนึ่ง row ต่อการ login หนึ่งครั้ง, SessionID คือ family ของ refresh token และ sid ใน token
+type Session struct {
+	ID        uint64 `gorm:"primaryKey" json:"id"`
+	SessionID string `gorm:"size:100;not null;uniqueIndex" json:"-"`
+	AccountID string `gorm:"size:100;not null;index" json:"-"`
+	// HashToken ของ device id ที่ client เก็บไว้ ใช้ดูว่าเคย login จากเครื่องนี้หรือยัง
+	// ไม่ใช้ user agent เพราะเปลี่ยนทุกครั้งที่อัปเดต browser, user agent ใช้แค่ทำ DeviceLabel
+	DeviceID    string    `gorm:"size:64;not null;index" json:"-"`
+	DeviceLabel string    `gorm:"size:100;not null" json:"device_label"`
+	UserAgent   string    `gorm:"size:500" json:"user_agent"`
+	IPAddress   string    `gorm:"size:64" json:"ip_address"`
+	Scope       string    `gorm:"size:50" json:"scope,omitempty"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+	// อัปเดตตอน login และ refresh token
+	LastSeenAt time.Time  `gorm:"not null" json:"last_seen_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	Current    bool       `gorm:"-" json:"current"`
+}
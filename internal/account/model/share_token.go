@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// share token ที่ออกไปแล้ว หนึ่ง row ต่อ jti ใช้ login ได้ครั้งเดียว
+type ShareToken struct {
+	ID         uint64     `gorm:"primaryKey" json:"id"`
+	TokenID    string     `gorm:"size:100;not null;uniqueIndex" json:"token_id"`
+	AccountID  string     `gorm:"size:100;not null;index" json:"account_id"`
+	Scope      string     `gorm:"size:50;not null" json:"scope"`
+	ExpiresAt  time.Time  `gorm:"not null" json:"expires_at"`
+	ConsumedAt *time.Time `json:"consumed_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// ยังไม่ถูกใช้ ไม่ถูก revoke และยังไม่หมดอายุ
+func (t *ShareToken) IsOutstanding(now time.Time) bool {
+	return t.ConsumedAt == nil && t.RevokedAt == nil && t.ExpiresAt.After(now)
+}
@@ -17,6 +17,8 @@ type User struct {
 	Email        string         `gorm:"size:100;index;uniqueIndex" json:"email"`
 	MobileNumber string         `gorm:"size:20;index" json:"mobile_number"`
 	IsActive     bool           `gorm:"default:true" json:"is_active"`
+	KYCTier      int            `gorm:"not null;default:0" json:"kyc_tier"`
+	Roles        []UserRole     `gorm:"foreignKey:AccountID;references:AccountId" json:"roles,omitempty"`
 	CreatedAt    time.Time      `gorm:"autoCreateTime" json:"created_at"`
 	CreatedBy    string         `gorm:"size:50;default:'SYSTEM'" json:"created_by"`
 	UpdatedAt    time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
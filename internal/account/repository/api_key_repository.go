@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/padapook/bestbit-core/internal/account/model"
+	"github.com/padapook/bestbit-core/internal/utils"
+	"gorm.io/gorm"
+)
+
+type APIKeyRepository interface {
+	CreateAPIKey(key *model.APIKey) error
+	// ไม่เจอคืน ErrInvalidAPIKey ไม่บอกว่า key มีอยู่หรือไม่
+	GetByKeyID(keyID string) (*model.APIKey, error)
+	// ไม่รวมตัวที่ revoke แล้ว
+	GetByAccountID(accountID string) ([]model.APIKey, error)
+	// ไม่เจอหรือ revoke ไปแล้วคืน ErrAPIKeyNotFound
+	Revoke(accountID string, id uint64, revokedAt time.Time) error
+	UpdateLastUsed(id uint64, usedAt time.Time) error
+}
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+func (r *apiKeyRepository) CreateAPIKey(key *model.APIKey) error {
+	return r.db.Create(key).Error
+}
+
+func (r *apiKeyRepository) GetByKeyID(keyID string) (*model.APIKey, error) {
+	var key model.APIKey
+	if err := r.db.Where("key_id = ?", keyID).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *apiKeyRepository) GetByAccountID(accountID string) ([]model.APIKey, error) {
+	var keys []model.APIKey
+	err := r.db.Where("account_id = ? AND revoked_at IS NULL", accountID).
+		Order("created_at DESC").
+		Find(&keys).Error
+	return keys, err
+}
+
+func (r *apiKeyRepository) Revoke(accountID string, id uint64, revokedAt time.Time) error {
+	result := r.db.Model(&model.APIKey{}).
+		Where("id = ? AND account_id = ? AND revoked_at IS NULL", id, accountID).
+		Update("revoked_at", revokedAt)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return utils.ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+func (r *apiKeyRepository) UpdateLastUsed(id uint64, usedAt time.Time) error {
+	return r.db.Model(&model.APIKey{}).Where("id = ?", id).Update("last_used_at", usedAt).Error
+}
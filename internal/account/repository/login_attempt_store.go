@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/padapook/bestbit-core/internal/account/model"
+	"github.com/padapook/bestbit-core/internal/utils/auth"
+	"gorm.io/gorm"
+)
+
+type loginAttemptStore struct {
+	db *gorm.DB
+}
+
+func NewLoginAttemptStore(db *gorm.DB) auth.LoginAttemptStore {
+	return &loginAttemptStore{db: db}
+}
+
+func (r *loginAttemptStore) Get(key string) (*model.LoginAttempt, error) {
+	var attempt model.LoginAttempt
+	err := r.db.Where("key = ?", key).First(&attempt).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+// upsert คำสั่งเดียว หลาย instance ยิงพร้อมกันก็นับไม่หาย
+func (r *loginAttemptStore) RecordFailure(key string, now time.Time, window time.Duration) (*model.LoginAttempt, error) {
+	var attempt model.LoginAttempt
+	err := r.db.Raw(`
+		INSERT INTO login_attempts (key, failures, last_failure_at, updated_at)
+		VALUES (@key, 1, @now, @now)
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE
+				WHEN login_attempts.last_failure_at < @cutoff OR login_attempts.locked_until <= @now THEN 1
+				ELSE login_attempts.failures + 1
+			END,
+			locked_until = CASE WHEN login_attempts.locked_until <= @now THEN NULL ELSE login_attempts.locked_until END,
+			last_failure_at = EXCLUDED.last_failure_at,
+			updated_at = EXCLUDED.updated_at
+		RETURNING *`,
+		map[string]interface{}{"key": key, "now": now, "cutoff": now.Add(-window)},
+	).Scan(&attempt).Error
+	if err != nil {
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+func (r *loginAttemptStore) Lock(key string, until time.Time) error {
+	return r.db.Model(&model.LoginAttempt{}).
+		Where("key = ?", key).
+		Updates(map[string]interface{}{"locked_until": until, "updated_at": time.Now()}).Error
+}
+
+func (r *loginAttemptStore) Reset(key string) error {
+	return r.db.Where("key = ?", key).Delete(&model.LoginAttempt{}).Error
+}
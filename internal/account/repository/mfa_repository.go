@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/padapook/bestbit-core/internal/account/model"
+	"github.com/padapook/bestbit-core/internal/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type MFARepository interface {
+	Transaction(fn func(tx *gorm.DB) error) error
+	GetMFA(accountID string) (*model.UserMFA, error)
+	GetMFAForUpdate(tx *gorm.DB, accountID string) (*model.UserMFA, error)
+	SaveMFA(tx *gorm.DB, mfa *model.UserMFA) error
+	// ลบทั้ง secret และ recovery code
+	DeleteMFA(tx *gorm.DB, accountID string) error
+	// ชุดใหม่แทนชุดเดิมทั้งหมด ตัวเก่าที่ยังไม่ใช้ก็ใช้ไม่ได้อีก
+	ReplaceRecoveryCodes(tx *gorm.DB, accountID string, codeHashes []string) error
+	GetUnusedRecoveryCodes(tx *gorm.DB, accountID string) ([]model.MFARecoveryCode, error)
+	MarkRecoveryCodeUsed(tx *gorm.DB, id uint64, usedAt time.Time) error
+}
+
+type mfaRepository struct {
+	db *gorm.DB
+}
+
+func NewMFARepository(db *gorm.DB) MFARepository {
+	return &mfaRepository{db: db}
+}
+
+func (r *mfaRepository) Transaction(fn func(tx *gorm.DB) error) error {
+	return r.db.Transaction(fn)
+}
+
+func (r *mfaRepository) GetMFA(accountID string) (*model.UserMFA, error) {
+	var mfa model.UserMFA
+	if err := r.db.Where("account_id = ?", accountID).First(&mfa).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.ErrMFANotEnrolled
+		}
+		return nil, err
+	}
+	return &mfa, nil
+}
+
+func (r *mfaRepository) GetMFAForUpdate(tx *gorm.DB, accountID string) (*model.UserMFA, error) {
+	var mfa model.UserMFA
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("account_id = ?", accountID).
+		First(&mfa).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.ErrMFANotEnrolled
+		}
+		return nil, err
+	}
+	return &mfa, nil
+}
+
+func (r *mfaRepository) SaveMFA(tx *gorm.DB, mfa *model.UserMFA) error {
+	return tx.Save(mfa).Error
+}
+
+func (r *mfaRepository) DeleteMFA(tx *gorm.DB, accountID string) error {
+	if err := tx.Where("account_id = ?", accountID).Delete(&model.MFARecoveryCode{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("account_id = ?", accountID).Delete(&model.UserMFA{}).Error
+}
+
+func (r *mfaRepository) ReplaceRecoveryCodes(tx *gorm.DB, accountID string, codeHashes []string) error {
+	if err := tx.Where("account_id = ?", accountID).Delete(&model.MFARecoveryCode{}).Error; err != nil {
+		return err
+	}
+
+	records := make([]model.MFARecoveryCode, 0, len(codeHashes))
+	for _, hash := range codeHashes {
+		records = append(records, model.MFARecoveryCode{AccountID: accountID, CodeHash: hash})
+	}
+	if len(records) == 0 {
+		return nil
+	}
+	return tx.Create(&records).Error
+}
+
+func (r *mfaRepository) GetUnusedRecoveryCodes(tx *gorm.DB, accountID string) ([]model.MFARecoveryCode, error) {
+	var codes []model.MFARecoveryCode
+	err := tx.Where("account_id = ? AND used_at IS NULL", accountID).Order("id ASC").Find(&codes).Error
+	return codes, err
+}
+
+func (r *mfaRepository) MarkRecoveryCodeUsed(tx *gorm.DB, id uint64, usedAt time.Time) error {
+	return tx.Model(&model.MFARecoveryCode{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", usedAt).Error
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/padapook/bestbit-core/internal/account/model"
+	"github.com/padapook/bestbit-core/internal/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PasswordRepository interface {
+	Transaction(fn func(tx *gorm.DB) error) error
+	UpdatePassword(tx *gorm.DB, accountID, passwordHash, updatedBy string) error
+	CreateResetToken(tx *gorm.DB, token *model.PasswordResetToken) error
+	GetResetTokenForUpdate(tx *gorm.DB, tokenHash string) (*model.PasswordResetToken, error)
+	// ปิดทุก token ที่ยังไม่ถูกใช้ของ account เรียกหลังเปลี่ยนรหัสผ่านแล้ว
+	ExpireResetTokens(tx *gorm.DB, accountID string, usedAt time.Time) error
+}
+
+type passwordRepository struct {
+	db *gorm.DB
+}
+
+func NewPasswordRepository(db *gorm.DB) PasswordRepository {
+	return &passwordRepository{db: db}
+}
+
+func (r *passwordRepository) Transaction(fn func(tx *gorm.DB) error) error {
+	return r.db.Transaction(fn)
+}
+
+func (r *passwordRepository) UpdatePassword(tx *gorm.DB, accountID, passwordHash, updatedBy string) error {
+	result := tx.Model(&model.User{}).
+		Where("account_id = ?", accountID).
+		Updates(map[string]interface{}{"password": passwordHash, "updated_by": updatedBy})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return utils.ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *passwordRepository) CreateResetToken(tx *gorm.DB, token *model.PasswordResetToken) error {
+	return tx.Create(token).Error
+}
+
+func (r *passwordRepository) GetResetTokenForUpdate(tx *gorm.DB, tokenHash string) (*model.PasswordResetToken, error) {
+	var token model.PasswordResetToken
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("token_hash = ?", tokenHash).
+		First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.ErrInvalidResetToken
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *passwordRepository) ExpireResetTokens(tx *gorm.DB, accountID string, usedAt time.Time) error {
+	return tx.Model(&model.PasswordResetToken{}).
+		Where("account_id = ? AND used_at IS NULL", accountID).
+		Update("used_at", usedAt).Error
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/padapook/bestbit-core/internal/account/model"
+	"github.com/padapook/bestbit-core/internal/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RefreshTokenRepository interface {
+	Transaction(fn func(tx *gorm.DB) error) error
+	CreateRefreshToken(tx *gorm.DB, token *model.RefreshToken) error
+	UpdateRefreshToken(tx *gorm.DB, token *model.RefreshToken) error
+	GetByTokenIDForUpdate(tx *gorm.DB, tokenID string) (*model.RefreshToken, error)
+	RevokeFamily(tx *gorm.DB, familyID string, revokedAt time.Time) error
+}
+
+type refreshTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewRefreshTokenRepository(db *gorm.DB) RefreshTokenRepository {
+	return &refreshTokenRepository{db: db}
+}
+
+func (r *refreshTokenRepository) Transaction(fn func(tx *gorm.DB) error) error {
+	return r.db.Transaction(fn)
+}
+
+func (r *refreshTokenRepository) CreateRefreshToken(tx *gorm.DB, token *model.RefreshToken) error {
+	return tx.Create(token).Error
+}
+
+func (r *refreshTokenRepository) UpdateRefreshToken(tx *gorm.DB, token *model.RefreshToken) error {
+	return tx.Save(token).Error
+}
+
+func (r *refreshTokenRepository) GetByTokenIDForUpdate(tx *gorm.DB, tokenID string) (*model.RefreshToken, error) {
+	var token model.RefreshToken
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("token_id = ?", tokenID).
+		First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// revoke ทุก token ใน family ที่ยังไม่ถูก revoke
+func (r *refreshTokenRepository) RevokeFamily(tx *gorm.DB, familyID string, revokedAt time.Time) error {
+	return tx.Model(&model.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", revokedAt).Error
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/padapook/bestbit-core/internal/account/model"
+	"github.com/padapook/bestbit-core/internal/utils/auth"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type replayStore struct {
+	db *gorm.DB
+}
+
+func NewReplayStore(db *gorm.DB) auth.ReplayStore {
+	return &replayStore{db: db}
+}
+
+// insert ได้ = ยังไม่เคยใช้, ชน primary key = ส่งซ้ำ
+func (r *replayStore) Use(key string, expiresAt time.Time) (bool, error) {
+	if err := r.db.Where("expires_at < ?", time.Now()).Delete(&model.UsedRequestSignature{}).Error; err != nil {
+		return false, err
+	}
+
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.UsedRequestSignature{Key: key, ExpiresAt: expiresAt})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
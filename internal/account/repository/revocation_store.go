@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/padapook/bestbit-core/internal/account/model"
+	"github.com/padapook/bestbit-core/internal/utils/auth"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type revocationStore struct {
+	db *gorm.DB
+}
+
+func NewRevocationStore(db *gorm.DB) auth.RevocationStore {
+	return &revocationStore{db: db}
+}
+
+func (r *revocationStore) RevokeToken(tokenID, accountID string, expiresAt time.Time) error {
+	record := &model.RevokedToken{
+		TokenID:   tokenID,
+		AccountID: accountID,
+		ExpiresAt: expiresAt,
+	}
+	if err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(record).Error; err != nil {
+		return err
+	}
+
+	// หมดอายุแล้วไม่ต้องเก็บ เพราะ jwt ก็ไม่ผ่านอยู่แล้ว
+	return r.db.Where("expires_at < ?", time.Now()).Delete(&model.RevokedToken{}).Error
+}
+
+// เก็บ sid ในตารางเดียวกับ jti เพราะเป็น uuid คนละชุดกันอยู่แล้ว
+func (r *revocationStore) RevokeSession(sessionID, accountID string, expiresAt time.Time) error {
+	return r.RevokeToken(sessionID, accountID, expiresAt)
+}
+
+// เก็บ cutoff ล่าสุดเสมอ ไม่ให้ถอยหลัง
+func (r *revocationStore) RevokeAllBefore(accountID string, before time.Time) error {
+	record := &model.TokenRevocation{
+		AccountID:     accountID,
+		RevokedBefore: auth.RevocationCutoff(before),
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "account_id"}},
+		DoUpdates: clause.Set{{
+			Column: clause.Column{Name: "revoked_before"},
+			Value:  gorm.Expr("GREATEST(token_revocations.revoked_before, EXCLUDED.revoked_before)"),
+		}, {
+			Column: clause.Column{Name: "updated_at"},
+			Value:  gorm.Expr("EXCLUDED.updated_at"),
+		}},
+	}).Create(record).Error
+}
+
+func (r *revocationStore) IsRevoked(claims *auth.Claims) (bool, error) {
+	var ids []string
+	for _, id := range []string{claims.ID, claims.SessionID} {
+		if id != "" {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) > 0 {
+		var count int64
+		if err := r.db.Model(&model.RevokedToken{}).
+			Where("token_id IN ?", ids).
+			Count(&count).Error; err != nil {
+			return false, err
+		}
+		if count > 0 {
+			return true, nil
+		}
+	}
+
+	var revocation model.TokenRevocation
+	err := r.db.Where("account_id = ?", claims.AccountID).First(&revocation).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return auth.IssuedBefore(claims, revocation.RevokedBefore), nil
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"github.com/padapook/bestbit-core/internal/account/model"
+	"github.com/padapook/bestbit-core/internal/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RoleRepository interface {
+	GetRoles(accountID string) ([]model.UserRole, error)
+	// แทนที่ role ทั้งชุดของ user ใน transaction เดียว
+	ReplaceRoles(accountID string, roles []string, actor string) ([]model.UserRole, error)
+	// เพิ่ม role ที่ยังไม่มี ไม่ลบของเดิม ใช้ตอน bootstrap
+	GrantRole(accountID, role, actor string) error
+}
+
+type roleRepository struct {
+	db *gorm.DB
+}
+
+func NewRoleRepository(db *gorm.DB) RoleRepository {
+	return &roleRepository{db: db}
+}
+
+func (r *roleRepository) GetRoles(accountID string) ([]model.UserRole, error) {
+	var roles []model.UserRole
+	err := r.db.Where("account_id = ?", accountID).Order("role ASC").Find(&roles).Error
+	return roles, err
+}
+
+func (r *roleRepository) ReplaceRoles(accountID string, roles []string, actor string) ([]model.UserRole, error) {
+	records := make([]model.UserRole, 0, len(roles))
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&model.User{}).Where("account_id = ?", accountID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return utils.ErrUserNotFound
+		}
+
+		if err := tx.Where("account_id = ?", accountID).Delete(&model.UserRole{}).Error; err != nil {
+			return err
+		}
+
+		for _, role := range roles {
+			records = append(records, model.UserRole{AccountID: accountID, Role: role, CreatedBy: actor})
+		}
+		if len(records) == 0 {
+			return nil
+		}
+		return tx.Create(&records).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func (r *roleRepository) GrantRole(accountID, role, actor string) error {
+	record := &model.UserRole{AccountID: accountID, Role: role, CreatedBy: actor}
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(record).Error
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/padapook/bestbit-core/internal/account/model"
+	"github.com/padapook/bestbit-core/internal/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SessionRepository interface {
+	CreateSession(tx *gorm.DB, session *model.Session) error
+	// deviceID ว่าง = session ใดก็ได้ของ account
+	HasSession(accountID, deviceID string) (bool, error)
+	// ยังไม่ถูก revoke และ last seen หลัง since
+	GetActiveSessions(accountID string, since time.Time) ([]model.Session, error)
+	// ไม่เจอหรือ revoke ไปแล้วคืน ErrSessionNotFound
+	GetSessionForUpdate(tx *gorm.DB, accountID string, id uint64) (*model.Session, error)
+	UpdateSession(tx *gorm.DB, session *model.Session) error
+	// session ที่สร้างก่อนมีตารางนี้ไม่มี row ไม่ถือเป็น error
+	TouchSession(tx *gorm.DB, sessionID string, at time.Time) error
+	EndSession(tx *gorm.DB, sessionID string, at time.Time) error
+}
+
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository(db *gorm.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+func (r *sessionRepository) CreateSession(tx *gorm.DB, session *model.Session) error {
+	return tx.Create(session).Error
+}
+
+func (r *sessionRepository) HasSession(accountID, deviceID string) (bool, error) {
+	query := r.db.Model(&model.Session{}).Where("account_id = ?", accountID)
+	if deviceID != "" {
+		query = query.Where("device_id = ?", deviceID)
+	}
+
+	var count int64
+	if err := query.Limit(1).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *sessionRepository) GetActiveSessions(accountID string, since time.Time) ([]model.Session, error) {
+	var sessions []model.Session
+	err := r.db.Where("account_id = ? AND revoked_at IS NULL AND last_seen_at > ?", accountID, since).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+func (r *sessionRepository) GetSessionForUpdate(tx *gorm.DB, accountID string, id uint64) (*model.Session, error) {
+	var session model.Session
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND account_id = ? AND revoked_at IS NULL", id, accountID).
+		First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.ErrSessionNotFound
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *sessionRepository) UpdateSession(tx *gorm.DB, session *model.Session) error {
+	return tx.Save(session).Error
+}
+
+func (r *sessionRepository) TouchSession(tx *gorm.DB, sessionID string, at time.Time) error {
+	return tx.Model(&model.Session{}).
+		Where("session_id = ? AND revoked_at IS NULL", sessionID).
+		Update("last_seen_at", at).Error
+}
+
+func (r *sessionRepository) EndSession(tx *gorm.DB, sessionID string, at time.Time) error {
+	return tx.Model(&model.Session{}).
+		Where("session_id = ? AND revoked_at IS NULL", sessionID).
+		Update("revoked_at", at).Error
+}
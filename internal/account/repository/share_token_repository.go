@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/padapook/bestbit-core/internal/account/model"
+	"github.com/padapook/bestbit-core/internal/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ShareTokenRepository interface {
+	Transaction(fn func(tx *gorm.DB) error) error
+	CreateShareToken(token *model.ShareToken) error
+	GetByTokenIDForUpdate(tx *gorm.DB, tokenID string) (*model.ShareToken, error)
+	UpdateShareToken(tx *gorm.DB, token *model.ShareToken) error
+	GetOutstanding(accountID string, now time.Time) ([]model.ShareToken, error)
+	// revoke เฉพาะของ account นั้นที่ยังใช้ได้อยู่, ไม่เจอคืน ErrShareTokenNotFound
+	Revoke(accountID string, id uint64, revokedAt time.Time) error
+}
+
+type shareTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewShareTokenRepository(db *gorm.DB) ShareTokenRepository {
+	return &shareTokenRepository{db: db}
+}
+
+func (r *shareTokenRepository) Transaction(fn func(tx *gorm.DB) error) error {
+	return r.db.Transaction(fn)
+}
+
+func (r *shareTokenRepository) CreateShareToken(token *model.ShareToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *shareTokenRepository) GetByTokenIDForUpdate(tx *gorm.DB, tokenID string) (*model.ShareToken, error) {
+	var token model.ShareToken
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("token_id = ?", tokenID).
+		First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.ErrInvalidShareToken
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *shareTokenRepository) UpdateShareToken(tx *gorm.DB, token *model.ShareToken) error {
+	return tx.Save(token).Error
+}
+
+func (r *shareTokenRepository) GetOutstanding(accountID string, now time.Time) ([]model.ShareToken, error) {
+	var tokens []model.ShareToken
+	err := r.db.Where("account_id = ? AND consumed_at IS NULL AND revoked_at IS NULL AND expires_at > ?", accountID, now).
+		Order("created_at DESC").
+		Find(&tokens).Error
+	return tokens, err
+}
+
+func (r *shareTokenRepository) Revoke(accountID string, id uint64, revokedAt time.Time) error {
+	result := r.db.Model(&model.ShareToken{}).
+		Where("id = ? AND account_id = ? AND consumed_at IS NULL AND revoked_at IS NULL AND expires_at > ?", id, accountID, revokedAt).
+		Update("revoked_at", revokedAt)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return utils.ErrShareTokenNotFound
+	}
+	return nil
+}
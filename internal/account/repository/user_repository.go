@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"strings"
+
 	"github.com/padapook/bestbit-core/internal/account/model"
 	"gorm.io/gorm"
 )
@@ -8,6 +10,12 @@ import (
 type UserRepository interface {
 	CreateUser(tx *gorm.DB, user *model.User) error
 	GetByUsername(username string) (*model.User, error)
+	GetByAccountID(accountID string) (*model.User, error)
+	GetByEmail(email string) (*model.User, error)
+	// ค้นจาก account id ตรงตัว หรือ username/email/ชื่อ แบบมีคำนี้อยู่
+	SearchUsers(query string, limit, offset int) ([]model.User, int64, error)
+	// เปลี่ยนเฉพาะถ้า hash ยังเป็นตัวเดิม กันทับรหัสผ่านที่เพิ่งถูกเปลี่ยนไประหว่างนั้น
+	UpdatePasswordHash(accountID, oldHash, newHash string) error
 }
 
 type userRepository struct {
@@ -25,7 +33,55 @@ func (r *userRepository) CreateUser(tx *gorm.DB, user *model.User) error {
 func (r *userRepository) GetByUsername(username string) (*model.User, error) {
 	var user model.User
 
-	err := r.db.Where("username = ?", username).First(&user).Error
+	err := r.db.Preload("Roles").Where("username = ?", username).First(&user).Error
+
+	return &user, err
+}
+
+func (r *userRepository) GetByAccountID(accountID string) (*model.User, error) {
+	var user model.User
+
+	err := r.db.Preload("Roles").Where("account_id = ?", accountID).First(&user).Error
+
+	return &user, err
+}
+
+// email ไม่สนตัวพิมพ์เล็กใหญ่ ใช้ตอนลืมรหัสผ่าน
+func (r *userRepository) GetByEmail(email string) (*model.User, error) {
+	var user model.User
+
+	err := r.db.Where("LOWER(email) = LOWER(?)", email).First(&user).Error
 
 	return &user, err
 }
+
+func (r *userRepository) SearchUsers(query string, limit, offset int) ([]model.User, int64, error) {
+	var users []model.User
+	var total int64
+
+	db := r.db.Model(&model.User{})
+	if query = strings.TrimSpace(query); query != "" {
+		like := "%" + escapeLike(query) + "%"
+		db = db.Where("account_id = ? OR username ILIKE ? OR email ILIKE ? OR first_name ILIKE ? OR last_name ILIKE ?",
+			query, like, like, like, like)
+	}
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := db.Preload("Roles").Order("id ASC").Limit(limit).Offset(offset).Find(&users).Error
+	return users, total, err
+}
+
+// กัน % กับ _ ที่ผู้ใช้พิมพ์มาไม่ให้กลายเป็น wildcard
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// แค่เปลี่ยนรูปแบบ hash ของรหัสผ่านเดิม ไม่นับเป็นการแก้ข้อมูล user เลยไม่แตะ updated_at
+func (r *userRepository) UpdatePasswordHash(accountID, oldHash, newHash string) error {
+	return r.db.Model(&model.User{}).
+		Where("account_id = ? AND password = ?", accountID, oldHash).
+		UpdateColumn("password", newHash).Error
+}
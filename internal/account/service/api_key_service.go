@@ -0,0 +1,202 @@
+package service
+
+import (
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/padapook/bestbit-core/internal/account/model"
+	"github.com/padapook/bestbit-core/internal/account/repository"
+	"github.com/padapook/bestbit-core/internal/utils"
+	"github.com/padapook/bestbit-core/internal/utils/auth"
+	"github.com/padapook/bestbit-core/internal/utils/crypto"
+)
+
+const (
+	// timestamp ของ request ต่างจากเวลา server ได้ไม่เกินนี้ทั้งสองทาง
+	APIKeyReplayWindow = 30 * time.Second
+	// last_used_at ไม่ต้องละเอียดทุก request
+	apiKeyLastUsedInterval = time.Minute
+)
+
+type APIKeyService interface {
+	Create(accountID string, input CreateAPIKeyInput) (*IssuedAPIKey, error)
+	GetAPIKeys(accountID string) ([]model.APIKey, error)
+	Revoke(accountID string, id uint64) error
+	// ตรวจ key, signature, เวลา, ip และการส่งซ้ำ แล้วคืนเจ้าของ key
+	Authenticate(req SignedRequest) (*model.User, *model.APIKey, error)
+}
+
+type CreateAPIKeyInput struct {
+	Label       string
+	Permissions []string
+	AllowedIPs  []string
+	ExpiresAt   *time.Time
+}
+
+// secret มีให้เห็นตอนสร้างครั้งเดียว
+type IssuedAPIKey struct {
+	Secret string        `json:"secret"`
+	Key    *model.APIKey `json:"api_key"`
+}
+
+type SignedRequest struct {
+	KeyID     string
+	Timestamp string
+	Signature string
+	Method    string
+	Path      string
+	Body      []byte
+	ClientIP  string
+}
+
+type apiKeyService struct {
+	repo      repository.APIKeyRepository
+	users     repository.UserRepository
+	replays   auth.ReplayStore
+	secretKey []byte
+	now       func() time.Time
+}
+
+// secretKey ว่าง = สร้างและใช้ API key ไม่ได้
+func NewAPIKeyService(repo repository.APIKeyRepository, users repository.UserRepository, replays auth.ReplayStore, secretKey []byte) APIKeyService {
+	return &apiKeyService{repo: repo, users: users, replays: replays, secretKey: secretKey, now: time.Now}
+}
+
+func (s *apiKeyService) Create(accountID string, input CreateAPIKeyInput) (*IssuedAPIKey, error) {
+	if len(s.secretKey) == 0 {
+		return nil, utils.ErrAPIKeysUnavailable
+	}
+
+	label := strings.TrimSpace(input.Label)
+	if label == "" || len(label) > 100 || len(input.Permissions) == 0 {
+		return nil, utils.ErrInvalidAPIKeyRequest
+	}
+
+	permissions := make([]string, 0, len(input.Permissions))
+	for _, p := range input.Permissions {
+		if !model.IsValidAPIKeyPermission(p) {
+			return nil, utils.ErrInvalidAPIKeyRequest
+		}
+		if !slices.Contains(permissions, p) {
+			permissions = append(permissions, p)
+		}
+	}
+
+	for _, ip := range input.AllowedIPs {
+		if !isValidIPOrCIDR(ip) {
+			return nil, utils.ErrInvalidAPIKeyRequest
+		}
+	}
+
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(s.now()) {
+		return nil, utils.ErrInvalidAPIKeyRequest
+	}
+
+	keyID, err := crypto.GenerateToken(12)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := crypto.GenerateToken(32)
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := crypto.EncryptSecret(s.secretKey, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &model.APIKey{
+		KeyID:           keyID,
+		AccountID:       accountID,
+		Label:           label,
+		SecretEncrypted: encrypted,
+		Permissions:     permissions,
+		AllowedIPs:      input.AllowedIPs,
+		ExpiresAt:       input.ExpiresAt,
+	}
+	if err := s.repo.CreateAPIKey(key); err != nil {
+		return nil, err
+	}
+
+	return &IssuedAPIKey{Secret: secret, Key: key}, nil
+}
+
+func (s *apiKeyService) GetAPIKeys(accountID string) ([]model.APIKey, error) {
+	return s.repo.GetByAccountID(accountID)
+}
+
+func (s *apiKeyService) Revoke(accountID string, id uint64) error {
+	return s.repo.Revoke(accountID, id, s.now())
+}
+
+// client sign ด้วย secret ที่ได้ตอนสร้างเป็น key ของ HMAC, db มีแค่ secret ที่เข้ารหัสไว้
+func (s *apiKeyService) Authenticate(req SignedRequest) (*model.User, *model.APIKey, error) {
+	if len(s.secretKey) == 0 {
+		return nil, nil, utils.ErrAPIKeysUnavailable
+	}
+	now := s.now()
+
+	unix, err := strconv.ParseInt(req.Timestamp, 10, 64)
+	if err != nil {
+		return nil, nil, utils.ErrAPIRequestExpired
+	}
+	signedAt := time.Unix(unix, 0)
+	if signedAt.Before(now.Add(-APIKeyReplayWindow)) || signedAt.After(now.Add(APIKeyReplayWindow)) {
+		return nil, nil, utils.ErrAPIRequestExpired
+	}
+
+	key, err := s.repo.GetByKeyID(req.KeyID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !key.IsUsable(now) {
+		return nil, nil, utils.ErrInvalidAPIKey
+	}
+	secret, err := crypto.DecryptSecret(s.secretKey, key.SecretEncrypted)
+	if err != nil {
+		return nil, nil, utils.ErrInvalidAPIKey
+	}
+	if !crypto.VerifyRequestSignature(secret, req.Timestamp, req.Method, req.Path, req.Body, req.Signature) {
+		return nil, nil, utils.ErrInvalidAPIKey
+	}
+	if !key.AllowsIP(req.ClientIP) {
+		return nil, nil, utils.ErrAPIKeyIPNotAllowed
+	}
+
+	// signature ผูกกับ timestamp แล้ว เก็บไว้แค่จนกว่า timestamp นั้นจะหลุด window
+	fresh, err := s.replays.Use(key.KeyID+":"+req.Signature, signedAt.Add(APIKeyReplayWindow))
+	if err != nil {
+		return nil, nil, err
+	}
+	if !fresh {
+		return nil, nil, utils.ErrAPIRequestReplayed
+	}
+
+	user, err := s.users.GetByAccountID(key.AccountID)
+	if err != nil {
+		return nil, nil, utils.ErrInvalidAPIKey
+	}
+	if !user.IsActive {
+		return nil, nil, utils.ErrAccountFrozen
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyLastUsedInterval {
+		if err := s.repo.UpdateLastUsed(key.ID, now); err != nil {
+			return nil, nil, err
+		}
+		key.LastUsedAt = &now
+	}
+
+	return user, key, nil
+}
+
+func isValidIPOrCIDR(value string) bool {
+	if strings.Contains(value, "/") {
+		_, _, err := net.ParseCIDR(value)
+		return err == nil
+	}
+	return net.ParseIP(value) != nil
+}
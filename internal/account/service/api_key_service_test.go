@@ -0,0 +1,251 @@
+package service
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/padapook/bestbit-core/internal/account/model"
+	"github.com/padapook/bestbit-core/internal/utils"
+	"github.com/padapook/bestbit-core/internal/utils/auth"
+	"github.com/padapook/bestbit-core/internal/utils/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockAPIKeyRepository struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyRepository) CreateAPIKey(key *model.APIKey) error {
+	args := m.Called(key)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) GetByKeyID(keyID string) (*model.APIKey, error) {
+	args := m.Called(keyID)
+	if args.Get(0) != nil {
+		return args.Get(0).(*model.APIKey), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) GetByAccountID(accountID string) ([]model.APIKey, error) {
+	args := m.Called(accountID)
+	if args.Get(0) != nil {
+		return args.Get(0).([]model.APIKey), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) Revoke(accountID string, id uint64, revokedAt time.Time) error {
+	args := m.Called(accountID, id, revokedAt)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) UpdateLastUsed(id uint64, usedAt time.Time) error {
+	args := m.Called(id, usedAt)
+	return args.Error(0)
+}
+
+func newTestAPIKeyService() (*apiKeyService, *MockAPIKeyRepository, *MockUserRepository) {
+	repo := new(MockAPIKeyRepository)
+	userRepo := new(MockUserRepository)
+	service := NewAPIKeyService(repo, userRepo, auth.NewMemoryReplayStore(), testAPIKeySecretKey).(*apiKeyService)
+	return service, repo, userRepo
+}
+
+var testAPIKeySecretKey = []byte("api-key-test-key")
+
+func testAPIKey(t *testing.T, secret string) *model.APIKey {
+	t.Helper()
+	encrypted, err := crypto.EncryptSecret(testAPIKeySecretKey, secret)
+	require.NoError(t, err)
+	return &model.APIKey{
+		ID:              1,
+		KeyID:           "key-1",
+		AccountID:       "acc-123",
+		Label:           "bot",
+		SecretEncrypted: encrypted,
+		Permissions:     []string{model.APIKeyPermRead, model.APIKeyPermTrade},
+	}
+}
+
+func signedRequest(secret string, at time.Time, body string) SignedRequest {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	return SignedRequest{
+		KeyID:     "key-1",
+		Timestamp: timestamp,
+		Signature: crypto.SignRequest(secret, timestamp, "POST", "/api/v1/order", []byte(body)),
+		Method:    "POST",
+		Path:      "/api/v1/order",
+		Body:      []byte(body),
+		ClientIP:  "10.0.0.5",
+	}
+}
+
+func TestCreateAPIKey_StoresEncryptedSecret(t *testing.T) {
+	service, repo, _ := newTestAPIKeyService()
+
+	var saved *model.APIKey
+	repo.On("CreateAPIKey", mock.AnythingOfType("*model.APIKey")).
+		Run(func(args mock.Arguments) { saved = args.Get(0).(*model.APIKey) }).
+		Return(nil)
+
+	issued, err := service.Create("acc-123", CreateAPIKeyInput{
+		Label:       " bot ",
+		Permissions: []string{model.APIKeyPermTrade, model.APIKeyPermTrade},
+		AllowedIPs:  []string{"10.0.0.0/24"},
+	})
+
+	require.NoError(t, err)
+	assert.NotEmpty(t, issued.Secret)
+	assert.NotContains(t, saved.SecretEncrypted, issued.Secret)
+	decrypted, err := crypto.DecryptSecret(testAPIKeySecretKey, saved.SecretEncrypted)
+	require.NoError(t, err)
+	assert.Equal(t, issued.Secret, decrypted)
+	assert.Equal(t, "bot", saved.Label)
+	assert.Equal(t, []string{model.APIKeyPermTrade}, saved.Permissions)
+}
+
+func TestCreateAPIKey_Fail_InvalidInput(t *testing.T) {
+	service, repo, _ := newTestAPIKeyService()
+	past := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name  string
+		input CreateAPIKeyInput
+	}{
+		{"no permission", CreateAPIKeyInput{Label: "bot"}},
+		{"unknown permission", CreateAPIKeyInput{Label: "bot", Permissions: []string{"admin"}}},
+		{"bad ip", CreateAPIKeyInput{Label: "bot", Permissions: []string{model.APIKeyPermRead}, AllowedIPs: []string{"not-an-ip"}}},
+		{"expired", CreateAPIKeyInput{Label: "bot", Permissions: []string{model.APIKeyPermRead}, ExpiresAt: &past}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.Create("acc-123", tt.input)
+			assert.Equal(t, utils.ErrInvalidAPIKeyRequest, err)
+		})
+	}
+	repo.AssertNotCalled(t, "CreateAPIKey", mock.Anything)
+}
+
+func TestAuthenticate_Success(t *testing.T) {
+	service, repo, userRepo := newTestAPIKeyService()
+	key := testAPIKey(t, "secret")
+	repo.On("GetByKeyID", "key-1").Return(key, nil)
+	repo.On("UpdateLastUsed", uint64(1), mock.AnythingOfType("time.Time")).Return(nil)
+	userRepo.On("GetByAccountID", "acc-123").Return(testUser(), nil)
+
+	user, gotKey, err := service.Authenticate(signedRequest("secret", time.Now(), `{"symbol":"BTC-THB"}`))
+
+	require.NoError(t, err)
+	assert.Equal(t, "acc-123", user.AccountId)
+	assert.Equal(t, key, gotKey)
+	assert.NotNil(t, key.LastUsedAt)
+}
+
+func TestAuthenticate_Fail_TamperedBody(t *testing.T) {
+	service, repo, _ := newTestAPIKeyService()
+	repo.On("GetByKeyID", "key-1").Return(testAPIKey(t, "secret"), nil)
+
+	req := signedRequest("secret", time.Now(), `{"amount":"1"}`)
+	req.Body = []byte(`{"amount":"100"}`)
+
+	_, _, err := service.Authenticate(req)
+
+	assert.Equal(t, utils.ErrInvalidAPIKey, err)
+}
+
+// ค่าที่อยู่ใน db ต้องใช้ sign แทน secret ไม่ได้
+func TestAuthenticate_Fail_SignedWithStoredValue(t *testing.T) {
+	service, repo, _ := newTestAPIKeyService()
+	key := testAPIKey(t, "secret")
+	repo.On("GetByKeyID", "key-1").Return(key, nil)
+
+	_, _, err := service.Authenticate(signedRequest(key.SecretEncrypted, time.Now(), `{}`))
+
+	assert.Equal(t, utils.ErrInvalidAPIKey, err)
+}
+
+func TestAPIKeys_UnavailableWithoutSecretKey(t *testing.T) {
+	repo := new(MockAPIKeyRepository)
+	service := NewAPIKeyService(repo, new(MockUserRepository), auth.NewMemoryReplayStore(), nil)
+
+	_, err := service.Create("acc-123", CreateAPIKeyInput{Label: "bot", Permissions: []string{model.APIKeyPermRead}})
+	assert.Equal(t, utils.ErrAPIKeysUnavailable, err)
+
+	_, _, err = service.Authenticate(signedRequest("secret", time.Now(), `{}`))
+	assert.Equal(t, utils.ErrAPIKeysUnavailable, err)
+	repo.AssertNotCalled(t, "GetByKeyID", mock.Anything)
+}
+
+func TestAuthenticate_Fail_OutsideWindow(t *testing.T) {
+	service, repo, _ := newTestAPIKeyService()
+
+	_, _, err := service.Authenticate(signedRequest("secret", time.Now().Add(-APIKeyReplayWindow-time.Second), ""))
+
+	assert.Equal(t, utils.ErrAPIRequestExpired, err)
+	repo.AssertNotCalled(t, "GetByKeyID", mock.Anything)
+}
+
+func TestAuthenticate_Fail_Replayed(t *testing.T) {
+	service, repo, userRepo := newTestAPIKeyService()
+	repo.On("GetByKeyID", "key-1").Return(testAPIKey(t, "secret"), nil)
+	repo.On("UpdateLastUsed", uint64(1), mock.AnythingOfType("time.Time")).Return(nil)
+	userRepo.On("GetByAccountID", "acc-123").Return(testUser(), nil)
+
+	req := signedRequest("secret", time.Now(), "{}")
+	_, _, err := service.Authenticate(req)
+	require.NoError(t, err)
+
+	_, _, err = service.Authenticate(req)
+	assert.Equal(t, utils.ErrAPIRequestReplayed, err)
+}
+
+func TestAuthenticate_Fail_IPNotAllowed(t *testing.T) {
+	service, repo, _ := newTestAPIKeyService()
+	key := testAPIKey(t, "secret")
+	key.AllowedIPs = []string{"192.168.1.0/24", "10.0.0.9"}
+	repo.On("GetByKeyID", "key-1").Return(key, nil)
+
+	_, _, err := service.Authenticate(signedRequest("secret", time.Now(), ""))
+
+	assert.Equal(t, utils.ErrAPIKeyIPNotAllowed, err)
+}
+
+func TestAuthenticate_Fail_RevokedOrExpired(t *testing.T) {
+	service, repo, _ := newTestAPIKeyService()
+	past := time.Now().Add(-time.Minute)
+
+	revoked := testAPIKey(t, "secret")
+	revoked.RevokedAt = &past
+	expired := testAPIKey(t, "secret")
+	expired.KeyID = "key-2"
+	expired.ExpiresAt = &past
+	repo.On("GetByKeyID", "key-1").Return(revoked, nil)
+	repo.On("GetByKeyID", "key-2").Return(expired, nil)
+
+	_, _, err := service.Authenticate(signedRequest("secret", time.Now(), ""))
+	assert.Equal(t, utils.ErrInvalidAPIKey, err)
+
+	req := signedRequest("secret", time.Now(), "")
+	req.KeyID = "key-2"
+	_, _, err = service.Authenticate(req)
+	assert.Equal(t, utils.ErrInvalidAPIKey, err)
+}
+
+func TestAuthenticate_Fail_AccountFrozen(t *testing.T) {
+	service, repo, userRepo := newTestAPIKeyService()
+	user := testUser()
+	user.IsActive = false
+	repo.On("GetByKeyID", "key-1").Return(testAPIKey(t, "secret"), nil)
+	userRepo.On("GetByAccountID", "acc-123").Return(user, nil)
+
+	_, _, err := service.Authenticate(signedRequest("secret", time.Now(), ""))
+
+	assert.Equal(t, utils.ErrAccountFrozen, err)
+	repo.AssertNotCalled(t, "UpdateLastUsed", mock.Anything, mock.Anything)
+}
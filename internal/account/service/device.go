@@ -0,0 +1,69 @@
+package service
+
+import "strings"
+
+// ข้อมูลเครื่องที่ขอ token มา เก็บไว้กับ session
+type ClientInfo struct {
+	// id ถาวรของเครื่องจาก cookie หรือ X-Device-ID, ว่าง = ไม่รู้จักเครื่อง
+	DeviceID  string
+	UserAgent string
+	IPAddress string
+}
+
+var deviceOSNames = []struct{ token, name string }{
+	{"iPhone", "iOS"},
+	{"iPad", "iPadOS"},
+	{"Android", "Android"},
+	{"Windows", "Windows"},
+	{"Mac OS X", "macOS"},
+	{"CrOS", "ChromeOS"},
+	{"Linux", "Linux"},
+}
+
+// ลำดับสำคัญ UA ของ Edge/Opera มีคำว่า Chrome และ Safari ติดมาด้วย
+var deviceBrowserNames = []struct{ token, name string }{
+	{"Edg/", "Edge"},
+	{"OPR/", "Opera"},
+	{"Firefox/", "Firefox"},
+	{"Chrome/", "Chrome"},
+	{"Safari/", "Safari"},
+}
+
+// ชื่อเครื่องแบบคร่าวๆ เช่น "Chrome on macOS" ไว้แสดงในรายการ session และ email แจ้งเตือน
+func deviceLabel(userAgent string) string {
+	userAgent = strings.TrimSpace(userAgent)
+	if userAgent == "" {
+		return "Unknown device"
+	}
+
+	var browser, os string
+	for _, b := range deviceBrowserNames {
+		if strings.Contains(userAgent, b.token) {
+			browser = b.name
+			break
+		}
+	}
+	for _, o := range deviceOSNames {
+		if strings.Contains(userAgent, o.token) {
+			os = o.name
+			break
+		}
+	}
+
+	switch {
+	case browser != "" && os != "":
+		return browser + " on " + os
+	case browser != "":
+		return browser
+	case os != "":
+		return os
+	}
+
+	// bot/script เช่น curl/8.0 หรือ python-requests/2.31 ใช้ชื่อ client
+	name, _, _ := strings.Cut(userAgent, "/")
+	name, _, _ = strings.Cut(name, " ")
+	if len(name) > 100 {
+		name = name[:100]
+	}
+	return name
+}
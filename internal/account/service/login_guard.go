@@ -0,0 +1,161 @@
+package service
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"github.com/padapook/bestbit-core/internal/account/repository"
+	"github.com/padapook/bestbit-core/internal/utils"
+	"github.com/padapook/bestbit-core/internal/utils/auth"
+)
+
+type LoginGuard interface {
+	// เรียกก่อนตรวจรหัสผ่าน ไม่ต้องเสีย argon2 กับคนที่ยังต้องรอ, คืนเวลาที่ต้องรอไว้ตอบ Retry-After
+	Check(username, clientIP string) (time.Duration, error)
+	RecordFailure(username, clientIP string) error
+	// ล้างเฉพาะตัวนับของ username, ตัวนับ ip ไม่ล้าง ไม่งั้น login account ตัวเองสลับกับเดารหัสคนอื่นได้
+	RecordSuccess(username string) error
+	// admin ปลด lock ของ account ก่อนหมดเวลา
+	UnlockAccount(accountID string) error
+}
+
+type LoginGuardPolicy struct {
+	// ผิดห่างกันเกินนี้เริ่มนับใหม่
+	Window time.Duration
+	// ผิดครบเท่านี้ต้องรอก่อนลองใหม่ 1, 2, 4, ... วินาที ไม่เกิน MaxDelay
+	DelayAfter int
+	MaxDelay   time.Duration
+	// username ผิดครบเท่านี้ lock และแจ้ง user
+	UserLockAfter int
+	// ip เดียวผิดครบเท่านี้ (รวมทุก username) lock ip
+	IPLockAfter  int
+	LockDuration time.Duration
+}
+
+func DefaultLoginGuardPolicy() LoginGuardPolicy {
+	return LoginGuardPolicy{
+		Window:        15 * time.Minute,
+		DelayAfter:    3,
+		MaxDelay:      30 * time.Second,
+		UserLockAfter: 10,
+		IPLockAfter:   50,
+		LockDuration:  15 * time.Minute,
+	}
+}
+
+type loginGuard struct {
+	store    auth.LoginAttemptStore
+	users    repository.UserRepository
+	notifier SecurityNotifier
+	policy   LoginGuardPolicy
+	now      func() time.Time
+}
+
+func NewLoginGuard(store auth.LoginAttemptStore, users repository.UserRepository, notifier SecurityNotifier, policy LoginGuardPolicy) LoginGuard {
+	return &loginGuard{store: store, users: users, notifier: notifier, policy: policy, now: time.Now}
+}
+
+func loginUserKey(username string) string {
+	return "user:" + strings.ToLower(strings.TrimSpace(username))
+}
+
+func loginIPKey(clientIP string) string {
+	return "ip:" + clientIP
+}
+
+func (g *loginGuard) Check(username, clientIP string) (time.Duration, error) {
+	now := g.now()
+
+	if clientIP != "" {
+		attempt, err := g.store.Get(loginIPKey(clientIP))
+		if err != nil {
+			return 0, err
+		}
+		if attempt != nil && attempt.IsLocked(now) {
+			return attempt.LockedUntil.Sub(now), utils.ErrLoginLocked
+		}
+	}
+
+	attempt, err := g.store.Get(loginUserKey(username))
+	if err != nil || attempt == nil {
+		return 0, err
+	}
+	if attempt.IsLocked(now) {
+		return attempt.LockedUntil.Sub(now), utils.ErrLoginLocked
+	}
+	if attempt.Failures < g.policy.DelayAfter || attempt.LastFailureAt.Before(now.Add(-g.policy.Window)) {
+		return 0, nil
+	}
+
+	nextAllowed := attempt.LastFailureAt.Add(g.delay(attempt.Failures))
+	if now.Before(nextAllowed) {
+		return nextAllowed.Sub(now), utils.ErrTooManyLoginAttempts
+	}
+	return 0, nil
+}
+
+func (g *loginGuard) delay(failures int) time.Duration {
+	shift := failures - g.policy.DelayAfter
+	if shift > 30 {
+		return g.policy.MaxDelay
+	}
+	delay := time.Second << shift
+	if delay > g.policy.MaxDelay {
+		return g.policy.MaxDelay
+	}
+	return delay
+}
+
+func (g *loginGuard) RecordFailure(username, clientIP string) error {
+	now := g.now()
+	until := now.Add(g.policy.LockDuration)
+
+	attempt, err := g.store.RecordFailure(loginUserKey(username), now, g.policy.Window)
+	if err != nil {
+		return err
+	}
+	// lock ตอนครบพอดีครั้งเดียว ระหว่าง lock Check ตัดไปก่อนแล้วเลยไม่นับเกิน
+	if attempt.Failures == g.policy.UserLockAfter {
+		if err := g.store.Lock(attempt.Key, until); err != nil {
+			return err
+		}
+		g.notifyLockout(username, until)
+	}
+
+	if clientIP == "" {
+		return nil
+	}
+	attempt, err = g.store.RecordFailure(loginIPKey(clientIP), now, g.policy.Window)
+	if err != nil {
+		return err
+	}
+	if attempt.Failures == g.policy.IPLockAfter {
+		log.Printf("[login] ip %s locked until %s", clientIP, until.Format(time.RFC3339))
+		return g.store.Lock(attempt.Key, until)
+	}
+	return nil
+}
+
+// username ที่ไม่มีจริงก็นับและ lock เหมือนกัน ไม่ให้ใช้แยกว่า account ไหนมีอยู่ แค่ไม่มีใครให้แจ้ง
+func (g *loginGuard) notifyLockout(username string, until time.Time) {
+	user, err := g.users.GetByUsername(strings.TrimSpace(username))
+	if err != nil || user == nil || user.AccountId == "" {
+		return
+	}
+	if err := g.notifier.NotifyLockout(user, until); err != nil {
+		log.Println("[login] notify lockout failed:", err)
+	}
+}
+
+func (g *loginGuard) RecordSuccess(username string) error {
+	return g.store.Reset(loginUserKey(username))
+}
+
+func (g *loginGuard) UnlockAccount(accountID string) error {
+	user, err := g.users.GetByAccountID(accountID)
+	if err != nil {
+		return utils.ErrUserNotFound
+	}
+	return g.store.Reset(loginUserKey(user.Username))
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/padapook/bestbit-core/internal/account/model"
+	"github.com/padapook/bestbit-core/internal/utils"
+	"github.com/padapook/bestbit-core/internal/utils/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type MockSecurityNotifier struct {
+	mock.Mock
+}
+
+func (m *MockSecurityNotifier) NotifyLockout(user *model.User, until time.Time) error {
+	args := m.Called(user, until)
+	return args.Error(0)
+}
+
+func (m *MockSecurityNotifier) NotifyNewDevice(user *model.User, session *model.Session) error {
+	args := m.Called(user, session)
+	return args.Error(0)
+}
+
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func newTestLoginGuard() (*loginGuard, *MockUserRepository, *MockSecurityNotifier, *testClock) {
+	userRepo := new(MockUserRepository)
+	notifier := new(MockSecurityNotifier)
+	clock := &testClock{now: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)}
+
+	guard := NewLoginGuard(auth.NewMemoryLoginAttemptStore(), userRepo, notifier, DefaultLoginGuardPolicy()).(*loginGuard)
+	guard.now = clock.Now
+	return guard, userRepo, notifier, clock
+}
+
+func TestLoginGuard_ProgressiveDelay(t *testing.T) {
+	guard, _, _, clock := newTestLoginGuard()
+
+	for i := 0; i < 3; i++ {
+		_, err := guard.Check("pook", "10.0.0.1")
+		require.NoError(t, err)
+		require.NoError(t, guard.RecordFailure("pook", "10.0.0.1"))
+	}
+
+	// ผิดครบ 3 ครั้ง ต้องรอ 1 วินาที
+	retryAfter, err := guard.Check("pook", "10.0.0.1")
+	assert.Equal(t, utils.ErrTooManyLoginAttempts, err)
+	assert.Equal(t, time.Second, retryAfter)
+
+	clock.now = clock.now.Add(time.Second)
+	_, err = guard.Check("pook", "10.0.0.1")
+	require.NoError(t, err)
+	require.NoError(t, guard.RecordFailure("pook", "10.0.0.1"))
+
+	// ครั้งที่ 4 ต้องรอ 2 วินาที
+	retryAfter, err = guard.Check("POOK", "10.0.0.2")
+	assert.Equal(t, utils.ErrTooManyLoginAttempts, err)
+	assert.Equal(t, 2*time.Second, retryAfter)
+}
+
+func TestLoginGuard_LocksAndNotifiesUser(t *testing.T) {
+	guard, userRepo, notifier, clock := newTestLoginGuard()
+	user := testUser()
+	until := clock.now.Add(15 * time.Minute)
+
+	userRepo.On("GetByUsername", "pook").Return(user, nil)
+	notifier.On("NotifyLockout", user, until).Return(nil).Once()
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, guard.RecordFailure("pook", ""))
+	}
+
+	retryAfter, err := guard.Check("pook", "")
+	assert.Equal(t, utils.ErrLoginLocked, err)
+	assert.Equal(t, 15*time.Minute, retryAfter)
+	notifier.AssertExpectations(t)
+
+	// หมดเวลา lock แล้วนับใหม่
+	clock.now = until
+	_, err = guard.Check("pook", "")
+	require.NoError(t, err)
+	require.NoError(t, guard.RecordFailure("pook", ""))
+	_, err = guard.Check("pook", "")
+	assert.NoError(t, err)
+}
+
+func TestLoginGuard_UnknownUsernameLockedWithoutNotify(t *testing.T) {
+	guard, userRepo, notifier, _ := newTestLoginGuard()
+
+	userRepo.On("GetByUsername", "ghost").Return(nil, gorm.ErrRecordNotFound)
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, guard.RecordFailure("ghost", ""))
+	}
+
+	_, err := guard.Check("ghost", "")
+	assert.Equal(t, utils.ErrLoginLocked, err)
+	notifier.AssertNotCalled(t, "NotifyLockout", mock.Anything, mock.Anything)
+}
+
+func TestLoginGuard_LocksIPAcrossUsernames(t *testing.T) {
+	guard, _, _, _ := newTestLoginGuard()
+
+	// ไล่ username ไม่ซ้ำกัน ไม่ให้ติด lock ราย user
+	for i := 0; i < 50; i++ {
+		require.NoError(t, guard.RecordFailure(fmt.Sprintf("user-%d", i), "10.0.0.1"))
+	}
+
+	_, err := guard.Check("someone-else", "10.0.0.1")
+	assert.Equal(t, utils.ErrLoginLocked, err)
+
+	_, err = guard.Check("someone-else", "10.0.0.2")
+	assert.NoError(t, err)
+}
+
+func TestLoginGuard_SuccessAndUnlockResetUserCounter(t *testing.T) {
+	guard, userRepo, notifier, _ := newTestLoginGuard()
+	user := testUser()
+
+	userRepo.On("GetByUsername", "pook").Return(user, nil)
+	userRepo.On("GetByAccountID", user.AccountId).Return(user, nil)
+	notifier.On("NotifyLockout", user, mock.Anything).Return(nil)
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, guard.RecordFailure("pook", ""))
+	}
+	require.NoError(t, guard.UnlockAccount(user.AccountId))
+
+	_, err := guard.Check("pook", "")
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, guard.RecordFailure("pook", ""))
+	}
+	require.NoError(t, guard.RecordSuccess("pook"))
+
+	_, err = guard.Check("pook", "")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,320 @@
+package service
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/padapook/bestbit-core/internal/account/model"
+	"github.com/padapook/bestbit-core/internal/account/repository"
+	"github.com/padapook/bestbit-core/internal/utils"
+	"github.com/padapook/bestbit-core/internal/utils/auth"
+	"github.com/padapook/bestbit-core/internal/utils/crypto"
+	"gorm.io/gorm"
+)
+
+const (
+	mfaIssuer          = "BestBit"
+	recoveryCodeCount  = 10
+	recoveryCodeLength = 10
+	// ตัดตัวที่อ่านสับสนออก (0/o, 1/l/i)
+	recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+	// code ผิดครบเท่านี้ภายใน window ล็อกทุกทางที่ตรวจ code ของ account นั้น กันไล่เดา 6 หลัก
+	mfaMaxFailures   = 5
+	mfaFailureWindow = 15 * time.Minute
+	mfaLockDuration  = 15 * time.Minute
+)
+
+type MFAService interface {
+	// สร้าง secret ใหม่ (ยังไม่เปิดใช้) คืน uri ไว้ทำ QR
+	Enroll(accountID, username string) (*MFAEnrollment, error)
+	// ยืนยัน code แรกแล้วเปิดใช้ คืน recovery code ซึ่งแสดงได้ครั้งเดียว
+	Activate(accountID, code string) ([]string, error)
+	Disable(accountID, code string) error
+	IsEnabled(accountID string) (bool, error)
+	// ขั้นที่สองของ login รับได้ทั้ง TOTP และ recovery code
+	VerifyLogin(accountID, code string) error
+	// ใช้กับรายการที่ต้องยืนยันซ้ำ (ถอน/โอน) รับเฉพาะ TOTP, user ที่ไม่ได้เปิด MFA ผ่านเลย
+	VerifyFreshCode(accountID, code string) error
+}
+
+type MFAEnrollment struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+type mfaService struct {
+	repo      repository.MFARepository
+	secretKey []byte
+	attempts  auth.LoginAttemptStore
+	now       func() time.Time
+}
+
+// secretKey ใช้เข้ารหัส TOTP secret ก่อนลง db, ว่าง = ปิด MFA (enroll/ตรวจ code ไม่ได้ user ที่ไม่ได้เปิดไว้ใช้งานได้ปกติ)
+// attempts ใช้ store เดียวกับ login นับ code ผิดต่อ account
+func NewMFAService(repo repository.MFARepository, secretKey []byte, attempts auth.LoginAttemptStore) MFAService {
+	return &mfaService{repo: repo, secretKey: secretKey, attempts: attempts, now: time.Now}
+}
+
+func (s *mfaService) Enroll(accountID, username string) (*MFAEnrollment, error) {
+	if len(s.secretKey) == 0 {
+		return nil, utils.ErrMFAUnavailable
+	}
+
+	secret, err := crypto.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := crypto.EncryptSecret(s.secretKey, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.Transaction(func(tx *gorm.DB) error {
+		record, err := s.repo.GetMFAForUpdate(tx, accountID)
+		if err != nil && !errors.Is(err, utils.ErrMFANotEnrolled) {
+			return err
+		}
+		if record == nil {
+			record = &model.UserMFA{AccountID: accountID}
+		}
+		// เปิดใช้อยู่แล้วต้องปิดก่อน ไม่งั้นใครได้ session ไปก็เปลี่ยนเครื่อง authenticator ได้
+		if record.Enabled {
+			return utils.ErrMFAAlreadyEnabled
+		}
+
+		record.Secret = encrypted
+		record.LastUsedStep = 0
+		return s.repo.SaveMFA(tx, record)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &MFAEnrollment{
+		Secret:     secret,
+		OTPAuthURI: crypto.TOTPURI(mfaIssuer, username, secret),
+	}, nil
+}
+
+func (s *mfaService) Activate(accountID, code string) ([]string, error) {
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.Transaction(func(tx *gorm.DB) error {
+		record, err := s.repo.GetMFAForUpdate(tx, accountID)
+		if err != nil {
+			return err
+		}
+		if record.Enabled {
+			return utils.ErrMFAAlreadyEnabled
+		}
+
+		if err := s.verifyTOTP(tx, record, code); err != nil {
+			return err
+		}
+
+		now := s.now()
+		record.Enabled = true
+		record.EnabledAt = &now
+		if err := s.repo.SaveMFA(tx, record); err != nil {
+			return err
+		}
+		return s.repo.ReplaceRecoveryCodes(tx, accountID, hashes)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (s *mfaService) Disable(accountID, code string) error {
+	return s.limitAttempts(accountID, func() error {
+		return s.repo.Transaction(func(tx *gorm.DB) error {
+			record, err := s.repo.GetMFAForUpdate(tx, accountID)
+			if err != nil {
+				return err
+			}
+			if !record.Enabled {
+				return utils.ErrMFANotEnrolled
+			}
+
+			if err := s.verifyCode(tx, record, code); err != nil {
+				return err
+			}
+			return s.repo.DeleteMFA(tx, accountID)
+		})
+	})
+}
+
+func (s *mfaService) IsEnabled(accountID string) (bool, error) {
+	record, err := s.repo.GetMFA(accountID)
+	if errors.Is(err, utils.ErrMFANotEnrolled) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return record.Enabled, nil
+}
+
+func (s *mfaService) VerifyLogin(accountID, code string) error {
+	return s.limitAttempts(accountID, func() error {
+		return s.repo.Transaction(func(tx *gorm.DB) error {
+			record, err := s.repo.GetMFAForUpdate(tx, accountID)
+			if err != nil {
+				return err
+			}
+			if !record.Enabled {
+				return utils.ErrMFANotEnrolled
+			}
+			return s.verifyCode(tx, record, code)
+		})
+	})
+}
+
+func (s *mfaService) VerifyFreshCode(accountID, code string) error {
+	enabled, err := s.IsEnabled(accountID)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		return nil
+	}
+	if strings.TrimSpace(code) == "" {
+		return utils.ErrMFARequired
+	}
+
+	return s.limitAttempts(accountID, func() error {
+		return s.repo.Transaction(func(tx *gorm.DB) error {
+			record, err := s.repo.GetMFAForUpdate(tx, accountID)
+			if err != nil {
+				return err
+			}
+			return s.verifyTOTP(tx, record, code)
+		})
+	})
+}
+
+func mfaAttemptKey(accountID string) string {
+	return "mfa:" + accountID
+}
+
+// ระหว่าง lock ไม่ตรวจ code เลย แม้ code ถูกก็ไม่ผ่าน, ผ่านแล้วล้างตัวนับ
+func (s *mfaService) limitAttempts(accountID string, verify func() error) error {
+	key := mfaAttemptKey(accountID)
+	now := s.now()
+
+	attempt, err := s.attempts.Get(key)
+	if err != nil {
+		return err
+	}
+	if attempt != nil && attempt.IsLocked(now) {
+		return utils.ErrMFALocked
+	}
+
+	err = verify()
+	switch {
+	case errors.Is(err, utils.ErrInvalidMFACode):
+		failed, recordErr := s.attempts.RecordFailure(key, now, mfaFailureWindow)
+		if recordErr != nil {
+			return recordErr
+		}
+		if failed.Failures >= mfaMaxFailures {
+			if lockErr := s.attempts.Lock(key, now.Add(mfaLockDuration)); lockErr != nil {
+				return lockErr
+			}
+		}
+		return err
+	case err == nil && attempt != nil:
+		return s.attempts.Reset(key)
+	}
+	return err
+}
+
+// code 6 หลักตรวจเป็น TOTP, นอกนั้นลองเป็น recovery code
+func (s *mfaService) verifyCode(tx *gorm.DB, record *model.UserMFA, code string) error {
+	code = strings.TrimSpace(code)
+	if len(code) == crypto.TOTPDigits {
+		return s.verifyTOTP(tx, record, code)
+	}
+	return s.useRecoveryCode(tx, record.AccountID, code)
+}
+
+func (s *mfaService) verifyTOTP(tx *gorm.DB, record *model.UserMFA, code string) error {
+	if len(s.secretKey) == 0 {
+		return utils.ErrMFAUnavailable
+	}
+	secret, err := crypto.DecryptSecret(s.secretKey, record.Secret)
+	if err != nil {
+		return err
+	}
+
+	step, ok := crypto.ValidateTOTP(secret, strings.TrimSpace(code), s.now(), record.LastUsedStep)
+	if !ok {
+		return utils.ErrInvalidMFACode
+	}
+
+	record.LastUsedStep = step
+	return s.repo.SaveMFA(tx, record)
+}
+
+func (s *mfaService) useRecoveryCode(tx *gorm.DB, accountID, code string) error {
+	code = normalizeRecoveryCode(code)
+	if len(code) != recoveryCodeLength {
+		return utils.ErrInvalidMFACode
+	}
+
+	stored, err := s.repo.GetUnusedRecoveryCodes(tx, accountID)
+	if err != nil {
+		return err
+	}
+
+	for _, candidate := range stored {
+		match, err := crypto.ComparePasswordAndHash(code, candidate.CodeHash)
+		if err != nil || !match {
+			continue
+		}
+		return s.repo.MarkRecoveryCodeUsed(tx, candidate.ID, s.now())
+	}
+	return utils.ErrInvalidMFACode
+}
+
+// แสดงเป็น xxxxx-xxxxx แต่ hash ตัวที่ไม่มีขีด ให้ user พิมพ์แบบไหนก็ได้
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+
+	max := big.NewInt(int64(len(recoveryCodeAlphabet)))
+	for i := 0; i < recoveryCodeCount; i++ {
+		raw := make([]byte, recoveryCodeLength)
+		for j := range raw {
+			n, err := rand.Int(rand.Reader, max)
+			if err != nil {
+				return nil, nil, err
+			}
+			raw[j] = recoveryCodeAlphabet[n.Int64()]
+		}
+
+		hash, err := crypto.HashPassword(string(raw))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		half := recoveryCodeLength / 2
+		codes = append(codes, string(raw[:half])+"-"+string(raw[half:]))
+		hashes = append(hashes, hash)
+	}
+	return codes, hashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
@@ -0,0 +1,259 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/padapook/bestbit-core/internal/account/model"
+	"github.com/padapook/bestbit-core/internal/utils"
+	"github.com/padapook/bestbit-core/internal/utils/auth"
+	"github.com/padapook/bestbit-core/internal/utils/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type MockMFARepository struct {
+	mock.Mock
+}
+
+func (m *MockMFARepository) Transaction(fn func(tx *gorm.DB) error) error {
+	return fn(nil)
+}
+
+func (m *MockMFARepository) GetMFA(accountID string) (*model.UserMFA, error) {
+	args := m.Called(accountID)
+	if args.Get(0) != nil {
+		return args.Get(0).(*model.UserMFA), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMFARepository) GetMFAForUpdate(tx *gorm.DB, accountID string) (*model.UserMFA, error) {
+	args := m.Called(tx, accountID)
+	if args.Get(0) != nil {
+		return args.Get(0).(*model.UserMFA), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMFARepository) SaveMFA(tx *gorm.DB, mfa *model.UserMFA) error {
+	args := m.Called(tx, mfa)
+	return args.Error(0)
+}
+
+func (m *MockMFARepository) DeleteMFA(tx *gorm.DB, accountID string) error {
+	args := m.Called(tx, accountID)
+	return args.Error(0)
+}
+
+func (m *MockMFARepository) ReplaceRecoveryCodes(tx *gorm.DB, accountID string, codeHashes []string) error {
+	args := m.Called(tx, accountID, codeHashes)
+	return args.Error(0)
+}
+
+func (m *MockMFARepository) GetUnusedRecoveryCodes(tx *gorm.DB, accountID string) ([]model.MFARecoveryCode, error) {
+	args := m.Called(tx, accountID)
+	if args.Get(0) != nil {
+		return args.Get(0).([]model.MFARecoveryCode), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMFARepository) MarkRecoveryCodeUsed(tx *gorm.DB, id uint64, usedAt time.Time) error {
+	args := m.Called(tx, id, usedAt)
+	return args.Error(0)
+}
+
+var testMFAKey = []byte("test-mfa-key")
+
+func newTestMFAService(now time.Time) (MFAService, *MockMFARepository) {
+	repo := new(MockMFARepository)
+	return &mfaService{repo: repo, secretKey: testMFAKey, attempts: auth.NewMemoryLoginAttemptStore(), now: func() time.Time { return now }}, repo
+}
+
+// record ที่ secret เข้ารหัสแล้ว กับ secret ตัวจริงไว้สร้าง code
+func testMFARecord(t *testing.T, enabled bool) (*model.UserMFA, string) {
+	secret, err := crypto.GenerateTOTPSecret()
+	require.NoError(t, err)
+	encrypted, err := crypto.EncryptSecret(testMFAKey, secret)
+	require.NoError(t, err)
+	return &model.UserMFA{AccountID: "acc-123", Secret: encrypted, Enabled: enabled}, secret
+}
+
+func currentCode(t *testing.T, secret string, now time.Time) string {
+	code, err := crypto.TOTPCode(secret, crypto.TOTPStep(now))
+	require.NoError(t, err)
+	return code
+}
+
+func TestEnroll_Success(t *testing.T) {
+	service, repo := newTestMFAService(time.Now())
+
+	var saved *model.UserMFA
+	repo.On("GetMFAForUpdate", mock.Anything, "acc-123").Return(nil, utils.ErrMFANotEnrolled)
+	repo.On("SaveMFA", mock.Anything, mock.AnythingOfType("*model.UserMFA")).
+		Run(func(args mock.Arguments) { saved = args.Get(1).(*model.UserMFA) }).
+		Return(nil)
+
+	enrollment, err := service.Enroll("acc-123", "pook")
+
+	require.NoError(t, err)
+	assert.False(t, saved.Enabled)
+	assert.NotEqual(t, enrollment.Secret, saved.Secret)
+	assert.True(t, strings.HasPrefix(enrollment.OTPAuthURI, "otpauth://totp/BestBit:pook?"))
+	assert.Contains(t, enrollment.OTPAuthURI, "secret="+enrollment.Secret)
+
+	decrypted, err := crypto.DecryptSecret(testMFAKey, saved.Secret)
+	require.NoError(t, err)
+	assert.Equal(t, enrollment.Secret, decrypted)
+}
+
+func TestEnroll_Fail_AlreadyEnabled(t *testing.T) {
+	service, repo := newTestMFAService(time.Now())
+	record, _ := testMFARecord(t, true)
+
+	repo.On("GetMFAForUpdate", mock.Anything, "acc-123").Return(record, nil)
+
+	_, err := service.Enroll("acc-123", "pook")
+
+	assert.Equal(t, utils.ErrMFAAlreadyEnabled, err)
+	repo.AssertNotCalled(t, "SaveMFA", mock.Anything, mock.Anything)
+}
+
+func TestActivate_Success_ReturnsRecoveryCodes(t *testing.T) {
+	now := time.Now()
+	service, repo := newTestMFAService(now)
+	record, secret := testMFARecord(t, false)
+
+	var hashes []string
+	repo.On("GetMFAForUpdate", mock.Anything, "acc-123").Return(record, nil)
+	repo.On("SaveMFA", mock.Anything, record).Return(nil)
+	repo.On("ReplaceRecoveryCodes", mock.Anything, "acc-123", mock.Anything).
+		Run(func(args mock.Arguments) { hashes = args.Get(2).([]string) }).
+		Return(nil)
+
+	codes, err := service.Activate("acc-123", currentCode(t, secret, now))
+
+	require.NoError(t, err)
+	assert.True(t, record.Enabled)
+	assert.Equal(t, crypto.TOTPStep(now), record.LastUsedStep)
+	require.Len(t, codes, recoveryCodeCount)
+	require.Len(t, hashes, recoveryCodeCount)
+
+	// เก็บแค่ hash, code ที่แสดงมีขีดกลางแต่ hash ตัวที่ไม่มีขีด
+	match, err := crypto.ComparePasswordAndHash(normalizeRecoveryCode(codes[0]), hashes[0])
+	require.NoError(t, err)
+	assert.True(t, match)
+	assert.NotContains(t, hashes, codes[0])
+}
+
+func TestActivate_Fail_WrongCode(t *testing.T) {
+	service, repo := newTestMFAService(time.Now())
+	record, _ := testMFARecord(t, false)
+
+	repo.On("GetMFAForUpdate", mock.Anything, "acc-123").Return(record, nil)
+
+	_, err := service.Activate("acc-123", "000000")
+
+	assert.Equal(t, utils.ErrInvalidMFACode, err)
+	assert.False(t, record.Enabled)
+	repo.AssertNotCalled(t, "ReplaceRecoveryCodes", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestVerifyFreshCode_SkipsWhenNotEnabled(t *testing.T) {
+	service, repo := newTestMFAService(time.Now())
+
+	repo.On("GetMFA", "acc-123").Return(nil, utils.ErrMFANotEnrolled)
+
+	assert.NoError(t, service.VerifyFreshCode("acc-123", ""))
+}
+
+func TestVerifyFreshCode_Fail_MissingCode(t *testing.T) {
+	service, repo := newTestMFAService(time.Now())
+	record, _ := testMFARecord(t, true)
+
+	repo.On("GetMFA", "acc-123").Return(record, nil)
+
+	assert.Equal(t, utils.ErrMFARequired, service.VerifyFreshCode("acc-123", ""))
+}
+
+func TestVerifyFreshCode_Fail_CodeReused(t *testing.T) {
+	now := time.Now()
+	service, repo := newTestMFAService(now)
+	record, secret := testMFARecord(t, true)
+	code := currentCode(t, secret, now)
+
+	repo.On("GetMFA", "acc-123").Return(record, nil)
+	repo.On("GetMFAForUpdate", mock.Anything, "acc-123").Return(record, nil)
+	repo.On("SaveMFA", mock.Anything, record).Return(nil)
+
+	require.NoError(t, service.VerifyFreshCode("acc-123", code))
+	assert.Equal(t, utils.ErrInvalidMFACode, service.VerifyFreshCode("acc-123", code))
+}
+
+func TestVerifyFreshCode_Fail_RecoveryCodeRejected(t *testing.T) {
+	service, repo := newTestMFAService(time.Now())
+	record, _ := testMFARecord(t, true)
+
+	repo.On("GetMFA", "acc-123").Return(record, nil)
+	repo.On("GetMFAForUpdate", mock.Anything, "acc-123").Return(record, nil)
+
+	err := service.VerifyFreshCode("acc-123", "abcde-fghjk")
+
+	assert.Equal(t, utils.ErrInvalidMFACode, err)
+	repo.AssertNotCalled(t, "GetUnusedRecoveryCodes", mock.Anything, mock.Anything)
+}
+
+func TestVerifyLogin_Success_RecoveryCode(t *testing.T) {
+	now := time.Now()
+	service, repo := newTestMFAService(now)
+	record, _ := testMFARecord(t, true)
+
+	hash, err := crypto.HashPassword("abcdefghjk")
+	require.NoError(t, err)
+
+	repo.On("GetMFAForUpdate", mock.Anything, "acc-123").Return(record, nil)
+	repo.On("GetUnusedRecoveryCodes", mock.Anything, "acc-123").
+		Return([]model.MFARecoveryCode{{ID: 7, AccountID: "acc-123", CodeHash: hash}}, nil)
+	repo.On("MarkRecoveryCodeUsed", mock.Anything, uint64(7), now).Return(nil)
+
+	err = service.VerifyLogin("acc-123", "ABCDE-FGHJK")
+
+	require.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+// เดา code ผิดครบแล้ว code ที่ถูกก็ไม่ผ่านจนหมดเวลา lock
+func TestVerifyFreshCode_LocksAfterRepeatedFailures(t *testing.T) {
+	now := time.Now()
+	service, repo := newTestMFAService(now)
+	record, secret := testMFARecord(t, true)
+
+	repo.On("GetMFA", "acc-123").Return(record, nil)
+	repo.On("GetMFAForUpdate", mock.Anything, "acc-123").Return(record, nil)
+	repo.On("SaveMFA", mock.Anything, record).Return(nil)
+
+	for i := 0; i < mfaMaxFailures; i++ {
+		assert.Equal(t, utils.ErrInvalidMFACode, service.VerifyFreshCode("acc-123", "000000"))
+	}
+
+	assert.Equal(t, utils.ErrMFALocked, service.VerifyFreshCode("acc-123", currentCode(t, secret, now)))
+	assert.Equal(t, utils.ErrMFALocked, service.VerifyLogin("acc-123", currentCode(t, secret, now)))
+	repo.AssertNotCalled(t, "SaveMFA", mock.Anything, mock.Anything)
+
+	service.(*mfaService).now = func() time.Time { return now.Add(mfaLockDuration + time.Second) }
+	assert.NoError(t, service.VerifyFreshCode("acc-123", currentCode(t, secret, now.Add(mfaLockDuration+time.Second))))
+}
+
+func TestEnroll_Fail_SecretKeyMissing(t *testing.T) {
+	repo := new(MockMFARepository)
+	service := NewMFAService(repo, nil, auth.NewMemoryLoginAttemptStore())
+
+	_, err := service.Enroll("acc-123", "pook")
+
+	assert.Equal(t, utils.ErrMFAUnavailable, err)
+	repo.AssertNotCalled(t, "SaveMFA", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/padapook/bestbit-core/internal/account/model"
+	"github.com/padapook/bestbit-core/internal/account/repository"
+	"github.com/padapook/bestbit-core/internal/mail/mailer"
+	mailService "github.com/padapook/bestbit-core/internal/mail/service"
+	"github.com/padapook/bestbit-core/internal/utils"
+	"github.com/padapook/bestbit-core/internal/utils/auth"
+	"github.com/padapook/bestbit-core/internal/utils/crypto"
+	"gorm.io/gorm"
+)
+
+const (
+	PasswordResetTTL       = 30 * time.Minute
+	passwordResetTokenSize = 32
+)
+
+type PasswordService interface {
+	// token อื่นของ user ถูก revoke ผู้เรียกต้องออก token ใหม่ให้ session ปัจจุบัน
+	ChangePassword(accountID, currentPassword, newPassword string) (*model.User, error)
+	// email ไม่มีในระบบก็ไม่ error จะได้ใช้เดาไม่ได้ว่า email ไหนสมัครไว้
+	RequestReset(email string) error
+	ResetPassword(token, newPassword string) error
+}
+
+type passwordService struct {
+	repo        repository.PasswordRepository
+	users       repository.UserRepository
+	outbox      mailService.OutboxService
+	revocations auth.RevocationStore
+	// หน้าเว็บที่รับ token เช่น https://bestbit.example/reset-password, ว่าง = ส่งแค่ token
+	resetURL string
+	now      func() time.Time
+}
+
+func NewPasswordService(repo repository.PasswordRepository, users repository.UserRepository, outbox mailService.OutboxService,
+	revocations auth.RevocationStore, resetURL string) PasswordService {
+	return &passwordService{repo: repo, users: users, outbox: outbox, revocations: revocations, resetURL: resetURL, now: time.Now}
+}
+
+func (s *passwordService) ChangePassword(accountID, currentPassword, newPassword string) (*model.User, error) {
+	user, err := s.users.GetByAccountID(accountID)
+	if err != nil {
+		return nil, utils.ErrUserNotFound
+	}
+
+	match, err := crypto.ComparePasswordAndHash(currentPassword, user.Password)
+	if err != nil || !match {
+		return nil, utils.ErrInvalidCurrentPassword
+	}
+
+	if err := s.setPassword(user, newPassword, accountID); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *passwordService) RequestReset(email string) error {
+	user, err := s.users.GetByEmail(strings.TrimSpace(email))
+	if err != nil || user.AccountId == "" {
+		return nil
+	}
+
+	token, err := crypto.GenerateToken(passwordResetTokenSize)
+	if err != nil {
+		return err
+	}
+
+	now := s.now()
+	return s.repo.Transaction(func(tx *gorm.DB) error {
+		record := &model.PasswordResetToken{
+			AccountID: user.AccountId,
+			TokenHash: crypto.HashToken(token),
+			ExpiresAt: now.Add(PasswordResetTTL),
+		}
+		if err := s.repo.CreateResetToken(tx, record); err != nil {
+			return err
+		}
+		return s.outbox.Enqueue(tx, mailer.Message{
+			To:      user.Email,
+			Subject: "Reset your BestBit password",
+			Body: fmt.Sprintf("Hi %s,\n\nUse the link below within %d minutes to set a new password:\n%s\n\nIf you did not request this, you can ignore this email.",
+				user.Username, int(PasswordResetTTL.Minutes()), s.resetLink(token)),
+		})
+	})
+}
+
+func (s *passwordService) resetLink(token string) string {
+	if s.resetURL == "" {
+		return token
+	}
+	return s.resetURL + "?token=" + token
+}
+
+// hash หลังตรวจ token แล้วเท่านั้น ไม่ให้ token มั่วๆ บังคับ server ทำ argon2 ได้ฟรี
+func (s *passwordService) ResetPassword(token, newPassword string) error {
+	var user *model.User
+	err := s.repo.Transaction(func(tx *gorm.DB) error {
+		record, err := s.repo.GetResetTokenForUpdate(tx, crypto.HashToken(token))
+		if err != nil {
+			return err
+		}
+		now := s.now()
+		if !record.IsUsable(now) {
+			return utils.ErrInvalidResetToken
+		}
+
+		user, err = s.users.GetByAccountID(record.AccountID)
+		if err != nil {
+			return utils.ErrInvalidResetToken
+		}
+
+		passwordHash, err := crypto.HashPassword(newPassword)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.UpdatePassword(tx, user.AccountId, passwordHash, "PASSWORD_RESET"); err != nil {
+			return err
+		}
+		// token ที่ใช้อยู่ก็ถูก mark ไปด้วย
+		if err := s.repo.ExpireResetTokens(tx, user.AccountId, now); err != nil {
+			return err
+		}
+		return s.outbox.Enqueue(tx, passwordChangedMessage(user))
+	})
+	if err != nil {
+		return err
+	}
+
+	// คนที่ขโมย session ไปก็หลุดด้วย
+	return s.revocations.RevokeAllBefore(user.AccountId, s.now())
+}
+
+func (s *passwordService) setPassword(user *model.User, newPassword, actor string) error {
+	passwordHash, err := crypto.HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	err = s.repo.Transaction(func(tx *gorm.DB) error {
+		if err := s.repo.UpdatePassword(tx, user.AccountId, passwordHash, actor); err != nil {
+			return err
+		}
+		if err := s.repo.ExpireResetTokens(tx, user.AccountId, s.now()); err != nil {
+			return err
+		}
+		return s.outbox.Enqueue(tx, passwordChangedMessage(user))
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.revocations.RevokeAllBefore(user.AccountId, s.now())
+}
+
+func passwordChangedMessage(user *model.User) mailer.Message {
+	return mailer.Message{
+		To:      user.Email,
+		Subject: "Your BestBit password was changed",
+		Body: fmt.Sprintf("Hi %s,\n\nYour password was just changed and other sessions were signed out.\nIf this was not you, reset your password immediately and contact support.",
+			user.Username),
+	}
+}
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/padapook/bestbit-core/internal/account/model"
+	"github.com/padapook/bestbit-core/internal/mail/mailer"
+	mailService "github.com/padapook/bestbit-core/internal/mail/service"
+	"github.com/padapook/bestbit-core/internal/utils"
+	"github.com/padapook/bestbit-core/internal/utils/auth"
+	"github.com/padapook/bestbit-core/internal/utils/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type MockPasswordRepository struct {
+	mock.Mock
+}
+
+func (m *MockPasswordRepository) Transaction(fn func(tx *gorm.DB) error) error {
+	return fn(nil)
+}
+
+func (m *MockPasswordRepository) UpdatePassword(tx *gorm.DB, accountID, passwordHash, updatedBy string) error {
+	args := m.Called(tx, accountID, passwordHash, updatedBy)
+	return args.Error(0)
+}
+
+func (m *MockPasswordRepository) CreateResetToken(tx *gorm.DB, token *model.PasswordResetToken) error {
+	args := m.Called(tx, token)
+	return args.Error(0)
+}
+
+func (m *MockPasswordRepository) GetResetTokenForUpdate(tx *gorm.DB, tokenHash string) (*model.PasswordResetToken, error) {
+	args := m.Called(tx, tokenHash)
+	if args.Get(0) != nil {
+		return args.Get(0).(*model.PasswordResetToken), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockPasswordRepository) ExpireResetTokens(tx *gorm.DB, accountID string, usedAt time.Time) error {
+	args := m.Called(tx, accountID, usedAt)
+	return args.Error(0)
+}
+
+type MockOutboxService struct {
+	mailService.OutboxService
+	mock.Mock
+}
+
+func (m *MockOutboxService) Enqueue(tx *gorm.DB, msg mailer.Message) error {
+	args := m.Called(tx, msg)
+	return args.Error(0)
+}
+
+func (m *MockOutboxService) Send(msg mailer.Message) error {
+	args := m.Called(msg)
+	return args.Error(0)
+}
+
+func newTestPasswordService(now time.Time) (PasswordService, *MockPasswordRepository, *MockUserRepository, *MockOutboxService, auth.RevocationStore) {
+	repo := new(MockPasswordRepository)
+	userRepo := new(MockUserRepository)
+	outbox := new(MockOutboxService)
+	revocations := auth.NewMemoryRevocationStore()
+	service := &passwordService{repo: repo, users: userRepo, outbox: outbox, revocations: revocations,
+		resetURL: "https://bestbit.example/reset-password", now: func() time.Time { return now }}
+	return service, repo, userRepo, outbox, revocations
+}
+
+func testUserWithPassword(t *testing.T, password string) *model.User {
+	hash, err := crypto.HashPassword(password)
+	require.NoError(t, err)
+	user := testUser()
+	user.Email = "pook@example.com"
+	user.Password = hash
+	return user
+}
+
+func claimsIssuedAt(accountID string, issuedAt time.Time) *auth.Claims {
+	return &auth.Claims{AccountID: accountID, RegisteredClaims: jwt.RegisteredClaims{IssuedAt: jwt.NewNumericDate(issuedAt)}}
+}
+
+func TestChangePassword_Success_RevokesOtherSessions(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 500, time.UTC)
+	service, repo, userRepo, outbox, revocations := newTestPasswordService(now)
+	user := testUserWithPassword(t, "old-password")
+
+	userRepo.On("GetByAccountID", user.AccountId).Return(user, nil)
+	repo.On("UpdatePassword", mock.Anything, user.AccountId, mock.AnythingOfType("string"), user.AccountId).Return(nil)
+	repo.On("ExpireResetTokens", mock.Anything, user.AccountId, now).Return(nil)
+	outbox.On("Enqueue", mock.Anything, mock.MatchedBy(func(msg mailer.Message) bool { return msg.To == "pook@example.com" })).Return(nil)
+
+	got, err := service.ChangePassword(user.AccountId, "old-password", "new-password")
+
+	require.NoError(t, err)
+	assert.Equal(t, user, got)
+	repo.AssertExpectations(t)
+
+	// token เก่าหลุด, token ที่จะออกให้ session นี้ (iat วินาทีเดียวกัน) ยังใช้ได้
+	revoked, _ := revocations.IsRevoked(claimsIssuedAt(user.AccountId, now.Add(-time.Minute)))
+	assert.True(t, revoked)
+	revoked, _ = revocations.IsRevoked(claimsIssuedAt(user.AccountId, now.Truncate(time.Second)))
+	assert.False(t, revoked)
+}
+
+func TestChangePassword_Fail_WrongCurrentPassword(t *testing.T) {
+	service, repo, userRepo, _, _ := newTestPasswordService(time.Now())
+	user := testUserWithPassword(t, "old-password")
+
+	userRepo.On("GetByAccountID", user.AccountId).Return(user, nil)
+
+	_, err := service.ChangePassword(user.AccountId, "wrong", "new-password")
+
+	assert.Equal(t, utils.ErrInvalidCurrentPassword, err)
+	repo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRequestReset_StoresHashAndEmailsToken(t *testing.T) {
+	now := time.Now()
+	service, repo, userRepo, outbox, _ := newTestPasswordService(now)
+	user := testUserWithPassword(t, "old-password")
+
+	var stored *model.PasswordResetToken
+	var sent mailer.Message
+	userRepo.On("GetByEmail", "pook@example.com").Return(user, nil)
+	repo.On("CreateResetToken", mock.Anything, mock.AnythingOfType("*model.PasswordResetToken")).
+		Run(func(args mock.Arguments) { stored = args.Get(1).(*model.PasswordResetToken) }).
+		Return(nil)
+	outbox.On("Enqueue", mock.Anything, mock.AnythingOfType("mailer.Message")).
+		Run(func(args mock.Arguments) { sent = args.Get(1).(mailer.Message) }).
+		Return(nil)
+
+	err := service.RequestReset(" pook@example.com ")
+
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(PasswordResetTTL), stored.ExpiresAt)
+	assert.Equal(t, "pook@example.com", sent.To)
+
+	// ใน email มี token จริง, ใน db มีแค่ hash
+	const prefix = "https://bestbit.example/reset-password?token="
+	require.Contains(t, sent.Body, prefix)
+	start := strings.Index(sent.Body, prefix) + len(prefix)
+	token := sent.Body[start : start+43]
+	assert.Equal(t, crypto.HashToken(token), stored.TokenHash)
+	assert.NotContains(t, stored.TokenHash, token)
+}
+
+func TestRequestReset_UnknownEmailIsSilent(t *testing.T) {
+	service, repo, userRepo, outbox, _ := newTestPasswordService(time.Now())
+
+	userRepo.On("GetByEmail", "nobody@example.com").Return(&model.User{}, gorm.ErrRecordNotFound)
+
+	err := service.RequestReset("nobody@example.com")
+
+	assert.NoError(t, err)
+	repo.AssertNotCalled(t, "CreateResetToken", mock.Anything, mock.Anything)
+	outbox.AssertNotCalled(t, "Enqueue", mock.Anything, mock.Anything)
+}
+
+func TestResetPassword_Success_SingleUse(t *testing.T) {
+	now := time.Now()
+	service, repo, userRepo, outbox, revocations := newTestPasswordService(now)
+	user := testUserWithPassword(t, "old-password")
+	record := &model.PasswordResetToken{ID: 1, AccountID: user.AccountId, TokenHash: crypto.HashToken("reset-token"), ExpiresAt: now.Add(time.Minute)}
+
+	repo.On("GetResetTokenForUpdate", mock.Anything, crypto.HashToken("reset-token")).Return(record, nil)
+	userRepo.On("GetByAccountID", user.AccountId).Return(user, nil)
+	repo.On("UpdatePassword", mock.Anything, user.AccountId, mock.AnythingOfType("string"), "PASSWORD_RESET").Return(nil)
+	repo.On("ExpireResetTokens", mock.Anything, user.AccountId, now).
+		Run(func(args mock.Arguments) { record.UsedAt = &now }).
+		Return(nil)
+	outbox.On("Enqueue", mock.Anything, mock.AnythingOfType("mailer.Message")).Return(nil)
+
+	require.NoError(t, service.ResetPassword("reset-token", "new-password"))
+
+	revoked, _ := revocations.IsRevoked(claimsIssuedAt(user.AccountId, now.Add(-time.Minute)))
+	assert.True(t, revoked)
+
+	// ใช้ซ้ำไม่ได้
+	err := service.ResetPassword("reset-token", "another-password")
+	assert.Equal(t, utils.ErrInvalidResetToken, err)
+	repo.AssertNumberOfCalls(t, "UpdatePassword", 1)
+}
+
+func TestResetPassword_Fail_Expired(t *testing.T) {
+	now := time.Now()
+	service, repo, _, _, _ := newTestPasswordService(now)
+	record := &model.PasswordResetToken{ID: 1, AccountID: "acc-123", ExpiresAt: now.Add(-time.Second)}
+
+	repo.On("GetResetTokenForUpdate", mock.Anything, crypto.HashToken("reset-token")).Return(record, nil)
+
+	err := service.ResetPassword("reset-token", "new-password")
+
+	assert.Equal(t, utils.ErrInvalidResetToken, err)
+	repo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/padapook/bestbit-core/internal/account/model"
+	"github.com/padapook/bestbit-core/internal/account/repository"
+	"github.com/padapook/bestbit-core/internal/utils"
+	"github.com/padapook/bestbit-core/internal/utils/auth"
+)
+
+type RoleService interface {
+	GetRoles(accountID string) ([]model.UserRole, error)
+	// token เดิมของ user ถูก revoke ให้ login ใหม่เพื่อรับ claim ชุดใหม่
+	SetRoles(accountID string, roles []string, actor string) ([]model.UserRole, error)
+}
+
+type roleService struct {
+	repo        repository.RoleRepository
+	revocations auth.RevocationStore
+	now         func() time.Time
+}
+
+func NewRoleService(repo repository.RoleRepository, revocations auth.RevocationStore) RoleService {
+	return &roleService{repo: repo, revocations: revocations, now: time.Now}
+}
+
+func (s *roleService) GetRoles(accountID string) ([]model.UserRole, error) {
+	return s.repo.GetRoles(accountID)
+}
+
+func (s *roleService) SetRoles(accountID string, roles []string, actor string) ([]model.UserRole, error) {
+	seen := make(map[string]bool)
+	normalized := make([]string, 0, len(roles))
+	for _, role := range roles {
+		role = strings.ToLower(strings.TrimSpace(role))
+		if !model.IsValidRole(role) {
+			return nil, utils.ErrInvalidRequest
+		}
+		if !seen[role] {
+			seen[role] = true
+			normalized = append(normalized, role)
+		}
+	}
+	sort.Strings(normalized)
+
+	// กันแอดมินถอดสิทธิ์จัดการ role ของตัวเองจนไม่มีใครแก้กลับได้
+	if accountID == actor {
+		canManage := false
+		for _, p := range model.PermissionsForRoles(normalized) {
+			if p == model.PermRolesManage {
+				canManage = true
+			}
+		}
+		if !canManage {
+			return nil, utils.ErrForbidden
+		}
+	}
+
+	records, err := s.repo.ReplaceRoles(accountID, normalized, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.revocations.RevokeAllBefore(accountID, s.now()); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/padapook/bestbit-core/internal/account/model"
+	"github.com/padapook/bestbit-core/internal/utils"
+	"github.com/padapook/bestbit-core/internal/utils/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockRoleRepository struct {
+	mock.Mock
+}
+
+func (m *MockRoleRepository) GetRoles(accountID string) ([]model.UserRole, error) {
+	args := m.Called(accountID)
+	if args.Get(0) != nil {
+		return args.Get(0).([]model.UserRole), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRoleRepository) ReplaceRoles(accountID string, roles []string, actor string) ([]model.UserRole, error) {
+	args := m.Called(accountID, roles, actor)
+	if args.Get(0) != nil {
+		return args.Get(0).([]model.UserRole), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRoleRepository) GrantRole(accountID, role, actor string) error {
+	args := m.Called(accountID, role, actor)
+	return args.Error(0)
+}
+
+func TestSetRoles_NormalizesAndRevokesTokens(t *testing.T) {
+	repo := new(MockRoleRepository)
+	revocations := auth.NewMemoryRevocationStore()
+	service := NewRoleService(repo, revocations)
+
+	repo.On("ReplaceRoles", "user-1", []string{"operator", "support"}, "admin-1").
+		Return([]model.UserRole{{AccountID: "user-1", Role: "operator"}, {AccountID: "user-1", Role: "support"}}, nil)
+
+	roles, err := service.SetRoles("user-1", []string{" Support", "operator", "support"}, "admin-1")
+
+	assert.NoError(t, err)
+	assert.Len(t, roles, 2)
+	repo.AssertExpectations(t)
+
+	revoked, err := revocations.IsRevoked(&auth.Claims{AccountID: "user-1"})
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+}
+
+func TestSetRoles_Fail_UnknownRole(t *testing.T) {
+	repo := new(MockRoleRepository)
+	service := NewRoleService(repo, auth.NewMemoryRevocationStore())
+
+	_, err := service.SetRoles("user-1", []string{"superuser"}, "admin-1")
+
+	assert.Equal(t, utils.ErrInvalidRequest, err)
+	repo.AssertNotCalled(t, "ReplaceRoles", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSetRoles_Fail_RemoveOwnRoleManagement(t *testing.T) {
+	repo := new(MockRoleRepository)
+	service := NewRoleService(repo, auth.NewMemoryRevocationStore())
+
+	_, err := service.SetRoles("admin-1", []string{model.RoleOperator}, "admin-1")
+
+	assert.Equal(t, utils.ErrForbidden, err)
+	repo.AssertNotCalled(t, "ReplaceRoles", mock.Anything, mock.Anything, mock.Anything)
+}
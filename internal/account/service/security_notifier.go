@@ -0,0 +1,43 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/padapook/bestbit-core/internal/account/model"
+	"github.com/padapook/bestbit-core/internal/mail/mailer"
+	mailService "github.com/padapook/bestbit-core/internal/mail/service"
+)
+
+// แจ้ง user เรื่องความปลอดภัยของ account, ส่งไม่สำเร็จไม่ทำให้ flow หลักพัง
+type SecurityNotifier interface {
+	NotifyLockout(user *model.User, until time.Time) error
+	NotifyNewDevice(user *model.User, session *model.Session) error
+}
+
+type mailSecurityNotifier struct {
+	outbox mailService.OutboxService
+}
+
+// ส่งทาง email ผ่าน outbox
+func NewMailSecurityNotifier(outbox mailService.OutboxService) SecurityNotifier {
+	return &mailSecurityNotifier{outbox: outbox}
+}
+
+func (n *mailSecurityNotifier) NotifyLockout(user *model.User, until time.Time) error {
+	return n.outbox.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Sign-in to your BestBit account is temporarily locked",
+		Body: fmt.Sprintf("Hi %s,\n\nWe blocked sign-in to your account until %s (UTC) after too many failed attempts.\nIf this was not you, consider changing your password once the lock expires.",
+			user.Username, until.UTC().Format("2006-01-02 15:04")),
+	})
+}
+
+func (n *mailSecurityNotifier) NotifyNewDevice(user *model.User, session *model.Session) error {
+	return n.outbox.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "New sign-in to your BestBit account",
+		Body: fmt.Sprintf("Hi %s,\n\nYour account was just signed in from a new device.\n\nDevice: %s\nIP address: %s\nTime: %s (UTC)\n\nIf this was not you, revoke the session under your account sessions and change your password.",
+			user.Username, session.DeviceLabel, session.IPAddress, session.CreatedAt.UTC().Format("2006-01-02 15:04")),
+	})
+}
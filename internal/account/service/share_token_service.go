@@ -0,0 +1,104 @@
+package service
+
+import (
+	"time"
+
+	"github.com/padapook/bestbit-core/internal/account/model"
+	"github.com/padapook/bestbit-core/internal/account/repository"
+	"github.com/padapook/bestbit-core/internal/utils"
+	"github.com/padapook/bestbit-core/internal/utils/auth"
+	"gorm.io/gorm"
+)
+
+type ShareTokenService interface {
+	Create(accountID string) (*IssuedShareToken, error)
+	// ใช้ได้ครั้งเดียว คืน user ไว้ออก session ตาม scope ที่บันทึกไว้
+	Consume(token string) (*model.User, string, error)
+	GetOutstanding(accountID string) ([]model.ShareToken, error)
+	Revoke(accountID string, id uint64) error
+}
+
+// token ตัวจริงมีให้เห็นตอนสร้างครั้งเดียว
+type IssuedShareToken struct {
+	Token  string            `json:"share_token"`
+	Record *model.ShareToken `json:"share_token_info"`
+}
+
+type shareTokenService struct {
+	repo  repository.ShareTokenRepository
+	users repository.UserRepository
+	now   func() time.Time
+}
+
+func NewShareTokenService(repo repository.ShareTokenRepository, users repository.UserRepository) ShareTokenService {
+	return &shareTokenService{repo: repo, users: users, now: time.Now}
+}
+
+func (s *shareTokenService) Create(accountID string) (*IssuedShareToken, error) {
+	user, err := s.users.GetByAccountID(accountID)
+	if err != nil {
+		return nil, utils.ErrUserNotFound
+	}
+
+	details, err := auth.GenerateShareToken(user)
+	if err != nil {
+		return nil, err
+	}
+
+	record := &model.ShareToken{
+		TokenID:   details.TokenID,
+		AccountID: user.AccountId,
+		Scope:     auth.ScopeReadOnly,
+		ExpiresAt: details.ExpiresAt,
+	}
+	if err := s.repo.CreateShareToken(record); err != nil {
+		return nil, err
+	}
+
+	return &IssuedShareToken{Token: details.Token, Record: record}, nil
+}
+
+func (s *shareTokenService) Consume(token string) (*model.User, string, error) {
+	claims, err := auth.ValidateShareToken(token)
+	if err != nil || claims.ID == "" {
+		return nil, "", utils.ErrInvalidShareToken
+	}
+
+	var scope string
+	err = s.repo.Transaction(func(tx *gorm.DB) error {
+		record, err := s.repo.GetByTokenIDForUpdate(tx, claims.ID)
+		if err != nil {
+			return err
+		}
+
+		now := s.now()
+		if record.AccountID != claims.AccountID || !record.IsOutstanding(now) {
+			return utils.ErrInvalidShareToken
+		}
+
+		record.ConsumedAt = &now
+		scope = record.Scope
+		return s.repo.UpdateShareToken(tx, record)
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	user, err := s.users.GetByAccountID(claims.AccountID)
+	if err != nil {
+		return nil, "", utils.ErrInvalidShareToken
+	}
+	if !user.IsActive {
+		return nil, "", utils.ErrAccountFrozen
+	}
+
+	return user, scope, nil
+}
+
+func (s *shareTokenService) GetOutstanding(accountID string) ([]model.ShareToken, error) {
+	return s.repo.GetOutstanding(accountID, s.now())
+}
+
+func (s *shareTokenService) Revoke(accountID string, id uint64) error {
+	return s.repo.Revoke(accountID, id, s.now())
+}
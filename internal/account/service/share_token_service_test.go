@@ -0,0 +1,132 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/padapook/bestbit-core/internal/account/model"
+	"github.com/padapook/bestbit-core/internal/utils"
+	"github.com/padapook/bestbit-core/internal/utils/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type MockShareTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockShareTokenRepository) Transaction(fn func(tx *gorm.DB) error) error {
+	return fn(nil)
+}
+
+func (m *MockShareTokenRepository) CreateShareToken(token *model.ShareToken) error {
+	args := m.Called(token)
+	return args.Error(0)
+}
+
+func (m *MockShareTokenRepository) GetByTokenIDForUpdate(tx *gorm.DB, tokenID string) (*model.ShareToken, error) {
+	args := m.Called(tx, tokenID)
+	if args.Get(0) != nil {
+		return args.Get(0).(*model.ShareToken), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockShareTokenRepository) UpdateShareToken(tx *gorm.DB, token *model.ShareToken) error {
+	args := m.Called(tx, token)
+	return args.Error(0)
+}
+
+func (m *MockShareTokenRepository) GetOutstanding(accountID string, now time.Time) ([]model.ShareToken, error) {
+	args := m.Called(accountID, now)
+	if args.Get(0) != nil {
+		return args.Get(0).([]model.ShareToken), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockShareTokenRepository) Revoke(accountID string, id uint64, revokedAt time.Time) error {
+	args := m.Called(accountID, id, revokedAt)
+	return args.Error(0)
+}
+
+func newTestShareTokenService(t *testing.T) (ShareTokenService, *MockShareTokenRepository, *MockUserRepository) {
+	t.Setenv("SHARE_TOKEN_SECRET_KEY", "test-share-secret")
+
+	repo := new(MockShareTokenRepository)
+	userRepo := new(MockUserRepository)
+	return NewShareTokenService(repo, userRepo), repo, userRepo
+}
+
+// สร้าง token แล้วคืน row ที่ถูกบันทึกไว้
+func createShareToken(t *testing.T, service ShareTokenService, repo *MockShareTokenRepository, userRepo *MockUserRepository) (*IssuedShareToken, *model.ShareToken) {
+	var saved *model.ShareToken
+	userRepo.On("GetByAccountID", "acc-123").Return(testUser(), nil)
+	repo.On("CreateShareToken", mock.AnythingOfType("*model.ShareToken")).
+		Run(func(args mock.Arguments) { saved = args.Get(0).(*model.ShareToken) }).
+		Return(nil).Once()
+
+	issued, err := service.Create("acc-123")
+	require.NoError(t, err)
+	return issued, saved
+}
+
+func TestCreateShareToken_RecordsJTI(t *testing.T) {
+	service, repo, userRepo := newTestShareTokenService(t)
+
+	issued, saved := createShareToken(t, service, repo, userRepo)
+
+	claims, err := auth.ValidateShareToken(issued.Token)
+	require.NoError(t, err)
+	assert.Equal(t, claims.ID, saved.TokenID)
+	assert.Equal(t, auth.ScopeReadOnly, saved.Scope)
+	assert.Equal(t, "acc-123", saved.AccountID)
+}
+
+func TestConsumeShareToken_OnlyOnce(t *testing.T) {
+	service, repo, userRepo := newTestShareTokenService(t)
+	issued, saved := createShareToken(t, service, repo, userRepo)
+
+	repo.On("GetByTokenIDForUpdate", mock.Anything, saved.TokenID).Return(saved, nil)
+	repo.On("UpdateShareToken", mock.Anything, saved).Return(nil)
+
+	user, scope, err := service.Consume(issued.Token)
+
+	require.NoError(t, err)
+	assert.Equal(t, "acc-123", user.AccountId)
+	assert.Equal(t, auth.ScopeReadOnly, scope)
+	assert.NotNil(t, saved.ConsumedAt)
+
+	_, _, err = service.Consume(issued.Token)
+	assert.Equal(t, utils.ErrInvalidShareToken, err)
+	repo.AssertNumberOfCalls(t, "UpdateShareToken", 1)
+}
+
+func TestConsumeShareToken_Fail_Revoked(t *testing.T) {
+	service, repo, userRepo := newTestShareTokenService(t)
+	issued, saved := createShareToken(t, service, repo, userRepo)
+	revokedAt := time.Now()
+	saved.RevokedAt = &revokedAt
+
+	repo.On("GetByTokenIDForUpdate", mock.Anything, saved.TokenID).Return(saved, nil)
+
+	_, _, err := service.Consume(issued.Token)
+
+	assert.Equal(t, utils.ErrInvalidShareToken, err)
+	repo.AssertNotCalled(t, "UpdateShareToken", mock.Anything, mock.Anything)
+}
+
+func TestConsumeShareToken_Fail_NotRecorded(t *testing.T) {
+	service, repo, _ := newTestShareTokenService(t)
+
+	// token ที่ sign ถูกแต่ไม่เคยถูกบันทึก (ออกก่อนมีตาราง share_tokens)
+	details, err := auth.GenerateShareToken(testUser())
+	require.NoError(t, err)
+	repo.On("GetByTokenIDForUpdate", mock.Anything, details.TokenID).Return(nil, utils.ErrInvalidShareToken)
+
+	_, _, err = service.Consume(details.Token)
+
+	assert.Equal(t, utils.ErrInvalidShareToken, err)
+}
@@ -0,0 +1,291 @@
+package service
+
+import (
+	"log"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	accountModel "github.com/padapook/bestbit-core/internal/account/model"
+	"github.com/padapook/bestbit-core/internal/account/repository"
+	"github.com/padapook/bestbit-core/internal/utils"
+	"github.com/padapook/bestbit-core/internal/utils/auth"
+	"github.com/padapook/bestbit-core/internal/utils/crypto"
+	"gorm.io/gorm"
+)
+
+type TokenService interface {
+	IssueTokens(user *accountModel.User, client ClientInfo) (*auth.TokenDetails, error)
+	// session ที่ถูกจำกัดสิทธิ์ เช่น read-only จาก share token
+	IssueScopedTokens(user *accountModel.User, scope string, client ClientInfo) (*auth.TokenDetails, error)
+	RefreshTokens(refreshToken string) (*accountModel.User, *auth.TokenDetails, error)
+	Logout(claims *auth.Claims, refreshToken string) error
+	LogoutAll(accountID string, before time.Time) error
+	// session ที่ยังใช้ได้อยู่ ตัวที่ตรงกับ currentSessionID ถูก mark current
+	GetSessions(accountID, currentSessionID string) ([]accountModel.Session, error)
+	RevokeSession(accountID string, id uint64) error
+}
+
+type tokenService struct {
+	repo        repository.RefreshTokenRepository
+	sessions    repository.SessionRepository
+	userRepo    repository.UserRepository
+	revocations auth.RevocationStore
+	notifier    SecurityNotifier
+	now         func() time.Time
+}
+
+func NewTokenService(repo repository.RefreshTokenRepository, sessions repository.SessionRepository, userRepo repository.UserRepository,
+	revocations auth.RevocationStore, notifier SecurityNotifier) TokenService {
+	return &tokenService{repo: repo, sessions: sessions, userRepo: userRepo, revocations: revocations, notifier: notifier, now: time.Now}
+}
+
+// login ใหม่ = session ใหม่ = เริ่ม family ใหม่
+func (s *tokenService) IssueTokens(user *accountModel.User, client ClientInfo) (*auth.TokenDetails, error) {
+	return s.IssueScopedTokens(user, "", client)
+}
+
+func (s *tokenService) IssueScopedTokens(user *accountModel.User, scope string, client ClientInfo) (*auth.TokenDetails, error) {
+	// ไม่มี device id ถือเป็นเครื่องที่ไม่เคยเห็น
+	deviceID := client.DeviceID
+	if deviceID == "" {
+		deviceID = uuid.New().String()
+	}
+
+	now := s.now()
+	session := &accountModel.Session{
+		SessionID:   uuid.New().String(),
+		AccountID:   user.AccountId,
+		DeviceID:    crypto.HashToken(deviceID),
+		DeviceLabel: deviceLabel(client.UserAgent),
+		UserAgent:   truncate(client.UserAgent, 500),
+		IPAddress:   client.IPAddress,
+		Scope:       scope,
+		CreatedAt:   now,
+		LastSeenAt:  now,
+	}
+
+	// เช็คก่อนสร้าง session ใหม่ ไม่งั้นจะเจอตัวเอง
+	newDevice, err := s.isNewDevice(user.AccountId, session.DeviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	var tokens *auth.TokenDetails
+	err = s.repo.Transaction(func(tx *gorm.DB) error {
+		if err := s.sessions.CreateSession(tx, session); err != nil {
+			return err
+		}
+		var err error
+		tokens, err = s.issue(tx, user, session.SessionID, scope)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// ส่งไม่ได้ไม่ทำให้ login ล้ม
+	if newDevice {
+		if err := s.notifier.NotifyNewDevice(user, session); err != nil {
+			log.Println("[session] notify new device:", err)
+		}
+	}
+
+	return tokens, nil
+}
+
+// login ครั้งแรกของ account ไม่นับเป็นเครื่องใหม่
+func (s *tokenService) isNewDevice(accountID, deviceID string) (bool, error) {
+	hasAny, err := s.sessions.HasSession(accountID, "")
+	if err != nil || !hasAny {
+		return false, err
+	}
+	known, err := s.sessions.HasSession(accountID, deviceID)
+	if err != nil {
+		return false, err
+	}
+	return !known, nil
+}
+
+// แลก refresh token เป็นคู่ใหม่ ตัวเก่าใช้ซ้ำไม่ได้
+// ถ้าเจอ token ที่ rotate ไปแล้วถูกส่งมาอีก ถือว่าหลุด -> revoke ทั้ง family
+func (s *tokenService) RefreshTokens(refreshToken string) (*accountModel.User, *auth.TokenDetails, error) {
+	claims, err := auth.ValidateRefreshToken(refreshToken)
+	if err != nil {
+		return nil, nil, utils.ErrInvalidRefreshToken
+	}
+
+	// โดน logout ทุก session หลังจาก token นี้ออก
+	revoked, err := s.revocations.IsRevoked(claims)
+	if err != nil {
+		return nil, nil, err
+	}
+	if revoked {
+		return nil, nil, utils.ErrInvalidRefreshToken
+	}
+
+	user, err := s.userRepo.GetByAccountID(claims.AccountID)
+	if err != nil {
+		return nil, nil, utils.ErrInvalidRefreshToken
+	}
+	if !user.IsActive {
+		return nil, nil, utils.ErrAccountFrozen
+	}
+
+	var tokens *auth.TokenDetails
+	reused := false
+
+	err = s.repo.Transaction(func(tx *gorm.DB) error {
+		stored, err := s.repo.GetByTokenIDForUpdate(tx, claims.ID)
+		if err != nil {
+			return err
+		}
+
+		if stored.AccountID != claims.AccountID || stored.IsRevoked() {
+			return utils.ErrInvalidRefreshToken
+		}
+
+		now := s.now()
+		if stored.IsUsed() {
+			// ต้อง commit การ revoke ก่อนแล้วค่อยตอบ error
+			reused = true
+			return s.repo.RevokeFamily(tx, stored.FamilyID, now)
+		}
+
+		// scope มากับ refresh token ที่ sign แล้ว ต่ออายุยังไงก็ได้สิทธิ์เท่าเดิม
+		tokens, err = s.issue(tx, user, stored.FamilyID, claims.Scope)
+		if err != nil {
+			return err
+		}
+		if err := s.sessions.TouchSession(tx, stored.FamilyID, now); err != nil {
+			return err
+		}
+
+		stored.UsedAt = &now
+		stored.ReplacedBy = tokens.RefreshTokenID
+		return s.repo.UpdateRefreshToken(tx, stored)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if reused {
+		return nil, nil, utils.ErrRefreshTokenReused
+	}
+
+	return user, tokens, nil
+}
+
+// revoke access token ที่ใช้อยู่ ถ้าส่ง refresh token มาด้วยจะ revoke ทั้ง family
+func (s *tokenService) Logout(claims *auth.Claims, refreshToken string) error {
+	if claims.ID != "" && claims.ExpiresAt != nil {
+		if err := s.revocations.RevokeToken(claims.ID, claims.AccountID, claims.ExpiresAt.Time); err != nil {
+			return err
+		}
+	}
+
+	if refreshToken == "" {
+		return nil
+	}
+
+	refreshClaims, err := auth.ValidateRefreshToken(refreshToken)
+	if err != nil || refreshClaims.AccountID != claims.AccountID {
+		return utils.ErrInvalidRefreshToken
+	}
+
+	return s.repo.Transaction(func(tx *gorm.DB) error {
+		stored, err := s.repo.GetByTokenIDForUpdate(tx, refreshClaims.ID)
+		if err != nil {
+			return err
+		}
+		now := s.now()
+		if err := s.repo.RevokeFamily(tx, stored.FamilyID, now); err != nil {
+			return err
+		}
+		return s.sessions.EndSession(tx, stored.FamilyID, now)
+	})
+}
+
+// revoke ทุก token (ทั้ง access และ refresh) ที่ออกให้ account ก่อน before
+// ไม่รับเวลาในอนาคต ไม่งั้นจะ login ใหม่ไม่ได้จนถึงเวลานั้น
+func (s *tokenService) LogoutAll(accountID string, before time.Time) error {
+	now := s.now()
+	if before.IsZero() || before.After(now) {
+		before = now
+	}
+	return s.revocations.RevokeAllBefore(accountID, before)
+}
+
+// session ที่ถูก logout ทั้งหมด (เช่นเปลี่ยนรหัสผ่าน) ไม่ต้องไปแก้ทุก row ใช้ revocation store กรองออกแทน
+// token ล่าสุดของ session ออกตอน last seen ถ้าตัวนั้นถูก revoke ก็ไม่เหลือ token ไหนใช้ได้
+func (s *tokenService) GetSessions(accountID, currentSessionID string) ([]accountModel.Session, error) {
+	sessions, err := s.sessions.GetActiveSessions(accountID, s.now().Add(-auth.RefreshTokenTTL))
+	if err != nil {
+		return nil, err
+	}
+
+	active := make([]accountModel.Session, 0, len(sessions))
+	for _, session := range sessions {
+		revoked, err := s.revocations.IsRevoked(&auth.Claims{
+			AccountID:        accountID,
+			SessionID:        session.SessionID,
+			RegisteredClaims: jwt.RegisteredClaims{IssuedAt: jwt.NewNumericDate(session.LastSeenAt)},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if revoked {
+			continue
+		}
+		session.Current = session.SessionID == currentSessionID
+		active = append(active, session)
+	}
+	return active, nil
+}
+
+// access token ของ session ที่ออกไปแล้วใช้ไม่ได้ทันทีผ่าน sid, refresh token ใช้ไม่ได้เพราะ family ถูก revoke
+func (s *tokenService) RevokeSession(accountID string, id uint64) error {
+	return s.repo.Transaction(func(tx *gorm.DB) error {
+		session, err := s.sessions.GetSessionForUpdate(tx, accountID, id)
+		if err != nil {
+			return err
+		}
+
+		now := s.now()
+		// token ใน session อายุไม่เกิน refresh token ที่ออกล่าสุด
+		if err := s.revocations.RevokeSession(session.SessionID, accountID, now.Add(auth.RefreshTokenTTL)); err != nil {
+			return err
+		}
+		if err := s.repo.RevokeFamily(tx, session.SessionID, now); err != nil {
+			return err
+		}
+
+		session.RevokedAt = &now
+		return s.sessions.UpdateSession(tx, session)
+	})
+}
+
+func truncate(value string, max int) string {
+	if len(value) > max {
+		return value[:max]
+	}
+	return value
+}
+
+func (s *tokenService) issue(tx *gorm.DB, user *accountModel.User, familyID, scope string) (*auth.TokenDetails, error) {
+	tokens, err := auth.GenerateSessionTokens(user, familyID, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	record := &accountModel.RefreshToken{
+		TokenID:   tokens.RefreshTokenID,
+		FamilyID:  familyID,
+		AccountID: user.AccountId,
+		ExpiresAt: tokens.RefreshExpiresAt,
+	}
+	if err := s.repo.CreateRefreshToken(tx, record); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
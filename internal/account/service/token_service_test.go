@@ -0,0 +1,466 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/padapook/bestbit-core/internal/account/model"
+	"github.com/padapook/bestbit-core/internal/utils"
+	"github.com/padapook/bestbit-core/internal/utils/auth"
+	"github.com/padapook/bestbit-core/internal/utils/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type MockRefreshTokenRepository struct {
+	mock.Mock
+}
+
+// ไม่มี db จริง เรียก fn ตรงๆ
+func (m *MockRefreshTokenRepository) Transaction(fn func(tx *gorm.DB) error) error {
+	return fn(nil)
+}
+
+func (m *MockRefreshTokenRepository) CreateRefreshToken(tx *gorm.DB, token *model.RefreshToken) error {
+	args := m.Called(tx, token)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) UpdateRefreshToken(tx *gorm.DB, token *model.RefreshToken) error {
+	args := m.Called(tx, token)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) GetByTokenIDForUpdate(tx *gorm.DB, tokenID string) (*model.RefreshToken, error) {
+	args := m.Called(tx, tokenID)
+	if args.Get(0) != nil {
+		return args.Get(0).(*model.RefreshToken), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) RevokeFamily(tx *gorm.DB, familyID string, revokedAt time.Time) error {
+	args := m.Called(tx, familyID, revokedAt)
+	return args.Error(0)
+}
+
+type MockSessionRepository struct {
+	mock.Mock
+}
+
+func (m *MockSessionRepository) CreateSession(tx *gorm.DB, session *model.Session) error {
+	args := m.Called(tx, session)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepository) HasSession(accountID, deviceID string) (bool, error) {
+	args := m.Called(accountID, deviceID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockSessionRepository) GetActiveSessions(accountID string, since time.Time) ([]model.Session, error) {
+	args := m.Called(accountID, since)
+	if args.Get(0) != nil {
+		return args.Get(0).([]model.Session), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockSessionRepository) GetSessionForUpdate(tx *gorm.DB, accountID string, id uint64) (*model.Session, error) {
+	args := m.Called(tx, accountID, id)
+	if args.Get(0) != nil {
+		return args.Get(0).(*model.Session), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockSessionRepository) UpdateSession(tx *gorm.DB, session *model.Session) error {
+	args := m.Called(tx, session)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepository) TouchSession(tx *gorm.DB, sessionID string, at time.Time) error {
+	args := m.Called(tx, sessionID, at)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepository) EndSession(tx *gorm.DB, sessionID string, at time.Time) error {
+	args := m.Called(tx, sessionID, at)
+	return args.Error(0)
+}
+
+type MockUserRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserRepository) CreateUser(tx *gorm.DB, user *model.User) error {
+	args := m.Called(tx, user)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) GetByUsername(username string) (*model.User, error) {
+	args := m.Called(username)
+	if args.Get(0) != nil {
+		return args.Get(0).(*model.User), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockUserRepository) GetByAccountID(accountID string) (*model.User, error) {
+	args := m.Called(accountID)
+	if args.Get(0) != nil {
+		return args.Get(0).(*model.User), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockUserRepository) GetByEmail(email string) (*model.User, error) {
+	args := m.Called(email)
+	if args.Get(0) != nil {
+		return args.Get(0).(*model.User), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockUserRepository) SearchUsers(query string, limit, offset int) ([]model.User, int64, error) {
+	args := m.Called(query, limit, offset)
+	if args.Get(0) != nil {
+		return args.Get(0).([]model.User), args.Get(1).(int64), args.Error(2)
+	}
+	return nil, 0, args.Error(2)
+}
+
+func (m *MockUserRepository) UpdatePasswordHash(accountID, oldHash, newHash string) error {
+	args := m.Called(accountID, oldHash, newHash)
+	return args.Error(0)
+}
+
+func newTestTokenService(t *testing.T) (TokenService, *MockRefreshTokenRepository, *MockUserRepository) {
+	service, repo, userRepo, _ := newTestTokenServiceWithStore(t)
+	return service, repo, userRepo
+}
+
+func newTestTokenServiceWithStore(t *testing.T) (TokenService, *MockRefreshTokenRepository, *MockUserRepository, auth.RevocationStore) {
+	service, repo, _, userRepo, revocations, _ := newTestTokenServiceWithSessions(t)
+	return service, repo, userRepo, revocations
+}
+
+// session repo ตอบ mock ทั่วไปไว้ให้ test ที่ไม่ได้สนใจ session
+func newTestTokenServiceWithSessions(t *testing.T) (TokenService, *MockRefreshTokenRepository, *MockSessionRepository, *MockUserRepository, auth.RevocationStore, *MockSecurityNotifier) {
+	t.Setenv("JWT_SECRET_KEY", "test-secret")
+
+	repo := new(MockRefreshTokenRepository)
+	sessions := new(MockSessionRepository)
+	sessions.On("TouchSession", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	sessions.On("EndSession", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	userRepo := new(MockUserRepository)
+	revocations := auth.NewMemoryRevocationStore()
+	notifier := new(MockSecurityNotifier)
+	return NewTokenService(repo, sessions, userRepo, revocations, notifier), repo, sessions, userRepo, revocations, notifier
+}
+
+func testUser() *model.User {
+	return &model.User{AccountId: "acc-123", Username: "pook", IsActive: true}
+}
+
+func TestIssueTokens_StartsNewFamily(t *testing.T) {
+	service, repo, sessions, _, _, _ := newTestTokenServiceWithSessions(t)
+	sessions.On("HasSession", "acc-123", "").Return(false, nil)
+	sessions.On("CreateSession", mock.Anything, mock.AnythingOfType("*model.Session")).Return(nil)
+
+	var saved *model.RefreshToken
+	repo.On("CreateRefreshToken", mock.Anything, mock.AnythingOfType("*model.RefreshToken")).
+		Run(func(args mock.Arguments) { saved = args.Get(1).(*model.RefreshToken) }).
+		Return(nil)
+
+	tokens, err := service.IssueTokens(testUser(), ClientInfo{})
+
+	require.NoError(t, err)
+	assert.Equal(t, tokens.RefreshTokenID, saved.TokenID)
+	assert.Equal(t, "acc-123", saved.AccountID)
+	assert.NotEmpty(t, saved.FamilyID)
+
+	// refresh token ใช้แทน access token ไม่ได้ และกลับกัน
+	_, err = auth.ValidateToken(tokens.RefreshToken)
+	assert.Error(t, err)
+	_, err = auth.ValidateRefreshToken(tokens.AccessToken)
+	assert.Error(t, err)
+}
+
+func TestRefreshTokens_Success_RotatesWithinFamily(t *testing.T) {
+	service, repo, userRepo := newTestTokenService(t)
+	user := testUser()
+
+	issued, err := auth.GenerateTokens(user)
+	require.NoError(t, err)
+
+	stored := &model.RefreshToken{TokenID: issued.RefreshTokenID, FamilyID: "family-1", AccountID: user.AccountId}
+	userRepo.On("GetByAccountID", user.AccountId).Return(user, nil)
+	repo.On("GetByTokenIDForUpdate", mock.Anything, issued.RefreshTokenID).Return(stored, nil)
+	repo.On("CreateRefreshToken", mock.Anything, mock.MatchedBy(func(t *model.RefreshToken) bool {
+		return t.FamilyID == "family-1" && t.TokenID != issued.RefreshTokenID
+	})).Return(nil)
+	repo.On("UpdateRefreshToken", mock.Anything, stored).Return(nil)
+
+	gotUser, tokens, err := service.RefreshTokens(issued.RefreshToken)
+
+	require.NoError(t, err)
+	assert.Equal(t, user, gotUser)
+	assert.NotNil(t, stored.UsedAt)
+	assert.Equal(t, tokens.RefreshTokenID, stored.ReplacedBy)
+	repo.AssertExpectations(t)
+}
+
+func TestRefreshTokens_Fail_AccountFrozen(t *testing.T) {
+	service, repo, userRepo := newTestTokenService(t)
+	user := testUser()
+	user.IsActive = false
+
+	issued, err := auth.GenerateTokens(user)
+	require.NoError(t, err)
+
+	userRepo.On("GetByAccountID", user.AccountId).Return(user, nil)
+
+	_, _, err = service.RefreshTokens(issued.RefreshToken)
+
+	assert.Equal(t, utils.ErrAccountFrozen, err)
+	repo.AssertNotCalled(t, "GetByTokenIDForUpdate", mock.Anything, mock.Anything)
+}
+
+func TestRefreshTokens_Fail_ReuseRevokesFamily(t *testing.T) {
+	service, repo, userRepo := newTestTokenService(t)
+	user := testUser()
+
+	issued, err := auth.GenerateTokens(user)
+	require.NoError(t, err)
+
+	usedAt := time.Now().Add(-time.Minute)
+	stored := &model.RefreshToken{TokenID: issued.RefreshTokenID, FamilyID: "family-1", AccountID: user.AccountId, UsedAt: &usedAt}
+	userRepo.On("GetByAccountID", user.AccountId).Return(user, nil)
+	repo.On("GetByTokenIDForUpdate", mock.Anything, issued.RefreshTokenID).Return(stored, nil)
+	repo.On("RevokeFamily", mock.Anything, "family-1", mock.AnythingOfType("time.Time")).Return(nil)
+
+	_, tokens, err := service.RefreshTokens(issued.RefreshToken)
+
+	assert.Nil(t, tokens)
+	assert.Equal(t, utils.ErrRefreshTokenReused, err)
+	repo.AssertExpectations(t)
+	repo.AssertNotCalled(t, "CreateRefreshToken")
+}
+
+func TestRefreshTokens_Fail_RevokedFamily(t *testing.T) {
+	service, repo, userRepo := newTestTokenService(t)
+	user := testUser()
+
+	issued, err := auth.GenerateTokens(user)
+	require.NoError(t, err)
+
+	revokedAt := time.Now()
+	stored := &model.RefreshToken{TokenID: issued.RefreshTokenID, FamilyID: "family-1", AccountID: user.AccountId, RevokedAt: &revokedAt}
+	userRepo.On("GetByAccountID", user.AccountId).Return(user, nil)
+	repo.On("GetByTokenIDForUpdate", mock.Anything, issued.RefreshTokenID).Return(stored, nil)
+
+	_, _, err = service.RefreshTokens(issued.RefreshToken)
+
+	assert.Equal(t, utils.ErrInvalidRefreshToken, err)
+	repo.AssertNotCalled(t, "CreateRefreshToken")
+}
+
+func TestRefreshTokens_Fail_AccessTokenRejected(t *testing.T) {
+	service, repo, _ := newTestTokenService(t)
+
+	issued, err := auth.GenerateTokens(testUser())
+	require.NoError(t, err)
+
+	_, _, err = service.RefreshTokens(issued.AccessToken)
+
+	assert.Equal(t, utils.ErrInvalidRefreshToken, err)
+	repo.AssertNotCalled(t, "GetByTokenIDForUpdate")
+}
+
+func TestLogout_RevokesAccessTokenAndRefreshFamily(t *testing.T) {
+	service, repo, _, revocations := newTestTokenServiceWithStore(t)
+	user := testUser()
+
+	issued, err := auth.GenerateTokens(user)
+	require.NoError(t, err)
+	claims, err := auth.ValidateToken(issued.AccessToken)
+	require.NoError(t, err)
+
+	stored := &model.RefreshToken{TokenID: issued.RefreshTokenID, FamilyID: "family-1", AccountID: user.AccountId}
+	repo.On("GetByTokenIDForUpdate", mock.Anything, issued.RefreshTokenID).Return(stored, nil)
+	repo.On("RevokeFamily", mock.Anything, "family-1", mock.AnythingOfType("time.Time")).Return(nil)
+
+	err = service.Logout(claims, issued.RefreshToken)
+
+	require.NoError(t, err)
+	revoked, err := revocations.IsRevoked(claims)
+	require.NoError(t, err)
+	assert.True(t, revoked)
+	repo.AssertExpectations(t)
+}
+
+func TestLogoutAll_RejectsEarlierRefreshTokens(t *testing.T) {
+	service, repo, _, revocations := newTestTokenServiceWithStore(t)
+	user := testUser()
+
+	issued, err := auth.GenerateTokens(user)
+	require.NoError(t, err)
+	claims, err := auth.ValidateToken(issued.AccessToken)
+	require.NoError(t, err)
+
+	// iat ละเอียดแค่วินาที และ cutoff ถูกปัดลงเป็นวินาที เลยให้ cutoff อยู่หลัง iat ชัดเจน
+	service.(*tokenService).now = func() time.Time { return claims.IssuedAt.Time.Add(time.Minute) }
+	err = service.LogoutAll(user.AccountId, claims.IssuedAt.Time.Add(time.Second))
+	require.NoError(t, err)
+
+	revoked, err := revocations.IsRevoked(claims)
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	_, _, err = service.RefreshTokens(issued.RefreshToken)
+	assert.Equal(t, utils.ErrInvalidRefreshToken, err)
+	repo.AssertNotCalled(t, "GetByTokenIDForUpdate")
+}
+
+func TestRefreshTokens_KeepsScope(t *testing.T) {
+	service, repo, userRepo := newTestTokenService(t)
+	user := testUser()
+	user.Roles = []model.UserRole{{Role: model.RoleAdmin}}
+
+	issued, err := auth.GenerateSessionTokens(user, "", auth.ScopeReadOnly)
+	require.NoError(t, err)
+
+	stored := &model.RefreshToken{TokenID: issued.RefreshTokenID, FamilyID: "family-1", AccountID: user.AccountId}
+	userRepo.On("GetByAccountID", user.AccountId).Return(user, nil)
+	repo.On("GetByTokenIDForUpdate", mock.Anything, issued.RefreshTokenID).Return(stored, nil)
+	repo.On("CreateRefreshToken", mock.Anything, mock.AnythingOfType("*model.RefreshToken")).Return(nil)
+	repo.On("UpdateRefreshToken", mock.Anything, stored).Return(nil)
+
+	_, tokens, err := service.RefreshTokens(issued.RefreshToken)
+	require.NoError(t, err)
+
+	// refresh แล้วยังเป็น read-only และไม่ได้สิทธิ์ admin
+	claims, err := auth.ValidateToken(tokens.AccessToken)
+	require.NoError(t, err)
+	assert.True(t, claims.IsReadOnly())
+	assert.Empty(t, claims.Permissions)
+}
+
+const testDeviceID = "device-0123456789abcdef"
+
+const testUserAgent = "Mozilla/5.0 (Macintosh; Intel Mac OS X 14_5) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Safari/605.1.15"
+
+func TestIssueTokens_CreatesSession(t *testing.T) {
+	service, repo, sessions, _, _, notifier := newTestTokenServiceWithSessions(t)
+	sessions.On("HasSession", "acc-123", "").Return(false, nil)
+	repo.On("CreateRefreshToken", mock.Anything, mock.AnythingOfType("*model.RefreshToken")).Return(nil)
+
+	var session *model.Session
+	sessions.On("CreateSession", mock.Anything, mock.AnythingOfType("*model.Session")).
+		Run(func(args mock.Arguments) { session = args.Get(1).(*model.Session) }).
+		Return(nil)
+
+	tokens, err := service.IssueTokens(testUser(), ClientInfo{UserAgent: testUserAgent, IPAddress: "203.0.113.7"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Safari on macOS", session.DeviceLabel)
+	assert.Equal(t, "203.0.113.7", session.IPAddress)
+	assert.False(t, session.LastSeenAt.IsZero())
+
+	claims, err := auth.ValidateToken(tokens.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, session.SessionID, claims.SessionID)
+
+	// login ครั้งแรกของ account ไม่ต้องแจ้ง
+	notifier.AssertNotCalled(t, "NotifyNewDevice", mock.Anything, mock.Anything)
+}
+
+func TestIssueTokens_NotifiesNewDevice(t *testing.T) {
+	service, repo, sessions, _, _, notifier := newTestTokenServiceWithSessions(t)
+	repo.On("CreateRefreshToken", mock.Anything, mock.AnythingOfType("*model.RefreshToken")).Return(nil)
+	sessions.On("CreateSession", mock.Anything, mock.AnythingOfType("*model.Session")).Return(nil)
+	sessions.On("HasSession", "acc-123", "").Return(true, nil)
+	sessions.On("HasSession", "acc-123", crypto.HashToken(testDeviceID)).Return(false, nil).Once()
+	sessions.On("HasSession", "acc-123", crypto.HashToken(testDeviceID)).Return(true, nil)
+	notifier.On("NotifyNewDevice", mock.Anything, mock.MatchedBy(func(s *model.Session) bool {
+		return s.DeviceLabel == "Safari on macOS"
+	})).Return(nil).Once()
+
+	client := ClientInfo{DeviceID: testDeviceID, UserAgent: testUserAgent, IPAddress: "203.0.113.7"}
+	_, err := service.IssueTokens(testUser(), client)
+	require.NoError(t, err)
+
+	// เครื่องเดิมอัปเดต browser แล้ว login ซ้ำไม่แจ้งอีก
+	client.UserAgent = strings.Replace(testUserAgent, "Version/17.5", "Version/18.0", 1)
+	_, err = service.IssueTokens(testUser(), client)
+	require.NoError(t, err)
+
+	notifier.AssertNumberOfCalls(t, "NotifyNewDevice", 1)
+}
+
+func TestRevokeSession_InvalidatesTokens(t *testing.T) {
+	service, repo, sessions, _, revocations, _ := newTestTokenServiceWithSessions(t)
+	user := testUser()
+
+	issued, err := auth.GenerateSessionTokens(user, "session-1", "")
+	require.NoError(t, err)
+	claims, err := auth.ValidateToken(issued.AccessToken)
+	require.NoError(t, err)
+
+	session := &model.Session{ID: 7, SessionID: "session-1", AccountID: user.AccountId}
+	sessions.On("GetSessionForUpdate", mock.Anything, user.AccountId, uint64(7)).Return(session, nil)
+	sessions.On("UpdateSession", mock.Anything, session).Return(nil)
+	repo.On("RevokeFamily", mock.Anything, "session-1", mock.AnythingOfType("time.Time")).Return(nil)
+
+	err = service.RevokeSession(user.AccountId, 7)
+
+	require.NoError(t, err)
+	assert.NotNil(t, session.RevokedAt)
+	revoked, err := revocations.IsRevoked(claims)
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	// session อื่นของ account เดียวกันไม่โดน
+	other, err := auth.GenerateSessionTokens(user, "session-2", "")
+	require.NoError(t, err)
+	otherClaims, err := auth.ValidateToken(other.AccessToken)
+	require.NoError(t, err)
+	revoked, err = revocations.IsRevoked(otherClaims)
+	require.NoError(t, err)
+	assert.False(t, revoked)
+}
+
+func TestRevokeSession_Fail_NotFound(t *testing.T) {
+	service, repo, sessions, _, _, _ := newTestTokenServiceWithSessions(t)
+	sessions.On("GetSessionForUpdate", mock.Anything, "acc-123", uint64(9)).Return(nil, utils.ErrSessionNotFound)
+
+	err := service.RevokeSession("acc-123", 9)
+
+	assert.Equal(t, utils.ErrSessionNotFound, err)
+	repo.AssertNotCalled(t, "RevokeFamily", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestGetSessions_SkipsSignedOutAndMarksCurrent(t *testing.T) {
+	service, _, sessions, _, revocations, _ := newTestTokenServiceWithSessions(t)
+	now := time.Now()
+
+	stored := []model.Session{
+		{ID: 1, SessionID: "current", LastSeenAt: now},
+		{ID: 2, SessionID: "other", LastSeenAt: now.Add(-time.Minute)},
+		{ID: 3, SessionID: "before-password-change", LastSeenAt: now.Add(-time.Hour)},
+	}
+	sessions.On("GetActiveSessions", "acc-123", mock.AnythingOfType("time.Time")).Return(stored, nil)
+	require.NoError(t, revocations.RevokeAllBefore("acc-123", now.Add(-30*time.Minute)))
+
+	result, err := service.GetSessions("acc-123", "current")
+
+	require.NoError(t, err)
+	require.Len(t, result, 2)
+	assert.True(t, result[0].Current)
+	assert.False(t, result[1].Current)
+	assert.Equal(t, uint64(2), result[1].ID)
+}
@@ -3,26 +3,33 @@ package service
 import (
 	"context"
 	"errors"
-	// "log"
+	"log"
 
 	"github.com/google/uuid"
 
 	accountModel "github.com/padapook/bestbit-core/internal/account/model"
 	"github.com/padapook/bestbit-core/internal/account/repository"
+	"github.com/padapook/bestbit-core/internal/utils"
 	"github.com/padapook/bestbit-core/internal/utils/crypto"
-	"github.com/padapook/bestbit-core/internal/utils/auth"
 	"gorm.io/gorm"
-
-	walletModel "github.com/padapook/bestbit-core/internal/wallet/model"
-
-	"github.com/shopspring/decimal"
 )
 
 type UserService interface {
 	Register(ctx context.Context, user accountModel.User) (*accountModel.User, error)
 	GetByUsername(username string) (*accountModel.User, error)
 	Login(username, password string) (*accountModel.User, error)
-	LoginByShareToken(token string) (*accountModel.User, error)
+	// admin
+	SearchUsers(query string, limit, offset int) (*UserPage, error)
+}
+
+const (
+	defaultUserSearchLimit = 50
+	maxUserSearchLimit     = 200
+)
+
+type UserPage struct {
+	Users []accountModel.User `json:"users"`
+	Total int64               `json:"total"`
 }
 
 type userService struct {
@@ -43,6 +50,7 @@ func (s *userService) Register(ctx context.Context, user accountModel.User) (*ac
 	user.Password = pwhashed
 
 	user.AccountId = uuid.New().String()
+	user.IsActive = true
 
 	var createdUser *accountModel.User
 
@@ -52,20 +60,11 @@ func (s *userService) Register(ctx context.Context, user accountModel.User) (*ac
 			return errors.New("username already exists")
 		}
 
+		// wallet สร้างตอนฝาก/รับโอน/เทรดสกุลนั้นครั้งแรก
 		if err := s.repo.CreateUser(tx, &user); err != nil {
 			return err
 		}
 
-		newWallet := &walletModel.Wallet{
-			UserID:   user.AccountId,
-			Currency: "THB",
-			Balance:  decimal.NewFromInt(0),
-		}
-
-		if err := tx.Create(newWallet).Error; err != nil {
-			return err
-		}
-
 		createdUser = &user
 		return nil
 	})
@@ -88,21 +87,51 @@ func (s *userService) Login(username, password string) (*accountModel.User, erro
 		return nil, errors.New("invalid username or password")
 	}
 
+	// เช็คหลังรหัสถูก ไม่งั้นใครก็ไล่เดาได้ว่า account ไหนถูก freeze
+	if !user.IsActive {
+		return nil, utils.ErrAccountFrozen
+	}
+
+	s.rehashPassword(user, password)
+
 	return user, nil
 }
 
-func (s *userService) LoginByShareToken(token string) (*accountModel.User, error) {
-	// log.Println("'token",token)
-	claims, err := auth.ValidateShareToken(token)
-	// log.Println("'claims",claims)
-	if err != nil {
-		return nil, errors.New("invalid or expired share token")
+// params หรือ pepper เปลี่ยนไปแล้ว hash ใหม่ด้วยรหัสผ่านที่เพิ่งตรวจผ่าน, ทำไม่สำเร็จก็ยัง login ได้ด้วย hash เดิม
+func (s *userService) rehashPassword(user *accountModel.User, password string) {
+	if !crypto.NeedsRehash(user.Password) {
+		return
 	}
 
-	user, err := s.repo.GetByUsername(claims.Username)
+	newHash, err := crypto.HashPassword(password)
 	if err != nil {
-		return nil, errors.New("user not found")
+		log.Println("[login] rehash password:", err)
+		return
+	}
+	if err := s.repo.UpdatePasswordHash(user.AccountId, user.Password, newHash); err != nil {
+		log.Println("[login] save rehashed password:", err)
+		return
 	}
+	user.Password = newHash
+}
 
-	return user, nil
+func (s *userService) SearchUsers(query string, limit, offset int) (*UserPage, error) {
+	if limit <= 0 {
+		limit = defaultUserSearchLimit
+	}
+	if limit > maxUserSearchLimit {
+		limit = maxUserSearchLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	users, total, err := s.repo.SearchUsers(query, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	if users == nil {
+		users = []accountModel.User{}
+	}
+	return &UserPage{Users: users, Total: total}, nil
 }
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/padapook/bestbit-core/internal/account/model"
+	"github.com/padapook/bestbit-core/internal/utils/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func setTestPasswordParams(t *testing.T, iterations uint32) {
+	t.Helper()
+	require.NoError(t, crypto.SetPasswordConfig(crypto.PasswordConfig{
+		Params: crypto.PasswordParams{Memory: 1024, Iterations: iterations, Parallelism: 1, SaltLength: 16, KeyLength: 32},
+	}))
+	t.Cleanup(func() {
+		require.NoError(t, crypto.SetPasswordConfig(crypto.PasswordConfig{Params: crypto.DefaultPasswordParams()}))
+	})
+}
+
+func TestLogin_RehashesOutdatedHash(t *testing.T) {
+	setTestPasswordParams(t, 1)
+	oldHash, err := crypto.HashPassword("secret123")
+	require.NoError(t, err)
+	setTestPasswordParams(t, 2)
+
+	repo := new(MockUserRepository)
+	svc := NewUserService(repo, nil)
+	repo.On("GetByUsername", "alice").Return(&model.User{AccountId: "acc-1", Username: "alice", Password: oldHash, IsActive: true}, nil)
+
+	var newHash string
+	repo.On("UpdatePasswordHash", "acc-1", oldHash, mock.AnythingOfType("string")).
+		Run(func(args mock.Arguments) { newHash = args.String(2) }).
+		Return(nil)
+
+	user, err := svc.Login("alice", "secret123")
+	require.NoError(t, err)
+	assert.Equal(t, newHash, user.Password)
+	assert.False(t, crypto.NeedsRehash(newHash))
+
+	match, err := crypto.ComparePasswordAndHash("secret123", newHash)
+	require.NoError(t, err)
+	assert.True(t, match)
+}
+
+func TestLogin_CurrentHashNotRehashed(t *testing.T) {
+	setTestPasswordParams(t, 1)
+	hash, err := crypto.HashPassword("secret123")
+	require.NoError(t, err)
+
+	repo := new(MockUserRepository)
+	svc := NewUserService(repo, nil)
+	repo.On("GetByUsername", "alice").Return(&model.User{AccountId: "acc-1", Username: "alice", Password: hash, IsActive: true}, nil)
+
+	_, err = svc.Login("alice", "secret123")
+	require.NoError(t, err)
+	repo.AssertNotCalled(t, "UpdatePasswordHash", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// บันทึก hash ใหม่ไม่ได้ต้องไม่ทำให้ user login ไม่ได้
+func TestLogin_RehashFailureStillLogsIn(t *testing.T) {
+	setTestPasswordParams(t, 1)
+	oldHash, err := crypto.HashPassword("secret123")
+	require.NoError(t, err)
+	setTestPasswordParams(t, 2)
+
+	repo := new(MockUserRepository)
+	svc := NewUserService(repo, nil)
+	repo.On("GetByUsername", "alice").Return(&model.User{AccountId: "acc-1", Username: "alice", Password: oldHash, IsActive: true}, nil)
+	repo.On("UpdatePasswordHash", "acc-1", oldHash, mock.AnythingOfType("string")).Return(errors.New("db down"))
+
+	user, err := svc.Login("alice", "secret123")
+	require.NoError(t, err)
+	assert.Equal(t, oldHash, user.Password)
+}
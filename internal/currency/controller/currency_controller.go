@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/padapook/bestbit-core/internal/currency/model"
+	"github.com/padapook/bestbit-core/internal/currency/service"
+	"github.com/padapook/bestbit-core/internal/utils"
+)
+
+type CurrencyController interface {
+	GetCurrencies(c *gin.Context)
+	AdminGetCurrencies(c *gin.Context)
+	SaveCurrency(c *gin.Context)
+}
+
+type currencyController struct {
+	currencyService service.CurrencyService
+}
+
+func NewCurrencyController(currencyService service.CurrencyService) CurrencyController {
+	return &currencyController{currencyService: currencyService}
+}
+
+type SaveCurrencyRequest struct {
+	Name            string `json:"name"`
+	Precision       *int   `json:"precision" binding:"required"`
+	Enabled         bool   `json:"enabled"`
+	DepositEnabled  bool   `json:"deposit_enabled"`
+	WithdrawEnabled bool   `json:"withdraw_enabled"`
+}
+
+// public เห็นเฉพาะสกุลที่เปิดใช้งาน
+func (ctrl *currencyController) GetCurrencies(c *gin.Context) {
+	currencies, err := ctrl.currencyService.GetCurrencies(true)
+	if err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": currencies,
+	})
+}
+
+func (ctrl *currencyController) AdminGetCurrencies(c *gin.Context) {
+	currencies, err := ctrl.currencyService.GetCurrencies(false)
+	if err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": currencies,
+	})
+}
+
+func (ctrl *currencyController) SaveCurrency(c *gin.Context) {
+	var req SaveCurrencyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleError(c, utils.ErrInvalidRequest)
+		return
+	}
+
+	currency, err := ctrl.currencyService.SaveCurrency(model.Currency{
+		Code:            c.Param("code"),
+		Name:            req.Name,
+		Precision:       *req.Precision,
+		Enabled:         req.Enabled,
+		DepositEnabled:  req.DepositEnabled,
+		WithdrawEnabled: req.WithdrawEnabled,
+	}, c.GetString("account_id"))
+	if err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Currency saved",
+		"data":    currency,
+	})
+}
@@ -0,0 +1,38 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	OperationDeposit  = "DEPOSIT"
+	OperationWithdraw = "WITHDRAW"
+)
+
+// สกุลเงินที่ระบบรองรับ, Enabled=false ปิดทั้งสกุล (ฝาก ถอน โอน เทรด)
+type Currency struct {
+	Code            string    `gorm:"primaryKey;size:10" json:"code"`
+	Name            string    `gorm:"size:50" json:"name"`
+	Precision       int       `gorm:"not null" json:"precision" comment:"จำนวนทศนิยมสูงสุดของยอด"`
+	Enabled         bool      `gorm:"not null" json:"enabled"`
+	DepositEnabled  bool      `gorm:"not null" json:"deposit_enabled"`
+	WithdrawEnabled bool      `gorm:"not null" json:"withdraw_enabled"`
+	CreatedAt       time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	UpdatedBy       string    `gorm:"size:100" json:"updated_by"`
+}
+
+// ทศนิยมไม่เกิน precision ของสกุล
+func (c *Currency) IsValidAmount(amount decimal.Decimal) bool {
+	return amount.Equal(amount.Truncate(int32(c.Precision)))
+}
+
+// สกุลตั้งต้นตอน migrate ครั้งแรก แก้ทีหลังผ่าน admin ได้
+var DefaultCurrencies = []Currency{
+	{Code: "THB", Name: "Thai Baht", Precision: 2, Enabled: true, DepositEnabled: true, WithdrawEnabled: true},
+	{Code: "BTC", Name: "Bitcoin", Precision: 8, Enabled: true, DepositEnabled: true, WithdrawEnabled: true},
+	{Code: "ETH", Name: "Ethereum", Precision: 8, Enabled: true, DepositEnabled: true, WithdrawEnabled: true},
+	{Code: "USDT", Name: "Tether", Precision: 6, Enabled: true, DepositEnabled: true, WithdrawEnabled: true},
+}
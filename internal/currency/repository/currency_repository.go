@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/padapook/bestbit-core/internal/currency/model"
+	"github.com/padapook/bestbit-core/internal/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CurrencyRepository interface {
+	GetByCode(code string) (*model.Currency, error)
+	GetCurrencies(enabledOnly bool) ([]model.Currency, error)
+	SaveCurrency(currency *model.Currency) error
+	// ใส่สกุลตั้งต้นที่ยังไม่มี ไม่ทับค่าที่ admin แก้ไว้
+	SeedCurrencies(currencies []model.Currency) error
+}
+
+type currencyRepository struct {
+	db *gorm.DB
+}
+
+func NewCurrencyRepository(db *gorm.DB) CurrencyRepository {
+	return &currencyRepository{db: db}
+}
+
+func (r *currencyRepository) GetByCode(code string) (*model.Currency, error) {
+	var currency model.Currency
+	if err := r.db.Where("code = ?", code).First(&currency).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.ErrCurrencyNotFound
+		}
+		return nil, err
+	}
+	return &currency, nil
+}
+
+func (r *currencyRepository) GetCurrencies(enabledOnly bool) ([]model.Currency, error) {
+	var currencies []model.Currency
+
+	query := r.db.Model(&model.Currency{})
+	if enabledOnly {
+		query = query.Where("enabled = ?", true)
+	}
+
+	err := query.Order("code ASC").Find(&currencies).Error
+	return currencies, err
+}
+
+func (r *currencyRepository) SaveCurrency(currency *model.Currency) error {
+	return r.db.Save(currency).Error
+}
+
+func (r *currencyRepository) SeedCurrencies(currencies []model.Currency) error {
+	if len(currencies) == 0 {
+		return nil
+	}
+	rows := append([]model.Currency(nil), currencies...)
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/padapook/bestbit-core/internal/currency/model"
+	"github.com/padapook/bestbit-core/internal/currency/repository"
+	"github.com/padapook/bestbit-core/internal/utils"
+	"github.com/shopspring/decimal"
+)
+
+const maxPrecision = 16 // ตาม decimal(32,16) ของ wallet
+
+type CurrencyService interface {
+	// ตรวจ code ที่มาจาก request, operation "" = ขอแค่สกุลเปิดใช้งาน
+	Validate(code, operation string) (*model.Currency, error)
+	// Validate พร้อมตรวจทศนิยมของยอด
+	ValidateAmount(code, operation string, amount decimal.Decimal) (*model.Currency, error)
+
+	GetCurrencies(enabledOnly bool) ([]model.Currency, error)
+	// admin
+	SaveCurrency(currency model.Currency, actorID string) (*model.Currency, error)
+}
+
+// domain อื่นที่ต้องมีข้อมูลตั้งต้นของสกุล (เช่น วงเงิน tier 0) ไม่งั้นสกุลใหม่ใช้งานไม่ได้
+// เรียกทุกครั้งที่ save ต้องไม่ทับค่าที่มีอยู่แล้ว
+type CurrencyInitializer interface {
+	InitCurrency(code string) error
+}
+
+type currencyService struct {
+	repo         repository.CurrencyRepository
+	initializers []CurrencyInitializer
+}
+
+// initializers ใช้แค่ตอน admin save สกุล, ที่ใช้แค่ Validate ไม่ต้องส่ง
+func NewCurrencyService(repo repository.CurrencyRepository, initializers ...CurrencyInitializer) CurrencyService {
+	return &currencyService{repo: repo, initializers: initializers}
+}
+
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func (s *currencyService) Validate(code, operation string) (*model.Currency, error) {
+	code = NormalizeCode(code)
+	if code == "" {
+		return nil, utils.ErrCurrencyNotFound
+	}
+
+	currency, err := s.repo.GetByCode(code)
+	if err != nil {
+		return nil, err
+	}
+
+	if !currency.Enabled {
+		return nil, utils.ErrCurrencyDisabled
+	}
+	switch operation {
+	case model.OperationDeposit:
+		if !currency.DepositEnabled {
+			return nil, utils.ErrDepositDisabled
+		}
+	case model.OperationWithdraw:
+		if !currency.WithdrawEnabled {
+			return nil, utils.ErrWithdrawDisabled
+		}
+	}
+
+	return currency, nil
+}
+
+func (s *currencyService) ValidateAmount(code, operation string, amount decimal.Decimal) (*model.Currency, error) {
+	currency, err := s.Validate(code, operation)
+	if err != nil {
+		return nil, err
+	}
+	if !currency.IsValidAmount(amount) {
+		return nil, utils.ErrInvalidAmountPrecision
+	}
+	return currency, nil
+}
+
+func (s *currencyService) GetCurrencies(enabledOnly bool) ([]model.Currency, error) {
+	return s.repo.GetCurrencies(enabledOnly)
+}
+
+// สร้างหรือแก้ทั้งแถว, code เดิมคือแก้
+func (s *currencyService) SaveCurrency(currency model.Currency, actorID string) (*model.Currency, error) {
+	currency.Code = NormalizeCode(currency.Code)
+	if currency.Code == "" || len(currency.Code) > 10 {
+		return nil, utils.ErrInvalidRequest
+	}
+	if currency.Precision < 0 || currency.Precision > maxPrecision {
+		return nil, utils.ErrInvalidRequest
+	}
+
+	existing, err := s.repo.GetByCode(currency.Code)
+	if err != nil && !errors.Is(err, utils.ErrCurrencyNotFound) {
+		return nil, err
+	}
+	if existing != nil {
+		currency.CreatedAt = existing.CreatedAt
+	}
+	currency.UpdatedBy = actorID
+
+	if err := s.repo.SaveCurrency(&currency); err != nil {
+		return nil, err
+	}
+	// ไม่ใช่แค่ตอนสร้าง save ซ้ำจะได้เติมส่วนที่ขาด เช่น init ครั้งก่อนพัง
+	for _, initializer := range s.initializers {
+		if err := initializer.InitCurrency(currency.Code); err != nil {
+			return nil, err
+		}
+	}
+	return &currency, nil
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/padapook/bestbit-core/internal/currency/model"
+	"github.com/padapook/bestbit-core/internal/utils"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockCurrencyRepository struct {
+	mock.Mock
+}
+
+func (m *MockCurrencyRepository) GetByCode(code string) (*model.Currency, error) {
+	args := m.Called(code)
+	if args.Get(0) != nil {
+		return args.Get(0).(*model.Currency), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockCurrencyRepository) GetCurrencies(enabledOnly bool) ([]model.Currency, error) {
+	args := m.Called(enabledOnly)
+	if args.Get(0) != nil {
+		return args.Get(0).([]model.Currency), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockCurrencyRepository) SaveCurrency(currency *model.Currency) error {
+	args := m.Called(currency)
+	return args.Error(0)
+}
+
+func (m *MockCurrencyRepository) SeedCurrencies(currencies []model.Currency) error {
+	args := m.Called(currencies)
+	return args.Error(0)
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		currency  model.Currency
+		operation string
+		expected  error
+	}{
+		{"enabled", model.Currency{Code: "BTC", Enabled: true, DepositEnabled: true, WithdrawEnabled: true}, model.OperationDeposit, nil},
+		{"disabled", model.Currency{Code: "BTC", Enabled: false, DepositEnabled: true, WithdrawEnabled: true}, "", utils.ErrCurrencyDisabled},
+		{"deposit paused", model.Currency{Code: "BTC", Enabled: true, DepositEnabled: false, WithdrawEnabled: true}, model.OperationDeposit, utils.ErrDepositDisabled},
+		{"withdraw paused", model.Currency{Code: "BTC", Enabled: true, DepositEnabled: true, WithdrawEnabled: false}, model.OperationWithdraw, utils.ErrWithdrawDisabled},
+		// ปิดถอนแต่ยังดูยอด/โอนภายในได้
+		{"withdraw paused, no operation", model.Currency{Code: "BTC", Enabled: true, DepositEnabled: true, WithdrawEnabled: false}, "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockCurrencyRepository)
+			service := NewCurrencyService(mockRepo)
+
+			currency := tt.currency
+			mockRepo.On("GetByCode", "BTC").Return(&currency, nil)
+
+			result, err := service.Validate(" btc ", tt.operation)
+
+			assert.Equal(t, tt.expected, err)
+			if tt.expected == nil {
+				assert.Equal(t, "BTC", result.Code)
+			}
+		})
+	}
+}
+
+func TestValidate_Fail_NotFound(t *testing.T) {
+	mockRepo := new(MockCurrencyRepository)
+	service := NewCurrencyService(mockRepo)
+
+	mockRepo.On("GetByCode", "DOGE").Return(nil, utils.ErrCurrencyNotFound)
+
+	_, err := service.Validate("doge", "")
+
+	assert.Equal(t, utils.ErrCurrencyNotFound, err)
+}
+
+func TestValidateAmount_Precision(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   string
+		expected error
+	}{
+		{"within precision", "10.25", nil},
+		{"trailing zeros", "10.2500", nil},
+		{"too many decimals", "10.255", utils.ErrInvalidAmountPrecision},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockCurrencyRepository)
+			service := NewCurrencyService(mockRepo)
+
+			mockRepo.On("GetByCode", "THB").Return(&model.Currency{Code: "THB", Precision: 2, Enabled: true, DepositEnabled: true, WithdrawEnabled: true}, nil)
+
+			_, err := service.ValidateAmount("THB", model.OperationDeposit, decimal.RequireFromString(tt.amount))
+
+			assert.Equal(t, tt.expected, err)
+		})
+	}
+}
+
+func TestSaveCurrency_KeepsCreatedAt(t *testing.T) {
+	mockRepo := new(MockCurrencyRepository)
+	service := NewCurrencyService(mockRepo)
+
+	existing := &model.Currency{Code: "USDT", Precision: 6, CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	mockRepo.On("GetByCode", "USDT").Return(existing, nil)
+	mockRepo.On("SaveCurrency", mock.AnythingOfType("*model.Currency")).Return(nil)
+
+	currency, err := service.SaveCurrency(model.Currency{Code: "usdt", Precision: 4, Enabled: true}, "admin-1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "USDT", currency.Code)
+	assert.Equal(t, existing.CreatedAt, currency.CreatedAt)
+	assert.Equal(t, "admin-1", currency.UpdatedBy)
+}
+
+func TestSaveCurrency_Fail_InvalidPrecision(t *testing.T) {
+	mockRepo := new(MockCurrencyRepository)
+	service := NewCurrencyService(mockRepo)
+
+	_, err := service.SaveCurrency(model.Currency{Code: "XYZ", Precision: 17}, "admin-1")
+
+	assert.Equal(t, utils.ErrInvalidRequest, err)
+	mockRepo.AssertNotCalled(t, "SaveCurrency", mock.Anything)
+}
+
+type MockCurrencyInitializer struct {
+	mock.Mock
+}
+
+func (m *MockCurrencyInitializer) InitCurrency(code string) error {
+	args := m.Called(code)
+	return args.Error(0)
+}
+
+// สกุลใหม่ต้องได้ข้อมูลตั้งต้นของ domain อื่น เช่น วงเงิน
+func TestSaveCurrency_InitializesNewCurrency(t *testing.T) {
+	mockRepo := new(MockCurrencyRepository)
+	initializer := new(MockCurrencyInitializer)
+	service := NewCurrencyService(mockRepo, initializer)
+
+	mockRepo.On("GetByCode", "DOGE").Return(nil, utils.ErrCurrencyNotFound)
+	mockRepo.On("SaveCurrency", mock.AnythingOfType("*model.Currency")).Return(nil)
+	initializer.On("InitCurrency", "DOGE").Return(nil)
+
+	_, err := service.SaveCurrency(model.Currency{Code: "doge", Precision: 8, Enabled: true}, "admin-1")
+
+	assert.NoError(t, err)
+	initializer.AssertExpectations(t)
+}
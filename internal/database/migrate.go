@@ -2,30 +2,102 @@ package database
 
 import (
 	accountModel "github.com/padapook/bestbit-core/internal/account/model"
+	accountRepository "github.com/padapook/bestbit-core/internal/account/repository"
+	currencyModel "github.com/padapook/bestbit-core/internal/currency/model"
+	currencyRepository "github.com/padapook/bestbit-core/internal/currency/repository"
+	freezeModel "github.com/padapook/bestbit-core/internal/freeze/model"
+	idempotencyModel "github.com/padapook/bestbit-core/internal/idempotency/model"
+	ledgerModel "github.com/padapook/bestbit-core/internal/ledger/model"
+	ledgerRepository "github.com/padapook/bestbit-core/internal/ledger/repository"
+	limitModel "github.com/padapook/bestbit-core/internal/limit/model"
+	limitRepository "github.com/padapook/bestbit-core/internal/limit/repository"
+	mailModel "github.com/padapook/bestbit-core/internal/mail/model"
+	marketModel "github.com/padapook/bestbit-core/internal/market/model"
 	orderModel "github.com/padapook/bestbit-core/internal/order/model"
+	reconciliationModel "github.com/padapook/bestbit-core/internal/reconciliation/model"
+	reconciliationRepository "github.com/padapook/bestbit-core/internal/reconciliation/repository"
 	tradeModel "github.com/padapook/bestbit-core/internal/trade/model"
 	walletModel "github.com/padapook/bestbit-core/internal/wallet/model"
+	withdrawalModel "github.com/padapook/bestbit-core/internal/withdrawal/model"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"log"
+	"os"
+	"strings"
+	"time"
 )
 
+// งาน migrate ข้อมูลที่ต้องทำครั้งเดียวจริงๆ (รันซ้ำแล้วจะทับข้อมูลที่เปลี่ยนไปหลังจากนั้น)
+type appliedMigration struct {
+	Name      string `gorm:"primaryKey;size:100"`
+	AppliedAt time.Time
+}
+
 func AutoMigrate(db *gorm.DB) error {
 	// log.Println("เข้า migrate")
 
+	// ใช้แค่กับติดตั้งเก่าที่ backfill is_active ไปก่อนมี marker
+	freezeEnabled := db.Migrator().HasTable(&freezeModel.FreezeEvent{})
+
 	err := db.AutoMigrate(
 		// account
 		&accountModel.User{},
+		&accountModel.RefreshToken{},
+		&accountModel.RevokedToken{},
+		&accountModel.TokenRevocation{},
+		&accountModel.UserRole{},
+		&accountModel.UserMFA{},
+		&accountModel.MFARecoveryCode{},
+		&accountModel.LoginAttempt{},
+		&accountModel.PasswordResetToken{},
+		&accountModel.ShareToken{},
+		&accountModel.Session{},
+		&accountModel.APIKey{},
+		&accountModel.UsedRequestSignature{},
+
+		// currency
+		&currencyModel.Currency{},
 
 		// wallet
-		&walletModel.Transaction{},
+		&walletModel.WalletTransaction{},
 		&walletModel.Wallet{},
 
+		// limit
+		&limitModel.TransactionLimit{},
+
+		// idempotency
+		&idempotencyModel.IdempotencyKey{},
+
+		// mail
+		&mailModel.OutboxEmail{},
+
+		// ledger
+		&ledgerModel.LedgerAccount{},
+		&ledgerModel.JournalEntry{},
+		&ledgerModel.Posting{},
+
+		// market
+		&marketModel.Market{},
+
 		// order
 		&orderModel.Order{},
 
 		// trade
 		&tradeModel.Trade{},
+
+		// reconciliation
+		&reconciliationModel.ReconciliationReport{},
+		&reconciliationModel.ReconciliationFinding{},
+
+		// withdrawal
+		&withdrawalModel.Withdrawal{},
+		&withdrawalModel.WithdrawalTransition{},
+
+		// freeze
+		&freezeModel.FreezeEvent{},
+
+		&appliedMigration{},
 	)
 
 	if err != nil {
@@ -33,6 +105,211 @@ func AutoMigrate(db *gorm.DB) error {
 		return err
 	}
 
+	if err := dropLegacyIndexes(db); err != nil {
+		log.Println("'drop legacy index พัง")
+		return err
+	}
+
+	if err := backfillTradeColumns(db); err != nil {
+		log.Println("'backfill trade พัง")
+		return err
+	}
+
+	err = runOnce(db, "wallet_is_active_backfill", func() error {
+		// ติดตั้งที่มีตาราง freeze อยู่แล้ว backfill ไปแล้วก่อนมี marker รันซ้ำจะปลด freeze จริง
+		if freezeEnabled {
+			return nil
+		}
+		return backfillWalletActive(db)
+	})
+	if err != nil {
+		log.Println("'backfill wallet is_active พัง")
+		return err
+	}
+
+	err = runOnce(db, "api_key_encrypted_secrets", func() error {
+		return retireHashedAPIKeys(db)
+	})
+	if err != nil {
+		log.Println("'retire hashed api key พัง")
+		return err
+	}
+
+	if err := seedAdminRoles(db); err != nil {
+		log.Println("'seed admin role พัง")
+		return err
+	}
+
+	if err := currencyRepository.NewCurrencyRepository(db).SeedCurrencies(currencyModel.DefaultCurrencies); err != nil {
+		log.Println("'seed currency พัง")
+		return err
+	}
+
+	if err := seedDefaultLimits(db); err != nil {
+		log.Println("'seed limit พัง")
+		return err
+	}
+
+	// wallet ที่มียอดก่อนเปิดใช้ ledger ต้องมียอดยกมา ไม่งั้น verify ไม่ผ่าน
+	posted, err := ledgerRepository.NewLedgerRepository(db).PostOpeningBalances()
+	if err != nil {
+		log.Println("'ledger opening balance พัง")
+		return err
+	}
+	if posted > 0 {
+		log.Println("ledger opening balances posted:", posted)
+	}
+
+	cleared, err := ledgerRepository.NewLedgerRepository(db).ClearPendingWithdrawals()
+	if err != nil {
+		log.Println("'ledger clear pending withdrawal พัง")
+		return err
+	}
+	if cleared > 0 {
+		log.Println("ledger pending withdrawals cleared:", cleared)
+	}
+
+	err = runOnce(db, "reconciliation_opening_balances", func() error {
+		posted, err := reconciliationRepository.NewReconciliationRepository(db).PostOpeningBalances()
+		if posted > 0 {
+			log.Println("wallet opening balances posted:", posted)
+		}
+		return err
+	})
+	if err != nil {
+		log.Println("'wallet opening balance พัง")
+		return err
+	}
+
 	log.Println("migrate success")
 	return nil
 }
+
+// index เก่าที่ถูกแทนด้วย index ใหม่ AutoMigrate ไม่ลบให้เอง
+func dropLegacyIndexes(db *gorm.DB) error {
+	// reference_id เคย unique ทั้งตาราง ทำให้ transfer (2 แถว reference เดียวกัน) insert ไม่ได้
+	if db.Migrator().HasIndex(&walletModel.WalletTransaction{}, "idx_wallet_transactions_reference_id") {
+		if err := db.Migrator().DropIndex(&walletModel.WalletTransaction{}, "idx_wallet_transactions_reference_id"); err != nil {
+			return err
+		}
+	}
+	// symbol เคย unique รวม market ที่ลบไปแล้ว ทำให้สร้าง symbol เดิมใหม่ไม่ได้ แทนด้วย idx_markets_symbol_active
+	if db.Migrator().HasIndex(&marketModel.Market{}, "idx_markets_symbol") {
+		if err := db.Migrator().DropIndex(&marketModel.Market{}, "idx_markets_symbol"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ไม่มีแถววงเงิน = ทำรายการไม่ได้, tier 0 ได้วงเงินจำกัดจาก LIMIT_TIER0_DEFAULTS ทุกสกุลที่มีอยู่ (รวมที่ admin เพิ่ม)
+// แถว 0 ทั้งหมด (ไม่จำกัด) ที่เคย seed ให้ทุก tier ถูกลบทิ้ง ไม่งั้น user ที่ยังไม่ยืนยันตัวตนถอนได้ไม่จำกัด
+func seedDefaultLimits(db *gorm.DB) error {
+	defaults, err := limitModel.ParseDefaultLimits(os.Getenv("LIMIT_TIER0_DEFAULTS"))
+	if err != nil {
+		return err
+	}
+
+	err = runOnce(db, "limit_unlimited_defaults_removed", func() error {
+		return db.Where("updated_by = ? AND max_single = 0 AND daily_limit = 0 AND monthly_limit = 0", "SYSTEM").
+			Delete(&limitModel.TransactionLimit{}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	currencies, err := currencyRepository.NewCurrencyRepository(db).GetCurrencies(false)
+	if err != nil {
+		return err
+	}
+	codes := make([]string, 0, len(currencies))
+	for _, c := range currencies {
+		codes = append(codes, c.Code)
+	}
+	return limitRepository.NewLimitRepository(db).SeedLimits(limitModel.DefaultLimits(codes, defaults))
+}
+
+// key ที่สร้างตอนเก็บแค่ hash ของ secret ถอด secret กลับมาตรวจ HMAC ไม่ได้ ต้อง revoke ให้ user สร้างใหม่
+// column secret_hash เดิมเป็น not null ต้องลบทิ้ง ไม่งั้นสร้าง key ใหม่ไม่ได้
+func retireHashedAPIKeys(db *gorm.DB) error {
+	err := db.Model(&accountModel.APIKey{}).
+		Where("secret_encrypted = '' AND revoked_at IS NULL").
+		Update("revoked_at", time.Now()).Error
+	if err != nil {
+		return err
+	}
+	if db.Migrator().HasColumn(&accountModel.APIKey{}, "secret_hash") {
+		return db.Migrator().DropColumn(&accountModel.APIKey{}, "secret_hash")
+	}
+	return nil
+}
+
+// Trade.Price/Amount เปลี่ยนจาก float64 เป็น decimal.Decimal ใน Go แต่ column เป็น decimal(32,16) อยู่แล้ว
+// AutoMigrate จึงไม่แตะข้อมูลเดิม เหลือแค่เติม column ใหม่ของแถวเก่าจาก orders
+// รันซ้ำได้ เพราะเลือกเฉพาะแถวที่ taker_side ยังว่าง
+func backfillTradeColumns(db *gorm.DB) error {
+	return db.Exec(`
+		UPDATE trades t SET
+			maker_user_id = mo.user_id,
+			taker_user_id = tko.user_id,
+			taker_side = tko.side,
+			quote_amount = ROUND(t.price * t.amount, 16),
+			maker_fee_currency = CASE WHEN tko.side = 'BUY' THEN split_part(t.symbol, '_', 2) ELSE split_part(t.symbol, '_', 1) END,
+			taker_fee_currency = CASE WHEN tko.side = 'BUY' THEN split_part(t.symbol, '_', 1) ELSE split_part(t.symbol, '_', 2) END
+		FROM orders mo, orders tko
+		WHERE mo.id = t.maker_order_id
+			AND tko.id = t.taker_order_id
+			AND (t.taker_side IS NULL OR t.taker_side = '')
+	`).Error
+}
+
+// wallet ที่ Register เคยสร้างไว้ได้ is_active = false (zero value) ทั้งที่ไม่ได้ถูก freeze
+// รันครั้งเดียวผ่าน runOnce หลังจากนั้น false คือ freeze จริงห้ามแตะ
+func backfillWalletActive(db *gorm.DB) error {
+	return db.Model(&walletModel.Wallet{}).
+		Where("is_active = ? OR is_active IS NULL", false).
+		Update("is_active", true).Error
+}
+
+// ADMIN_ACCOUNT_IDS (คั่นด้วย comma) เดิมใช้เช็ค admin ตรงๆ ตอนนี้ใช้แค่ตั้งต้นให้มี role admin คนแรก
+// ให้ role เพิ่มอย่างเดียวทุกครั้งที่ migrate ถ้าจะถอด admin ที่อยู่ใน env ต้องเอาออกจาก env ด้วย
+func seedAdminRoles(db *gorm.DB) error {
+	roles := accountRepository.NewRoleRepository(db)
+	for _, id := range strings.Split(os.Getenv("ADMIN_ACCOUNT_IDS"), ",") {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			continue
+		}
+
+		var count int64
+		if err := db.Model(&accountModel.User{}).Where("account_id = ?", id).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			log.Println("ADMIN_ACCOUNT_IDS: account not found, skip", id)
+			continue
+		}
+
+		if err := roles.GrantRole(id, accountModel.RoleAdmin, "SYSTEM"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fn สำเร็จแล้วจึงบันทึกชื่อ ครั้งต่อไปข้าม ถ้า fn พังกลางทางรอบหน้าจะรันใหม่ fn จึงต้องรันซ้ำส่วนที่ทำไปแล้วได้
+func runOnce(db *gorm.DB, name string, fn func() error) error {
+	var count int64
+	if err := db.Model(&appliedMigration{}).Where("name = ?", name).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	if err := fn(); err != nil {
+		return err
+	}
+	return db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&appliedMigration{Name: name, AppliedAt: time.Now()}).Error
+}
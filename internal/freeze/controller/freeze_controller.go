@@ -0,0 +1,89 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/padapook/bestbit-core/internal/freeze/service"
+	"github.com/padapook/bestbit-core/internal/utils"
+)
+
+type FreezeController interface {
+	FreezeAccount(c *gin.Context)
+	UnfreezeAccount(c *gin.Context)
+	FreezeWallet(c *gin.Context)
+	UnfreezeWallet(c *gin.Context)
+	UnlockLogin(c *gin.Context)
+	GetEvents(c *gin.Context)
+}
+
+type freezeController struct {
+	freezeService service.FreezeService
+}
+
+func NewFreezeController(freezeService service.FreezeService) FreezeController {
+	return &freezeController{freezeService: freezeService}
+}
+
+type FreezeRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+func (ctrl *freezeController) FreezeAccount(c *gin.Context) {
+	ctrl.handle(c, "Account frozen", func(reason, actor string) error {
+		return ctrl.freezeService.FreezeAccount(c.Param("account_id"), reason, actor)
+	})
+}
+
+func (ctrl *freezeController) UnfreezeAccount(c *gin.Context) {
+	ctrl.handle(c, "Account unfrozen", func(reason, actor string) error {
+		return ctrl.freezeService.UnfreezeAccount(c.Param("account_id"), reason, actor)
+	})
+}
+
+func (ctrl *freezeController) FreezeWallet(c *gin.Context) {
+	ctrl.handle(c, "Wallet frozen", func(reason, actor string) error {
+		return ctrl.freezeService.FreezeWallet(c.Param("account_id"), c.Param("currency"), reason, actor)
+	})
+}
+
+func (ctrl *freezeController) UnfreezeWallet(c *gin.Context) {
+	ctrl.handle(c, "Wallet unfrozen", func(reason, actor string) error {
+		return ctrl.freezeService.UnfreezeWallet(c.Param("account_id"), c.Param("currency"), reason, actor)
+	})
+}
+
+func (ctrl *freezeController) UnlockLogin(c *gin.Context) {
+	ctrl.handle(c, "Login unlocked", func(reason, actor string) error {
+		return ctrl.freezeService.UnlockLogin(c.Param("account_id"), reason, actor)
+	})
+}
+
+func (ctrl *freezeController) GetEvents(c *gin.Context) {
+	events, err := ctrl.freezeService.GetEvents(c.Param("account_id"))
+	if err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": events,
+	})
+}
+
+func (ctrl *freezeController) handle(c *gin.Context, message string, action func(reason, actor string) error) {
+	var req FreezeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleError(c, utils.ErrInvalidRequest)
+		return
+	}
+
+	if err := action(req.Reason, c.GetString("account_id")); err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+	})
+}
@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+const (
+	TargetAccount = "ACCOUNT"
+	TargetWallet  = "WALLET"
+
+	ActionFreeze   = "FREEZE"
+	ActionUnfreeze = "UNFREEZE"
+	// admin ปลด lock login/MFA ก่อนหมดเวลา
+	ActionUnlockLogin = "UNLOCK_LOGIN"
+)
+
+// audit trail ของการ freeze/unfreeze และปลด lock login ทุกครั้ง เขียนอย่างเดียวไม่แก้ไม่ลบ
+// Currency ว่าง = ทั้ง account
+type FreezeEvent struct {
+	ID         uint64    `gorm:"primaryKey" json:"id"`
+	TargetType string    `gorm:"type:varchar(20);not null" json:"target_type"`
+	AccountID  string    `gorm:"size:100;not null;index" json:"account_id"`
+	Currency   string    `gorm:"size:10" json:"currency,omitempty"`
+	Action     string    `gorm:"type:varchar(20);not null" json:"action"`
+	Reason     string    `gorm:"type:text;not null" json:"reason"`
+	Actor      string    `gorm:"size:100;not null" json:"actor"`
+	CreatedAt  time.Time `gorm:"autoCreateTime;index" json:"created_at"`
+}
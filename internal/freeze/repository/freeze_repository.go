@@ -0,0 +1,64 @@
+package repository
+
+import (
+	accountModel "github.com/padapook/bestbit-core/internal/account/model"
+	"github.com/padapook/bestbit-core/internal/freeze/model"
+	"github.com/padapook/bestbit-core/internal/utils"
+	walletModel "github.com/padapook/bestbit-core/internal/wallet/model"
+	"gorm.io/gorm"
+)
+
+type FreezeRepository interface {
+	Transaction(fn func(tx *gorm.DB) error) error
+	SetAccountActive(tx *gorm.DB, accountID string, active bool, updatedBy string) error
+	SetWalletActive(tx *gorm.DB, accountID, currency string, active bool) error
+	CreateEvent(tx *gorm.DB, event *model.FreezeEvent) error
+	GetEvents(accountID string) ([]model.FreezeEvent, error)
+}
+
+type freezeRepository struct {
+	db *gorm.DB
+}
+
+func NewFreezeRepository(db *gorm.DB) FreezeRepository {
+	return &freezeRepository{db: db}
+}
+
+func (r *freezeRepository) Transaction(fn func(tx *gorm.DB) error) error {
+	return r.db.Transaction(fn)
+}
+
+func (r *freezeRepository) SetAccountActive(tx *gorm.DB, accountID string, active bool, updatedBy string) error {
+	result := tx.Model(&accountModel.User{}).Where("account_id = ?", accountID).
+		Updates(map[string]interface{}{"is_active": active, "updated_by": updatedBy})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return utils.ErrUserNotFound
+	}
+	return nil
+}
+
+// update ตรงแถว ไม่ผ่าน Save ของ wallet เพื่อไม่ทับยอดที่ tx อื่นกำลังแก้
+func (r *freezeRepository) SetWalletActive(tx *gorm.DB, accountID, currency string, active bool) error {
+	result := tx.Model(&walletModel.Wallet{}).Where("user_id = ? AND currency = ?", accountID, currency).
+		Update("is_active", active)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return utils.ErrWalletNotFound
+	}
+	return nil
+}
+
+func (r *freezeRepository) CreateEvent(tx *gorm.DB, event *model.FreezeEvent) error {
+	return tx.Create(event).Error
+}
+
+func (r *freezeRepository) GetEvents(accountID string) ([]model.FreezeEvent, error) {
+	var events []model.FreezeEvent
+	err := r.db.Where("account_id = ?", accountID).Order("id DESC").Find(&events).Error
+	return events, err
+}
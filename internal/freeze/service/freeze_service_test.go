@@ -0,0 +1,191 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	accountService "github.com/padapook/bestbit-core/internal/account/service"
+	"github.com/padapook/bestbit-core/internal/freeze/model"
+	orderService "github.com/padapook/bestbit-core/internal/order/service"
+	"github.com/padapook/bestbit-core/internal/utils"
+	"github.com/padapook/bestbit-core/internal/utils/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+type MockFreezeRepository struct {
+	mock.Mock
+}
+
+func (m *MockFreezeRepository) Transaction(fn func(tx *gorm.DB) error) error {
+	return fn(nil)
+}
+
+func (m *MockFreezeRepository) SetAccountActive(tx *gorm.DB, accountID string, active bool, updatedBy string) error {
+	args := m.Called(tx, accountID, active, updatedBy)
+	return args.Error(0)
+}
+
+func (m *MockFreezeRepository) SetWalletActive(tx *gorm.DB, accountID, currency string, active bool) error {
+	args := m.Called(tx, accountID, currency, active)
+	return args.Error(0)
+}
+
+func (m *MockFreezeRepository) CreateEvent(tx *gorm.DB, event *model.FreezeEvent) error {
+	args := m.Called(tx, event)
+	return args.Error(0)
+}
+
+func (m *MockFreezeRepository) GetEvents(accountID string) ([]model.FreezeEvent, error) {
+	args := m.Called(accountID)
+	if args.Get(0) != nil {
+		return args.Get(0).([]model.FreezeEvent), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+// freeze ใช้แค่ CancelOpenOrders
+type MockOrderService struct {
+	orderService.OrderService
+	mock.Mock
+}
+
+func (m *MockOrderService) CancelOpenOrders(userID, currency string) (int, error) {
+	args := m.Called(userID, currency)
+	return args.Int(0), args.Error(1)
+}
+
+// freeze ใช้แค่ UnlockAccount
+type MockLoginGuard struct {
+	accountService.LoginGuard
+	mock.Mock
+}
+
+func (m *MockLoginGuard) UnlockAccount(accountID string) error {
+	args := m.Called(accountID)
+	return args.Error(0)
+}
+
+var testNow = time.Date(2024, 5, 20, 12, 0, 0, 0, time.UTC)
+
+func newTestFreezeService(repo *MockFreezeRepository, revocations auth.RevocationStore, orders *MockOrderService) *freezeService {
+	return &freezeService{repo: repo, revocations: revocations, orders: orders, now: func() time.Time { return testNow }}
+}
+
+func isEvent(target, currency, action string) interface{} {
+	return mock.MatchedBy(func(e *model.FreezeEvent) bool {
+		return e.TargetType == target && e.Currency == currency && e.Action == action &&
+			e.AccountID == "user-1" && e.Actor == "admin-1" && e.Reason == "suspicious activity"
+	})
+}
+
+func TestFreezeAccount_RecordsEventAndRevokesTokens(t *testing.T) {
+	mockRepo := new(MockFreezeRepository)
+	mockOrders := new(MockOrderService)
+	revocations := auth.NewMemoryRevocationStore()
+	service := newTestFreezeService(mockRepo, revocations, mockOrders)
+
+	mockRepo.On("SetAccountActive", mock.Anything, "user-1", false, "admin-1").Return(nil)
+	mockRepo.On("CreateEvent", mock.Anything, isEvent(model.TargetAccount, "", model.ActionFreeze)).Return(nil)
+	mockOrders.On("CancelOpenOrders", "user-1", "").Return(2, nil)
+
+	err := service.FreezeAccount("user-1", "  suspicious activity ", "admin-1")
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	mockOrders.AssertExpectations(t)
+
+	// token ที่ออกก่อน freeze ใช้ต่อไม่ได้
+	claims := &auth.Claims{AccountID: "user-1", RegisteredClaims: jwt.RegisteredClaims{IssuedAt: jwt.NewNumericDate(testNow.Add(-time.Minute))}}
+	revoked, err := revocations.IsRevoked(claims)
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+}
+
+func TestFreezeAccount_Fail_UserNotFound(t *testing.T) {
+	mockRepo := new(MockFreezeRepository)
+	mockOrders := new(MockOrderService)
+	revocations := auth.NewMemoryRevocationStore()
+	service := newTestFreezeService(mockRepo, revocations, mockOrders)
+
+	mockRepo.On("SetAccountActive", mock.Anything, "ghost", false, "admin-1").Return(utils.ErrUserNotFound)
+
+	err := service.FreezeAccount("ghost", "suspicious activity", "admin-1")
+
+	assert.Equal(t, utils.ErrUserNotFound, err)
+	mockRepo.AssertNotCalled(t, "CreateEvent", mock.Anything, mock.Anything)
+	mockOrders.AssertNotCalled(t, "CancelOpenOrders", mock.Anything, mock.Anything)
+}
+
+// order ในตลาดของสกุลที่ถูก freeze ต้องถูกยกเลิก ไม่งั้นยัง match ต่อได้
+func TestFreezeWallet_CancelsOpenOrders(t *testing.T) {
+	mockRepo := new(MockFreezeRepository)
+	mockOrders := new(MockOrderService)
+	service := newTestFreezeService(mockRepo, auth.NewMemoryRevocationStore(), mockOrders)
+
+	mockRepo.On("SetWalletActive", mock.Anything, "user-1", "BTC", false).Return(nil)
+	mockRepo.On("CreateEvent", mock.Anything, isEvent(model.TargetWallet, "BTC", model.ActionFreeze)).Return(nil)
+	mockOrders.On("CancelOpenOrders", "user-1", "BTC").Return(1, nil)
+
+	err := service.FreezeWallet("user-1", "btc", "suspicious activity", "admin-1")
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	mockOrders.AssertExpectations(t)
+}
+
+func TestUnfreezeWallet_NormalizesCurrency(t *testing.T) {
+	mockRepo := new(MockFreezeRepository)
+	service := newTestFreezeService(mockRepo, auth.NewMemoryRevocationStore(), new(MockOrderService))
+
+	mockRepo.On("SetWalletActive", mock.Anything, "user-1", "BTC", true).Return(nil)
+	mockRepo.On("CreateEvent", mock.Anything, isEvent(model.TargetWallet, "BTC", model.ActionUnfreeze)).Return(nil)
+
+	err := service.UnfreezeWallet("user-1", " btc", "suspicious activity", "admin-1")
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestFreezeWallet_Fail_ReasonRequired(t *testing.T) {
+	mockRepo := new(MockFreezeRepository)
+	service := newTestFreezeService(mockRepo, auth.NewMemoryRevocationStore(), new(MockOrderService))
+
+	err := service.FreezeWallet("user-1", "BTC", "   ", "admin-1")
+
+	assert.Equal(t, utils.ErrInvalidRequest, err)
+	mockRepo.AssertNotCalled(t, "SetWalletActive", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// ปลด lock ต้องมี event ใน audit trail เหมือน freeze
+func TestUnlockLogin_RecordsEvent(t *testing.T) {
+	mockRepo := new(MockFreezeRepository)
+	mockGuard := new(MockLoginGuard)
+	service := newTestFreezeService(mockRepo, auth.NewMemoryRevocationStore(), new(MockOrderService))
+	service.loginGuard = mockGuard
+
+	mockGuard.On("UnlockAccount", "user-1").Return(nil)
+	mockRepo.On("CreateEvent", mock.Anything, isEvent(model.TargetAccount, "", model.ActionUnlockLogin)).Return(nil)
+
+	err := service.UnlockLogin("user-1", "suspicious activity", "admin-1")
+
+	assert.NoError(t, err)
+	mockGuard.AssertExpectations(t)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUnlockLogin_Fail_UserNotFound(t *testing.T) {
+	mockRepo := new(MockFreezeRepository)
+	mockGuard := new(MockLoginGuard)
+	service := newTestFreezeService(mockRepo, auth.NewMemoryRevocationStore(), new(MockOrderService))
+	service.loginGuard = mockGuard
+
+	mockGuard.On("UnlockAccount", "ghost").Return(utils.ErrUserNotFound)
+
+	err := service.UnlockLogin("ghost", "suspicious activity", "admin-1")
+
+	assert.Equal(t, utils.ErrUserNotFound, err)
+	mockRepo.AssertNotCalled(t, "CreateEvent", mock.Anything, mock.Anything)
+}
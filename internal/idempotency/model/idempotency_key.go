@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+const (
+	StatusInProgress = "IN_PROGRESS"
+	StatusCompleted  = "COMPLETED"
+)
+
+// คำตอบของ request แรกที่ใช้ Idempotency-Key นี้ เก็บไว้ตอบซ้ำตอน client retry
+type IdempotencyKey struct {
+	ID           uint64    `gorm:"primaryKey" json:"id"`
+	AccountID    string    `gorm:"size:100;not null;uniqueIndex:idx_idempotency_account_key" json:"account_id"`
+	Key          string    `gorm:"size:255;not null;uniqueIndex:idx_idempotency_account_key" json:"key"`
+	Method       string    `gorm:"size:10;not null" json:"method"`
+	Path         string    `gorm:"size:255;not null" json:"path"`
+	RequestHash  string    `gorm:"size:64;not null" json:"request_hash"`
+	Status       string    `gorm:"type:varchar(20);not null" json:"status"`
+	StatusCode   int       `json:"status_code"`
+	ContentType  string    `gorm:"size:100" json:"content_type"`
+	ResponseBody []byte    `json:"-"`
+	CreatedAt    time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/padapook/bestbit-core/internal/idempotency/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IdempotencyRepository interface {
+	// จอง key, ได้ false ถ้ามีคนจองไว้แล้ว
+	Reserve(record *model.IdempotencyKey) (bool, error)
+	Get(accountID, key string) (*model.IdempotencyKey, error)
+	Complete(record *model.IdempotencyKey) error
+	Release(record *model.IdempotencyKey) error
+	// ลบ key ที่ค้าง IN_PROGRESS นานเกิน (process ตายกลางทาง)
+	ReleaseStale(record *model.IdempotencyKey, staleBefore time.Time) (bool, error)
+}
+
+type idempotencyRepository struct {
+	db *gorm.DB
+}
+
+func NewIdempotencyRepository(db *gorm.DB) IdempotencyRepository {
+	return &idempotencyRepository{db: db}
+}
+
+func (r *idempotencyRepository) Reserve(record *model.IdempotencyKey) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *idempotencyRepository) Get(accountID, key string) (*model.IdempotencyKey, error) {
+	var record model.IdempotencyKey
+	err := r.db.Where("account_id = ? AND key = ?", accountID, key).First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (r *idempotencyRepository) Complete(record *model.IdempotencyKey) error {
+	record.Status = model.StatusCompleted
+	return r.db.Save(record).Error
+}
+
+func (r *idempotencyRepository) Release(record *model.IdempotencyKey) error {
+	return r.db.Delete(&model.IdempotencyKey{}, record.ID).Error
+}
+
+func (r *idempotencyRepository) ReleaseStale(record *model.IdempotencyKey, staleBefore time.Time) (bool, error) {
+	result := r.db.Where("id = ? AND status = ? AND updated_at < ?", record.ID, model.StatusInProgress, staleBefore).
+		Delete(&model.IdempotencyKey{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
@@ -0,0 +1,33 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/padapook/bestbit-core/internal/ledger/service"
+	"github.com/padapook/bestbit-core/internal/utils"
+)
+
+type LedgerController interface {
+	Verify(c *gin.Context)
+}
+
+type ledgerController struct {
+	ledgerService service.LedgerService
+}
+
+func NewLedgerController(ledgerService service.LedgerService) LedgerController {
+	return &ledgerController{ledgerService: ledgerService}
+}
+
+func (ctrl *ledgerController) Verify(c *gin.Context) {
+	report, err := ctrl.ledgerService.Verify()
+	if err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": report,
+	})
+}
@@ -0,0 +1,74 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// เงินของ user ที่ exchange ถือแทน (หนี้สินของระบบ) หนึ่งบัญชีต่อ wallet
+	AccountTypeUserWallet = "USER_WALLET"
+	// เงินจริงที่ระบบถืออยู่ เพิ่มตอนฝาก ลดตอนจ่ายถอนออกไปแล้ว
+	AccountTypeSystemOmnibus = "SYSTEM_OMNIBUS"
+	AccountTypeFeeRevenue    = "FEE_REVENUE"
+	// เงินที่หักจาก wallet แล้วแต่ยังไม่ได้จ่ายออก ล้างเข้า omnibus ตอน withdrawal จ่ายสำเร็จ
+	AccountTypePendingWithdrawal = "PENDING_WITHDRAWAL"
+	// พักเงินระหว่างขา trade, settle ครบแล้วต้องเป็นศูนย์
+	AccountTypeTradeClearing = "TRADE_CLEARING"
+	// คู่บัญชีของการปรับยอดมือโดย admin ยอดบวก = ระบบให้เงิน user ไปสุทธิเท่าไหร่
+	AccountTypeManualAdjustment = "MANUAL_ADJUSTMENT"
+
+	SystemOwnerID = "SYSTEM"
+)
+
+type LedgerAccount struct {
+	ID        uint64    `gorm:"primaryKey" json:"id"`
+	Type      string    `gorm:"type:varchar(30);not null;uniqueIndex:idx_ledger_account" json:"type"`
+	OwnerID   string    `gorm:"size:100;not null;uniqueIndex:idx_ledger_account" json:"owner_id"`
+	Currency  string    `gorm:"type:varchar(20);not null;uniqueIndex:idx_ledger_account" json:"currency"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// ระบุบัญชีโดยไม่ต้องรู้ id, repository จะสร้างให้ถ้ายังไม่มี
+type AccountRef struct {
+	Type     string
+	OwnerID  string
+	Currency string
+}
+
+func UserWallet(userID, currency string) AccountRef {
+	return AccountRef{Type: AccountTypeUserWallet, OwnerID: userID, Currency: currency}
+}
+
+func SystemOmnibus(currency string) AccountRef {
+	return AccountRef{Type: AccountTypeSystemOmnibus, OwnerID: SystemOwnerID, Currency: currency}
+}
+
+func FeeRevenue(currency string) AccountRef {
+	return AccountRef{Type: AccountTypeFeeRevenue, OwnerID: SystemOwnerID, Currency: currency}
+}
+
+func PendingWithdrawals(currency string) AccountRef {
+	return AccountRef{Type: AccountTypePendingWithdrawal, OwnerID: SystemOwnerID, Currency: currency}
+}
+
+func TradeClearing(currency string) AccountRef {
+	return AccountRef{Type: AccountTypeTradeClearing, OwnerID: SystemOwnerID, Currency: currency}
+}
+
+func ManualAdjustment(currency string) AccountRef {
+	return AccountRef{Type: AccountTypeManualAdjustment, OwnerID: SystemOwnerID, Currency: currency}
+}
+
+// omnibus เป็นสินทรัพย์ และ adjustment เป็นค่าใช้จ่าย (debit เพิ่ม) ที่เหลือเป็นหนี้สิน/รายได้ (credit เพิ่ม)
+func IsDebitNormal(accountType string) bool {
+	return accountType == AccountTypeSystemOmnibus || accountType == AccountTypeManualAdjustment
+}
+
+func Balance(accountType string, debits, credits decimal.Decimal) decimal.Decimal {
+	if IsDebitNormal(accountType) {
+		return debits.Sub(credits)
+	}
+	return credits.Sub(debits)
+}
@@ -0,0 +1,98 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	DirectionDebit  = "DEBIT"
+	DirectionCredit = "CREDIT"
+
+	// ยอดยกมาของ wallet ที่มีเงินอยู่ก่อนเปิดใช้ ledger
+	EntryTypeOpeningBalance = "OPENING_BALANCE"
+	// ล้าง pending withdrawal ออกจาก omnibus หลัง provider จ่ายเงินออกไปแล้ว
+	EntryTypeWithdrawalPayout = "WITHDRAWAL_PAYOUT"
+)
+
+var ErrUnbalancedEntry = errors.New("journal entry debits and credits do not balance")
+
+// หนึ่ง entry ต่อหนึ่งการเคลื่อนไหวของเงิน, EntryType ใช้ค่าเดียวกับ WalletTransaction.TransactionType
+type JournalEntry struct {
+	ID          uint64    `gorm:"primaryKey" json:"id"`
+	ReferenceID string    `gorm:"index;not null" json:"reference_id"`
+	EntryType   string    `gorm:"type:varchar(30);not null" json:"entry_type"`
+	Description string    `gorm:"type:text" json:"description"`
+	Postings    []Posting `gorm:"foreignKey:EntryID" json:"postings"`
+	CreatedAt   time.Time `gorm:"autoCreateTime;index" json:"created_at"`
+	CreatedBy   string    `gorm:"size:100" json:"created_by"`
+}
+
+type Posting struct {
+	ID        uint64          `gorm:"primaryKey" json:"id"`
+	EntryID   uint64          `gorm:"index;not null" json:"entry_id"`
+	AccountID uint64          `gorm:"index;not null" json:"account_id"`
+	Account   AccountRef      `gorm:"-" json:"-"`
+	Currency  string          `gorm:"type:varchar(20);not null" json:"currency"`
+	Direction string          `gorm:"type:varchar(10);not null" json:"direction"`
+	Amount    decimal.Decimal `gorm:"type:decimal(32,16);not null" json:"amount"`
+	CreatedAt time.Time       `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// entry สองขา debit บัญชีหนึ่ง credit อีกบัญชี สกุลเงินเดียวกัน
+func NewEntry(entryType, referenceID, createdBy, description string, debit, credit AccountRef, amount decimal.Decimal) *JournalEntry {
+	return &JournalEntry{
+		ReferenceID: referenceID,
+		EntryType:   entryType,
+		Description: description,
+		CreatedBy:   createdBy,
+		Postings: []Posting{
+			{Account: debit, Currency: debit.Currency, Direction: DirectionDebit, Amount: amount},
+			{Account: credit, Currency: credit.Currency, Direction: DirectionCredit, Amount: amount},
+		},
+	}
+}
+
+// ขาที่ยอดเป็นศูนย์ไม่ขยับยอดบัญชี ตัดทิ้งก่อน validate เช่น trade ที่ quote ปัดแล้วเหลือ 0
+// คืน false ถ้าไม่เหลือขาไหนให้ลง
+func (e *JournalEntry) DropZeroPostings() bool {
+	postings := e.Postings[:0]
+	for _, p := range e.Postings {
+		if !p.Amount.IsZero() {
+			postings = append(postings, p)
+		}
+	}
+	e.Postings = postings
+	return len(postings) > 0
+}
+
+// debit ต้องเท่ากับ credit แยกตามสกุลเงิน
+func (e *JournalEntry) Validate() error {
+	if len(e.Postings) < 2 {
+		return ErrUnbalancedEntry
+	}
+
+	totals := make(map[string]decimal.Decimal)
+	for _, p := range e.Postings {
+		if !p.Amount.IsPositive() || p.Currency == "" || p.Currency != p.Account.Currency {
+			return ErrUnbalancedEntry
+		}
+		switch p.Direction {
+		case DirectionDebit:
+			totals[p.Currency] = totals[p.Currency].Add(p.Amount)
+		case DirectionCredit:
+			totals[p.Currency] = totals[p.Currency].Sub(p.Amount)
+		default:
+			return ErrUnbalancedEntry
+		}
+	}
+
+	for _, total := range totals {
+		if !total.IsZero() {
+			return ErrUnbalancedEntry
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJournalEntry_Validate(t *testing.T) {
+	amount := decimal.NewFromInt(100)
+
+	balanced := NewEntry("DEPOSIT", "ref-1", "user-1", "", SystemOmnibus("THB"), UserWallet("user-1", "THB"), amount)
+	assert.NoError(t, balanced.Validate())
+
+	zero := NewEntry("DEPOSIT", "ref-1", "user-1", "", SystemOmnibus("THB"), UserWallet("user-1", "THB"), decimal.Zero)
+	assert.ErrorIs(t, zero.Validate(), ErrUnbalancedEntry)
+
+	// ข้ามสกุลเงินใน entry เดียวไม่ได้
+	crossCurrency := NewEntry("DEPOSIT", "ref-1", "user-1", "", SystemOmnibus("THB"), UserWallet("user-1", "BTC"), amount)
+	assert.ErrorIs(t, crossCurrency.Validate(), ErrUnbalancedEntry)
+
+	oneSided := &JournalEntry{Postings: []Posting{
+		{Account: SystemOmnibus("THB"), Currency: "THB", Direction: DirectionDebit, Amount: amount},
+		{Account: UserWallet("user-1", "THB"), Currency: "THB", Direction: DirectionCredit, Amount: amount.Sub(decimal.NewFromInt(1))},
+	}}
+	assert.ErrorIs(t, oneSided.Validate(), ErrUnbalancedEntry)
+}
+
+func TestJournalEntry_DropZeroPostings(t *testing.T) {
+	zero := NewEntry("TRADE_CREDIT", "ref-1", "user-1", "", TradeClearing("THB"), UserWallet("user-1", "THB"), decimal.Zero)
+	assert.False(t, zero.DropZeroPostings())
+	assert.Empty(t, zero.Postings)
+
+	entry := &JournalEntry{Postings: []Posting{
+		{Account: SystemOmnibus("THB"), Currency: "THB", Direction: DirectionDebit, Amount: decimal.NewFromInt(5)},
+		{Account: FeeRevenue("THB"), Currency: "THB", Direction: DirectionCredit, Amount: decimal.Zero},
+		{Account: UserWallet("user-1", "THB"), Currency: "THB", Direction: DirectionCredit, Amount: decimal.NewFromInt(5)},
+	}}
+	assert.True(t, entry.DropZeroPostings())
+	assert.Len(t, entry.Postings, 2)
+	assert.NoError(t, entry.Validate())
+}
+
+func TestBalance_NormalSide(t *testing.T) {
+	debits := decimal.NewFromInt(30)
+	credits := decimal.NewFromInt(100)
+
+	assert.True(t, Balance(AccountTypeUserWallet, debits, credits).Equal(decimal.NewFromInt(70)))
+	assert.True(t, Balance(AccountTypeSystemOmnibus, credits, debits).Equal(decimal.NewFromInt(70)))
+}
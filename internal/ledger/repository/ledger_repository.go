@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/padapook/bestbit-core/internal/ledger/model"
+	walletModel "github.com/padapook/bestbit-core/internal/wallet/model"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ยอดรวมจาก postings ของแต่ละบัญชี
+type AccountBalance struct {
+	AccountID uint64
+	Type      string
+	OwnerID   string
+	Currency  string
+	Debits    decimal.Decimal
+	Credits   decimal.Decimal
+}
+
+func (b AccountBalance) Balance() decimal.Decimal {
+	return model.Balance(b.Type, b.Debits, b.Credits)
+}
+
+type LedgerRepository interface {
+	// ต้องเรียกใน tx เดียวกับที่แก้ Wallet.Balance
+	PostEntry(tx *gorm.DB, entry *model.JournalEntry) error
+	GetAccountBalances() ([]AccountBalance, error)
+	PostOpeningBalances() (int, error)
+	ClearPendingWithdrawals() (int, error)
+}
+
+type ledgerRepository struct {
+	db *gorm.DB
+}
+
+func NewLedgerRepository(db *gorm.DB) LedgerRepository {
+	return &ledgerRepository{db: db}
+}
+
+// entry ที่ทุกขาเป็นศูนย์ไม่ลงอะไรเลย ไม่ถือเป็น error
+func (r *ledgerRepository) PostEntry(tx *gorm.DB, entry *model.JournalEntry) error {
+	if !entry.DropZeroPostings() {
+		return nil
+	}
+	if err := entry.Validate(); err != nil {
+		return err
+	}
+
+	for i := range entry.Postings {
+		account, err := r.getOrCreateAccount(tx, entry.Postings[i].Account)
+		if err != nil {
+			return err
+		}
+		entry.Postings[i].AccountID = account.ID
+	}
+
+	return tx.Create(entry).Error
+}
+
+func (r *ledgerRepository) getOrCreateAccount(tx *gorm.DB, ref model.AccountRef) (*model.LedgerAccount, error) {
+	account := model.LedgerAccount{Type: ref.Type, OwnerID: ref.OwnerID, Currency: ref.Currency}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&account).Error; err != nil {
+		return nil, err
+	}
+	if account.ID != 0 {
+		return &account, nil
+	}
+
+	if err := tx.Where("type = ? AND owner_id = ? AND currency = ?", ref.Type, ref.OwnerID, ref.Currency).
+		First(&account).Error; err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+func (r *ledgerRepository) GetAccountBalances() ([]AccountBalance, error) {
+	var balances []AccountBalance
+	err := r.db.Raw(`
+		SELECT a.id AS account_id, a.type, a.owner_id, a.currency,
+			COALESCE(SUM(CASE WHEN p.direction = ? THEN p.amount END), 0) AS debits,
+			COALESCE(SUM(CASE WHEN p.direction = ? THEN p.amount END), 0) AS credits
+		FROM ledger_accounts a
+		LEFT JOIN postings p ON p.account_id = a.id
+		GROUP BY a.id, a.type, a.owner_id, a.currency
+		ORDER BY a.id`, model.DirectionDebit, model.DirectionCredit).
+		Scan(&balances).Error
+	return balances, err
+}
+
+// wallet ที่มีเงินอยู่ก่อนเปิด ledger ให้ลงยอดยกมา (Dr omnibus / Cr user) ครั้งเดียว
+// lock wallet ก่อนตรวจ กันชนกับรายการที่เข้ามาพร้อมกัน
+func (r *ledgerRepository) PostOpeningBalances() (int, error) {
+	var walletIDs []uint64
+	err := r.db.Raw(`
+		SELECT w.id FROM wallets w
+		WHERE w.balance > 0 AND NOT EXISTS (
+			SELECT 1 FROM ledger_accounts a
+			JOIN postings p ON p.account_id = a.id
+			WHERE a.type = ? AND a.owner_id = w.user_id AND a.currency = w.currency
+		)`, model.AccountTypeUserWallet).
+		Scan(&walletIDs).Error
+	if err != nil {
+		return 0, err
+	}
+
+	posted := 0
+	for _, walletID := range walletIDs {
+		err := r.db.Transaction(func(tx *gorm.DB) error {
+			var wallet walletModel.Wallet
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&wallet, walletID).Error; err != nil {
+				return err
+			}
+
+			var count int64
+			if err := tx.Model(&model.Posting{}).
+				Joins("JOIN ledger_accounts a ON a.id = postings.account_id").
+				Where("a.type = ? AND a.owner_id = ? AND a.currency = ?", model.AccountTypeUserWallet, wallet.UserID, wallet.Currency).
+				Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 || !wallet.Balance.IsPositive() {
+				return errNothingToPost
+			}
+
+			entry := model.NewEntry(model.EntryTypeOpeningBalance, "OPENING-"+wallet.UserID+"-"+wallet.Currency, "SYSTEM",
+				"Opening balance", model.SystemOmnibus(wallet.Currency), model.UserWallet(wallet.UserID, wallet.Currency), wallet.Balance)
+			return r.PostEntry(tx, entry)
+		})
+		if errors.Is(err, errNothingToPost) {
+			continue
+		}
+		if err != nil {
+			return posted, err
+		}
+		posted++
+	}
+
+	return posted, nil
+}
+
+var errNothingToPost = errors.New("nothing to post")
+
+// ก่อนมีขา payout withdrawal ที่จ่ายไปแล้วค้างอยู่ใน pending withdrawal ล้างเข้า omnibus ทีละสกุล
+// ทุกยอดในบัญชีนี้จ่ายสำเร็จแล้วเพราะลงตอน CompleteWithdrawal เท่านั้น รันซ้ำได้เพราะยอดเป็นศูนย์แล้วจะไม่ลงซ้ำ
+func (r *ledgerRepository) ClearPendingWithdrawals() (int, error) {
+	var accounts []model.LedgerAccount
+	if err := r.db.Where("type = ?", model.AccountTypePendingWithdrawal).Find(&accounts).Error; err != nil {
+		return 0, err
+	}
+
+	cleared := 0
+	for _, account := range accounts {
+		err := r.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&account, account.ID).Error; err != nil {
+				return err
+			}
+
+			var balance AccountBalance
+			if err := tx.Raw(`
+				SELECT COALESCE(SUM(CASE WHEN direction = ? THEN amount END), 0) AS debits,
+					COALESCE(SUM(CASE WHEN direction = ? THEN amount END), 0) AS credits
+				FROM postings WHERE account_id = ?`, model.DirectionDebit, model.DirectionCredit, account.ID).
+				Scan(&balance).Error; err != nil {
+				return err
+			}
+			pending := model.Balance(account.Type, balance.Debits, balance.Credits)
+			if !pending.IsPositive() {
+				return errNothingToPost
+			}
+
+			entry := model.NewEntry(model.EntryTypeWithdrawalPayout, "PAYOUT-BACKFILL-"+account.Currency, "SYSTEM",
+				"Clear withdrawals paid before payout entries", model.PendingWithdrawals(account.Currency), model.SystemOmnibus(account.Currency), pending)
+			return r.PostEntry(tx, entry)
+		})
+		if errors.Is(err, errNothingToPost) {
+			continue
+		}
+		if err != nil {
+			return cleared, err
+		}
+		cleared++
+	}
+
+	return cleared, nil
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/padapook/bestbit-core/internal/ledger/model"
+	"github.com/padapook/bestbit-core/internal/ledger/repository"
+	walletModel "github.com/padapook/bestbit-core/internal/wallet/model"
+	walletRepository "github.com/padapook/bestbit-core/internal/wallet/repository"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type MockLedgerRepository struct {
+	mock.Mock
+}
+
+func (m *MockLedgerRepository) PostEntry(tx *gorm.DB, entry *model.JournalEntry) error {
+	args := m.Called(tx, entry)
+	return args.Error(0)
+}
+
+func (m *MockLedgerRepository) GetAccountBalances() ([]repository.AccountBalance, error) {
+	args := m.Called()
+	if args.Get(0) != nil {
+		return args.Get(0).([]repository.AccountBalance), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockLedgerRepository) ClearPendingWithdrawals() (int, error) {
+	args := m.Called()
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockLedgerRepository) PostOpeningBalances() (int, error) {
+	args := m.Called()
+	return args.Int(0), args.Error(1)
+}
+
+// Verify ใช้แค่ GetAllWallets ที่เหลือไม่ถูกเรียก
+type MockWalletRepository struct {
+	walletRepository.WalletRepository
+	mock.Mock
+}
+
+func (m *MockWalletRepository) GetAllWallets(afterID uint64, limit int) ([]walletModel.Wallet, error) {
+	args := m.Called(afterID, limit)
+	if args.Get(0) != nil {
+		return args.Get(0).([]walletModel.Wallet), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func d(v int64) decimal.Decimal {
+	return decimal.NewFromInt(v)
+}
+
+func TestVerify_Success_Balanced(t *testing.T) {
+	repo := new(MockLedgerRepository)
+	walletRepo := new(MockWalletRepository)
+	service := NewLedgerService(repo, walletRepo)
+
+	// ฝาก 100 แล้วโอนให้ user-2 30
+	repo.On("GetAccountBalances").Return([]repository.AccountBalance{
+		{Type: model.AccountTypeSystemOmnibus, OwnerID: model.SystemOwnerID, Currency: "THB", Debits: d(100), Credits: d(0)},
+		{Type: model.AccountTypeUserWallet, OwnerID: "user-1", Currency: "THB", Debits: d(30), Credits: d(100)},
+		{Type: model.AccountTypeUserWallet, OwnerID: "user-2", Currency: "THB", Debits: d(0), Credits: d(30)},
+	}, nil)
+	walletRepo.On("GetAllWallets", uint64(0), verifyWalletPageSize).Return([]walletModel.Wallet{
+		{ID: 1, UserID: "user-1", Currency: "THB", Balance: d(70)},
+		{ID: 2, UserID: "user-2", Currency: "THB", Balance: d(30)},
+	}, nil)
+
+	report, err := service.Verify()
+
+	require.NoError(t, err)
+	assert.True(t, report.Balanced)
+	assert.Empty(t, report.Discrepancies)
+	require.Len(t, report.Currencies, 1)
+	assert.True(t, report.Currencies[0].Debits.Equal(d(130)))
+}
+
+func TestVerify_Fail_WalletDrift(t *testing.T) {
+	repo := new(MockLedgerRepository)
+	walletRepo := new(MockWalletRepository)
+	service := NewLedgerService(repo, walletRepo)
+
+	repo.On("GetAccountBalances").Return([]repository.AccountBalance{
+		{Type: model.AccountTypeSystemOmnibus, OwnerID: model.SystemOwnerID, Currency: "THB", Debits: d(100), Credits: d(0)},
+		{Type: model.AccountTypeUserWallet, OwnerID: "user-1", Currency: "THB", Debits: d(0), Credits: d(100)},
+	}, nil)
+	// balance ถูกแก้ตรงๆ โดยไม่ผ่าน ledger
+	walletRepo.On("GetAllWallets", uint64(0), verifyWalletPageSize).Return([]walletModel.Wallet{
+		{ID: 1, UserID: "user-1", Currency: "THB", Balance: d(150)},
+		{ID: 2, UserID: "user-2", Currency: "THB", Balance: d(5)},
+	}, nil)
+
+	report, err := service.Verify()
+
+	require.NoError(t, err)
+	assert.False(t, report.Balanced)
+	require.Len(t, report.Discrepancies, 2)
+	assert.Equal(t, "user-1", report.Discrepancies[0].UserID)
+	assert.True(t, report.Discrepancies[0].LedgerBalance.Equal(d(100)))
+	assert.Equal(t, "user-2", report.Discrepancies[1].UserID)
+	assert.True(t, report.Discrepancies[1].LedgerBalance.IsZero())
+}
+
+func TestVerify_Fail_OpenClearing(t *testing.T) {
+	repo := new(MockLedgerRepository)
+	walletRepo := new(MockWalletRepository)
+	service := NewLedgerService(repo, walletRepo)
+
+	// จ่ายขาเดียวแล้วไม่มีขารับ
+	repo.On("GetAccountBalances").Return([]repository.AccountBalance{
+		{Type: model.AccountTypeUserWallet, OwnerID: "user-1", Currency: "THB", Debits: d(10), Credits: d(10)},
+		{Type: model.AccountTypeTradeClearing, OwnerID: model.SystemOwnerID, Currency: "THB", Debits: d(0), Credits: d(10)},
+		{Type: model.AccountTypeSystemOmnibus, OwnerID: model.SystemOwnerID, Currency: "THB", Debits: d(10), Credits: d(0)},
+	}, nil)
+	walletRepo.On("GetAllWallets", uint64(0), verifyWalletPageSize).Return([]walletModel.Wallet{
+		{ID: 1, UserID: "user-1", Currency: "THB", Balance: d(0)},
+	}, nil)
+
+	report, err := service.Verify()
+
+	require.NoError(t, err)
+	assert.False(t, report.Balanced)
+	assert.False(t, report.Currencies[0].Balanced)
+	assert.True(t, report.Currencies[0].ClearingBalance.Equal(d(10)))
+}
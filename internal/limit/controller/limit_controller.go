@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/padapook/bestbit-core/internal/limit/model"
+	"github.com/padapook/bestbit-core/internal/limit/service"
+	"github.com/padapook/bestbit-core/internal/utils"
+	"github.com/shopspring/decimal"
+)
+
+type LimitController interface {
+	GetLimits(c *gin.Context)
+	SetLimit(c *gin.Context)
+	SetUserTier(c *gin.Context)
+}
+
+type limitController struct {
+	limitService service.LimitService
+}
+
+func NewLimitController(limitService service.LimitService) LimitController {
+	return &limitController{limitService: limitService}
+}
+
+type SetLimitRequest struct {
+	Tier         *int            `json:"tier" binding:"required"`
+	Currency     string          `json:"currency" binding:"required"`
+	Operation    string          `json:"operation" binding:"required"`
+	MaxSingle    decimal.Decimal `json:"max_single"`
+	DailyLimit   decimal.Decimal `json:"daily_limit"`
+	MonthlyLimit decimal.Decimal `json:"monthly_limit"`
+}
+
+type SetUserTierRequest struct {
+	Tier *int `json:"tier" binding:"required"`
+}
+
+func (ctrl *limitController) GetLimits(c *gin.Context) {
+	var tier *int
+	if value := c.Query("tier"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			utils.HandleError(c, utils.ErrInvalidRequest)
+			return
+		}
+		tier = &parsed
+	}
+
+	limits, err := ctrl.limitService.GetLimits(tier, c.Query("currency"))
+	if err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": limits,
+	})
+}
+
+func (ctrl *limitController) SetLimit(c *gin.Context) {
+	var req SetLimitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleError(c, utils.ErrInvalidRequest)
+		return
+	}
+
+	limit, err := ctrl.limitService.SetLimit(model.TransactionLimit{
+		Tier:         *req.Tier,
+		Currency:     req.Currency,
+		Operation:    req.Operation,
+		MaxSingle:    req.MaxSingle,
+		DailyLimit:   req.DailyLimit,
+		MonthlyLimit: req.MonthlyLimit,
+	}, c.GetString("account_id"))
+	if err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Limit saved",
+		"data":    limit,
+	})
+}
+
+func (ctrl *limitController) SetUserTier(c *gin.Context) {
+	var req SetUserTierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleError(c, utils.ErrInvalidRequest)
+		return
+	}
+
+	if err := ctrl.limitService.SetUserTier(c.Param("account_id"), *req.Tier, c.GetString("account_id")); err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "KYC tier updated",
+	})
+}
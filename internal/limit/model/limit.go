@@ -0,0 +1,102 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	OperationDeposit  = "DEPOSIT"
+	OperationWithdraw = "WITHDRAW"
+	OperationTransfer = "TRANSFER"
+
+	MaxKYCTier = 10
+)
+
+var operations = []string{OperationDeposit, OperationWithdraw, OperationTransfer}
+
+// วงเงินต่อ (tier, currency, operation), ค่า 0 = ไม่จำกัดในมิตินั้น
+// ไม่มีแถวของ tier/currency/operation ไหน = ทำรายการนั้นไม่ได้
+type TransactionLimit struct {
+	ID           uint64          `gorm:"primaryKey" json:"id"`
+	Tier         int             `gorm:"not null;uniqueIndex:idx_limit_key" json:"tier"`
+	Currency     string          `gorm:"size:10;not null;uniqueIndex:idx_limit_key" json:"currency"`
+	Operation    string          `gorm:"size:20;not null;uniqueIndex:idx_limit_key" json:"operation" comment:"DEPOSIT, WITHDRAW, TRANSFER"`
+	MaxSingle    decimal.Decimal `gorm:"type:decimal(32,16);default:0" json:"max_single"`
+	DailyLimit   decimal.Decimal `gorm:"type:decimal(32,16);default:0" json:"daily_limit" comment:"ยอดรวมย้อนหลัง 24 ชม."`
+	MonthlyLimit decimal.Decimal `gorm:"type:decimal(32,16);default:0" json:"monthly_limit" comment:"ยอดรวมย้อนหลัง 30 วัน"`
+	CreatedAt    time.Time       `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time       `gorm:"autoUpdateTime" json:"updated_at"`
+	UpdatedBy    string          `gorm:"size:100" json:"updated_by"`
+}
+
+func IsValidOperation(operation string) bool {
+	return operation == OperationDeposit || operation == OperationWithdraw || operation == OperationTransfer
+}
+
+// วงเงินตั้งต้นของ tier 0 ต่อสกุล ใช้กับทุก operation
+type LimitValues struct {
+	MaxSingle    decimal.Decimal
+	DailyLimit   decimal.Decimal
+	MonthlyLimit decimal.Decimal
+}
+
+var ErrInvalidDefaultLimits = errors.New("invalid default limits, expected CODE=single/daily/monthly with positive values")
+
+// รูปแบบ "THB=50000/200000/1000000,BTC=0.1/0.5/2" ทุกค่าต้องมากกว่า 0
+// ค่า 0 = ไม่จำกัด ซึ่งไม่ใช่ค่าตั้งต้นที่ควรให้ user ที่ยังไม่ยืนยันตัวตน
+func ParseDefaultLimits(value string) (map[string]LimitValues, error) {
+	defaults := map[string]LimitValues{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		code, amounts, ok := strings.Cut(entry, "=")
+		parts := strings.Split(amounts, "/")
+		if !ok || strings.TrimSpace(code) == "" || len(parts) != 3 {
+			return nil, ErrInvalidDefaultLimits
+		}
+
+		values := make([]decimal.Decimal, len(parts))
+		for i, part := range parts {
+			amount, err := decimal.NewFromString(strings.TrimSpace(part))
+			if err != nil || !amount.IsPositive() {
+				return nil, ErrInvalidDefaultLimits
+			}
+			values[i] = amount
+		}
+		if values[0].GreaterThan(values[1]) || values[1].GreaterThan(values[2]) {
+			return nil, ErrInvalidDefaultLimits
+		}
+		defaults[strings.ToUpper(strings.TrimSpace(code))] = LimitValues{MaxSingle: values[0], DailyLimit: values[1], MonthlyLimit: values[2]}
+	}
+	return defaults, nil
+}
+
+// แถวตั้งต้นของ tier 0 เฉพาะสกุลที่มีใน defaults, tier อื่นและสกุลที่ไม่ได้ตั้งไม่ถูกสร้าง
+// ไม่มีแถว = ทำรายการไม่ได้จนกว่า admin จะตั้งผ่าน /admin/limits, seed ซ้ำไม่ทับค่าที่ตั้งไว้แล้ว
+func DefaultLimits(currencies []string, defaults map[string]LimitValues) []TransactionLimit {
+	limits := make([]TransactionLimit, 0, len(currencies)*len(operations))
+	for _, currency := range currencies {
+		values, ok := defaults[currency]
+		if !ok {
+			continue
+		}
+		for _, operation := range operations {
+			limits = append(limits, TransactionLimit{
+				Tier:         0,
+				Currency:     currency,
+				Operation:    operation,
+				MaxSingle:    values.MaxSingle,
+				DailyLimit:   values.DailyLimit,
+				MonthlyLimit: values.MonthlyLimit,
+				UpdatedBy:    "SYSTEM",
+			})
+		}
+	}
+	return limits
+}
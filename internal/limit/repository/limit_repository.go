@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	accountModel "github.com/padapook/bestbit-core/internal/account/model"
+	"github.com/padapook/bestbit-core/internal/limit/model"
+	"github.com/padapook/bestbit-core/internal/utils"
+	walletModel "github.com/padapook/bestbit-core/internal/wallet/model"
+	withdrawalModel "github.com/padapook/bestbit-core/internal/withdrawal/model"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LimitRepository interface {
+	// ใช้ภายใน transaction ของผู้เรียกหลัง lock wallet แล้ว ยอดรวมจะไม่ขยับระหว่างเช็ค
+	GetUserTier(tx *gorm.DB, userID string) (int, error)
+	GetLimit(tx *gorm.DB, tier int, currency, operation string) (*model.TransactionLimit, error)
+	GetUsage(tx *gorm.DB, userID, currency, operation string, since time.Time) (decimal.Decimal, error)
+
+	GetLimits(tier *int, currency string) ([]model.TransactionLimit, error)
+	UpsertLimit(limit *model.TransactionLimit) error
+	SetUserTier(userID string, tier int, updatedBy string) error
+	// เพิ่มเฉพาะแถวที่ยังไม่มี ไม่ทับวงเงินที่ admin ตั้งไว้
+	SeedLimits(limits []model.TransactionLimit) error
+}
+
+type limitRepository struct {
+	db *gorm.DB
+}
+
+func NewLimitRepository(db *gorm.DB) LimitRepository {
+	return &limitRepository{db: db}
+}
+
+func (r *limitRepository) GetUserTier(tx *gorm.DB, userID string) (int, error) {
+	var user accountModel.User
+	if err := tx.Select("kyc_tier").Where("account_id = ?", userID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, utils.ErrUserNotFound
+		}
+		return 0, err
+	}
+	return user.KYCTier, nil
+}
+
+func (r *limitRepository) GetLimit(tx *gorm.DB, tier int, currency, operation string) (*model.TransactionLimit, error) {
+	var limit model.TransactionLimit
+	err := tx.Where("tier = ? AND currency = ? AND operation = ?", tier, currency, operation).First(&limit).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.ErrLimitNotConfigured
+		}
+		return nil, err
+	}
+	return &limit, nil
+}
+
+// ยอดที่ใช้ไปแล้วนับจาก WalletTransaction ของ wallet นั้น
+// ถอน = hold ของ withdrawal ที่ยังไม่ถูกคืน (รอจ่ายหรือจ่ายแล้ว) + WITHDRAW แบบเดิม
+func (r *limitRepository) GetUsage(tx *gorm.DB, userID, currency, operation string, since time.Time) (decimal.Decimal, error) {
+	var condition string
+	var args []interface{}
+
+	switch operation {
+	case model.OperationDeposit:
+		condition = "t.transaction_type = ?"
+		args = []interface{}{walletModel.TxTypeDeposit}
+	case model.OperationTransfer:
+		condition = "t.transaction_type = ?"
+		args = []interface{}{walletModel.TxTypeTransferOut}
+	case model.OperationWithdraw:
+		condition = `(t.transaction_type = ? OR (t.transaction_type = ? AND t.reference_id LIKE ? AND NOT EXISTS (
+			SELECT 1 FROM wallet_transactions r
+			WHERE r.wallet_id = t.wallet_id AND r.reference_id = t.reference_id AND r.transaction_type = ?)))`
+		args = []interface{}{walletModel.TxTypeWithdraw, walletModel.TxTypeHold, withdrawalModel.HoldReferencePrefix + "%", walletModel.TxTypeRelease}
+	default:
+		return decimal.Zero, utils.ErrInvalidLimit
+	}
+
+	var total decimal.Decimal
+	args = append([]interface{}{userID, currency, since}, args...)
+	err := tx.Raw(`
+		SELECT COALESCE(SUM(t.amount), 0) FROM wallet_transactions t
+		JOIN wallets w ON w.id = t.wallet_id
+		WHERE w.user_id = ? AND w.currency = ? AND t.created_at >= ? AND `+condition, args...).
+		Scan(&total).Error
+	return total, err
+}
+
+func (r *limitRepository) GetLimits(tier *int, currency string) ([]model.TransactionLimit, error) {
+	var limits []model.TransactionLimit
+
+	query := r.db.Model(&model.TransactionLimit{})
+	if tier != nil {
+		query = query.Where("tier = ?", *tier)
+	}
+	if currency != "" {
+		query = query.Where("currency = ?", currency)
+	}
+
+	err := query.Order("tier ASC, currency ASC, operation ASC").Find(&limits).Error
+	return limits, err
+}
+
+func (r *limitRepository) UpsertLimit(limit *model.TransactionLimit) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tier"}, {Name: "currency"}, {Name: "operation"}},
+		DoUpdates: clause.AssignmentColumns([]string{"max_single", "daily_limit", "monthly_limit", "updated_at", "updated_by"}),
+	}).Create(limit).Error
+}
+
+func (r *limitRepository) SetUserTier(userID string, tier int, updatedBy string) error {
+	result := r.db.Model(&accountModel.User{}).Where("account_id = ?", userID).
+		Updates(map[string]interface{}{"kyc_tier": tier, "updated_by": updatedBy})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return utils.ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *limitRepository) SeedLimits(limits []model.TransactionLimit) error {
+	if len(limits) == 0 {
+		return nil
+	}
+	rows := append([]model.TransactionLimit(nil), limits...)
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error
+}
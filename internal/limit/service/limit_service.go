@@ -0,0 +1,130 @@
+package service
+
+import (
+	"strings"
+	"time"
+
+	currencyService "github.com/padapook/bestbit-core/internal/currency/service"
+	"github.com/padapook/bestbit-core/internal/limit/model"
+	"github.com/padapook/bestbit-core/internal/limit/repository"
+	"github.com/padapook/bestbit-core/internal/utils"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+const (
+	dailyWindow   = 24 * time.Hour
+	monthlyWindow = 30 * 24 * time.Hour
+)
+
+type LimitService interface {
+	// เรียกใน transaction เดียวกับรายการ หลัง lock wallet ของ userID/currency แล้ว
+	// และก่อนเขียน WalletTransaction ของรายการนี้ ไม่งั้นยอดจะถูกนับซ้ำ
+	Check(tx *gorm.DB, userID, currency, operation string, amount decimal.Decimal) error
+
+	// admin
+	GetLimits(tier *int, currency string) ([]model.TransactionLimit, error)
+	SetLimit(limit model.TransactionLimit, updatedBy string) (*model.TransactionLimit, error)
+	SetUserTier(userID string, tier int, updatedBy string) error
+}
+
+type limitService struct {
+	repo       repository.LimitRepository
+	currencies currencyService.CurrencyService
+	now        func() time.Time
+}
+
+func NewLimitService(repo repository.LimitRepository, currencies currencyService.CurrencyService) LimitService {
+	return &limitService{repo: repo, currencies: currencies, now: time.Now}
+}
+
+func (s *limitService) Check(tx *gorm.DB, userID, currency, operation string, amount decimal.Decimal) error {
+	tier, err := s.repo.GetUserTier(tx, userID)
+	if err != nil {
+		return err
+	}
+
+	limit, err := s.repo.GetLimit(tx, tier, currency, operation)
+	if err != nil {
+		return err
+	}
+
+	if limit.MaxSingle.IsPositive() && amount.GreaterThan(limit.MaxSingle) {
+		return utils.ErrSingleLimitExceeded
+	}
+
+	now := s.now()
+
+	if limit.DailyLimit.IsPositive() {
+		used, err := s.repo.GetUsage(tx, userID, currency, operation, now.Add(-dailyWindow))
+		if err != nil {
+			return err
+		}
+		if used.Add(amount).GreaterThan(limit.DailyLimit) {
+			return utils.ErrDailyLimitExceeded
+		}
+	}
+
+	if limit.MonthlyLimit.IsPositive() {
+		used, err := s.repo.GetUsage(tx, userID, currency, operation, now.Add(-monthlyWindow))
+		if err != nil {
+			return err
+		}
+		if used.Add(amount).GreaterThan(limit.MonthlyLimit) {
+			return utils.ErrMonthlyLimitExceeded
+		}
+	}
+
+	return nil
+}
+
+func (s *limitService) GetLimits(tier *int, currency string) ([]model.TransactionLimit, error) {
+	return s.repo.GetLimits(tier, strings.ToUpper(currency))
+}
+
+func (s *limitService) SetLimit(limit model.TransactionLimit, updatedBy string) (*model.TransactionLimit, error) {
+	limit.ID = 0
+	limit.Currency = strings.ToUpper(strings.TrimSpace(limit.Currency))
+	limit.Operation = strings.ToUpper(limit.Operation)
+	limit.UpdatedBy = updatedBy
+
+	if limit.Tier < 0 || limit.Tier > model.MaxKYCTier || limit.Currency == "" || !model.IsValidOperation(limit.Operation) {
+		return nil, utils.ErrInvalidLimit
+	}
+	if limit.MaxSingle.IsNegative() || limit.DailyLimit.IsNegative() || limit.MonthlyLimit.IsNegative() {
+		return nil, utils.ErrInvalidLimit
+	}
+	// วงเงินรายวันเกินรายเดือนไม่มีความหมาย
+	if limit.DailyLimit.IsPositive() && limit.MonthlyLimit.IsPositive() && limit.DailyLimit.GreaterThan(limit.MonthlyLimit) {
+		return nil, utils.ErrInvalidLimit
+	}
+	if _, err := s.currencies.Validate(limit.Currency, ""); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpsertLimit(&limit); err != nil {
+		return nil, err
+	}
+	return &limit, nil
+}
+
+func (s *limitService) SetUserTier(userID string, tier int, updatedBy string) error {
+	if tier < 0 || tier > model.MaxKYCTier {
+		return utils.ErrInvalidLimit
+	}
+	return s.repo.SetUserTier(userID, tier, updatedBy)
+}
+
+type limitSeeder struct {
+	repo     repository.LimitRepository
+	defaults map[string]model.LimitValues
+}
+
+// สกุลที่ admin เพิ่มทีหลังได้วงเงินตั้งต้นแบบเดียวกับตอน migrate
+func NewLimitSeeder(repo repository.LimitRepository, defaults map[string]model.LimitValues) currencyService.CurrencyInitializer {
+	return &limitSeeder{repo: repo, defaults: defaults}
+}
+
+func (s *limitSeeder) InitCurrency(code string) error {
+	return s.repo.SeedLimits(model.DefaultLimits([]string{code}, s.defaults))
+}
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/padapook/bestbit-core/internal/limit/model"
+	"github.com/padapook/bestbit-core/internal/utils"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+type MockLimitRepository struct {
+	mock.Mock
+}
+
+func (m *MockLimitRepository) GetUserTier(tx *gorm.DB, userID string) (int, error) {
+	args := m.Called(tx, userID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockLimitRepository) GetLimit(tx *gorm.DB, tier int, currency, operation string) (*model.TransactionLimit, error) {
+	args := m.Called(tx, tier, currency, operation)
+	if args.Get(0) != nil {
+		return args.Get(0).(*model.TransactionLimit), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockLimitRepository) GetUsage(tx *gorm.DB, userID, currency, operation string, since time.Time) (decimal.Decimal, error) {
+	args := m.Called(tx, userID, currency, operation, since)
+	return args.Get(0).(decimal.Decimal), args.Error(1)
+}
+
+func (m *MockLimitRepository) GetLimits(tier *int, currency string) ([]model.TransactionLimit, error) {
+	args := m.Called(tier, currency)
+	if args.Get(0) != nil {
+		return args.Get(0).([]model.TransactionLimit), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockLimitRepository) UpsertLimit(limit *model.TransactionLimit) error {
+	args := m.Called(limit)
+	return args.Error(0)
+}
+
+func (m *MockLimitRepository) SetUserTier(userID string, tier int, updatedBy string) error {
+	args := m.Called(userID, tier, updatedBy)
+	return args.Error(0)
+}
+
+func (m *MockLimitRepository) SeedLimits(limits []model.TransactionLimit) error {
+	args := m.Called(limits)
+	return args.Error(0)
+}
+
+var testNow = time.Date(2024, 5, 20, 12, 0, 0, 0, time.UTC)
+
+func newTestLimitService(repo *MockLimitRepository) *limitService {
+	return &limitService{repo: repo, now: func() time.Time { return testNow }}
+}
+
+func thbWithdrawLimit() *model.TransactionLimit {
+	return &model.TransactionLimit{
+		Tier:         1,
+		Currency:     "THB",
+		Operation:    model.OperationWithdraw,
+		MaxSingle:    decimal.NewFromInt(50000),
+		DailyLimit:   decimal.NewFromInt(100000),
+		MonthlyLimit: decimal.NewFromInt(500000),
+	}
+}
+
+func TestCheck_Limits(t *testing.T) {
+	daily := testNow.Add(-dailyWindow)
+	monthly := testNow.Add(-monthlyWindow)
+
+	tests := []struct {
+		name      string
+		amount    int64
+		usedDay   int64
+		usedMonth int64
+		expected  error
+	}{
+		{"within limits", 50000, 50000, 400000, nil},
+		{"single exceeded", 50001, 0, 0, utils.ErrSingleLimitExceeded},
+		{"daily exceeded", 20000, 80001, 80001, utils.ErrDailyLimitExceeded},
+		{"monthly exceeded", 20000, 0, 480001, utils.ErrMonthlyLimitExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockLimitRepository)
+			service := newTestLimitService(repo)
+
+			repo.On("GetUserTier", mock.Anything, "user-1").Return(1, nil)
+			repo.On("GetLimit", mock.Anything, 1, "THB", model.OperationWithdraw).Return(thbWithdrawLimit(), nil)
+			repo.On("GetUsage", mock.Anything, "user-1", "THB", model.OperationWithdraw, daily).Return(decimal.NewFromInt(tt.usedDay), nil)
+			repo.On("GetUsage", mock.Anything, "user-1", "THB", model.OperationWithdraw, monthly).Return(decimal.NewFromInt(tt.usedMonth), nil)
+
+			err := service.Check(nil, "user-1", "THB", model.OperationWithdraw, decimal.NewFromInt(tt.amount))
+
+			if tt.expected == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.Equal(t, tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestCheck_ZeroMeansUnlimited(t *testing.T) {
+	repo := new(MockLimitRepository)
+	service := newTestLimitService(repo)
+
+	repo.On("GetUserTier", mock.Anything, "user-1").Return(2, nil)
+	repo.On("GetLimit", mock.Anything, 2, "BTC", model.OperationDeposit).Return(&model.TransactionLimit{Tier: 2, Currency: "BTC", Operation: model.OperationDeposit}, nil)
+
+	err := service.Check(nil, "user-1", "BTC", model.OperationDeposit, decimal.NewFromInt(1000))
+
+	assert.NoError(t, err)
+	repo.AssertNotCalled(t, "GetUsage")
+}
+
+func TestCheck_Fail_NotConfigured(t *testing.T) {
+	repo := new(MockLimitRepository)
+	service := newTestLimitService(repo)
+
+	repo.On("GetUserTier", mock.Anything, "user-1").Return(0, nil)
+	repo.On("GetLimit", mock.Anything, 0, "THB", model.OperationTransfer).Return(nil, utils.ErrLimitNotConfigured)
+
+	err := service.Check(nil, "user-1", "THB", model.OperationTransfer, decimal.NewFromInt(1))
+
+	assert.Equal(t, utils.ErrLimitNotConfigured, err)
+}
+
+func TestSetLimit_Fail_Invalid(t *testing.T) {
+	repo := new(MockLimitRepository)
+	service := newTestLimitService(repo)
+
+	tests := []struct {
+		name  string
+		limit model.TransactionLimit
+	}{
+		{"unknown operation", model.TransactionLimit{Tier: 1, Currency: "THB", Operation: "TRADE"}},
+		{"negative amount", model.TransactionLimit{Tier: 1, Currency: "THB", Operation: "deposit", MaxSingle: decimal.NewFromInt(-1)}},
+		{"daily above monthly", model.TransactionLimit{Tier: 1, Currency: "THB", Operation: "deposit", DailyLimit: decimal.NewFromInt(10), MonthlyLimit: decimal.NewFromInt(5)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.SetLimit(tt.limit, "admin-1")
+			assert.Equal(t, utils.ErrInvalidLimit, err)
+		})
+	}
+	repo.AssertNotCalled(t, "UpsertLimit")
+}
+
+// user ใหม่ (tier 0) หลัง migrate ทำรายการได้ภายในวงเงินตั้งต้นเท่านั้น, สกุลที่ไม่ได้ตั้งไม่มีแถว
+func TestCheck_DefaultLimitsRestrictNewUser(t *testing.T) {
+	defaults, err := model.ParseDefaultLimits("thb=50000/200000/1000000")
+	if !assert.NoError(t, err) {
+		return
+	}
+	seeded := model.DefaultLimits([]string{"THB", "BTC"}, defaults)
+	assert.Len(t, seeded, 3)
+
+	var withdraw *model.TransactionLimit
+	for i := range seeded {
+		assert.Equal(t, 0, seeded[i].Tier)
+		assert.Equal(t, "THB", seeded[i].Currency)
+		if seeded[i].Operation == model.OperationWithdraw {
+			withdraw = &seeded[i]
+		}
+	}
+	if !assert.NotNil(t, withdraw) {
+		return
+	}
+
+	repo := new(MockLimitRepository)
+	service := newTestLimitService(repo)
+	repo.On("GetUserTier", mock.Anything, "user-1").Return(0, nil)
+	repo.On("GetLimit", mock.Anything, 0, "THB", model.OperationWithdraw).Return(withdraw, nil)
+	repo.On("GetUsage", mock.Anything, "user-1", "THB", model.OperationWithdraw, mock.Anything).Return(decimal.Zero, nil)
+
+	assert.NoError(t, service.Check(nil, "user-1", "THB", model.OperationWithdraw, decimal.NewFromInt(1000)))
+	assert.Equal(t, utils.ErrSingleLimitExceeded, service.Check(nil, "user-1", "THB", model.OperationWithdraw, decimal.NewFromInt(60000)))
+}
+
+func TestParseDefaultLimits_RejectsUnlimitedOrInvalid(t *testing.T) {
+	for _, value := range []string{
+		"THB=0/200000/1000000",
+		"THB=50000/200000",
+		"THB=300000/200000/1000000",
+		"=1/2/3",
+		"THB=abc/2/3",
+	} {
+		_, err := model.ParseDefaultLimits(value)
+		assert.ErrorIs(t, err, model.ErrInvalidDefaultLimits, value)
+	}
+
+	defaults, err := model.ParseDefaultLimits("")
+	assert.NoError(t, err)
+	assert.Empty(t, model.DefaultLimits([]string{"THB"}, defaults))
+}
+
+// สกุลที่ admin เพิ่มทีหลังได้แถวตามนโยบายเดียวกับตอน migrate
+func TestLimitSeeder_InitCurrency(t *testing.T) {
+	defaults, _ := model.ParseDefaultLimits("DOGE=100/500/2000")
+	repo := new(MockLimitRepository)
+	repo.On("SeedLimits", mock.MatchedBy(func(limits []model.TransactionLimit) bool {
+		return len(limits) == 3 && limits[0].Currency == "DOGE" && limits[0].MaxSingle.Equal(decimal.NewFromInt(100))
+	})).Return(nil)
+
+	err := NewLimitSeeder(repo, defaults).InitCurrency("DOGE")
+
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,43 @@
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// สำหรับ dev/test ไม่ส่งจริง เขียนต่อท้ายไฟล์ ถ้าไม่ระบุไฟล์ log แค่ผู้รับกับหัวเรื่อง
+// body มี token reset รหัสผ่านที่ยังใช้ได้ ห้ามลง log
+type fileMailer struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewFileMailer(path string) Mailer {
+	return &fileMailer{path: path}
+}
+
+func (m *fileMailer) Name() string {
+	return "FILE"
+}
+
+func (m *fileMailer) Send(msg Message) error {
+	if m.path == "" {
+		log.Printf("[mail] to=%s subject=%q (set MAIL_FILE_PATH to keep the body)", msg.To, msg.Subject)
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, err := os.OpenFile(m.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintf(f, "--- %s\nTo: %s\nSubject: %s\n\n%s\n\n", time.Now().Format(time.RFC3339), msg.To, msg.Subject, msg.Body)
+	return err
+}
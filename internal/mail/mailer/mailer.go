@@ -0,0 +1,14 @@
+package mailer
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// ช่องทางส่ง email จริง, ถูกเรียกจาก outbox dispatcher เท่านั้น
+// ส่งซ้ำได้ถ้า dispatcher ตายก่อน mark ว่าส่งแล้ว
+type Mailer interface {
+	Name() string
+	Send(msg Message) error
+}
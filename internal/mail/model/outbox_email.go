@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+const (
+	OutboxStatusPending = "PENDING"
+	OutboxStatusSent    = "SENT"
+	// ส่งไม่สำเร็จครบจำนวนครั้งแล้ว เลิกลอง
+	OutboxStatusFailed = "FAILED"
+)
+
+// email ที่รอส่ง, เขียนใน transaction เดียวกับงานที่ทำให้ต้องส่ง แล้ว dispatcher ค่อยมาส่งทีหลัง
+type OutboxEmail struct {
+	ID        uint64 `gorm:"primaryKey" json:"id"`
+	Recipient string `gorm:"size:255;not null" json:"recipient"`
+	Subject   string `gorm:"size:255;not null" json:"subject"`
+	// ล้างทิ้งหลังส่งเสร็จ เพราะอาจมี token อยู่ในเนื้อหา
+	Body          string     `gorm:"type:text;not null" json:"-"`
+	Status        string     `gorm:"size:20;not null;default:'PENDING';index:idx_outbox_emails_due,priority:1" json:"status"`
+	Attempts      int        `gorm:"not null;default:0" json:"attempts"`
+	LastError     string     `gorm:"size:500" json:"last_error"`
+	NextAttemptAt time.Time  `gorm:"not null;index:idx_outbox_emails_due,priority:2" json:"next_attempt_at"`
+	SentAt        *time.Time `json:"sent_at"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
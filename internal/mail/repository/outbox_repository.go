@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/padapook/bestbit-core/internal/mail/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OutboxRepository interface {
+	Transaction(fn func(tx *gorm.DB) error) error
+	CreateEmail(tx *gorm.DB, email *model.OutboxEmail) error
+	// lock แถวที่ถึงเวลาส่ง instance อื่นข้ามแถวที่ถูก lock ไป ไม่ส่งซ้ำกัน
+	ClaimDue(tx *gorm.DB, now time.Time, limit int) ([]model.OutboxEmail, error)
+	UpdateEmail(tx *gorm.DB, email *model.OutboxEmail) error
+}
+
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+func (r *outboxRepository) Transaction(fn func(tx *gorm.DB) error) error {
+	return r.db.Transaction(fn)
+}
+
+func (r *outboxRepository) CreateEmail(tx *gorm.DB, email *model.OutboxEmail) error {
+	return tx.Create(email).Error
+}
+
+func (r *outboxRepository) ClaimDue(tx *gorm.DB, now time.Time, limit int) ([]model.OutboxEmail, error) {
+	var emails []model.OutboxEmail
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("status = ? AND next_attempt_at <= ?", model.OutboxStatusPending, now).
+		Order("id ASC").
+		Limit(limit).
+		Find(&emails).Error
+	return emails, err
+}
+
+func (r *outboxRepository) UpdateEmail(tx *gorm.DB, email *model.OutboxEmail) error {
+	return tx.Save(email).Error
+}
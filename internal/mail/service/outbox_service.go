@@ -0,0 +1,140 @@
+package service
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/padapook/bestbit-core/internal/mail/mailer"
+	"github.com/padapook/bestbit-core/internal/mail/model"
+	"github.com/padapook/bestbit-core/internal/mail/repository"
+	"gorm.io/gorm"
+)
+
+const (
+	maxSendAttempts = 5
+	// ลองใหม่ห่างขึ้นเรื่อยๆ 1, 2, 4, 8 นาที
+	baseRetryDelay   = time.Minute
+	maxLastErrorSize = 500
+)
+
+type OutboxService interface {
+	// เขียนลง outbox ใน transaction ของงานหลัก rollback แล้วก็ไม่ส่ง
+	Enqueue(tx *gorm.DB, msg mailer.Message) error
+	// ไม่มี transaction ของงานหลัก เปิด transaction ของตัวเอง
+	Send(msg mailer.Message) error
+	// ส่ง email ที่ถึงเวลา คืนจำนวนที่ส่งสำเร็จ
+	DispatchDue(limit int) (int, error)
+	Start(interval time.Duration) (stop func())
+}
+
+type outboxService struct {
+	repo   repository.OutboxRepository
+	mailer mailer.Mailer
+	now    func() time.Time
+}
+
+func NewOutboxService(repo repository.OutboxRepository, mailer mailer.Mailer) OutboxService {
+	return &outboxService{repo: repo, mailer: mailer, now: time.Now}
+}
+
+func (s *outboxService) Enqueue(tx *gorm.DB, msg mailer.Message) error {
+	return s.repo.CreateEmail(tx, &model.OutboxEmail{
+		Recipient:     msg.To,
+		Subject:       msg.Subject,
+		Body:          msg.Body,
+		Status:        model.OutboxStatusPending,
+		NextAttemptAt: s.now(),
+	})
+}
+
+func (s *outboxService) Send(msg mailer.Message) error {
+	return s.repo.Transaction(func(tx *gorm.DB) error {
+		return s.Enqueue(tx, msg)
+	})
+}
+
+// หยิบ ส่ง และ mark ทีละฉบับใน transaction สั้นของตัวเอง ถือ lock แถวไว้ระหว่างส่ง instance อื่นจะได้ไม่หยิบไปส่งซ้ำ
+// ฉบับที่บันทึกผลไม่ได้หยุดรอบนี้เลย ไม่งั้นจะถูกหยิบมาส่งซ้ำทันที
+func (s *outboxService) DispatchDue(limit int) (int, error) {
+	sent := 0
+	for i := 0; i < limit; i++ {
+		claimed := false
+		err := s.repo.Transaction(func(tx *gorm.DB) error {
+			emails, err := s.repo.ClaimDue(tx, s.now(), 1)
+			if err != nil || len(emails) == 0 {
+				return err
+			}
+			claimed = true
+
+			email := &emails[0]
+			s.deliver(email)
+			if err := s.repo.UpdateEmail(tx, email); err != nil {
+				return err
+			}
+			if email.Status == model.OutboxStatusSent {
+				sent++
+			}
+			return nil
+		})
+		if err != nil {
+			return sent, err
+		}
+		if !claimed {
+			break
+		}
+	}
+	return sent, nil
+}
+
+func (s *outboxService) deliver(email *model.OutboxEmail) {
+	now := s.now()
+	email.Attempts++
+
+	err := s.mailer.Send(mailer.Message{To: email.Recipient, Subject: email.Subject, Body: email.Body})
+	if err == nil {
+		email.Status = model.OutboxStatusSent
+		email.SentAt = &now
+		email.LastError = ""
+		email.Body = ""
+		return
+	}
+
+	email.LastError = err.Error()
+	if len(email.LastError) > maxLastErrorSize {
+		email.LastError = email.LastError[:maxLastErrorSize]
+	}
+	if email.Attempts >= maxSendAttempts {
+		log.Printf("[mail] email %d to %s failed after %d attempts: %v", email.ID, email.Recipient, email.Attempts, err)
+		email.Status = model.OutboxStatusFailed
+		email.Body = ""
+		return
+	}
+	email.NextAttemptAt = now.Add(baseRetryDelay << (email.Attempts - 1))
+}
+
+func (s *outboxService) Start(interval time.Duration) func() {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	var once sync.Once
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := s.DispatchDue(50); err != nil {
+					log.Println("[mail] dispatch failed:", err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		once.Do(func() {
+			ticker.Stop()
+			close(done)
+		})
+	}
+}
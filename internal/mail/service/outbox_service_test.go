@@ -0,0 +1,142 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/padapook/bestbit-core/internal/mail/mailer"
+	"github.com/padapook/bestbit-core/internal/mail/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type MockOutboxRepository struct {
+	mock.Mock
+}
+
+func (m *MockOutboxRepository) Transaction(fn func(tx *gorm.DB) error) error {
+	return fn(nil)
+}
+
+func (m *MockOutboxRepository) CreateEmail(tx *gorm.DB, email *model.OutboxEmail) error {
+	args := m.Called(tx, email)
+	return args.Error(0)
+}
+
+func (m *MockOutboxRepository) ClaimDue(tx *gorm.DB, now time.Time, limit int) ([]model.OutboxEmail, error) {
+	args := m.Called(tx, now, limit)
+	if args.Get(0) != nil {
+		return args.Get(0).([]model.OutboxEmail), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockOutboxRepository) UpdateEmail(tx *gorm.DB, email *model.OutboxEmail) error {
+	args := m.Called(tx, email)
+	return args.Error(0)
+}
+
+type MockMailer struct {
+	mock.Mock
+}
+
+func (m *MockMailer) Name() string {
+	return "MOCK"
+}
+
+func (m *MockMailer) Send(msg mailer.Message) error {
+	args := m.Called(msg)
+	return args.Error(0)
+}
+
+func newTestOutboxService(now time.Time) (*outboxService, *MockOutboxRepository, *MockMailer) {
+	repo := new(MockOutboxRepository)
+	sender := new(MockMailer)
+	return &outboxService{repo: repo, mailer: sender, now: func() time.Time { return now }}, repo, sender
+}
+
+func TestEnqueue_WritesPendingEmail(t *testing.T) {
+	now := time.Now()
+	service, repo, sender := newTestOutboxService(now)
+
+	repo.On("CreateEmail", mock.Anything, mock.MatchedBy(func(e *model.OutboxEmail) bool {
+		return e.Recipient == "pook@example.com" && e.Status == model.OutboxStatusPending && e.NextAttemptAt.Equal(now)
+	})).Return(nil)
+
+	err := service.Send(mailer.Message{To: "pook@example.com", Subject: "hi", Body: "body"})
+
+	require.NoError(t, err)
+	repo.AssertExpectations(t)
+	sender.AssertNotCalled(t, "Send", mock.Anything)
+}
+
+func TestDispatchDue_MarksSentAndClearsBody(t *testing.T) {
+	now := time.Now()
+	service, repo, sender := newTestOutboxService(now)
+
+	emails := []model.OutboxEmail{{ID: 1, Recipient: "pook@example.com", Subject: "hi", Body: "token", Status: model.OutboxStatusPending}}
+	repo.On("ClaimDue", mock.Anything, now, 1).Return(emails, nil).Once()
+	repo.On("ClaimDue", mock.Anything, now, 1).Return([]model.OutboxEmail{}, nil).Once()
+	sender.On("Send", mailer.Message{To: "pook@example.com", Subject: "hi", Body: "token"}).Return(nil)
+	repo.On("UpdateEmail", mock.Anything, mock.AnythingOfType("*model.OutboxEmail")).Return(nil)
+
+	sent, err := service.DispatchDue(10)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Equal(t, model.OutboxStatusSent, emails[0].Status)
+	assert.Empty(t, emails[0].Body)
+	assert.Equal(t, &now, emails[0].SentAt)
+}
+
+func TestDispatchDue_RetriesWithBackoffThenFails(t *testing.T) {
+	now := time.Now()
+	service, repo, sender := newTestOutboxService(now)
+
+	emails := []model.OutboxEmail{
+		{ID: 1, Recipient: "a@example.com", Body: "x", Status: model.OutboxStatusPending, Attempts: 1},
+		{ID: 2, Recipient: "b@example.com", Body: "y", Status: model.OutboxStatusPending, Attempts: maxSendAttempts - 1},
+	}
+	repo.On("ClaimDue", mock.Anything, now, 1).Return(emails[0:1], nil).Once()
+	repo.On("ClaimDue", mock.Anything, now, 1).Return(emails[1:2], nil).Once()
+	repo.On("ClaimDue", mock.Anything, now, 1).Return([]model.OutboxEmail{}, nil).Once()
+	sender.On("Send", mock.Anything).Return(errors.New("connection refused"))
+	repo.On("UpdateEmail", mock.Anything, mock.AnythingOfType("*model.OutboxEmail")).Return(nil)
+
+	sent, err := service.DispatchDue(10)
+
+	require.NoError(t, err)
+	assert.Equal(t, 0, sent)
+
+	assert.Equal(t, model.OutboxStatusPending, emails[0].Status)
+	assert.Equal(t, now.Add(2*time.Minute), emails[0].NextAttemptAt)
+	assert.Equal(t, "connection refused", emails[0].LastError)
+
+	assert.Equal(t, model.OutboxStatusFailed, emails[1].Status)
+	assert.Empty(t, emails[1].Body)
+}
+
+// บันทึกผลฉบับหนึ่งไม่ได้ ฉบับก่อนหน้าที่ commit ไปแล้วต้องไม่ถูกนับว่าล้มเหลว
+func TestDispatchDue_UpdateFailureStopsAfterCommittedEmails(t *testing.T) {
+	now := time.Now()
+	service, repo, sender := newTestOutboxService(now)
+
+	emails := []model.OutboxEmail{
+		{ID: 1, Recipient: "a@example.com", Body: "x", Status: model.OutboxStatusPending},
+		{ID: 2, Recipient: "b@example.com", Body: "y", Status: model.OutboxStatusPending},
+	}
+	repo.On("ClaimDue", mock.Anything, now, 1).Return(emails[0:1], nil).Once()
+	repo.On("ClaimDue", mock.Anything, now, 1).Return(emails[1:2], nil).Once()
+	sender.On("Send", mock.Anything).Return(nil)
+	repo.On("UpdateEmail", mock.Anything, &emails[0]).Return(nil)
+	repo.On("UpdateEmail", mock.Anything, &emails[1]).Return(errors.New("db down"))
+
+	sent, err := service.DispatchDue(10)
+
+	assert.EqualError(t, err, "db down")
+	assert.Equal(t, 1, sent)
+	repo.AssertNumberOfCalls(t, "ClaimDue", 2)
+}
@@ -0,0 +1,126 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/padapook/bestbit-core/internal/market/model"
+	"github.com/padapook/bestbit-core/internal/market/service"
+	"github.com/padapook/bestbit-core/internal/utils"
+	"github.com/shopspring/decimal"
+)
+
+type MarketController interface {
+	GetMarkets(c *gin.Context)
+	GetMarket(c *gin.Context)
+	CreateMarket(c *gin.Context)
+	UpdateMarket(c *gin.Context)
+	DeleteMarket(c *gin.Context)
+}
+
+type marketController struct {
+	marketService service.MarketService
+}
+
+func NewMarketController(marketService service.MarketService) MarketController {
+	return &marketController{marketService: marketService}
+}
+
+type MarketRequest struct {
+	Symbol        string          `json:"symbol"`
+	BaseCurrency  string          `json:"base_currency"`
+	QuoteCurrency string          `json:"quote_currency"`
+	PriceTick     decimal.Decimal `json:"price_tick" binding:"required"`
+	QuantityStep  decimal.Decimal `json:"quantity_step" binding:"required"`
+	MinQuantity   decimal.Decimal `json:"min_quantity"`
+	MaxQuantity   decimal.Decimal `json:"max_quantity"`
+	MinNotional   decimal.Decimal `json:"min_notional"`
+	Status        string          `json:"status"`
+}
+
+func (req MarketRequest) toModel() model.Market {
+	return model.Market{
+		Symbol:        req.Symbol,
+		BaseCurrency:  req.BaseCurrency,
+		QuoteCurrency: req.QuoteCurrency,
+		PriceTick:     req.PriceTick,
+		QuantityStep:  req.QuantityStep,
+		MinQuantity:   req.MinQuantity,
+		MaxQuantity:   req.MaxQuantity,
+		MinNotional:   req.MinNotional,
+		Status:        req.Status,
+	}
+}
+
+func (ctrl *marketController) GetMarkets(c *gin.Context) {
+	markets, err := ctrl.marketService.GetMarkets(c.Query("status"))
+	if err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": markets,
+	})
+}
+
+func (ctrl *marketController) GetMarket(c *gin.Context) {
+	market, err := ctrl.marketService.GetMarket(c.Param("symbol"))
+	if err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": market,
+	})
+}
+
+func (ctrl *marketController) CreateMarket(c *gin.Context) {
+	var req MarketRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleError(c, utils.ErrInvalidRequest)
+		return
+	}
+
+	market, err := ctrl.marketService.CreateMarket(req.toModel(), c.GetString("account_id"))
+	if err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Market created successfully",
+		"data":    market,
+	})
+}
+
+func (ctrl *marketController) UpdateMarket(c *gin.Context) {
+	var req MarketRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleError(c, utils.ErrInvalidRequest)
+		return
+	}
+
+	market, err := ctrl.marketService.UpdateMarket(c.Param("symbol"), req.toModel(), c.GetString("account_id"))
+	if err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Market updated successfully",
+		"data":    market,
+	})
+}
+
+func (ctrl *marketController) DeleteMarket(c *gin.Context) {
+	if err := ctrl.marketService.DeleteMarket(c.Param("symbol"), c.GetString("account_id")); err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Market deleted successfully",
+	})
+}
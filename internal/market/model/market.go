@@ -0,0 +1,36 @@
+package model
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+const (
+	StatusTrading  = "TRADING"
+	StatusHalted   = "HALTED"
+	StatusDelisted = "DELISTED"
+)
+
+type Market struct {
+	ID            uint64          `gorm:"primaryKey" json:"id"`
+	Symbol        string          `gorm:"size:20;not null;uniqueIndex:idx_markets_symbol_active,where:deleted_at IS NULL" json:"symbol" comment:"BASE_QUOTE เช่น BTC_THB"`
+	BaseCurrency  string          `gorm:"size:10;not null" json:"base_currency"`
+	QuoteCurrency string          `gorm:"size:10;not null" json:"quote_currency"`
+	PriceTick     decimal.Decimal `gorm:"type:decimal(32,16);not null" json:"price_tick"`
+	QuantityStep  decimal.Decimal `gorm:"type:decimal(32,16);not null" json:"quantity_step"`
+	MinQuantity   decimal.Decimal `gorm:"type:decimal(32,16);default:0" json:"min_quantity"`
+	MaxQuantity   decimal.Decimal `gorm:"type:decimal(32,16);default:0" json:"max_quantity" comment:"0 = ไม่จำกัด"`
+	MinNotional   decimal.Decimal `gorm:"type:decimal(32,16);default:0" json:"min_notional" comment:"price * amount ขั้นต่ำ"`
+	Status        string          `gorm:"size:20;not null;default:'TRADING';index" json:"status" comment:"TRADING, HALTED, DELISTED"`
+	CreatedAt     time.Time       `gorm:"autoCreateTime" json:"created_at"`
+	CreatedBy     string          `gorm:"size:100" json:"created_by"`
+	UpdatedAt     time.Time       `gorm:"autoUpdateTime" json:"updated_at"`
+	UpdatedBy     string          `gorm:"size:100" json:"updated_by"`
+	DeletedAt     gorm.DeletedAt  `gorm:"index" json:"-"`
+}
+
+func IsValidStatus(status string) bool {
+	return status == StatusTrading || status == StatusHalted || status == StatusDelisted
+}
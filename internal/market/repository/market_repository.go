@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/padapook/bestbit-core/internal/market/model"
+	"github.com/padapook/bestbit-core/internal/utils"
+	"gorm.io/gorm"
+)
+
+type MarketRepository interface {
+	CreateMarket(market *model.Market) error
+	UpdateMarket(market *model.Market) error
+	DeleteMarket(market *model.Market) error
+	GetBySymbol(symbol string) (*model.Market, error)
+	GetMarkets(status string) ([]model.Market, error)
+}
+
+type marketRepository struct {
+	db *gorm.DB
+}
+
+func NewMarketRepository(db *gorm.DB) MarketRepository {
+	return &marketRepository{db: db}
+}
+
+func (r *marketRepository) CreateMarket(market *model.Market) error {
+	err := r.db.Create(market).Error
+	if utils.IsDuplicateKey(r.db, err) {
+		return utils.ErrMarketConflict
+	}
+	return err
+}
+
+func (r *marketRepository) UpdateMarket(market *model.Market) error {
+	return r.db.Save(market).Error
+}
+
+// soft delete พร้อมเปลี่ยนสถานะเป็น DELISTED
+func (r *marketRepository) DeleteMarket(market *model.Market) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		market.Status = model.StatusDelisted
+		if err := tx.Save(market).Error; err != nil {
+			return err
+		}
+		return tx.Delete(market).Error
+	})
+}
+
+func (r *marketRepository) GetBySymbol(symbol string) (*model.Market, error) {
+	var market model.Market
+	err := r.db.Where("symbol = ?", symbol).First(&market).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.ErrMarketNotFound
+		}
+		return nil, err
+	}
+	return &market, nil
+}
+
+func (r *marketRepository) GetMarkets(status string) ([]model.Market, error) {
+	var markets []model.Market
+
+	query := r.db.Model(&model.Market{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	err := query.Order("symbol ASC").Find(&markets).Error
+	return markets, err
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"strings"
+
+	currencyService "github.com/padapook/bestbit-core/internal/currency/service"
+	"github.com/padapook/bestbit-core/internal/market/model"
+	"github.com/padapook/bestbit-core/internal/market/repository"
+	"github.com/padapook/bestbit-core/internal/utils"
+	"github.com/shopspring/decimal"
+)
+
+type MarketService interface {
+	CreateMarket(market model.Market, actorID string) (*model.Market, error)
+	UpdateMarket(symbol string, market model.Market, actorID string) (*model.Market, error)
+	DeleteMarket(symbol, actorID string) error
+	GetMarket(symbol string) (*model.Market, error)
+	GetMarkets(status string) ([]model.Market, error)
+}
+
+type marketService struct {
+	repo       repository.MarketRepository
+	currencies currencyService.CurrencyService
+}
+
+func NewMarketService(repo repository.MarketRepository, currencies currencyService.CurrencyService) MarketService {
+	return &marketService{repo: repo, currencies: currencies}
+}
+
+func (s *marketService) CreateMarket(market model.Market, actorID string) (*model.Market, error) {
+	if market.Status == "" {
+		market.Status = model.StatusTrading
+	}
+	if err := normalizeAndValidate(&market); err != nil {
+		return nil, err
+	}
+	// ทั้งสองขาต้องอยู่ใน registry ไม่งั้น settle แล้วไม่มี wallet ให้ลง
+	for _, code := range []string{market.BaseCurrency, market.QuoteCurrency} {
+		if _, err := s.currencies.Validate(code, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	market.ID = 0
+	market.CreatedBy = actorID
+	market.UpdatedBy = actorID
+
+	if err := s.repo.CreateMarket(&market); err != nil {
+		return nil, err
+	}
+	return &market, nil
+}
+
+func (s *marketService) UpdateMarket(symbol string, market model.Market, actorID string) (*model.Market, error) {
+	existing, err := s.repo.GetBySymbol(strings.ToUpper(symbol))
+	if err != nil {
+		return nil, err
+	}
+
+	// symbol กับคู่สกุลเงินเปลี่ยนไม่ได้ เพราะ order/trade เดิมอ้างอิงอยู่
+	market.Symbol = existing.Symbol
+	market.BaseCurrency = existing.BaseCurrency
+	market.QuoteCurrency = existing.QuoteCurrency
+	if market.Status == "" {
+		market.Status = existing.Status
+	}
+	if err := normalizeAndValidate(&market); err != nil {
+		return nil, err
+	}
+
+	existing.PriceTick = market.PriceTick
+	existing.QuantityStep = market.QuantityStep
+	existing.MinQuantity = market.MinQuantity
+	existing.MaxQuantity = market.MaxQuantity
+	existing.MinNotional = market.MinNotional
+	existing.Status = market.Status
+	existing.UpdatedBy = actorID
+
+	if err := s.repo.UpdateMarket(existing); err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+func (s *marketService) DeleteMarket(symbol, actorID string) error {
+	market, err := s.repo.GetBySymbol(strings.ToUpper(symbol))
+	if err != nil {
+		return err
+	}
+
+	market.UpdatedBy = actorID
+	return s.repo.DeleteMarket(market)
+}
+
+func (s *marketService) GetMarket(symbol string) (*model.Market, error) {
+	return s.repo.GetBySymbol(strings.ToUpper(symbol))
+}
+
+func (s *marketService) GetMarkets(status string) ([]model.Market, error) {
+	status = strings.ToUpper(status)
+	if status != "" && !model.IsValidStatus(status) {
+		return nil, utils.ErrInvalidRequest
+	}
+	return s.repo.GetMarkets(status)
+}
+
+// symbol ต้องเป็น BASE_QUOTE ให้ตรงกับที่ order/settlement ใช้แยกสกุลเงิน
+func normalizeAndValidate(market *model.Market) error {
+	market.BaseCurrency = strings.ToUpper(strings.TrimSpace(market.BaseCurrency))
+	market.QuoteCurrency = strings.ToUpper(strings.TrimSpace(market.QuoteCurrency))
+	market.Symbol = strings.ToUpper(strings.TrimSpace(market.Symbol))
+	market.Status = strings.ToUpper(market.Status)
+
+	switch {
+	case market.BaseCurrency == "" || market.QuoteCurrency == "" || market.BaseCurrency == market.QuoteCurrency:
+		return utils.ErrInvalidMarket
+	case market.Symbol != market.BaseCurrency+"_"+market.QuoteCurrency:
+		return utils.ErrInvalidMarket
+	case !market.PriceTick.IsPositive() || !market.QuantityStep.IsPositive():
+		return utils.ErrInvalidMarket
+	case market.MinQuantity.IsNegative() || market.MinNotional.IsNegative() || market.MaxQuantity.IsNegative():
+		return utils.ErrInvalidMarket
+	case market.MaxQuantity.IsPositive() && market.MaxQuantity.LessThan(market.MinQuantity):
+		return utils.ErrInvalidMarket
+	case !model.IsValidStatus(market.Status):
+		return utils.ErrInvalidMarket
+	}
+
+	return nil
+}
+
+// ValidateOrder ตรวจ order กับกติกาของตลาด ราคาใช้ตรวจ tick เฉพาะ limit (market order ส่ง price เป็น 0)
+// notional ของ market order คิดจาก referencePrice คือราคาดีที่สุดฝั่งตรงข้ามใน book ไม่มี = ไม่มีให้ match
+func ValidateOrder(market *model.Market, price, amount, referencePrice decimal.Decimal) error {
+	if market.Status != model.StatusTrading {
+		return utils.ErrMarketNotTrading
+	}
+
+	if !price.IsZero() && !price.Mod(market.PriceTick).IsZero() {
+		return utils.ErrInvalidPriceTick
+	}
+	if !amount.Mod(market.QuantityStep).IsZero() {
+		return utils.ErrInvalidQuantityStep
+	}
+	if amount.LessThan(market.MinQuantity) {
+		return utils.ErrQuantityTooSmall
+	}
+	if market.MaxQuantity.IsPositive() && amount.GreaterThan(market.MaxQuantity) {
+		return utils.ErrQuantityTooLarge
+	}
+
+	notionalPrice := price
+	if price.IsZero() {
+		if !referencePrice.IsPositive() {
+			return utils.ErrNoLiquidity
+		}
+		notionalPrice = referencePrice
+	}
+	if notionalPrice.Mul(amount).LessThan(market.MinNotional) {
+		return utils.ErrNotionalTooSmall
+	}
+
+	return nil
+}
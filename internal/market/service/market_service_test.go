@@ -0,0 +1,164 @@
+package service
+
+import (
+	"testing"
+
+	currencyModel "github.com/padapook/bestbit-core/internal/currency/model"
+	currencyService "github.com/padapook/bestbit-core/internal/currency/service"
+	"github.com/padapook/bestbit-core/internal/market/model"
+	"github.com/padapook/bestbit-core/internal/utils"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockMarketRepository struct {
+	mock.Mock
+}
+
+func (m *MockMarketRepository) CreateMarket(market *model.Market) error {
+	args := m.Called(market)
+	return args.Error(0)
+}
+
+func (m *MockMarketRepository) UpdateMarket(market *model.Market) error {
+	args := m.Called(market)
+	return args.Error(0)
+}
+
+func (m *MockMarketRepository) DeleteMarket(market *model.Market) error {
+	args := m.Called(market)
+	return args.Error(0)
+}
+
+func (m *MockMarketRepository) GetBySymbol(symbol string) (*model.Market, error) {
+	args := m.Called(symbol)
+	if args.Get(0) != nil {
+		return args.Get(0).(*model.Market), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMarketRepository) GetMarkets(status string) ([]model.Market, error) {
+	args := m.Called(status)
+	if args.Get(0) != nil {
+		return args.Get(0).([]model.Market), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func testMarket() *model.Market {
+	return &model.Market{
+		Symbol:        "BTC_THB",
+		BaseCurrency:  "BTC",
+		QuoteCurrency: "THB",
+		PriceTick:     decimal.RequireFromString("0.5"),
+		QuantityStep:  decimal.RequireFromString("0.001"),
+		MinQuantity:   decimal.RequireFromString("0.01"),
+		MaxQuantity:   decimal.NewFromInt(10),
+		MinNotional:   decimal.NewFromInt(100),
+		Status:        model.StatusTrading,
+	}
+}
+
+func TestValidateOrder(t *testing.T) {
+	cases := []struct {
+		name      string
+		price     string
+		amount    string
+		reference string
+		expected  error
+	}{
+		{"valid limit", "1000.5", "0.5", "1000.5", nil},
+		{"valid market ใช้ราคาฝั่งตรงข้าม", "0", "0.5", "1000", nil},
+		{"price off tick", "1000.25", "0.5", "1000.25", utils.ErrInvalidPriceTick},
+		{"amount off step", "1000", "0.0105", "1000", utils.ErrInvalidQuantityStep},
+		{"below min quantity", "100000", "0.005", "100000", utils.ErrQuantityTooSmall},
+		{"above max quantity", "1000", "10.001", "1000", utils.ErrQuantityTooLarge},
+		{"below min notional", "1000", "0.05", "1000", utils.ErrNotionalTooSmall},
+		{"market below min notional", "0", "0.05", "1000", utils.ErrNotionalTooSmall},
+		{"market ไม่มีฝั่งตรงข้าม", "0", "0.5", "0", utils.ErrNoLiquidity},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateOrder(testMarket(), decimal.RequireFromString(tc.price), decimal.RequireFromString(tc.amount), decimal.RequireFromString(tc.reference))
+			if tc.expected == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.Equal(t, tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestValidateOrder_Fail_NotTrading(t *testing.T) {
+	market := testMarket()
+	market.Status = model.StatusHalted
+
+	err := ValidateOrder(market, decimal.NewFromInt(1000), decimal.NewFromInt(1), decimal.NewFromInt(1000))
+
+	assert.Equal(t, utils.ErrMarketNotTrading, err)
+}
+
+type MockCurrencyService struct {
+	currencyService.CurrencyService
+	mock.Mock
+}
+
+func (m *MockCurrencyService) Validate(code, operation string) (*currencyModel.Currency, error) {
+	args := m.Called(code, operation)
+	if args.Get(0) != nil {
+		return args.Get(0).(*currencyModel.Currency), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func TestCreateMarket_Fail_SymbolMismatch(t *testing.T) {
+	mockRepo := new(MockMarketRepository)
+	service := NewMarketService(mockRepo, new(MockCurrencyService))
+
+	market := *testMarket()
+	market.Symbol = "BTCTHB"
+
+	created, err := service.CreateMarket(market, "admin")
+
+	assert.Nil(t, created)
+	assert.Equal(t, utils.ErrInvalidMarket, err)
+	mockRepo.AssertNotCalled(t, "CreateMarket")
+}
+
+func TestCreateMarket_Fail_UnknownCurrency(t *testing.T) {
+	mockRepo := new(MockMarketRepository)
+	mockCurrencies := new(MockCurrencyService)
+	service := NewMarketService(mockRepo, mockCurrencies)
+
+	mockCurrencies.On("Validate", "BTC", "").Return(nil, utils.ErrCurrencyNotFound)
+
+	created, err := service.CreateMarket(*testMarket(), "admin")
+
+	assert.Nil(t, created)
+	assert.Equal(t, utils.ErrCurrencyNotFound, err)
+	mockRepo.AssertNotCalled(t, "CreateMarket")
+}
+
+func TestCreateMarket_Success_DefaultsToTrading(t *testing.T) {
+	mockRepo := new(MockMarketRepository)
+	mockCurrencies := new(MockCurrencyService)
+	service := NewMarketService(mockRepo, mockCurrencies)
+
+	mockCurrencies.On("Validate", mock.Anything, "").Return(&currencyModel.Currency{}, nil)
+	mockRepo.On("CreateMarket", mock.AnythingOfType("*model.Market")).Return(nil)
+
+	market := *testMarket()
+	market.Symbol = "btc_thb"
+	market.Status = ""
+
+	created, err := service.CreateMarket(market, "admin")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "BTC_THB", created.Symbol)
+	assert.Equal(t, model.StatusTrading, created.Status)
+	assert.Equal(t, "admin", created.CreatedBy)
+	mockRepo.AssertExpectations(t)
+}
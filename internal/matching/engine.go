@@ -0,0 +1,164 @@
+package matching
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	orderModel "github.com/padapook/bestbit-core/internal/order/model"
+)
+
+const defaultCommandBuffer = 1024
+
+var (
+	ErrEngineStopped  = errors.New("matching engine is stopped")
+	ErrSymbolMismatch = errors.New("order symbol does not match engine symbol")
+	ErrInvalidOrder   = errors.New("order is not valid for matching")
+)
+
+// Engine รัน OrderBook ของ symbol เดียวบน goroutine เดียว
+// ทุกคำสั่งส่งผ่าน channel จึงไม่ต้องใช้ lock กับ book
+type Engine struct {
+	book     *OrderBook
+	commands chan func(*OrderBook)
+	quit     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+	now      func() time.Time
+}
+
+// now ใช้กำหนดเวลาของ trade ให้ test ได้ผลคงที่ ถ้าเป็น nil ใช้ time.Now
+func NewEngine(symbol string, now func() time.Time) *Engine {
+	if now == nil {
+		now = time.Now
+	}
+
+	e := &Engine{
+		book:     NewOrderBook(symbol),
+		commands: make(chan func(*OrderBook), defaultCommandBuffer),
+		quit:     make(chan struct{}),
+		done:     make(chan struct{}),
+		now:      now,
+	}
+	go e.run()
+	return e
+}
+
+func (e *Engine) Symbol() string {
+	return e.book.Symbol()
+}
+
+func (e *Engine) run() {
+	defer close(e.done)
+	for {
+		select {
+		case cmd := <-e.commands:
+			cmd(e.book)
+		case <-e.quit:
+			return
+		}
+	}
+}
+
+// Stop หยุด goroutine คำสั่งที่ยังค้างใน channel จะไม่ถูกประมวลผล
+func (e *Engine) Stop() {
+	e.stopOnce.Do(func() { close(e.quit) })
+	<-e.done
+}
+
+func (e *Engine) Submit(order orderModel.Order) (MatchResult, error) {
+	if order.Symbol != e.book.Symbol() {
+		return MatchResult{}, ErrSymbolMismatch
+	}
+	if !orderModel.IsValidSide(order.Side) || !orderModel.IsValidOrderType(order.OrderType) || !order.Amount.IsPositive() {
+		return MatchResult{}, ErrInvalidOrder
+	}
+	if order.OrderType == orderModel.OrderTypeLimit && !order.Price.IsPositive() {
+		return MatchResult{}, ErrInvalidOrder
+	}
+
+	var result MatchResult
+	err := e.exec(func(b *OrderBook) {
+		result = b.Place(order, e.now())
+	})
+	return result, err
+}
+
+func (e *Engine) Cancel(orderID uint64) (orderModel.Order, bool, error) {
+	var (
+		order orderModel.Order
+		found bool
+	)
+	err := e.exec(func(b *OrderBook) {
+		order, found = b.Cancel(orderID)
+	})
+	return order, found, err
+}
+
+func (e *Engine) Depth(levels int) (bids, asks []PriceLevel, err error) {
+	err = e.exec(func(b *OrderBook) {
+		bids, asks = b.Depth(levels)
+	})
+	return bids, asks, err
+}
+
+// exec ส่งคำสั่งเข้า goroutine ของ engine แล้วรอจนทำเสร็จ
+func (e *Engine) exec(fn func(*OrderBook)) error {
+	reply := make(chan struct{})
+	cmd := func(b *OrderBook) {
+		fn(b)
+		close(reply)
+	}
+
+	select {
+	case e.commands <- cmd:
+	case <-e.quit:
+		return ErrEngineStopped
+	}
+
+	select {
+	case <-reply:
+		return nil
+	case <-e.done:
+		// engine หยุดพร้อมกับที่คำสั่งทำเสร็จพอดี
+		select {
+		case <-reply:
+			return nil
+		default:
+			return ErrEngineStopped
+		}
+	}
+}
+
+// Manager สร้าง Engine ต่อ symbol เมื่อถูกใช้ครั้งแรก
+type Manager struct {
+	mu      sync.Mutex
+	engines map[string]*Engine
+	now     func() time.Time
+}
+
+func NewManager(now func() time.Time) *Manager {
+	return &Manager{engines: make(map[string]*Engine), now: now}
+}
+
+func (m *Manager) Engine(symbol string) *Engine {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	engine, ok := m.engines[symbol]
+	if !ok {
+		engine = NewEngine(symbol, m.now)
+		m.engines[symbol] = engine
+	}
+	return engine
+}
+
+func (m *Manager) StopAll() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for symbol, engine := range m.engines {
+		engine.Stop()
+		delete(m.engines, symbol)
+	}
+}
@@ -0,0 +1,279 @@
+package matching
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	orderModel "github.com/padapook/bestbit-core/internal/order/model"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSymbol = "BTC_THB"
+
+var fixedTime = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func newTestEngine(t *testing.T) *Engine {
+	engine := NewEngine(testSymbol, func() time.Time { return fixedTime })
+	t.Cleanup(engine.Stop)
+	return engine
+}
+
+// ทุก order คนละ user ไม่ติด self-trade prevention, test ที่ต้องการ user เดียวกันตั้ง UserID เอง
+func testUserID(id uint64) string {
+	return "user-" + strconv.FormatUint(id, 10)
+}
+
+func limitOrder(id uint64, side, price, amount string) orderModel.Order {
+	return orderModel.Order{
+		ID:        id,
+		UserID:    testUserID(id),
+		Symbol:    testSymbol,
+		Side:      side,
+		OrderType: orderModel.OrderTypeLimit,
+		Status:    orderModel.StatusPending,
+		Price:     decimal.RequireFromString(price),
+		Amount:    decimal.RequireFromString(amount),
+	}
+}
+
+func marketOrder(id uint64, side, amount string) orderModel.Order {
+	return orderModel.Order{
+		ID:        id,
+		UserID:    testUserID(id),
+		Symbol:    testSymbol,
+		Side:      side,
+		OrderType: orderModel.OrderTypeMarket,
+		Status:    orderModel.StatusPending,
+		Amount:    decimal.RequireFromString(amount),
+	}
+}
+
+func dec(s string) decimal.Decimal {
+	return decimal.RequireFromString(s)
+}
+
+func TestSubmit_NonCrossingLimitOrdersRest(t *testing.T) {
+	engine := newTestEngine(t)
+
+	res, err := engine.Submit(limitOrder(1, orderModel.SideBuy, "100", "1"))
+	require.NoError(t, err)
+	assert.Empty(t, res.Trades)
+	assert.True(t, res.Resting)
+	assert.Equal(t, orderModel.StatusPending, res.Order.Status)
+
+	// ask สูงกว่า bid ไม่เกิด match
+	res, err = engine.Submit(limitOrder(2, orderModel.SideSell, "101", "2"))
+	require.NoError(t, err)
+	assert.Empty(t, res.Trades)
+	assert.True(t, res.Resting)
+
+	bids, asks, err := engine.Depth(0)
+	require.NoError(t, err)
+	require.Len(t, bids, 1)
+	require.Len(t, asks, 1)
+	assert.True(t, bids[0].Price.Equal(dec("100")))
+	assert.True(t, asks[0].Price.Equal(dec("101")))
+	assert.True(t, asks[0].Amount.Equal(dec("2")))
+}
+
+func TestSubmit_CrossingLimitFillsAtMakerPrice(t *testing.T) {
+	engine := newTestEngine(t)
+
+	_, err := engine.Submit(limitOrder(1, orderModel.SideSell, "100", "1"))
+	require.NoError(t, err)
+
+	// buy ที่ 105 ต้องได้ราคาของ maker คือ 100
+	res, err := engine.Submit(limitOrder(2, orderModel.SideBuy, "105", "1"))
+	require.NoError(t, err)
+
+	require.Len(t, res.Trades, 1)
+	trade := res.Trades[0]
+	assert.Equal(t, uint64(1), trade.MakerOrderID)
+	assert.Equal(t, uint64(2), trade.TakerOrderID)
+	assert.Equal(t, orderModel.SideBuy, trade.TakerSide)
+	assert.True(t, trade.QuoteAmount.Equal(dec("100")))
+	assert.True(t, trade.Price.Equal(dec("100")))
+	assert.True(t, trade.Amount.Equal(dec("1")))
+	assert.Equal(t, fixedTime, trade.ExecutedAt)
+
+	assert.Equal(t, orderModel.StatusFilled, res.Order.Status)
+	assert.False(t, res.Resting)
+	require.Len(t, res.Makers, 1)
+	assert.Equal(t, orderModel.StatusFilled, res.Makers[0].Status)
+
+	bids, asks, _ := engine.Depth(0)
+	assert.Empty(t, bids)
+	assert.Empty(t, asks)
+}
+
+func TestSubmit_PartialFillRestsRemainder(t *testing.T) {
+	engine := newTestEngine(t)
+
+	_, err := engine.Submit(limitOrder(1, orderModel.SideSell, "100", "0.4"))
+	require.NoError(t, err)
+
+	res, err := engine.Submit(limitOrder(2, orderModel.SideBuy, "100", "1"))
+	require.NoError(t, err)
+
+	require.Len(t, res.Trades, 1)
+	assert.True(t, res.Trades[0].Amount.Equal(dec("0.4")))
+	assert.Equal(t, orderModel.StatusPartialFilled, res.Order.Status)
+	assert.True(t, res.Order.FilledAmount.Equal(dec("0.4")))
+	assert.True(t, res.Resting)
+
+	// ส่วนที่เหลือ 0.6 ค้างเป็น bid
+	bids, asks, _ := engine.Depth(0)
+	assert.Empty(t, asks)
+	require.Len(t, bids, 1)
+	assert.True(t, bids[0].Amount.Equal(dec("0.6")))
+
+	// sell ตามมาทีหลัง maker คือ order 2 ที่ partial อยู่
+	res, err = engine.Submit(limitOrder(3, orderModel.SideSell, "99", "0.25"))
+	require.NoError(t, err)
+	require.Len(t, res.Makers, 1)
+	assert.Equal(t, uint64(2), res.Makers[0].ID)
+	assert.Equal(t, orderModel.StatusPartialFilled, res.Makers[0].Status)
+	assert.True(t, res.Makers[0].FilledAmount.Equal(dec("0.65")))
+}
+
+func TestSubmit_PriceTimePriority(t *testing.T) {
+	engine := newTestEngine(t)
+
+	_, _ = engine.Submit(limitOrder(1, orderModel.SideSell, "101", "1"))
+	_, _ = engine.Submit(limitOrder(2, orderModel.SideSell, "100", "1"))
+	_, _ = engine.Submit(limitOrder(3, orderModel.SideSell, "100", "1"))
+
+	// ราคาดีสุดก่อน และราคาเท่ากันตัวที่มาก่อนได้ก่อน
+	res, err := engine.Submit(limitOrder(4, orderModel.SideBuy, "101", "2.5"))
+	require.NoError(t, err)
+
+	require.Len(t, res.Trades, 3)
+	assert.Equal(t, uint64(2), res.Trades[0].MakerOrderID)
+	assert.Equal(t, uint64(3), res.Trades[1].MakerOrderID)
+	assert.Equal(t, uint64(1), res.Trades[2].MakerOrderID)
+	assert.True(t, res.Trades[2].Price.Equal(dec("101")))
+	assert.True(t, res.Trades[2].Amount.Equal(dec("0.5")))
+	assert.Equal(t, orderModel.StatusFilled, res.Order.Status)
+}
+
+func TestSubmit_MarketOrderSweepsAndCancelsRemainder(t *testing.T) {
+	engine := newTestEngine(t)
+
+	_, _ = engine.Submit(limitOrder(1, orderModel.SideBuy, "100", "1"))
+	_, _ = engine.Submit(limitOrder(2, orderModel.SideBuy, "99", "1"))
+
+	res, err := engine.Submit(marketOrder(3, orderModel.SideSell, "3"))
+	require.NoError(t, err)
+
+	require.Len(t, res.Trades, 2)
+	assert.True(t, res.Trades[0].Price.Equal(dec("100")))
+	assert.True(t, res.Trades[1].Price.Equal(dec("99")))
+
+	// market ไม่ค้างใน book ส่วนที่เหลือถูกยกเลิก แต่ยังเห็นว่ามี fill
+	assert.False(t, res.Resting)
+	assert.Equal(t, orderModel.StatusPartialCanceled, res.Order.Status)
+	assert.True(t, res.Order.FilledAmount.Equal(dec("2")))
+
+	bids, asks, _ := engine.Depth(0)
+	assert.Empty(t, bids)
+	assert.Empty(t, asks)
+}
+
+func TestSubmit_MarketOrderWithoutLiquidityIsCanceled(t *testing.T) {
+	engine := newTestEngine(t)
+
+	res, err := engine.Submit(marketOrder(1, orderModel.SideSell, "1"))
+	require.NoError(t, err)
+
+	assert.Empty(t, res.Trades)
+	assert.Equal(t, orderModel.StatusCanceled, res.Order.Status)
+}
+
+func TestSubmit_MarketBuyRespectsLockedBudget(t *testing.T) {
+	engine := newTestEngine(t)
+
+	_, _ = engine.Submit(limitOrder(1, orderModel.SideSell, "100", "1"))
+	_, _ = engine.Submit(limitOrder(2, orderModel.SideSell, "200", "1"))
+
+	// งบ 200 THB ซื้อได้ 1 ที่ 100 และอีก 0.5 ที่ 200
+	order := marketOrder(3, orderModel.SideBuy, "5")
+	order.LockedAmount = dec("200")
+
+	res, err := engine.Submit(order)
+	require.NoError(t, err)
+
+	require.Len(t, res.Trades, 2)
+	assert.True(t, res.Trades[0].Amount.Equal(dec("1")))
+	assert.True(t, res.Trades[1].Amount.Equal(dec("0.5")))
+	assert.True(t, res.Order.FilledAmount.Equal(dec("1.5")))
+	assert.Equal(t, orderModel.StatusPartialCanceled, res.Order.Status)
+
+	_, asks, _ := engine.Depth(0)
+	require.Len(t, asks, 1)
+	assert.True(t, asks[0].Amount.Equal(dec("0.5")))
+}
+
+func TestCancel_RemovesRestingOrder(t *testing.T) {
+	engine := newTestEngine(t)
+
+	_, _ = engine.Submit(limitOrder(1, orderModel.SideBuy, "100", "1"))
+
+	order, found, err := engine.Cancel(1)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, orderModel.StatusCanceled, order.Status)
+
+	_, found, err = engine.Cancel(1)
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	// ไม่มี bid แล้ว market sell ต้องไม่ได้ trade
+	res, err := engine.Submit(marketOrder(2, orderModel.SideSell, "1"))
+	require.NoError(t, err)
+	assert.Empty(t, res.Trades)
+}
+
+func TestSubmit_RejectsWrongSymbolAndStoppedEngine(t *testing.T) {
+	engine := NewEngine(testSymbol, nil)
+
+	order := limitOrder(1, orderModel.SideBuy, "100", "1")
+	order.Symbol = "ETH_THB"
+	_, err := engine.Submit(order)
+	assert.ErrorIs(t, err, ErrSymbolMismatch)
+
+	engine.Stop()
+	_, err = engine.Submit(limitOrder(2, orderModel.SideBuy, "100", "1"))
+	assert.ErrorIs(t, err, ErrEngineStopped)
+}
+
+// order ใหม่ที่จะชนกับ order ของตัวเองถูกยกเลิกส่วนที่เหลือ ส่วนที่ match กับคนอื่นก่อนหน้ายังได้
+func TestSubmit_SelfTradePreventionCancelsTaker(t *testing.T) {
+	engine := newTestEngine(t)
+
+	_, err := engine.Submit(limitOrder(1, orderModel.SideSell, "100", "1"))
+	require.NoError(t, err)
+	own := limitOrder(2, orderModel.SideSell, "101", "1")
+	own.UserID = "trader"
+	_, err = engine.Submit(own)
+	require.NoError(t, err)
+
+	taker := limitOrder(3, orderModel.SideBuy, "105", "2")
+	taker.UserID = "trader"
+	res, err := engine.Submit(taker)
+	require.NoError(t, err)
+
+	require.Len(t, res.Trades, 1)
+	assert.Equal(t, uint64(1), res.Trades[0].MakerOrderID)
+	assert.True(t, res.SelfTradePrevented)
+	assert.False(t, res.Resting)
+	assert.Equal(t, orderModel.StatusPartialCanceled, res.Order.Status)
+
+	// order เดิมของ trader ยังอยู่ ฝั่ง bid ไม่มี order ใหม่ค้าง
+	bids, asks, _ := engine.Depth(0)
+	assert.Empty(t, bids)
+	require.Len(t, asks, 1)
+	assert.True(t, asks[0].Price.Equal(dec("101")))
+}
@@ -0,0 +1,203 @@
+package matching
+
+import (
+	"sort"
+	"time"
+
+	orderModel "github.com/padapook/bestbit-core/internal/order/model"
+	tradeModel "github.com/padapook/bestbit-core/internal/trade/model"
+	"github.com/shopspring/decimal"
+)
+
+// ทศนิยมสูงสุดตาม column decimal(32,16)
+const amountPrecision = 16
+
+type PriceLevel struct {
+	Price  decimal.Decimal `json:"price"`
+	Amount decimal.Decimal `json:"amount"`
+}
+
+type MatchResult struct {
+	// taker หลัง match แล้ว (FilledAmount/Status อัปเดตแล้ว)
+	Order  orderModel.Order
+	Trades []tradeModel.Trade
+	// maker ที่ถูก match ตามลำดับเดียวกับ Trades
+	Makers []orderModel.Order
+	// true ถ้า order ค้างอยู่ใน book หลัง match
+	Resting bool
+	// true ถ้าส่วนที่เหลือถูกยกเลิกเพราะจะ match กับ order ของ user เดียวกัน
+	SelfTradePrevented bool
+}
+
+// OrderBook ไม่ thread-safe ต้องถูกเรียกจาก Engine goroutine เท่านั้น
+// ทุกคำสั่งผ่าน goroutine เดียว ลำดับการเข้า book จึงเป็น time priority
+type OrderBook struct {
+	symbol string
+	bids   []*orderModel.Order // ราคาสูงไปต่ำ แล้วตามลำดับเข้า
+	asks   []*orderModel.Order // ราคาต่ำไปสูง แล้วตามลำดับเข้า
+}
+
+func NewOrderBook(symbol string) *OrderBook {
+	return &OrderBook{symbol: symbol}
+}
+
+func (b *OrderBook) Symbol() string {
+	return b.symbol
+}
+
+// Place จับคู่ order กับฝั่งตรงข้าม ส่วนที่เหลือของ LIMIT จะค้างใน book
+// ส่วนที่เหลือของ MARKET ถูกยกเลิก (PARTIAL_CANCELED ถ้า match ได้บางส่วน)
+// เจอ order ของ user เดียวกันที่ราคาชน ยกเลิกส่วนที่เหลือของ order ใหม่ ไม่ให้เกิด wash trade
+// และไม่ค้างใน book ไม่งั้น book จะมีราคาซื้อขายทับกัน
+func (b *OrderBook) Place(order orderModel.Order, executedAt time.Time) MatchResult {
+	taker := &order
+	result := MatchResult{}
+
+	// market buy ใช้ยอดที่ hold ไว้เป็นงบสูงสุดของฝั่ง quote
+	budget := decimal.Zero
+	useBudget := taker.OrderType == orderModel.OrderTypeMarket && taker.Side == orderModel.SideBuy && taker.LockedAmount.IsPositive()
+	if useBudget {
+		budget = taker.LockedAmount
+	}
+
+	for remaining(taker).IsPositive() {
+		book := b.opposite(taker.Side)
+		if len(*book) == 0 {
+			break
+		}
+
+		maker := (*book)[0]
+		if !crosses(taker, maker.Price) {
+			break
+		}
+		if maker.UserID == taker.UserID {
+			result.SelfTradePrevented = true
+			break
+		}
+
+		qty := decimal.Min(remaining(taker), remaining(maker))
+		if useBudget {
+			affordable := budget.DivRound(maker.Price, amountPrecision+1).Truncate(amountPrecision)
+			qty = decimal.Min(qty, affordable)
+			if !qty.IsPositive() {
+				break
+			}
+			budget = budget.Sub(qty.Mul(maker.Price))
+		}
+
+		fill(taker, qty)
+		fill(maker, qty)
+
+		result.Trades = append(result.Trades, tradeModel.Trade{
+			Symbol:       b.symbol,
+			MakerOrderID: maker.ID,
+			TakerOrderID: taker.ID,
+			MakerUserID:  maker.UserID,
+			TakerUserID:  taker.UserID,
+			TakerSide:    taker.Side,
+			Price:        maker.Price,
+			Amount:       qty,
+			QuoteAmount:  maker.Price.Mul(qty).Round(amountPrecision),
+			ExecutedAt:   executedAt,
+		})
+		result.Makers = append(result.Makers, *maker)
+
+		if !remaining(maker).IsPositive() {
+			*book = (*book)[1:]
+		}
+	}
+
+	if remaining(taker).IsPositive() {
+		if taker.OrderType == orderModel.OrderTypeLimit && !result.SelfTradePrevented {
+			b.insert(taker)
+			result.Resting = true
+		} else if taker.FilledAmount.IsPositive() {
+			taker.Status = orderModel.StatusPartialCanceled
+		} else {
+			taker.Status = orderModel.StatusCanceled
+		}
+	}
+
+	result.Order = *taker
+	return result
+}
+
+// Cancel เอา order ออกจาก book คืน false ถ้าไม่พบ (เช่น match หมดไปแล้ว)
+func (b *OrderBook) Cancel(orderID uint64) (orderModel.Order, bool) {
+	for _, book := range []*[]*orderModel.Order{&b.bids, &b.asks} {
+		for i, order := range *book {
+			if order.ID == orderID {
+				*book = append((*book)[:i], (*book)[i+1:]...)
+				order.Status = orderModel.StatusCanceled
+				return *order, true
+			}
+		}
+	}
+	return orderModel.Order{}, false
+}
+
+// Depth รวมยอดคงเหลือต่อระดับราคา levels <= 0 คือทั้งหมด
+func (b *OrderBook) Depth(levels int) (bids, asks []PriceLevel) {
+	return aggregate(b.bids, levels), aggregate(b.asks, levels)
+}
+
+func (b *OrderBook) opposite(side string) *[]*orderModel.Order {
+	if side == orderModel.SideBuy {
+		return &b.asks
+	}
+	return &b.bids
+}
+
+func (b *OrderBook) insert(order *orderModel.Order) {
+	book := &b.asks
+	worse := func(i int) bool { return (*book)[i].Price.GreaterThan(order.Price) }
+	if order.Side == orderModel.SideBuy {
+		book = &b.bids
+		worse = func(i int) bool { return (*book)[i].Price.LessThan(order.Price) }
+	}
+
+	// ราคาเท่ากันต่อท้ายตัวที่มาก่อน (time priority)
+	idx := sort.Search(len(*book), worse)
+	*book = append(*book, nil)
+	copy((*book)[idx+1:], (*book)[idx:])
+	(*book)[idx] = order
+}
+
+func crosses(taker *orderModel.Order, makerPrice decimal.Decimal) bool {
+	if taker.OrderType == orderModel.OrderTypeMarket {
+		return true
+	}
+	if taker.Side == orderModel.SideBuy {
+		return taker.Price.GreaterThanOrEqual(makerPrice)
+	}
+	return taker.Price.LessThanOrEqual(makerPrice)
+}
+
+func remaining(order *orderModel.Order) decimal.Decimal {
+	return order.Amount.Sub(order.FilledAmount)
+}
+
+func fill(order *orderModel.Order, qty decimal.Decimal) {
+	order.FilledAmount = order.FilledAmount.Add(qty)
+	if remaining(order).IsPositive() {
+		order.Status = orderModel.StatusPartialFilled
+	} else {
+		order.Status = orderModel.StatusFilled
+	}
+}
+
+func aggregate(book []*orderModel.Order, levels int) []PriceLevel {
+	var result []PriceLevel
+	for _, order := range book {
+		last := len(result) - 1
+		if last >= 0 && result[last].Price.Equal(order.Price) {
+			result[last].Amount = result[last].Amount.Add(remaining(order))
+			continue
+		}
+		if levels > 0 && len(result) == levels {
+			break
+		}
+		result = append(result, PriceLevel{Price: order.Price, Amount: remaining(order)})
+	}
+	return result
+}
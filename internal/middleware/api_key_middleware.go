@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/gin-gonic/gin"
+	accountModel "github.com/padapook/bestbit-core/internal/account/model"
+	accountService "github.com/padapook/bestbit-core/internal/account/service"
+	"github.com/padapook/bestbit-core/internal/utils"
+)
+
+const (
+	APIKeyHeader       = "X-API-Key"
+	APITimestampHeader = "X-API-Timestamp"
+	APISignatureHeader = "X-API-Signature"
+)
+
+// APIKeyMiddleware ตรวจ request ที่ sign ด้วย API key แล้วตั้ง account_id/username แบบเดียวกับ AuthMiddleware
+// key ต้องมีสิทธิ์ permission, route ที่ต้องการสิทธิ์เพิ่มใช้ RequireAPIKeyPermission ต่อ
+func APIKeyMiddleware(apiKeys accountService.APIKeyService, permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			utils.HandleError(c, utils.ErrInvalidRequest)
+			c.Abort()
+			return
+		}
+		// handler ถัดไปยังต้องอ่าน body ได้
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		user, key, err := apiKeys.Authenticate(accountService.SignedRequest{
+			KeyID:     c.GetHeader(APIKeyHeader),
+			Timestamp: c.GetHeader(APITimestampHeader),
+			Signature: c.GetHeader(APISignatureHeader),
+			Method:    c.Request.Method,
+			Path:      c.Request.URL.RequestURI(),
+			Body:      body,
+			ClientIP:  c.ClientIP(),
+		})
+		if err != nil {
+			utils.HandleServiceError(c, err)
+			c.Abort()
+			return
+		}
+
+		if !key.HasPermission(permission) {
+			utils.HandleError(c, utils.ErrAPIKeyPermissionDenied)
+			c.Abort()
+			return
+		}
+
+		c.Set("account_id", user.AccountId)
+		c.Set("username", user.Username)
+		c.Set("api_key", key)
+
+		c.Next()
+	}
+}
+
+// มี X-API-Key ใช้ API key, ไม่มีใช้ JWT ตามเดิม
+func AuthOrAPIKey(jwtAuth, apiKeyAuth gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader(APIKeyHeader) != "" {
+			apiKeyAuth(c)
+			return
+		}
+		jwtAuth(c)
+	}
+}
+
+// RequireAPIKeyPermission มีผลเฉพาะ request ที่มาด้วย API key, session JWT ผ่านไปเลย
+func RequireAPIKeyPermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if value, ok := c.Get("api_key"); ok {
+			key, _ := value.(*accountModel.APIKey)
+			if key == nil || !key.HasPermission(permission) {
+				utils.HandleError(c, utils.ErrAPIKeyPermissionDenied)
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
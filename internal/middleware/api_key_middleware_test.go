@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/padapook/bestbit-core/internal/account/model"
+	accountService "github.com/padapook/bestbit-core/internal/account/service"
+	"github.com/padapook/bestbit-core/internal/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeAPIKeyService struct {
+	accountService.APIKeyService
+	key      *model.APIKey
+	err      error
+	received accountService.SignedRequest
+}
+
+func (s *fakeAPIKeyService) Authenticate(req accountService.SignedRequest) (*model.User, *model.APIKey, error) {
+	s.received = req
+	if s.err != nil {
+		return nil, nil, s.err
+	}
+	return &model.User{AccountId: s.key.AccountID, Username: "bot-owner", IsActive: true}, s.key, nil
+}
+
+func newAPIKeyRouter(apiKeys accountService.APIKeyService, jwtCalled *bool) *gin.Engine {
+	jwtAuth := func(c *gin.Context) {
+		*jwtCalled = true
+		c.AbortWithStatus(http.StatusUnauthorized)
+	}
+
+	router := gin.New()
+	group := router.Group("", AuthOrAPIKey(jwtAuth, APIKeyMiddleware(apiKeys, model.APIKeyPermRead)))
+	handler := func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusOK, c.GetString("account_id")+"|"+c.GetString("username")+"|"+string(body))
+	}
+	group.GET("/orders", handler)
+	group.POST("/order", RequireAPIKeyPermission(model.APIKeyPermTrade), handler)
+	return router
+}
+
+func TestAPIKeyMiddleware_SetsAccountContext(t *testing.T) {
+	apiKeys := &fakeAPIKeyService{key: &model.APIKey{AccountID: "acc-1", Permissions: []string{model.APIKeyPermRead, model.APIKeyPermTrade}}}
+	jwtCalled := false
+	router := newAPIKeyRouter(apiKeys, &jwtCalled)
+
+	req := httptest.NewRequest(http.MethodPost, "/order?side=BUY", strings.NewReader(`{"amount":"1"}`))
+	req.Header.Set(APIKeyHeader, "key-1")
+	req.Header.Set(APITimestampHeader, "1700000000")
+	req.Header.Set(APISignatureHeader, "sig")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	// controller ยังอ่าน body เดิมได้
+	assert.Equal(t, `acc-1|bot-owner|{"amount":"1"}`, w.Body.String())
+	assert.False(t, jwtCalled)
+	assert.Equal(t, "/order?side=BUY", apiKeys.received.Path)
+	assert.Equal(t, `{"amount":"1"}`, string(apiKeys.received.Body))
+	assert.Equal(t, "sig", apiKeys.received.Signature)
+}
+
+func TestAPIKeyMiddleware_PermissionDenied(t *testing.T) {
+	jwtCalled := false
+	readOnly := &fakeAPIKeyService{key: &model.APIKey{AccountID: "acc-1", Permissions: []string{model.APIKeyPermRead}}}
+	router := newAPIKeyRouter(readOnly, &jwtCalled)
+
+	req := httptest.NewRequest(http.MethodPost, "/order", strings.NewReader("{}"))
+	req.Header.Set(APIKeyHeader, "key-1")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), utils.ErrAPIKeyPermissionDenied.ErrorCode)
+
+	// key ที่ไม่มีสิทธิ์ขั้นต่ำของ group ก็เข้า GET ไม่ได้
+	tradeOnly := &fakeAPIKeyService{key: &model.APIKey{AccountID: "acc-1", Permissions: []string{model.APIKeyPermTrade}}}
+	router = newAPIKeyRouter(tradeOnly, &jwtCalled)
+	req = httptest.NewRequest(http.MethodGet, "/orders", nil)
+	req.Header.Set(APIKeyHeader, "key-1")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestAPIKeyMiddleware_AuthenticateError(t *testing.T) {
+	jwtCalled := false
+	router := newAPIKeyRouter(&fakeAPIKeyService{err: utils.ErrAPIRequestReplayed}, &jwtCalled)
+
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	req.Header.Set(APIKeyHeader, "key-1")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), utils.ErrAPIRequestReplayed.ErrorCode)
+}
+
+func TestAuthOrAPIKey_FallsBackToJWT(t *testing.T) {
+	jwtCalled := false
+	router := newAPIKeyRouter(&fakeAPIKeyService{}, &jwtCalled)
+
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.True(t, jwtCalled)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
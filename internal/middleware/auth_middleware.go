@@ -1,15 +1,16 @@
 package middleware
 
 import (
-	// "net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	accountRepository "github.com/padapook/bestbit-core/internal/account/repository"
 	"github.com/padapook/bestbit-core/internal/utils"
 	"github.com/padapook/bestbit-core/internal/utils/auth"
 )
 
-func AuthMiddleware() gin.HandlerFunc {
+// ตรวจ user ทุก request เพราะ account ที่ถูก freeze ต้องใช้งานไม่ได้ทันที ไม่รอ token หมดอายุ
+func AuthMiddleware(revocations auth.RevocationStore, users accountRepository.UserRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -35,9 +36,60 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		// token ที่ logout ไปแล้วใช้ต่อไม่ได้
+		revoked, err := revocations.IsRevoked(claims)
+		if err != nil {
+			utils.HandleError(c, utils.ErrInternalServer)
+			c.Abort()
+			return
+		}
+		if revoked {
+			utils.HandleError(c, utils.ErrUnauthorized)
+			c.Abort()
+			return
+		}
+
+		user, err := users.GetByAccountID(claims.AccountID)
+		if err != nil {
+			utils.HandleError(c, utils.ErrUnauthorized)
+			c.Abort()
+			return
+		}
+		if !user.IsActive {
+			utils.HandleError(c, utils.ErrAccountFrozen)
+			c.Abort()
+			return
+		}
+
+		if claims.IsReadOnly() && !readOnlyAllowed(c) {
+			utils.HandleError(c, utils.ErrSessionScopeRestricted)
+			c.Abort()
+			return
+		}
+
 		c.Set("account_id", claims.AccountID)
 		c.Set("username", claims.Username)
+		c.Set("claims", claims)
 
 		c.Next()
 	}
 }
+
+// session read-only (จาก share token) เรียกได้แค่ route ในรายการนี้ ไม่ใช่ทุก GET
+// เพราะ GET บางตัวเปิดเผยของที่ใช้ยึด account ได้ (api key, session, สถานะ MFA) และ route ใหม่ต้องเพิ่มเองถึงจะเปิด
+var readOnlyRoutes = map[string]bool{
+	"GET /api/v1/user/:username":                              true,
+	"GET /api/v1/wallet/":                                     true,
+	"GET /api/v1/wallet/:currency":                            true,
+	"GET /api/v1/wallet/:currency/transactions":               true,
+	"GET /api/v1/wallet/:currency/transactions/:reference_id": true,
+	"GET /api/v1/order/:id":                                   true,
+	"GET /api/v1/orders":                                      true,
+	"GET /api/v1/withdrawals":                                 true,
+	"GET /api/v1/withdrawals/:id":                             true,
+	"POST /api/v1/user/logout":                                true,
+}
+
+func readOnlyAllowed(c *gin.Context) bool {
+	return readOnlyRoutes[c.Request.Method+" "+c.FullPath()]
+}
@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/padapook/bestbit-core/internal/account/model"
+	"github.com/padapook/bestbit-core/internal/account/repository"
+	"github.com/padapook/bestbit-core/internal/utils/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type fakeUserRepository struct {
+	repository.UserRepository
+	users map[string]*model.User
+}
+
+func (r *fakeUserRepository) GetByAccountID(accountID string) (*model.User, error) {
+	user, ok := r.users[accountID]
+	if !ok {
+		return &model.User{}, gorm.ErrRecordNotFound
+	}
+	return user, nil
+}
+
+func performAuthRequest(t *testing.T, user *model.User, users *fakeUserRepository) *httptest.ResponseRecorder {
+	t.Setenv("JWT_SECRET_KEY", "test-secret")
+
+	tokens, err := auth.GenerateTokens(user)
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.GET("/me", AuthMiddleware(auth.NewMemoryRevocationStore(), users), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("account_id"))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAuthMiddleware_ActiveUser(t *testing.T) {
+	user := &model.User{AccountId: "acc-1", Username: "pook", IsActive: true}
+	users := &fakeUserRepository{users: map[string]*model.User{"acc-1": user}}
+
+	w := performAuthRequest(t, user, users)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "acc-1", w.Body.String())
+}
+
+func TestAuthMiddleware_Fail_FrozenUser(t *testing.T) {
+	user := &model.User{AccountId: "acc-1", Username: "pook", IsActive: false}
+	users := &fakeUserRepository{users: map[string]*model.User{"acc-1": user}}
+
+	w := performAuthRequest(t, user, users)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "ERR_4034")
+}
+
+func TestAuthMiddleware_Fail_DeletedUser(t *testing.T) {
+	user := &model.User{AccountId: "acc-1", Username: "pook", IsActive: true}
+
+	w := performAuthRequest(t, user, &fakeUserRepository{users: map[string]*model.User{}})
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestAuthMiddleware_ReadOnlySession(t *testing.T) {
+	t.Setenv("JWT_SECRET_KEY", "test-secret")
+	user := &model.User{AccountId: "acc-1", Username: "pook", IsActive: true}
+	users := &fakeUserRepository{users: map[string]*model.User{"acc-1": user}}
+
+	tokens, err := auth.GenerateSessionTokens(user, "", auth.ScopeReadOnly)
+	require.NoError(t, err)
+
+	router := gin.New()
+	api := router.Group("/api/v1", AuthMiddleware(auth.NewMemoryRevocationStore(), users))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	api.GET("/wallet/", ok)
+	api.POST("/wallet/transfer", ok)
+	api.POST("/wallet/withdraw", ok)
+	api.POST("/user/logout", ok)
+	api.GET("/user/api-keys", ok)
+	api.GET("/user/sessions", ok)
+	api.GET("/user/mfa", ok)
+	api.GET("/admin/users", ok)
+
+	tests := []struct {
+		method   string
+		path     string
+		expected int
+	}{
+		{http.MethodGet, "/api/v1/wallet/", http.StatusOK},
+		{http.MethodPost, "/api/v1/wallet/transfer", http.StatusForbidden},
+		{http.MethodPost, "/api/v1/wallet/withdraw", http.StatusForbidden},
+		{http.MethodPost, "/api/v1/user/logout", http.StatusOK},
+		{http.MethodGet, "/api/v1/user/api-keys", http.StatusForbidden},
+		{http.MethodGet, "/api/v1/user/sessions", http.StatusForbidden},
+		{http.MethodGet, "/api/v1/user/mfa", http.StatusForbidden},
+		{http.MethodGet, "/api/v1/admin/users", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		req.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, tt.expected, w.Code, tt.method+" "+tt.path)
+	}
+}
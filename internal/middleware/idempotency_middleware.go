@@ -0,0 +1,181 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/padapook/bestbit-core/internal/idempotency/model"
+	"github.com/padapook/bestbit-core/internal/idempotency/repository"
+	"github.com/padapook/bestbit-core/internal/utils"
+)
+
+const (
+	IdempotencyHeader = "Idempotency-Key"
+	// key ที่ค้าง IN_PROGRESS นานกว่านี้ถือว่า request เดิมตายไปแล้ว
+	idempotencyLockTimeout = 1 * time.Minute
+	maxIdempotencyKeyLen   = 255
+)
+
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// ต้องวางหลัง AuthMiddleware เพราะ key แยกตาม account_id
+// ไม่ส่ง header มาก็ทำงานตามปกติ, handler อ่าน key ได้จาก c.GetString("idempotency_key")
+func IdempotencyMiddleware(repo repository.IdempotencyRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		if !isValidIdempotencyKey(key) {
+			utils.HandleError(c, utils.ErrInvalidIdempotencyKey)
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			utils.HandleError(c, utils.ErrInvalidRequest)
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		record := &model.IdempotencyKey{
+			AccountID:   c.GetString("account_id"),
+			Key:         key,
+			Method:      c.Request.Method,
+			Path:        c.Request.URL.Path,
+			RequestHash: hashRequest(c.Request.Method, c.Request.URL.Path, body),
+			Status:      model.StatusInProgress,
+		}
+
+		existing, err := reserveIdempotencyKey(repo, record)
+		if err != nil {
+			utils.HandleError(c, utils.ErrInternalServer)
+			c.Abort()
+			return
+		}
+
+		if existing != nil {
+			switch {
+			case existing.RequestHash != record.RequestHash:
+				utils.HandleError(c, utils.ErrIdempotencyKeyConflict)
+			case existing.Status != model.StatusCompleted:
+				utils.HandleError(c, utils.ErrIdempotencyKeyInProgress)
+			default:
+				c.Header("Idempotent-Replayed", "true")
+				c.Data(existing.StatusCode, existing.ContentType, existing.ResponseBody)
+			}
+			c.Abort()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Set("idempotency_key", key)
+
+		completed := false
+		defer func() {
+			if completed {
+				return
+			}
+			// 5xx หรือ panic ให้ client retry ด้วย key เดิมได้
+			if err := repo.Release(record); err != nil {
+				log.Println("[idempotency] release key failed:", err)
+			}
+		}()
+
+		c.Next()
+
+		if recorder.Status() >= http.StatusInternalServerError {
+			return
+		}
+
+		record.StatusCode = recorder.Status()
+		record.ContentType = recorder.Header().Get("Content-Type")
+		record.ResponseBody = recorder.body.Bytes()
+		if err := repo.Complete(record); err != nil {
+			log.Println("[idempotency] save response failed:", err)
+			return
+		}
+		completed = true
+	}
+}
+
+// คืน record เดิมถ้า key ถูกใช้ไปแล้ว, คืน nil ถ้าจองสำเร็จ
+func reserveIdempotencyKey(repo repository.IdempotencyRepository, record *model.IdempotencyKey) (*model.IdempotencyKey, error) {
+	for attempt := 0; attempt < 2; attempt++ {
+		reserved, err := repo.Reserve(record)
+		if err != nil {
+			return nil, err
+		}
+		if reserved {
+			return nil, nil
+		}
+
+		existing, err := repo.Get(record.AccountID, record.Key)
+		if err != nil {
+			return nil, err
+		}
+		if existing == nil {
+			// ถูกปล่อยระหว่างทาง ลองจองใหม่
+			continue
+		}
+
+		if existing.Status == model.StatusInProgress {
+			released, err := repo.ReleaseStale(existing, time.Now().Add(-idempotencyLockTimeout))
+			if err != nil {
+				return nil, err
+			}
+			if released {
+				continue
+			}
+		}
+		return existing, nil
+	}
+
+	return &model.IdempotencyKey{RequestHash: record.RequestHash, Status: model.StatusInProgress}, nil
+}
+
+func hashRequest(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method))
+	h.Write([]byte("\n"))
+	h.Write([]byte(path))
+	h.Write([]byte("\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func isValidIdempotencyKey(key string) bool {
+	if len(key) > maxIdempotencyKeyLen {
+		return false
+	}
+	for _, r := range key {
+		if r < 0x21 || r > 0x7e {
+			return false
+		}
+	}
+	return true
+}
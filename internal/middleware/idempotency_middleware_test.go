@@ -0,0 +1,179 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/padapook/bestbit-core/internal/idempotency/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// เก็บใน map แทน postgres
+type fakeIdempotencyRepository struct {
+	mu      sync.Mutex
+	records map[string]*model.IdempotencyKey
+	nextID  uint64
+}
+
+func newFakeIdempotencyRepository() *fakeIdempotencyRepository {
+	return &fakeIdempotencyRepository{records: make(map[string]*model.IdempotencyKey)}
+}
+
+func (r *fakeIdempotencyRepository) Reserve(record *model.IdempotencyKey) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	k := record.AccountID + "|" + record.Key
+	if _, ok := r.records[k]; ok {
+		return false, nil
+	}
+	r.nextID++
+	record.ID = r.nextID
+	record.UpdatedAt = time.Now()
+	copied := *record
+	r.records[k] = &copied
+	return true, nil
+}
+
+func (r *fakeIdempotencyRepository) Get(accountID, key string) (*model.IdempotencyKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	record, ok := r.records[accountID+"|"+key]
+	if !ok {
+		return nil, nil
+	}
+	copied := *record
+	return &copied, nil
+}
+
+func (r *fakeIdempotencyRepository) Complete(record *model.IdempotencyKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	record.Status = model.StatusCompleted
+	copied := *record
+	r.records[record.AccountID+"|"+record.Key] = &copied
+	return nil
+}
+
+func (r *fakeIdempotencyRepository) Release(record *model.IdempotencyKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.records, record.AccountID+"|"+record.Key)
+	return nil
+}
+
+func (r *fakeIdempotencyRepository) ReleaseStale(record *model.IdempotencyKey, staleBefore time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	k := record.AccountID + "|" + record.Key
+	current, ok := r.records[k]
+	if !ok || current.Status != model.StatusInProgress || !current.UpdatedAt.Before(staleBefore) {
+		return false, nil
+	}
+	delete(r.records, k)
+	return true, nil
+}
+
+func newIdempotencyTestRouter(repo *fakeIdempotencyRepository, calls *int, status int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/deposit", func(c *gin.Context) {
+		c.Set("account_id", "acc-1")
+	}, IdempotencyMiddleware(repo), func(c *gin.Context) {
+		*calls++
+		c.JSON(status, gin.H{"call": *calls, "reference_id": c.GetString("idempotency_key")})
+	})
+	return r
+}
+
+func doIdempotentRequest(r *gin.Engine, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/deposit", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(IdempotencyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotency_ReplaysStoredResponse(t *testing.T) {
+	repo := newFakeIdempotencyRepository()
+	calls := 0
+	r := newIdempotencyTestRouter(repo, &calls, http.StatusOK)
+
+	first := doIdempotentRequest(r, "key-1", `{"amount":"100"}`)
+	second := doIdempotentRequest(r, "key-1", `{"amount":"100"}`)
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusOK, second.Code)
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Contains(t, first.Body.String(), `"reference_id":"key-1"`)
+	assert.Equal(t, "true", second.Header().Get("Idempotent-Replayed"))
+}
+
+func TestIdempotency_Fail_KeyReusedWithDifferentBody(t *testing.T) {
+	repo := newFakeIdempotencyRepository()
+	calls := 0
+	r := newIdempotencyTestRouter(repo, &calls, http.StatusOK)
+
+	doIdempotentRequest(r, "key-1", `{"amount":"100"}`)
+	second := doIdempotentRequest(r, "key-1", `{"amount":"200"}`)
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusConflict, second.Code)
+	assert.Contains(t, second.Body.String(), "ERR_4095")
+}
+
+func TestIdempotency_Fail_InProgress(t *testing.T) {
+	repo := newFakeIdempotencyRepository()
+	calls := 0
+	r := newIdempotencyTestRouter(repo, &calls, http.StatusOK)
+
+	// จำลอง request แรกที่ยังทำงานไม่เสร็จ
+	pending := &model.IdempotencyKey{
+		AccountID:   "acc-1",
+		Key:         "key-1",
+		RequestHash: hashRequest(http.MethodPost, "/deposit", []byte(`{"amount":"100"}`)),
+		Status:      model.StatusInProgress,
+	}
+	_, _ = repo.Reserve(pending)
+
+	w := doIdempotentRequest(r, "key-1", `{"amount":"100"}`)
+
+	assert.Equal(t, 0, calls)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "ERR_4096")
+}
+
+func TestIdempotency_ServerErrorReleasesKey(t *testing.T) {
+	repo := newFakeIdempotencyRepository()
+	calls := 0
+	r := newIdempotencyTestRouter(repo, &calls, http.StatusInternalServerError)
+
+	doIdempotentRequest(r, "key-1", `{"amount":"100"}`)
+	doIdempotentRequest(r, "key-1", `{"amount":"100"}`)
+
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotency_NoHeaderPassesThrough(t *testing.T) {
+	repo := newFakeIdempotencyRepository()
+	calls := 0
+	r := newIdempotencyTestRouter(repo, &calls, http.StatusOK)
+
+	doIdempotentRequest(r, "", `{"amount":"100"}`)
+	doIdempotentRequest(r, "", `{"amount":"100"}`)
+
+	assert.Equal(t, 2, calls)
+	assert.Empty(t, repo.records)
+}
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	accountService "github.com/padapook/bestbit-core/internal/account/service"
+	"github.com/padapook/bestbit-core/internal/utils"
+)
+
+const MFACodeHeader = "X-MFA-Code"
+
+// RequireMFA ต้องใช้ต่อจาก AuthMiddleware และวางก่อน IdempotencyMiddleware
+// ไม่งั้นคำตอบ code ผิดจะถูกเก็บไว้ตอบซ้ำทั้งที่ retry ด้วย code ใหม่
+// user ที่ไม่ได้เปิด MFA ผ่านไปเลย
+func RequireMFA(mfaService accountService.MFAService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := mfaService.VerifyFreshCode(c.GetString("account_id"), c.GetHeader(MFACodeHeader)); err != nil {
+			utils.HandleServiceError(c, err)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
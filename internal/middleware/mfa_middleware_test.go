@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	accountService "github.com/padapook/bestbit-core/internal/account/service"
+	"github.com/padapook/bestbit-core/internal/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// รับ code "123456" เฉพาะ acc-mfa, account อื่นถือว่าไม่ได้เปิด MFA
+type fakeMFAService struct {
+	accountService.MFAService
+}
+
+func (s *fakeMFAService) VerifyFreshCode(accountID, code string) error {
+	if accountID != "acc-mfa" {
+		return nil
+	}
+	if code == "" {
+		return utils.ErrMFARequired
+	}
+	if code != "123456" {
+		return utils.ErrInvalidMFACode
+	}
+	return nil
+}
+
+func performMFARequest(accountID, code string) *httptest.ResponseRecorder {
+	router := gin.New()
+	router.POST("/wallet/transfer", func(c *gin.Context) {
+		c.Set("account_id", accountID)
+		c.Next()
+	}, RequireMFA(&fakeMFAService{}), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/wallet/transfer", nil)
+	if code != "" {
+		req.Header.Set(MFACodeHeader, code)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRequireMFA(t *testing.T) {
+	tests := []struct {
+		name      string
+		accountID string
+		code      string
+		expected  int
+	}{
+		{"mfa not enabled", "acc-1", "", http.StatusOK},
+		{"valid code", "acc-mfa", "123456", http.StatusOK},
+		{"missing code", "acc-mfa", "", http.StatusUnauthorized},
+		{"wrong code", "acc-mfa", "000000", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := performMFARequest(tt.accountID, tt.code)
+			assert.Equal(t, tt.expected, w.Code)
+		})
+	}
+}
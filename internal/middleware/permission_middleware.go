@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/padapook/bestbit-core/internal/utils"
+	"github.com/padapook/bestbit-core/internal/utils/auth"
+)
+
+// RequirePermission ต้องใช้ต่อจาก AuthMiddleware
+// ต้องมีครบทุกสิทธิ์ที่ระบุ อ่านจาก permissions ใน claim ของ access token
+func RequirePermission(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, _ := c.Get("claims")
+		claims, ok := value.(*auth.Claims)
+		if !ok {
+			utils.HandleError(c, utils.ErrUnauthorized)
+			c.Abort()
+			return
+		}
+
+		for _, permission := range permissions {
+			if !claims.HasPermission(permission) {
+				utils.HandleError(c, utils.ErrForbidden)
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/padapook/bestbit-core/internal/utils/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+func performPermissionRequest(claims *auth.Claims, permissions ...string) *httptest.ResponseRecorder {
+	router := gin.New()
+	router.GET("/admin", func(c *gin.Context) {
+		if claims != nil {
+			c.Set("claims", claims)
+		}
+		c.Next()
+	}, RequirePermission(permissions...), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin", nil))
+	return w
+}
+
+func TestRequirePermission(t *testing.T) {
+	staff := &auth.Claims{AccountID: "acc-1", Permissions: []string{"users:read", "wallets:read"}}
+
+	tests := []struct {
+		name        string
+		claims      *auth.Claims
+		permissions []string
+		expected    int
+	}{
+		{"has permission", staff, []string{"users:read"}, http.StatusOK},
+		{"has all permissions", staff, []string{"users:read", "wallets:read"}, http.StatusOK},
+		{"missing one permission", staff, []string{"users:read", "wallets:adjust"}, http.StatusForbidden},
+		{"no permissions", &auth.Claims{AccountID: "acc-2"}, []string{"users:read"}, http.StatusForbidden},
+		{"not authenticated", nil, []string{"users:read"}, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := performPermissionRequest(tt.claims, tt.permissions...)
+			assert.Equal(t, tt.expected, w.Code)
+		})
+	}
+}
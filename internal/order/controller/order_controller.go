@@ -0,0 +1,141 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/padapook/bestbit-core/internal/order/model"
+	"github.com/padapook/bestbit-core/internal/order/repository"
+	"github.com/padapook/bestbit-core/internal/order/service"
+	"github.com/padapook/bestbit-core/internal/utils"
+	"github.com/shopspring/decimal"
+)
+
+type OrderController interface {
+	PlaceOrder(c *gin.Context)
+	CancelOrder(c *gin.Context)
+	GetOrder(c *gin.Context)
+	GetOrders(c *gin.Context)
+}
+
+type orderController struct {
+	orderService service.OrderService
+}
+
+func NewOrderController(orderService service.OrderService) OrderController {
+	return &orderController{orderService: orderService}
+}
+
+type PlaceOrderRequest struct {
+	Symbol    string          `json:"symbol" binding:"required"`
+	Side      string          `json:"side" binding:"required"`
+	OrderType string          `json:"order_type" binding:"required"`
+	Price     decimal.Decimal `json:"price"`
+	Amount    decimal.Decimal `json:"amount" binding:"required"`
+}
+
+func (ctrl *orderController) PlaceOrder(c *gin.Context) {
+	accountID, exists := c.Get("account_id")
+	if !exists {
+		utils.HandleError(c, utils.ErrUnauthorized)
+		return
+	}
+
+	var req PlaceOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleError(c, utils.ErrInvalidRequest)
+		return
+	}
+
+	order, err := ctrl.orderService.PlaceOrder(model.Order{
+		UserID:    accountID.(string),
+		Symbol:    req.Symbol,
+		Side:      req.Side,
+		OrderType: req.OrderType,
+		Price:     req.Price,
+		Amount:    req.Amount,
+	})
+	if err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Order placed successfully",
+		"data":    order,
+	})
+}
+
+func (ctrl *orderController) CancelOrder(c *gin.Context) {
+	accountID, exists := c.Get("account_id")
+	if !exists {
+		utils.HandleError(c, utils.ErrUnauthorized)
+		return
+	}
+
+	orderID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.HandleError(c, utils.ErrInvalidRequest)
+		return
+	}
+
+	order, err := ctrl.orderService.CancelOrder(accountID.(string), orderID)
+	if err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Order canceled successfully",
+		"data":    order,
+	})
+}
+
+func (ctrl *orderController) GetOrder(c *gin.Context) {
+	accountID, exists := c.Get("account_id")
+	if !exists {
+		utils.HandleError(c, utils.ErrUnauthorized)
+		return
+	}
+
+	orderID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.HandleError(c, utils.ErrInvalidRequest)
+		return
+	}
+
+	order, err := ctrl.orderService.GetOrder(accountID.(string), orderID)
+	if err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": order,
+	})
+}
+
+func (ctrl *orderController) GetOrders(c *gin.Context) {
+	accountID, exists := c.Get("account_id")
+	if !exists {
+		utils.HandleError(c, utils.ErrUnauthorized)
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	orders, err := ctrl.orderService.GetUserOrders(accountID.(string), repository.OrderFilter{
+		Symbol: c.Query("symbol"),
+		Status: c.Query("status"),
+		Limit:  limit,
+	})
+	if err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": orders,
+	})
+}
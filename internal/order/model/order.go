@@ -1,20 +1,87 @@
 package model
 
 import (
-	"github.com/shopspring/decimal"
+	"fmt"
+	"strings"
 	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	SideBuy  = "BUY"
+	SideSell = "SELL"
+
+	OrderTypeLimit  = "LIMIT"
+	OrderTypeMarket = "MARKET"
+
+	StatusPending       = "PENDING"
+	StatusPartialFilled = "PARTIAL_FILLED"
+	StatusFilled        = "FILLED"
+	StatusCanceled      = "CANCELED"
+	// market ที่ match ได้บางส่วนแล้วสภาพคล่องหมด ส่วนที่เหลือถูกยกเลิกแต่ยังมี fill อยู่
+	StatusPartialCanceled = "PARTIAL_CANCELED"
 )
 
 type Order struct {
 	ID           uint64          `gorm:"primaryKey" json:"id"`
-	UserID       uint64          `gorm:"index" json:"userId"`
+	UserID       string          `gorm:"size:100;index" json:"userId"`
 	Symbol       string          `gorm:"size:20;index" json:"symbol"`
 	Side         string          `gorm:"size:10;index" json:"side" comment:"BUY, SELL"`
 	OrderType    string          `gorm:"size:10" json:"order_type" comment:"LIMIT, MARKET"`
-	Status       string          `gorm:"size:20;index" json:"status" comment:"PENDING, PARTIAL_FILLED, FILLED, CANCELED"`
+	Status       string          `gorm:"size:20;index" json:"status" comment:"PENDING, PARTIAL_FILLED, FILLED, CANCELED, PARTIAL_CANCELED"`
 	Price        decimal.Decimal `gorm:"type:decimal(32,16)" json:"price"`
 	Amount       decimal.Decimal `gorm:"type:decimal(32,16)" json:"amount"`
 	FilledAmount decimal.Decimal `gorm:"type:decimal(32,16);default:0" json:"filled_amount" comment:""`
+	LockedAmount decimal.Decimal `gorm:"type:decimal(32,16);default:0" json:"locked_amount" comment:"ยอดที่ยัง hold อยู่ใน wallet ของ HoldCurrency"`
 	CreatedAt    time.Time       `gorm:"index" json:"created_at"`
 	UpdatedAt    time.Time       `json:"updated_at"`
 }
+
+func IsValidSide(side string) bool {
+	return side == SideBuy || side == SideSell
+}
+
+func IsValidOrderType(orderType string) bool {
+	return orderType == OrderTypeLimit || orderType == OrderTypeMarket
+}
+
+func IsValidStatus(status string) bool {
+	switch status {
+	case StatusPending, StatusPartialFilled, StatusFilled, StatusCanceled, StatusPartialCanceled:
+		return true
+	}
+	return false
+}
+
+// FILLED/CANCELED/PARTIAL_CANCELED ไม่มีการ match เพิ่มอีกแล้ว
+func (o *Order) IsTerminal() bool {
+	return o.Status == StatusFilled || o.Status == StatusCanceled || o.Status == StatusPartialCanceled
+}
+
+// ยกเลิกได้เฉพาะ order ที่ยังเปิดอยู่
+func (o *Order) IsCancelable() bool {
+	return o.Status == StatusPending || o.Status == StatusPartialFilled
+}
+
+// symbol อยู่ในรูป BASE_QUOTE เช่น BTC_THB
+func SplitSymbol(symbol string) (base, quote string, ok bool) {
+	parts := strings.Split(symbol, "_")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" || parts[0] == parts[1] {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// BUY hold เงินฝั่ง quote, SELL hold เหรียญฝั่ง base
+func (o *Order) HoldCurrency() string {
+	base, quote, _ := SplitSymbol(o.Symbol)
+	if o.Side == SideBuy {
+		return quote
+	}
+	return base
+}
+
+func (o *Order) HoldReference() string {
+	return fmt.Sprintf("ORDER-%d", o.ID)
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/padapook/bestbit-core/internal/order/model"
+	"github.com/padapook/bestbit-core/internal/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OrderFilter struct {
+	Symbol string
+	Status string
+	Limit  int
+}
+
+type OrderRepository interface {
+	// service ใช้รวม order กับการ hold เงินใน wallet ให้อยู่ใน transaction เดียว
+	Transaction(fn func(tx *gorm.DB) error) error
+	CreateOrder(tx *gorm.DB, order *model.Order) error
+	UpdateOrder(tx *gorm.DB, order *model.Order) error
+	GetOrderForUpdate(tx *gorm.DB, userID string, orderID uint64) (*model.Order, error)
+	GetOrderByIDForUpdate(tx *gorm.DB, orderID uint64) (*model.Order, error)
+	GetOpenLimitOrders() ([]model.Order, error)
+	GetOpenOrdersByUserID(userID string) ([]model.Order, error)
+	GetOrderByID(userID string, orderID uint64) (*model.Order, error)
+	GetOrdersByUserID(userID string, filter OrderFilter) ([]model.Order, error)
+}
+
+type orderRepository struct {
+	db *gorm.DB
+}
+
+func NewOrderRepository(db *gorm.DB) OrderRepository {
+	return &orderRepository{db: db}
+}
+
+func (r *orderRepository) Transaction(fn func(tx *gorm.DB) error) error {
+	return r.db.Transaction(fn)
+}
+
+func (r *orderRepository) CreateOrder(tx *gorm.DB, order *model.Order) error {
+	return tx.Create(order).Error
+}
+
+func (r *orderRepository) UpdateOrder(tx *gorm.DB, order *model.Order) error {
+	return tx.Save(order).Error
+}
+
+func (r *orderRepository) GetOrderForUpdate(tx *gorm.DB, userID string, orderID uint64) (*model.Order, error) {
+	var order model.Order
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND user_id = ?", orderID, userID).
+		First(&order).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.ErrOrderNotFound
+		}
+		return nil, err
+	}
+	return &order, nil
+}
+
+// ใช้ภายในระบบ (matching/settlement) ที่ไม่ได้ผูกกับ user ที่ login
+func (r *orderRepository) GetOrderByIDForUpdate(tx *gorm.DB, orderID uint64) (*model.Order, error) {
+	var order model.Order
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", orderID).
+		First(&order).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.ErrOrderNotFound
+		}
+		return nil, err
+	}
+	return &order, nil
+}
+
+// order ที่ต้องใส่กลับเข้า book ตอน start server เรียงตามเวลาเพื่อรักษา time priority
+func (r *orderRepository) GetOpenLimitOrders() ([]model.Order, error) {
+	var orders []model.Order
+	err := r.db.Where("order_type = ? AND status IN ?", model.OrderTypeLimit, []string{model.StatusPending, model.StatusPartialFilled}).
+		Order("created_at ASC, id ASC").
+		Find(&orders).Error
+	return orders, err
+}
+
+func (r *orderRepository) GetOpenOrdersByUserID(userID string) ([]model.Order, error) {
+	var orders []model.Order
+	err := r.db.Where("user_id = ? AND status IN ?", userID, []string{model.StatusPending, model.StatusPartialFilled}).
+		Order("id ASC").
+		Find(&orders).Error
+	return orders, err
+}
+
+func (r *orderRepository) GetOrderByID(userID string, orderID uint64) (*model.Order, error) {
+	var order model.Order
+	err := r.db.Where("id = ? AND user_id = ?", orderID, userID).First(&order).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.ErrOrderNotFound
+		}
+		return nil, err
+	}
+	return &order, nil
+}
+
+func (r *orderRepository) GetOrdersByUserID(userID string, filter OrderFilter) ([]model.Order, error) {
+	var orders []model.Order
+
+	query := r.db.Where("user_id = ?", userID)
+	if filter.Symbol != "" {
+		query = query.Where("symbol = ?", filter.Symbol)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	err := query.Order("created_at DESC, id DESC").Find(&orders).Error
+	return orders, err
+}
@@ -0,0 +1,405 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+
+	currencyService "github.com/padapook/bestbit-core/internal/currency/service"
+	marketRepository "github.com/padapook/bestbit-core/internal/market/repository"
+	marketService "github.com/padapook/bestbit-core/internal/market/service"
+	"github.com/padapook/bestbit-core/internal/matching"
+	"github.com/padapook/bestbit-core/internal/order/model"
+	"github.com/padapook/bestbit-core/internal/order/repository"
+	tradeModel "github.com/padapook/bestbit-core/internal/trade/model"
+	tradeRepository "github.com/padapook/bestbit-core/internal/trade/repository"
+	tradeService "github.com/padapook/bestbit-core/internal/trade/service"
+	"github.com/padapook/bestbit-core/internal/utils"
+	walletRepository "github.com/padapook/bestbit-core/internal/wallet/repository"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultOrderListLimit = 50
+	maxOrderListLimit     = 200
+)
+
+type OrderService interface {
+	PlaceOrder(order model.Order) (*model.Order, error)
+	CancelOrder(userID string, orderID uint64) (*model.Order, error)
+	// ยกเลิก order ที่เปิดอยู่ของ user, currency ไม่ว่าง = เฉพาะตลาดที่มีสกุลนั้น คืนจำนวนที่ยกเลิก
+	CancelOpenOrders(userID, currency string) (int, error)
+	GetOrder(userID string, orderID uint64) (*model.Order, error)
+	GetUserOrders(userID string, filter repository.OrderFilter) ([]model.Order, error)
+	RestoreOrderBooks() error
+}
+
+type orderService struct {
+	repo       repository.OrderRepository
+	walletRepo walletRepository.WalletRepository
+	tradeRepo  tradeRepository.TradeRepository
+	settlement tradeService.SettlementService
+	marketRepo marketRepository.MarketRepository
+	currencies currencyService.CurrencyService
+	engines    *matching.Manager
+
+	// match -> บันทึก DB -> settle ของ symbol เดียวกันต้องทำทีละคำสั่ง
+	// ไม่งั้นผลที่บันทึกลง DB อาจสลับลำดับกับใน book
+	symbolMu    sync.Mutex
+	symbolLocks map[string]*sync.Mutex
+}
+
+func NewOrderService(
+	repo repository.OrderRepository,
+	walletRepo walletRepository.WalletRepository,
+	tradeRepo tradeRepository.TradeRepository,
+	settlement tradeService.SettlementService,
+	marketRepo marketRepository.MarketRepository,
+	currencies currencyService.CurrencyService,
+	engines *matching.Manager,
+) OrderService {
+	return &orderService{
+		repo:        repo,
+		walletRepo:  walletRepo,
+		tradeRepo:   tradeRepo,
+		settlement:  settlement,
+		marketRepo:  marketRepo,
+		currencies:  currencies,
+		engines:     engines,
+		symbolLocks: make(map[string]*sync.Mutex),
+	}
+}
+
+func (s *orderService) PlaceOrder(order model.Order) (*model.Order, error) {
+	order.Symbol = strings.ToUpper(strings.TrimSpace(order.Symbol))
+	order.Side = strings.ToUpper(order.Side)
+	order.OrderType = strings.ToUpper(order.OrderType)
+
+	if _, _, ok := model.SplitSymbol(order.Symbol); !ok {
+		return nil, utils.ErrInvalidSymbol
+	}
+	if !model.IsValidSide(order.Side) {
+		return nil, utils.ErrInvalidOrderSide
+	}
+	if !model.IsValidOrderType(order.OrderType) {
+		return nil, utils.ErrInvalidOrderType
+	}
+	if order.Amount.LessThanOrEqual(decimal.Zero) {
+		return nil, utils.ErrInvalidOrderAmount
+	}
+
+	switch order.OrderType {
+	case model.OrderTypeLimit:
+		if order.Price.LessThanOrEqual(decimal.Zero) {
+			return nil, utils.ErrInvalidOrderPrice
+		}
+	case model.OrderTypeMarket:
+		// market order จับคู่ตามราคาใน book ไม่รับราคาจาก client
+		if !order.Price.IsZero() {
+			return nil, utils.ErrInvalidOrderPrice
+		}
+	}
+
+	market, err := s.marketRepo.GetBySymbol(order.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	referencePrice := order.Price
+	if order.OrderType == model.OrderTypeMarket {
+		if referencePrice, err = s.bestOppositePrice(order.Symbol, order.Side); err != nil {
+			return nil, err
+		}
+	}
+	if err := marketService.ValidateOrder(market, order.Price, order.Amount, referencePrice); err != nil {
+		return nil, err
+	}
+	base, quote := market.BaseCurrency, market.QuoteCurrency
+	// ปิดสกุลเงินแล้วตลาดที่ใช้สกุลนั้นห้ามรับ order ใหม่ แม้ market ยัง TRADING
+	for _, code := range []string{base, quote} {
+		if _, err := s.currencies.Validate(code, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	order.ID = 0
+	order.Status = model.StatusPending
+	order.FilledAmount = decimal.Zero
+
+	err = s.repo.Transaction(func(tx *gorm.DB) error {
+		// สกุลที่จะได้รับตอน match อาจยังไม่มี wallet สร้างไว้ก่อนให้ settlement ลงได้
+		receiveCurrency := quote
+		if order.Side == model.SideBuy {
+			receiveCurrency = base
+		}
+		if err := s.walletRepo.EnsureWallet(tx, order.UserID, receiveCurrency); err != nil {
+			return err
+		}
+
+		holdCurrency, holdAmount := base, order.Amount
+		if order.Side == model.SideBuy {
+			holdCurrency = quote
+			holdAmount = order.Price.Mul(order.Amount)
+
+			if order.OrderType == model.OrderTypeMarket {
+				// market buy ไม่รู้ราคาล่วงหน้า hold ยอด available ทั้งหมดไว้เป็นงบ ส่วนที่เหลือคืนตอน order จบ
+				wallet, err := s.walletRepo.GetWalletForUpdate(tx, order.UserID, quote)
+				if err != nil {
+					return err
+				}
+				holdAmount = wallet.AvailableBalance()
+				if holdAmount.LessThanOrEqual(decimal.Zero) {
+					return utils.ErrInsufficientBalance
+				}
+			}
+		}
+
+		order.LockedAmount = holdAmount
+		if err := s.repo.CreateOrder(tx, &order); err != nil {
+			return err
+		}
+
+		_, err := s.walletRepo.HoldFunds(tx, order.UserID, holdCurrency, holdAmount, order.HoldReference())
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	unlock := s.lockSymbol(order.Symbol)
+	defer unlock()
+
+	return s.match(order)
+}
+
+func (s *orderService) CancelOrder(userID string, orderID uint64) (*model.Order, error) {
+	existing, err := s.repo.GetOrderByID(userID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if !existing.IsCancelable() {
+		return nil, utils.ErrOrderNotCancelable
+	}
+
+	// ถือ lock ของ symbol ไว้ ระหว่างนี้ไม่มีการ match จึงเปลี่ยนสถานะใน DB ก่อนได้
+	// DB พังแล้ว order ยังอยู่ใน book ตามเดิม ไม่หลุดจาก book ทั้งที่ยังเปิดอยู่
+	unlock := s.lockSymbol(existing.Symbol)
+	defer unlock()
+
+	order, err := s.cancelInDB(userID, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	// engine หยุดอยู่ = ไม่มีการ match แล้ว ตอน restore ก็ไม่ใส่ order ที่ยกเลิกกลับ
+	if _, _, err := s.engines.Engine(existing.Symbol).Cancel(orderID); err != nil {
+		log.Println("[matching] cancel order", orderID, "failed:", err)
+	}
+
+	return order, nil
+}
+
+// ราคาที่ market order จะได้ match ก่อน ใช้ประเมิน notional ไม่มี order ฝั่งตรงข้าม = 0
+func (s *orderService) bestOppositePrice(symbol, side string) (decimal.Decimal, error) {
+	bids, asks, err := s.engines.Engine(symbol).Depth(1)
+	if err != nil {
+		log.Println("[matching] depth", symbol, "failed:", err)
+		return decimal.Zero, utils.ErrOrderNotAccepted
+	}
+
+	levels := bids
+	if side == model.SideBuy {
+		levels = asks
+	}
+	if len(levels) == 0 {
+		return decimal.Zero, nil
+	}
+	return levels[0].Price, nil
+}
+
+// เปลี่ยนเป็น CANCELED และคืน hold ส่วนที่เหลือ
+func (s *orderService) cancelInDB(userID string, orderID uint64) (*model.Order, error) {
+	var order *model.Order
+	err := s.repo.Transaction(func(tx *gorm.DB) error {
+		var err error
+		order, err = s.repo.GetOrderForUpdate(tx, userID, orderID)
+		if err != nil {
+			return err
+		}
+
+		if !order.IsCancelable() {
+			return utils.ErrOrderNotCancelable
+		}
+
+		order.Status = model.StatusCanceled
+		if err := s.settlement.ReleaseRemainingHold(tx, order); err != nil {
+			return err
+		}
+		return s.repo.UpdateOrder(tx, order)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+func (s *orderService) CancelOpenOrders(userID, currency string) (int, error) {
+	orders, err := s.repo.GetOpenOrdersByUserID(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	canceled := 0
+	for _, o := range orders {
+		if currency != "" {
+			base, quote, _ := model.SplitSymbol(o.Symbol)
+			if base != currency && quote != currency {
+				continue
+			}
+		}
+
+		if _, err := s.CancelOrder(userID, o.ID); err != nil {
+			// match จนจบไประหว่างไล่ยกเลิก
+			if errors.Is(err, utils.ErrOrderNotCancelable) {
+				continue
+			}
+			return canceled, err
+		}
+		canceled++
+	}
+	return canceled, nil
+}
+
+func (s *orderService) GetOrder(userID string, orderID uint64) (*model.Order, error) {
+	return s.repo.GetOrderByID(userID, orderID)
+}
+
+func (s *orderService) GetUserOrders(userID string, filter repository.OrderFilter) ([]model.Order, error) {
+	filter.Symbol = strings.ToUpper(filter.Symbol)
+	filter.Status = strings.ToUpper(filter.Status)
+
+	if filter.Status != "" && !model.IsValidStatus(filter.Status) {
+		return nil, utils.ErrInvalidOrderStatus
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = defaultOrderListLimit
+	}
+	if filter.Limit > maxOrderListLimit {
+		filter.Limit = maxOrderListLimit
+	}
+
+	return s.repo.GetOrdersByUserID(userID, filter)
+}
+
+// RestoreOrderBooks settle trade ที่ค้าง แล้วใส่ limit order ที่ยังเปิดอยู่กลับเข้า book
+// book อยู่ใน memory จึงต้องเรียกทุกครั้งที่ start server
+func (s *orderService) RestoreOrderBooks() error {
+	if _, err := s.settlement.SettlePending(); err != nil {
+		return err
+	}
+
+	orders, err := s.repo.GetOpenLimitOrders()
+	if err != nil {
+		return err
+	}
+
+	for _, order := range orders {
+		unlock := s.lockSymbol(order.Symbol)
+		_, err := s.match(order)
+		unlock()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ผู้เรียกต้องถือ lock ของ symbol ไว้แล้ว
+func (s *orderService) match(order model.Order) (*model.Order, error) {
+	result, err := s.engines.Engine(order.Symbol).Submit(order)
+	if err != nil {
+		// ไม่ค้างไว้รอ restore เพราะ restore ใส่กลับแค่ limit order, market order จะค้างพร้อม hold
+		log.Println("[matching] submit order", order.ID, "failed:", err)
+		if _, err := s.cancelInDB(order.UserID, order.ID); err != nil {
+			log.Println("[matching] cancel rejected order", order.ID, "failed:", err)
+		}
+		return nil, utils.ErrOrderNotAccepted
+	}
+
+	if len(result.Trades) == 0 && result.Order.Status == order.Status {
+		return &result.Order, nil
+	}
+
+	trades, err := s.persistMatch(result)
+	if err != nil {
+		log.Println("[matching] persist result of order", order.ID, "failed:", err)
+		return nil, err
+	}
+
+	// settle ไม่สำเร็จไม่ทำให้ order fail trade ยังค้างรอ SettlePending
+	for _, trade := range trades {
+		if err := s.settlement.Settle(trade.ID); err != nil {
+			log.Println("[settlement] trade", trade.ID, "failed:", err)
+		}
+	}
+
+	return s.repo.GetOrderByID(order.UserID, order.ID)
+}
+
+func (s *orderService) persistMatch(result matching.MatchResult) ([]tradeModel.Trade, error) {
+	trades := result.Trades
+
+	matched := append([]model.Order{result.Order}, result.Makers...)
+	// lock order ตาม id เหมือน settlement กัน deadlock
+	sort.Slice(matched, func(i, j int) bool { return matched[i].ID < matched[j].ID })
+
+	err := s.repo.Transaction(func(tx *gorm.DB) error {
+		// สร้าง trade ก่อน เพื่อให้ ReleaseRemainingHold เห็นว่ายังมี trade รอ settle
+		for i := range trades {
+			if err := s.tradeRepo.CreateTrade(tx, &trades[i]); err != nil {
+				return err
+			}
+		}
+
+		for _, m := range matched {
+			current, err := s.repo.GetOrderByIDForUpdate(tx, m.ID)
+			if err != nil {
+				return err
+			}
+
+			current.FilledAmount = m.FilledAmount
+			current.Status = m.Status
+
+			// market ที่ไม่ได้ match เลยจะถูกคืน hold ตรงนี้
+			if err := s.settlement.ReleaseRemainingHold(tx, current); err != nil {
+				return err
+			}
+			if err := s.repo.UpdateOrder(tx, current); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return trades, nil
+}
+
+func (s *orderService) lockSymbol(symbol string) func() {
+	s.symbolMu.Lock()
+	mu, ok := s.symbolLocks[symbol]
+	if !ok {
+		mu = &sync.Mutex{}
+		s.symbolLocks[symbol] = mu
+	}
+	s.symbolMu.Unlock()
+
+	mu.Lock()
+	return mu.Unlock
+}
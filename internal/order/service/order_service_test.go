@@ -0,0 +1,732 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	currencyModel "github.com/padapook/bestbit-core/internal/currency/model"
+	currencyRepository "github.com/padapook/bestbit-core/internal/currency/repository"
+	currencyService "github.com/padapook/bestbit-core/internal/currency/service"
+	marketModel "github.com/padapook/bestbit-core/internal/market/model"
+	"github.com/padapook/bestbit-core/internal/matching"
+	"github.com/padapook/bestbit-core/internal/order/model"
+	"github.com/padapook/bestbit-core/internal/order/repository"
+	tradeModel "github.com/padapook/bestbit-core/internal/trade/model"
+	"github.com/padapook/bestbit-core/internal/utils"
+	walletModel "github.com/padapook/bestbit-core/internal/wallet/model"
+	walletRepository "github.com/padapook/bestbit-core/internal/wallet/repository"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+type MockOrderRepository struct {
+	mock.Mock
+}
+
+// ไม่มี DB จริง เรียก fn ตรงๆ ด้วย tx = nil
+func (m *MockOrderRepository) Transaction(fn func(tx *gorm.DB) error) error {
+	return fn(nil)
+}
+
+func (m *MockOrderRepository) CreateOrder(tx *gorm.DB, order *model.Order) error {
+	args := m.Called(tx, order)
+	return args.Error(0)
+}
+
+func (m *MockOrderRepository) UpdateOrder(tx *gorm.DB, order *model.Order) error {
+	args := m.Called(tx, order)
+	return args.Error(0)
+}
+
+func (m *MockOrderRepository) GetOrderForUpdate(tx *gorm.DB, userID string, orderID uint64) (*model.Order, error) {
+	args := m.Called(tx, userID, orderID)
+	if args.Get(0) != nil {
+		return args.Get(0).(*model.Order), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockOrderRepository) GetOrderByIDForUpdate(tx *gorm.DB, orderID uint64) (*model.Order, error) {
+	args := m.Called(tx, orderID)
+	if args.Get(0) != nil {
+		return args.Get(0).(*model.Order), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockOrderRepository) GetOpenLimitOrders() ([]model.Order, error) {
+	args := m.Called()
+	if args.Get(0) != nil {
+		return args.Get(0).([]model.Order), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockOrderRepository) GetOpenOrdersByUserID(userID string) ([]model.Order, error) {
+	args := m.Called(userID)
+	if args.Get(0) != nil {
+		return args.Get(0).([]model.Order), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockOrderRepository) GetOrderByID(userID string, orderID uint64) (*model.Order, error) {
+	args := m.Called(userID, orderID)
+	if args.Get(0) != nil {
+		return args.Get(0).(*model.Order), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockOrderRepository) GetOrdersByUserID(userID string, filter repository.OrderFilter) ([]model.Order, error) {
+	args := m.Called(userID, filter)
+	if args.Get(0) != nil {
+		return args.Get(0).([]model.Order), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+type MockWalletRepository struct {
+	mock.Mock
+}
+
+func (m *MockWalletRepository) GetWalletByUserID(userID string) ([]walletModel.Wallet, error) {
+	args := m.Called(userID)
+	if args.Get(0) != nil {
+		return args.Get(0).([]walletModel.Wallet), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockWalletRepository) GetWalletByUserIDAndCurrency(userID, currency string) (*walletModel.Wallet, error) {
+	args := m.Called(userID, currency)
+	if args.Get(0) != nil {
+		return args.Get(0).(*walletModel.Wallet), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockWalletRepository) GetAllWallets(afterID uint64, limit int) ([]walletModel.Wallet, error) {
+	args := m.Called(afterID, limit)
+	if args.Get(0) != nil {
+		return args.Get(0).([]walletModel.Wallet), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockWalletRepository) Transaction(fn func(tx *gorm.DB) error) error {
+	return fn(nil)
+}
+
+func (m *MockWalletRepository) EnsureWallet(tx *gorm.DB, userID, currency string) error {
+	args := m.Called(tx, userID, currency)
+	return args.Error(0)
+}
+
+func (m *MockWalletRepository) Deposit(tx *gorm.DB, userID, currency string, amount decimal.Decimal, referenceID string) (*walletModel.Wallet, error) {
+	args := m.Called(tx, userID, currency, amount, referenceID)
+	if args.Get(0) != nil {
+		return args.Get(0).(*walletModel.Wallet), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockWalletRepository) Transfer(tx *gorm.DB, fromUserID, toUserID, currency string, amount decimal.Decimal, referenceID string) error {
+	args := m.Called(tx, fromUserID, toUserID, currency, amount, referenceID)
+	return args.Error(0)
+}
+
+func (m *MockWalletRepository) GetWalletForUpdate(tx *gorm.DB, userID, currency string) (*walletModel.Wallet, error) {
+	args := m.Called(tx, userID, currency)
+	if args.Get(0) != nil {
+		return args.Get(0).(*walletModel.Wallet), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockWalletRepository) HoldFunds(tx *gorm.DB, userID, currency string, amount decimal.Decimal, referenceID string) (*walletModel.Wallet, error) {
+	args := m.Called(tx, userID, currency, amount, referenceID)
+	if args.Get(0) != nil {
+		return args.Get(0).(*walletModel.Wallet), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockWalletRepository) ReleaseFunds(tx *gorm.DB, userID, currency string, amount decimal.Decimal, referenceID string) (*walletModel.Wallet, error) {
+	args := m.Called(tx, userID, currency, amount, referenceID)
+	if args.Get(0) != nil {
+		return args.Get(0).(*walletModel.Wallet), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockWalletRepository) ConsumeHold(tx *gorm.DB, userID, currency string, amount decimal.Decimal, referenceID string) (*walletModel.Wallet, error) {
+	args := m.Called(tx, userID, currency, amount, referenceID)
+	if args.Get(0) != nil {
+		return args.Get(0).(*walletModel.Wallet), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockWalletRepository) CreditFunds(tx *gorm.DB, userID, currency string, amount decimal.Decimal, referenceID string) (*walletModel.Wallet, error) {
+	args := m.Called(tx, userID, currency, amount, referenceID)
+	if args.Get(0) != nil {
+		return args.Get(0).(*walletModel.Wallet), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockWalletRepository) ChargeFee(tx *gorm.DB, userID, currency string, amount decimal.Decimal, referenceID string) (*walletModel.Wallet, error) {
+	args := m.Called(tx, userID, currency, amount, referenceID)
+	if args.Get(0) != nil {
+		return args.Get(0).(*walletModel.Wallet), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockWalletRepository) CompleteWithdrawal(tx *gorm.DB, userID, currency string, amount decimal.Decimal, referenceID string) (*walletModel.Wallet, error) {
+	args := m.Called(tx, userID, currency, amount, referenceID)
+	if args.Get(0) != nil {
+		return args.Get(0).(*walletModel.Wallet), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockWalletRepository) Adjust(tx *gorm.DB, userID, currency string, amount decimal.Decimal, referenceID, reason, actor string) (*walletModel.Wallet, error) {
+	args := m.Called(tx, userID, currency, amount, referenceID, reason, actor)
+	if args.Get(0) != nil {
+		return args.Get(0).(*walletModel.Wallet), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockWalletRepository) LockWallets(tx *gorm.DB, keys []walletRepository.WalletKey) error {
+	args := m.Called(tx, keys)
+	return args.Error(0)
+}
+
+type MockTradeRepository struct {
+	mock.Mock
+}
+
+func (m *MockTradeRepository) Transaction(fn func(tx *gorm.DB) error) error {
+	return fn(nil)
+}
+
+func (m *MockTradeRepository) CreateTrade(tx *gorm.DB, trade *tradeModel.Trade) error {
+	args := m.Called(tx, trade)
+	return args.Error(0)
+}
+
+func (m *MockTradeRepository) GetTradeForUpdate(tx *gorm.DB, tradeID uint64) (*tradeModel.Trade, error) {
+	args := m.Called(tx, tradeID)
+	if args.Get(0) != nil {
+		return args.Get(0).(*tradeModel.Trade), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockTradeRepository) MarkSettled(tx *gorm.DB, trade *tradeModel.Trade) error {
+	args := m.Called(tx, trade)
+	return args.Error(0)
+}
+
+func (m *MockTradeRepository) CountUnsettledByOrderID(tx *gorm.DB, orderID uint64) (int64, error) {
+	args := m.Called(tx, orderID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockTradeRepository) GetUnsettledTradeIDs(limit int) ([]uint64, error) {
+	args := m.Called(limit)
+	if args.Get(0) != nil {
+		return args.Get(0).([]uint64), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+type MockSettlementService struct {
+	mock.Mock
+}
+
+func (m *MockSettlementService) Settle(tradeID uint64) error {
+	args := m.Called(tradeID)
+	return args.Error(0)
+}
+
+func (m *MockSettlementService) SettlePending() (int, error) {
+	args := m.Called()
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockSettlementService) Start(interval time.Duration) func() {
+	m.Called(interval)
+	return func() {}
+}
+
+func (m *MockSettlementService) ReleaseRemainingHold(tx *gorm.DB, order *model.Order) error {
+	args := m.Called(tx, order)
+	return args.Error(0)
+}
+
+type MockMarketRepository struct {
+	mock.Mock
+}
+
+func (m *MockMarketRepository) CreateMarket(market *marketModel.Market) error {
+	args := m.Called(market)
+	return args.Error(0)
+}
+
+func (m *MockMarketRepository) UpdateMarket(market *marketModel.Market) error {
+	args := m.Called(market)
+	return args.Error(0)
+}
+
+func (m *MockMarketRepository) DeleteMarket(market *marketModel.Market) error {
+	args := m.Called(market)
+	return args.Error(0)
+}
+
+func (m *MockMarketRepository) GetBySymbol(symbol string) (*marketModel.Market, error) {
+	args := m.Called(symbol)
+	if args.Get(0) != nil {
+		return args.Get(0).(*marketModel.Market), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMarketRepository) GetMarkets(status string) ([]marketModel.Market, error) {
+	args := m.Called(status)
+	if args.Get(0) != nil {
+		return args.Get(0).([]marketModel.Market), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+type testDeps struct {
+	orders     *MockOrderRepository
+	wallets    *MockWalletRepository
+	trades     *MockTradeRepository
+	settlement *MockSettlementService
+	markets    *MockMarketRepository
+	currencies *StubCurrencyRepository
+}
+
+// สกุลตั้งต้นทั้งหมด ปิดบางสกุลได้ผ่าน disabled
+type StubCurrencyRepository struct {
+	currencyRepository.CurrencyRepository
+	disabled map[string]bool
+}
+
+func (r *StubCurrencyRepository) GetByCode(code string) (*currencyModel.Currency, error) {
+	for _, currency := range currencyModel.DefaultCurrencies {
+		if currency.Code == code {
+			currency.Enabled = !r.disabled[code]
+			return &currency, nil
+		}
+	}
+	return nil, utils.ErrCurrencyNotFound
+}
+
+// ตลาด BTC_THB ที่กติกาหลวมพอให้ test อื่นผ่าน
+func btcThbMarket() *marketModel.Market {
+	return &marketModel.Market{
+		Symbol:        "BTC_THB",
+		BaseCurrency:  "BTC",
+		QuoteCurrency: "THB",
+		PriceTick:     decimal.RequireFromString("0.01"),
+		QuantityStep:  decimal.RequireFromString("0.0001"),
+		Status:        marketModel.StatusTrading,
+	}
+}
+
+func newTestOrderService(t *testing.T) (OrderService, *testDeps) {
+	deps := &testDeps{
+		orders:     new(MockOrderRepository),
+		wallets:    new(MockWalletRepository),
+		trades:     new(MockTradeRepository),
+		settlement: new(MockSettlementService),
+		markets:    new(MockMarketRepository),
+		currencies: &StubCurrencyRepository{disabled: map[string]bool{}},
+	}
+	deps.markets.On("GetBySymbol", "BTC_THB").Return(btcThbMarket(), nil).Maybe()
+
+	engines := matching.NewManager(nil)
+	t.Cleanup(engines.StopAll)
+
+	return NewOrderService(deps.orders, deps.wallets, deps.trades, deps.settlement, deps.markets, currencyService.NewCurrencyService(deps.currencies), engines), deps
+}
+
+// decimal ที่ค่าเท่ากันอาจมี exponent ต่างกัน เทียบด้วย Equal แทน
+func decimalEq(value int64) interface{} {
+	return mock.MatchedBy(func(d decimal.Decimal) bool {
+		return d.Equal(decimal.NewFromInt(value))
+	})
+}
+
+func TestPlaceOrder_Limit_Success(t *testing.T) {
+	service, deps := newTestOrderService(t)
+
+	// จำลองว่า DB ให้ id = 7
+	deps.orders.On("CreateOrder", mock.Anything, mock.AnythingOfType("*model.Order")).
+		Run(func(args mock.Arguments) { args.Get(1).(*model.Order).ID = 7 }).
+		Return(nil)
+	// buy ต้องมี wallet BTC ไว้รับของ
+	deps.wallets.On("EnsureWallet", mock.Anything, "user-123", "BTC").Return(nil)
+	// buy 0.5 BTC ที่ 1,000,000 ต้อง hold 500,000 THB
+	deps.wallets.On("HoldFunds", mock.Anything, "user-123", "THB", decimalEq(500000), "ORDER-7").
+		Return(&walletModel.Wallet{}, nil)
+
+	order, err := service.PlaceOrder(model.Order{
+		UserID:    "user-123",
+		Symbol:    "btc_thb",
+		Side:      "buy",
+		OrderType: "limit",
+		Price:     decimal.NewFromInt(1000000),
+		Amount:    decimal.RequireFromString("0.5"),
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "BTC_THB", order.Symbol)
+	assert.Equal(t, model.SideBuy, order.Side)
+	assert.Equal(t, model.StatusPending, order.Status)
+	assert.True(t, order.FilledAmount.IsZero())
+	assert.True(t, order.LockedAmount.Equal(decimal.NewFromInt(500000)))
+	deps.orders.AssertExpectations(t)
+	deps.wallets.AssertExpectations(t)
+	// book ว่าง ไม่มี trade ไม่ต้อง settle
+	deps.settlement.AssertNotCalled(t, "Settle", mock.Anything)
+}
+
+func TestPlaceOrder_CrossingOrderPersistsAndSettlesTrade(t *testing.T) {
+	service, deps := newTestOrderService(t)
+
+	nextID := uint64(0)
+	deps.orders.On("CreateOrder", mock.Anything, mock.AnythingOfType("*model.Order")).
+		Run(func(args mock.Arguments) {
+			nextID++
+			args.Get(1).(*model.Order).ID = nextID
+		}).
+		Return(nil)
+	deps.wallets.On("EnsureWallet", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	deps.wallets.On("HoldFunds", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(&walletModel.Wallet{}, nil)
+
+	// order แรกค้างใน book
+	_, err := service.PlaceOrder(model.Order{
+		UserID: "seller", Symbol: "BTC_THB", Side: model.SideSell, OrderType: model.OrderTypeLimit,
+		Price: decimal.NewFromInt(100), Amount: decimal.NewFromInt(1),
+	})
+	assert.NoError(t, err)
+
+	seller := &model.Order{ID: 1, UserID: "seller", Symbol: "BTC_THB", Side: model.SideSell, Status: model.StatusPending}
+	buyer := &model.Order{ID: 2, UserID: "buyer", Symbol: "BTC_THB", Side: model.SideBuy, Status: model.StatusPending}
+	deps.orders.On("GetOrderByIDForUpdate", mock.Anything, uint64(1)).Return(seller, nil)
+	deps.orders.On("GetOrderByIDForUpdate", mock.Anything, uint64(2)).Return(buyer, nil)
+	deps.orders.On("UpdateOrder", mock.Anything, mock.AnythingOfType("*model.Order")).Return(nil)
+	deps.settlement.On("ReleaseRemainingHold", mock.Anything, mock.AnythingOfType("*model.Order")).Return(nil)
+	deps.trades.On("CreateTrade", mock.Anything, mock.AnythingOfType("*model.Trade")).
+		Run(func(args mock.Arguments) { args.Get(1).(*tradeModel.Trade).ID = 55 }).
+		Return(nil)
+	deps.settlement.On("Settle", uint64(55)).Return(nil)
+	deps.orders.On("GetOrderByID", "buyer", uint64(2)).Return(buyer, nil)
+
+	order, err := service.PlaceOrder(model.Order{
+		UserID: "buyer", Symbol: "BTC_THB", Side: model.SideBuy, OrderType: model.OrderTypeLimit,
+		Price: decimal.NewFromInt(100), Amount: decimal.NewFromInt(1),
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, model.StatusFilled, order.Status)
+	assert.Equal(t, model.StatusFilled, seller.Status)
+	assert.True(t, seller.FilledAmount.Equal(decimal.NewFromInt(1)))
+	deps.trades.AssertNumberOfCalls(t, "CreateTrade", 1)
+	deps.settlement.AssertCalled(t, "Settle", uint64(55))
+}
+
+func TestPlaceOrder_Fail_InsufficientBalance(t *testing.T) {
+	service, deps := newTestOrderService(t)
+
+	deps.orders.On("CreateOrder", mock.Anything, mock.AnythingOfType("*model.Order")).Return(nil)
+	deps.wallets.On("EnsureWallet", mock.Anything, "user-123", "THB").Return(nil)
+	// sell hold ฝั่ง base
+	deps.wallets.On("HoldFunds", mock.Anything, "user-123", "BTC", decimalEq(2), "ORDER-0").
+		Return(nil, utils.ErrInsufficientBalance)
+
+	order, err := service.PlaceOrder(model.Order{
+		UserID:    "user-123",
+		Symbol:    "BTC_THB",
+		Side:      model.SideSell,
+		OrderType: model.OrderTypeLimit,
+		Price:     decimal.NewFromInt(1000000),
+		Amount:    decimal.NewFromInt(2),
+	})
+
+	assert.Nil(t, order)
+	assert.Equal(t, utils.ErrInsufficientBalance, err)
+	deps.wallets.AssertExpectations(t)
+}
+
+func TestPlaceOrder_Fail_InvalidSide(t *testing.T) {
+	service, deps := newTestOrderService(t)
+
+	order, err := service.PlaceOrder(model.Order{
+		UserID:    "user-123",
+		Symbol:    "BTC_THB",
+		Side:      "HOLD",
+		OrderType: model.OrderTypeLimit,
+		Price:     decimal.NewFromInt(1000000),
+		Amount:    decimal.NewFromInt(1),
+	})
+
+	assert.Nil(t, order)
+	assert.Equal(t, utils.ErrInvalidOrderSide, err)
+	deps.orders.AssertNotCalled(t, "CreateOrder")
+}
+
+func TestPlaceOrder_Fail_LimitWithoutPrice(t *testing.T) {
+	service, deps := newTestOrderService(t)
+
+	// limit order ต้องมีราคา
+	order, err := service.PlaceOrder(model.Order{
+		UserID:    "user-123",
+		Symbol:    "BTC_THB",
+		Side:      model.SideSell,
+		OrderType: model.OrderTypeLimit,
+		Amount:    decimal.NewFromInt(1),
+	})
+
+	assert.Nil(t, order)
+	assert.Equal(t, utils.ErrInvalidOrderPrice, err)
+	deps.orders.AssertNotCalled(t, "CreateOrder")
+}
+
+func TestPlaceOrder_Fail_MarketRules(t *testing.T) {
+	service, deps := newTestOrderService(t)
+
+	halted := btcThbMarket()
+	halted.Symbol = "ETH_THB"
+	halted.Status = marketModel.StatusHalted
+	deps.markets.On("GetBySymbol", "ETH_THB").Return(halted, nil)
+	deps.markets.On("GetBySymbol", "DOGE_THB").Return(nil, utils.ErrMarketNotFound)
+
+	cases := []struct {
+		name     string
+		symbol   string
+		price    string
+		amount   string
+		expected error
+	}{
+		{"unknown market", "DOGE_THB", "1", "1", utils.ErrMarketNotFound},
+		{"halted market", "ETH_THB", "1", "1", utils.ErrMarketNotTrading},
+		{"price off tick", "BTC_THB", "100.005", "1", utils.ErrInvalidPriceTick},
+		{"amount off step", "BTC_THB", "100", "0.00005", utils.ErrInvalidQuantityStep},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			order, err := service.PlaceOrder(model.Order{
+				UserID:    "user-123",
+				Symbol:    tc.symbol,
+				Side:      model.SideBuy,
+				OrderType: model.OrderTypeLimit,
+				Price:     decimal.RequireFromString(tc.price),
+				Amount:    decimal.RequireFromString(tc.amount),
+			})
+
+			assert.Nil(t, order)
+			assert.Equal(t, tc.expected, err)
+		})
+	}
+	deps.orders.AssertNotCalled(t, "CreateOrder")
+}
+
+// market order ไม่มีราคา ต้องคิด notional จากราคาดีที่สุดฝั่งตรงข้าม
+func TestPlaceOrder_Fail_MarketOrderNotional(t *testing.T) {
+	service, deps := newTestOrderService(t)
+
+	market := btcThbMarket()
+	market.Symbol, market.BaseCurrency = "ETH_THB", "ETH"
+	market.MinNotional = decimal.NewFromInt(100)
+	deps.markets.On("GetBySymbol", "ETH_THB").Return(market, nil)
+
+	deps.orders.On("CreateOrder", mock.Anything, mock.AnythingOfType("*model.Order")).
+		Run(func(args mock.Arguments) { args.Get(1).(*model.Order).ID = 1 }).
+		Return(nil).Once()
+	deps.wallets.On("EnsureWallet", mock.Anything, "seller", "THB").Return(nil)
+	deps.wallets.On("HoldFunds", mock.Anything, "seller", "ETH", mock.Anything, "ORDER-1").
+		Return(&walletModel.Wallet{}, nil)
+
+	// ask เดียวที่ 100 THB
+	_, err := service.PlaceOrder(model.Order{
+		UserID: "seller", Symbol: "ETH_THB", Side: model.SideSell, OrderType: model.OrderTypeLimit,
+		Price: decimal.NewFromInt(100), Amount: decimal.NewFromInt(1),
+	})
+	assert.NoError(t, err)
+
+	// 0.5 * 100 = 50 ต่ำกว่า min notional
+	order, err := service.PlaceOrder(model.Order{
+		UserID: "buyer", Symbol: "ETH_THB", Side: model.SideBuy, OrderType: model.OrderTypeMarket,
+		Amount: decimal.RequireFromString("0.5"),
+	})
+	assert.Nil(t, order)
+	assert.Equal(t, utils.ErrNotionalTooSmall, err)
+
+	// ไม่มี bid ให้ขายใส่
+	order, err = service.PlaceOrder(model.Order{
+		UserID: "buyer", Symbol: "ETH_THB", Side: model.SideSell, OrderType: model.OrderTypeMarket,
+		Amount: decimal.NewFromInt(5),
+	})
+	assert.Nil(t, order)
+	assert.Equal(t, utils.ErrNoLiquidity, err)
+
+	deps.orders.AssertNumberOfCalls(t, "CreateOrder", 1)
+}
+
+// ตลาดยัง TRADING แต่ admin ปิดสกุลใดสกุลหนึ่งไปแล้ว
+func TestPlaceOrder_Fail_CurrencyDisabled(t *testing.T) {
+	service, deps := newTestOrderService(t)
+	deps.currencies.disabled["BTC"] = true
+
+	order, err := service.PlaceOrder(model.Order{
+		UserID:    "user-123",
+		Symbol:    "BTC_THB",
+		Side:      model.SideBuy,
+		OrderType: model.OrderTypeLimit,
+		Price:     decimal.NewFromInt(1000000),
+		Amount:    decimal.NewFromInt(1),
+	})
+
+	assert.Nil(t, order)
+	assert.Equal(t, utils.ErrCurrencyDisabled, err)
+	deps.orders.AssertNotCalled(t, "CreateOrder")
+	deps.wallets.AssertNotCalled(t, "HoldFunds")
+}
+
+func TestCancelOrder_ReleasesHold(t *testing.T) {
+	service, deps := newTestOrderService(t)
+
+	existing := &model.Order{
+		ID:           9,
+		UserID:       "user-123",
+		Symbol:       "BTC_THB",
+		Side:         model.SideBuy,
+		Status:       model.StatusPartialFilled,
+		LockedAmount: decimal.NewFromInt(300),
+	}
+	deps.orders.On("GetOrderByID", "user-123", uint64(9)).Return(existing, nil)
+	deps.orders.On("GetOrderForUpdate", mock.Anything, "user-123", uint64(9)).Return(existing, nil)
+	deps.settlement.On("ReleaseRemainingHold", mock.Anything, existing).
+		Run(func(args mock.Arguments) { args.Get(1).(*model.Order).LockedAmount = decimal.Zero }).
+		Return(nil)
+	deps.orders.On("UpdateOrder", mock.Anything, existing).Return(nil)
+
+	order, err := service.CancelOrder("user-123", 9)
+
+	assert.NoError(t, err)
+	assert.Equal(t, model.StatusCanceled, order.Status)
+	assert.True(t, order.LockedAmount.IsZero())
+	deps.orders.AssertExpectations(t)
+	deps.settlement.AssertExpectations(t)
+}
+
+// freeze wallet BTC ยกเลิกเฉพาะ order ในตลาดที่มี BTC
+func TestCancelOpenOrders_FiltersByCurrency(t *testing.T) {
+	service, deps := newTestOrderService(t)
+
+	btc := model.Order{ID: 9, UserID: "user-123", Symbol: "BTC_THB", Side: model.SideSell, Status: model.StatusPending}
+	eth := model.Order{ID: 10, UserID: "user-123", Symbol: "ETH_THB", Side: model.SideSell, Status: model.StatusPending}
+	deps.orders.On("GetOpenOrdersByUserID", "user-123").Return([]model.Order{btc, eth}, nil)
+	deps.orders.On("GetOrderByID", "user-123", uint64(9)).Return(&btc, nil)
+	deps.orders.On("GetOrderForUpdate", mock.Anything, "user-123", uint64(9)).Return(&btc, nil)
+	deps.settlement.On("ReleaseRemainingHold", mock.Anything, &btc).Return(nil)
+	deps.orders.On("UpdateOrder", mock.Anything, &btc).Return(nil)
+
+	canceled, err := service.CancelOpenOrders("user-123", "BTC")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, canceled)
+	assert.Equal(t, model.StatusCanceled, btc.Status)
+	deps.orders.AssertNotCalled(t, "GetOrderByID", "user-123", uint64(10))
+}
+
+// engine รับ order ไม่ได้ ต้องยกเลิกและคืน hold ทันที ไม่ค้างรอ restore
+func TestPlaceOrder_SubmitFailureCancelsAndReleasesHold(t *testing.T) {
+	service, deps := newTestOrderService(t)
+	service.(*orderService).engines.Engine("BTC_THB").Stop()
+
+	deps.orders.On("CreateOrder", mock.Anything, mock.AnythingOfType("*model.Order")).
+		Run(func(args mock.Arguments) { args.Get(1).(*model.Order).ID = 7 }).
+		Return(nil)
+	deps.wallets.On("EnsureWallet", mock.Anything, "user-123", "BTC").Return(nil)
+	deps.wallets.On("HoldFunds", mock.Anything, "user-123", "THB", mock.Anything, "ORDER-7").Return(&walletModel.Wallet{}, nil)
+
+	stored := &model.Order{ID: 7, UserID: "user-123", Symbol: "BTC_THB", Side: model.SideBuy, Status: model.StatusPending, LockedAmount: decimal.NewFromInt(100)}
+	deps.orders.On("GetOrderForUpdate", mock.Anything, "user-123", uint64(7)).Return(stored, nil)
+	deps.settlement.On("ReleaseRemainingHold", mock.Anything, stored).Return(nil)
+	deps.orders.On("UpdateOrder", mock.Anything, stored).Return(nil)
+
+	order, err := service.PlaceOrder(model.Order{
+		UserID: "user-123", Symbol: "BTC_THB", Side: model.SideBuy, OrderType: model.OrderTypeLimit,
+		Price: decimal.NewFromInt(100), Amount: decimal.NewFromInt(1),
+	})
+
+	assert.Nil(t, order)
+	assert.Equal(t, utils.ErrOrderNotAccepted, err)
+	assert.Equal(t, model.StatusCanceled, stored.Status)
+	deps.settlement.AssertExpectations(t)
+}
+
+// บันทึกการยกเลิกไม่สำเร็จ order ต้องยังอยู่ใน book ให้ match ต่อได้
+func TestCancelOrder_DBFailureKeepsOrderInBook(t *testing.T) {
+	service, deps := newTestOrderService(t)
+
+	deps.orders.On("CreateOrder", mock.Anything, mock.AnythingOfType("*model.Order")).
+		Run(func(args mock.Arguments) { args.Get(1).(*model.Order).ID = 9 }).
+		Return(nil)
+	deps.wallets.On("EnsureWallet", mock.Anything, "user-123", "THB").Return(nil)
+	deps.wallets.On("HoldFunds", mock.Anything, "user-123", "BTC", mock.Anything, "ORDER-9").Return(&walletModel.Wallet{}, nil)
+	_, err := service.PlaceOrder(model.Order{
+		UserID: "user-123", Symbol: "BTC_THB", Side: model.SideSell, OrderType: model.OrderTypeLimit,
+		Price: decimal.NewFromInt(100), Amount: decimal.NewFromInt(1),
+	})
+	assert.NoError(t, err)
+
+	existing := &model.Order{ID: 9, UserID: "user-123", Symbol: "BTC_THB", Side: model.SideSell, Status: model.StatusPending}
+	deps.orders.On("GetOrderByID", "user-123", uint64(9)).Return(existing, nil)
+	deps.orders.On("GetOrderForUpdate", mock.Anything, "user-123", uint64(9)).Return(nil, errors.New("db down"))
+
+	_, err = service.CancelOrder("user-123", 9)
+
+	assert.EqualError(t, err, "db down")
+	_, asks, err := service.(*orderService).engines.Engine("BTC_THB").Depth(0)
+	assert.NoError(t, err)
+	assert.Len(t, asks, 1)
+}
+
+func TestCancelOrder_Fail_AlreadyFilled(t *testing.T) {
+	service, deps := newTestOrderService(t)
+
+	deps.orders.On("GetOrderByID", "user-123", uint64(9)).
+		Return(&model.Order{ID: 9, UserID: "user-123", Status: model.StatusFilled}, nil)
+
+	order, err := service.CancelOrder("user-123", 9)
+
+	assert.Nil(t, order)
+	assert.Equal(t, utils.ErrOrderNotCancelable, err)
+	deps.settlement.AssertNotCalled(t, "ReleaseRemainingHold")
+	deps.orders.AssertNotCalled(t, "UpdateOrder")
+}
+
+func TestGetUserOrders_Fail_InvalidStatus(t *testing.T) {
+	service, deps := newTestOrderService(t)
+
+	orders, err := service.GetUserOrders("user-123", repository.OrderFilter{Status: "OPEN"})
+
+	assert.Nil(t, orders)
+	assert.Equal(t, utils.ErrInvalidOrderStatus, err)
+	deps.orders.AssertNotCalled(t, "GetOrdersByUserID")
+}
@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/padapook/bestbit-core/internal/reconciliation/service"
+	"github.com/padapook/bestbit-core/internal/utils"
+)
+
+type ReconciliationController interface {
+	GetReports(c *gin.Context)
+	GetReport(c *gin.Context)
+}
+
+type reconciliationController struct {
+	reconciliationService service.ReconciliationService
+}
+
+func NewReconciliationController(reconciliationService service.ReconciliationService) ReconciliationController {
+	return &reconciliationController{reconciliationService: reconciliationService}
+}
+
+func (ctrl *reconciliationController) GetReports(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	reports, err := ctrl.reconciliationService.GetReports(strings.ToUpper(c.Query("status")), limit)
+	if err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": reports,
+	})
+}
+
+func (ctrl *reconciliationController) GetReport(c *gin.Context) {
+	reportID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.HandleError(c, utils.ErrInvalidRequest)
+		return
+	}
+
+	report, err := ctrl.reconciliationService.GetReport(reportID)
+	if err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": report,
+	})
+}
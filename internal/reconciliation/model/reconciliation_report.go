@@ -0,0 +1,53 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	ReportStatusClean         = "CLEAN"
+	ReportStatusDiscrepancies = "DISCREPANCIES"
+	ReportStatusFailed        = "FAILED"
+
+	// Wallet.Balance ไม่ตรงกับผลรวมของรายการทั้งหมด
+	FindingBalanceDrift = "BALANCE_DRIFT"
+	// Wallet.AmountLocked ไม่ตรงกับ hold - release - consume
+	FindingLockedDrift = "LOCKED_DRIFT"
+	// Wallet.Balance ไม่ตรงกับ BalanceAfter ของรายการล่าสุด
+	FindingLastBalanceMismatch = "LAST_BALANCE_MISMATCH"
+	// BalanceBefore ไม่ต่อจาก BalanceAfter ของรายการก่อนหน้า
+	FindingBrokenChain = "BROKEN_CHAIN"
+	// รายการที่ชี้ไป wallet ที่ไม่มีอยู่
+	FindingOrphanedTransaction = "ORPHANED_TRANSACTION"
+	// TRANSFER_OUT/TRANSFER_IN ที่ไม่มีขาคู่
+	FindingUnmatchedTransfer = "UNMATCHED_TRANSFER"
+)
+
+type ReconciliationReport struct {
+	ID             uint64                  `gorm:"primaryKey" json:"id"`
+	Status         string                  `gorm:"type:varchar(20);not null;index" json:"status"`
+	StartedAt      time.Time               `gorm:"not null;index" json:"started_at"`
+	FinishedAt     time.Time               `json:"finished_at"`
+	WalletsChecked int                     `json:"wallets_checked"`
+	FindingsCount  int                     `json:"findings_count"`
+	Error          string                  `gorm:"type:text" json:"error,omitempty"`
+	TriggeredBy    string                  `gorm:"size:50" json:"triggered_by"`
+	Findings       []ReconciliationFinding `gorm:"foreignKey:ReportID" json:"findings,omitempty"`
+}
+
+type ReconciliationFinding struct {
+	ID            uint64           `gorm:"primaryKey" json:"id"`
+	ReportID      uint64           `gorm:"index;not null" json:"report_id"`
+	Type          string           `gorm:"type:varchar(30);not null" json:"type"`
+	WalletID      uint64           `gorm:"index" json:"wallet_id"`
+	UserID        string           `gorm:"size:100" json:"user_id"`
+	Currency      string           `gorm:"type:varchar(20)" json:"currency"`
+	TransactionID *uuid.UUID       `json:"transaction_id,omitempty"`
+	ReferenceID   string           `json:"reference_id,omitempty"`
+	Expected      *decimal.Decimal `gorm:"type:decimal(32,16)" json:"expected,omitempty"`
+	Actual        *decimal.Decimal `gorm:"type:decimal(32,16)" json:"actual,omitempty"`
+	Detail        string           `gorm:"type:text" json:"detail"`
+}
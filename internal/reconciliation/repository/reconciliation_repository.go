@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/padapook/bestbit-core/internal/reconciliation/model"
+	"github.com/padapook/bestbit-core/internal/utils"
+	walletModel "github.com/padapook/bestbit-core/internal/wallet/model"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReconciliationRepository interface {
+	// อ่าน wallet กับรายการของมันใน snapshot เดียวกัน กันรายการที่เข้ามาระหว่างตรวจ
+	GetWalletSnapshot(walletID uint64) (*walletModel.Wallet, []walletModel.WalletTransaction, error)
+	GetOrphanedTransactions(limit int) ([]walletModel.WalletTransaction, error)
+	GetUnmatchedTransfers(limit int) ([]walletModel.WalletTransaction, error)
+	CreateReport(report *model.ReconciliationReport) error
+	GetReports(status string, limit int) ([]model.ReconciliationReport, error)
+	GetReport(id uint64) (*model.ReconciliationReport, error)
+	// ลงรายการ OPENING_BALANCE ให้ wallet ที่มียอดอยู่ก่อนเริ่มบันทึกรายการ คืนจำนวน wallet ที่ลง
+	PostOpeningBalances() (int, error)
+}
+
+type reconciliationRepository struct {
+	db *gorm.DB
+}
+
+func NewReconciliationRepository(db *gorm.DB) ReconciliationRepository {
+	return &reconciliationRepository{db: db}
+}
+
+func (r *reconciliationRepository) GetWalletSnapshot(walletID uint64) (*walletModel.Wallet, []walletModel.WalletTransaction, error) {
+	var wallet walletModel.Wallet
+	var transactions []walletModel.WalletTransaction
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&wallet, walletID).Error; err != nil {
+			return err
+		}
+		return tx.Where("wallet_id = ?", walletID).
+			Order("created_at ASC, id ASC").
+			Find(&transactions).Error
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, utils.ErrWalletNotFound
+		}
+		return nil, nil, err
+	}
+
+	return &wallet, transactions, nil
+}
+
+func (r *reconciliationRepository) GetOrphanedTransactions(limit int) ([]walletModel.WalletTransaction, error) {
+	var transactions []walletModel.WalletTransaction
+	err := r.db.Where("NOT EXISTS (SELECT 1 FROM wallets w WHERE w.id = wallet_transactions.wallet_id)").
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&transactions).Error
+	return transactions, err
+}
+
+// transfer เขียนสองขาด้วย reference เดียวกันใน tx เดียว ขาดขาใดขาหนึ่งคือผิดปกติ
+func (r *reconciliationRepository) GetUnmatchedTransfers(limit int) ([]walletModel.WalletTransaction, error) {
+	var transactions []walletModel.WalletTransaction
+	err := r.db.Raw(`
+		SELECT t.* FROM wallet_transactions t
+		WHERE t.transaction_type IN (?, ?)
+			AND NOT EXISTS (
+				SELECT 1 FROM wallet_transactions c
+				WHERE c.reference_id = t.reference_id
+					AND c.transaction_type = CASE WHEN t.transaction_type = ? THEN ? ELSE ? END
+					AND c.amount = t.amount
+					AND c.currency = t.currency
+			)
+		ORDER BY t.created_at ASC
+		LIMIT ?`,
+		walletModel.TxTypeTransferOut, walletModel.TxTypeTransferIn,
+		walletModel.TxTypeTransferOut, walletModel.TxTypeTransferIn, walletModel.TxTypeTransferOut,
+		limit).
+		Scan(&transactions).Error
+	return transactions, err
+}
+
+func (r *reconciliationRepository) CreateReport(report *model.ReconciliationReport) error {
+	return r.db.Create(report).Error
+}
+
+func (r *reconciliationRepository) GetReports(status string, limit int) ([]model.ReconciliationReport, error) {
+	var reports []model.ReconciliationReport
+
+	query := r.db.Model(&model.ReconciliationReport{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	err := query.Order("started_at DESC, id DESC").Find(&reports).Error
+	return reports, err
+}
+
+func (r *reconciliationRepository) GetReport(id uint64) (*model.ReconciliationReport, error) {
+	var report model.ReconciliationReport
+	err := r.db.Preload("Findings", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	}).First(&report, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.ErrReconciliationReportNotFound
+		}
+		return nil, err
+	}
+	return &report, nil
+}
+
+// ยอดยกมา = balance_before ของรายการแรก (ยอดที่มีอยู่ก่อน history) หรือ balance ทั้งก้อนถ้ายังไม่มีรายการ
+// ลงไว้ก่อนรายการแรก 1 microsecond ให้ chain ต่อกันได้ reference ต่อ wallet ทำให้ลงซ้ำไม่ได้
+// ต้องรันครั้งเดียวตอนเปิดใช้ reconciler ส่วนต่างที่เกิดทีหลังคือ drift จริงที่ต้องให้ reconciler เจอ
+func (r *reconciliationRepository) PostOpeningBalances() (int, error) {
+	var walletIDs []uint64
+	if err := r.db.Model(&walletModel.Wallet{}).Order("id ASC").Pluck("id", &walletIDs).Error; err != nil {
+		return 0, err
+	}
+
+	posted := 0
+	for _, walletID := range walletIDs {
+		err := r.db.Transaction(func(tx *gorm.DB) error {
+			var wallet walletModel.Wallet
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&wallet, walletID).Error; err != nil {
+				return err
+			}
+
+			opening := wallet.Balance
+			at := time.Now()
+
+			var first walletModel.WalletTransaction
+			err := tx.Where("wallet_id = ? AND status = ?", wallet.ID, walletModel.TxStatusCompleted).
+				Order("created_at ASC, id ASC").
+				First(&first).Error
+			switch {
+			case err == nil:
+				opening = first.BalanceBefore
+				at = first.CreatedAt.Add(-time.Microsecond)
+			case !errors.Is(err, gorm.ErrRecordNotFound):
+				return err
+			}
+
+			if !opening.GreaterThan(decimal.Zero) {
+				return nil
+			}
+
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&walletModel.WalletTransaction{
+				WalletID:        wallet.ID,
+				ReferenceID:     fmt.Sprintf("OPENING-%d", wallet.ID),
+				TransactionType: walletModel.TxTypeOpening,
+				Status:          walletModel.TxStatusCompleted,
+				Amount:          opening,
+				Currency:        wallet.Currency,
+				BalanceBefore:   decimal.Zero,
+				BalanceAfter:    opening,
+				Description:     "Opening balance",
+				CreatedAt:       at,
+				CreatedBy:       "SYSTEM",
+			})
+			if result.Error != nil {
+				return result.Error
+			}
+			posted += int(result.RowsAffected)
+			return nil
+		})
+		if err != nil {
+			return posted, err
+		}
+	}
+	return posted, nil
+}
@@ -0,0 +1,291 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/padapook/bestbit-core/internal/reconciliation/model"
+	"github.com/padapook/bestbit-core/internal/reconciliation/repository"
+	"github.com/padapook/bestbit-core/internal/utils"
+	walletModel "github.com/padapook/bestbit-core/internal/wallet/model"
+	walletRepository "github.com/padapook/bestbit-core/internal/wallet/repository"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	reconcileWalletPageSize = 500
+	// wallet ที่ chain พังมักพังต่อกันยาว เก็บแค่ช่วงแรกพอให้ตามต่อได้
+	maxChainFindingsPerWallet = 20
+	maxGlobalFindings         = 1000
+
+	defaultReportListLimit = 20
+	maxReportListLimit     = 100
+)
+
+type ReconciliationService interface {
+	Run(triggeredBy string) (*model.ReconciliationReport, error)
+	// รันตามรอบใน process เดียวกับ server, เรียก stop เพื่อหยุด
+	Start(interval time.Duration) (stop func())
+	GetReports(status string, limit int) ([]model.ReconciliationReport, error)
+	GetReport(id uint64) (*model.ReconciliationReport, error)
+}
+
+type reconciliationService struct {
+	repo       repository.ReconciliationRepository
+	walletRepo walletRepository.WalletRepository
+	running    sync.Mutex
+	now        func() time.Time
+}
+
+func NewReconciliationService(repo repository.ReconciliationRepository, walletRepo walletRepository.WalletRepository) ReconciliationService {
+	return &reconciliationService{repo: repo, walletRepo: walletRepo, now: time.Now}
+}
+
+// ไล่ตรวจทุก wallet แล้วบันทึก report แม้จะพังกลางทาง (status FAILED พร้อม findings ที่เจอแล้ว)
+func (s *reconciliationService) Run(triggeredBy string) (*model.ReconciliationReport, error) {
+	if !s.running.TryLock() {
+		return nil, utils.ErrReconciliationInProgress
+	}
+	defer s.running.Unlock()
+
+	report := &model.ReconciliationReport{
+		StartedAt:   s.now(),
+		TriggeredBy: triggeredBy,
+	}
+
+	runErr := s.checkAll(report)
+
+	report.FinishedAt = s.now()
+	report.FindingsCount = len(report.Findings)
+	switch {
+	case runErr != nil:
+		report.Status = model.ReportStatusFailed
+		report.Error = runErr.Error()
+	case len(report.Findings) > 0:
+		report.Status = model.ReportStatusDiscrepancies
+	default:
+		report.Status = model.ReportStatusClean
+	}
+
+	if err := s.repo.CreateReport(report); err != nil {
+		return nil, err
+	}
+	if runErr != nil {
+		return report, runErr
+	}
+	return report, nil
+}
+
+func (s *reconciliationService) checkAll(report *model.ReconciliationReport) error {
+	var afterID uint64
+	for {
+		wallets, err := s.walletRepo.GetAllWallets(afterID, reconcileWalletPageSize)
+		if err != nil {
+			return err
+		}
+
+		for _, w := range wallets {
+			afterID = w.ID
+
+			wallet, transactions, err := s.repo.GetWalletSnapshot(w.ID)
+			if err != nil {
+				return err
+			}
+			report.WalletsChecked++
+			report.Findings = append(report.Findings, CheckWallet(wallet, transactions)...)
+		}
+
+		if len(wallets) < reconcileWalletPageSize {
+			break
+		}
+	}
+
+	orphans, err := s.repo.GetOrphanedTransactions(maxGlobalFindings)
+	if err != nil {
+		return err
+	}
+	for _, t := range orphans {
+		report.Findings = append(report.Findings, transactionFinding(model.FindingOrphanedTransaction, t,
+			fmt.Sprintf("wallet %d does not exist", t.WalletID)))
+	}
+
+	unmatched, err := s.repo.GetUnmatchedTransfers(maxGlobalFindings)
+	if err != nil {
+		return err
+	}
+	for _, t := range unmatched {
+		report.Findings = append(report.Findings, transactionFinding(model.FindingUnmatchedTransfer, t,
+			t.TransactionType+" has no counterpart leg"))
+	}
+
+	return nil
+}
+
+func (s *reconciliationService) Start(interval time.Duration) func() {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	var once sync.Once
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				report, err := s.Run("SCHEDULER")
+				if err != nil {
+					log.Println("[reconcile] run failed:", err)
+					continue
+				}
+				if report.Status != model.ReportStatusClean {
+					log.Printf("[reconcile] report %d: %d findings across %d wallets", report.ID, report.FindingsCount, report.WalletsChecked)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		once.Do(func() {
+			ticker.Stop()
+			close(done)
+		})
+	}
+}
+
+func (s *reconciliationService) GetReports(status string, limit int) ([]model.ReconciliationReport, error) {
+	if limit <= 0 {
+		limit = defaultReportListLimit
+	}
+	if limit > maxReportListLimit {
+		limit = maxReportListLimit
+	}
+	return s.repo.GetReports(status, limit)
+}
+
+func (s *reconciliationService) GetReport(id uint64) (*model.ReconciliationReport, error) {
+	return s.repo.GetReport(id)
+}
+
+// ตรวจ wallet เดียวจาก snapshot, transactions ต้องเรียงตาม created_at
+// นับเฉพาะรายการ COMPLETED
+func CheckWallet(wallet *walletModel.Wallet, transactions []walletModel.WalletTransaction) []model.ReconciliationFinding {
+	var findings []model.ReconciliationFinding
+	chainFindings := 0
+
+	expectedBalance := decimal.Zero
+	expectedLocked := decimal.Zero
+	var prev *walletModel.WalletTransaction
+
+	completed := make([]walletModel.WalletTransaction, 0, len(transactions))
+	for _, t := range transactions {
+		if t.Status == walletModel.TxStatusCompleted {
+			completed = append(completed, t)
+		}
+	}
+
+	for _, t := range orderChain(completed) {
+		t := t
+		expectedBefore := decimal.Zero
+		if prev != nil {
+			expectedBefore = prev.BalanceAfter
+		}
+
+		if chainFindings < maxChainFindingsPerWallet {
+			if !t.BalanceBefore.Equal(expectedBefore) {
+				findings = append(findings, walletFinding(model.FindingBrokenChain, wallet, &t, expectedBefore, t.BalanceBefore,
+					"balance_before does not continue from the previous balance_after"))
+				chainFindings++
+			} else if !t.BalanceAfter.Sub(t.BalanceBefore).Equal(t.BalanceEffect()) {
+				findings = append(findings, walletFinding(model.FindingBrokenChain, wallet, &t, t.BalanceBefore.Add(t.BalanceEffect()), t.BalanceAfter,
+					"balance_after does not match balance_before plus amount"))
+				chainFindings++
+			}
+		}
+
+		expectedBalance = expectedBalance.Add(t.BalanceEffect())
+		expectedLocked = expectedLocked.Add(t.LockedEffect())
+		prev = &t
+	}
+
+	if !expectedBalance.Equal(wallet.Balance) {
+		findings = append(findings, walletFinding(model.FindingBalanceDrift, wallet, nil, expectedBalance, wallet.Balance,
+			"balance does not equal the sum of transactions"))
+	}
+	if !expectedLocked.Equal(wallet.AmountLocked) {
+		findings = append(findings, walletFinding(model.FindingLockedDrift, wallet, nil, expectedLocked, wallet.AmountLocked,
+			"amount_locked does not equal holds minus releases and consumed holds"))
+	}
+	if prev != nil && !prev.BalanceAfter.Equal(wallet.Balance) {
+		findings = append(findings, walletFinding(model.FindingLastBalanceMismatch, wallet, prev, prev.BalanceAfter, wallet.Balance,
+			"balance does not equal balance_after of the latest transaction"))
+	}
+
+	return findings
+}
+
+// created_at ละเอียดแค่ microsecond รายการที่เวลาเท่ากันเรียงตาม id ไม่ได้
+// ในกลุ่มเวลาเดียวกันเลือกแถวที่ต่อ chain ได้ก่อน
+func orderChain(transactions []walletModel.WalletTransaction) []walletModel.WalletTransaction {
+	result := make([]walletModel.WalletTransaction, 0, len(transactions))
+
+	for i := 0; i < len(transactions); {
+		j := i
+		for j < len(transactions) && transactions[j].CreatedAt.Equal(transactions[i].CreatedAt) {
+			j++
+		}
+
+		group := append([]walletModel.WalletTransaction(nil), transactions[i:j]...)
+		for len(group) > 0 {
+			// wallet เริ่มจากศูนย์
+			last := decimal.Zero
+			if len(result) > 0 {
+				last = result[len(result)-1].BalanceAfter
+			}
+
+			pick := 0
+			for k, t := range group {
+				if t.BalanceBefore.Equal(last) {
+					pick = k
+					break
+				}
+			}
+			result = append(result, group[pick])
+			group = append(group[:pick], group[pick+1:]...)
+		}
+		i = j
+	}
+
+	return result
+}
+
+func walletFinding(findingType string, wallet *walletModel.Wallet, t *walletModel.WalletTransaction, expected, actual decimal.Decimal, detail string) model.ReconciliationFinding {
+	finding := model.ReconciliationFinding{
+		Type:     findingType,
+		WalletID: wallet.ID,
+		UserID:   wallet.UserID,
+		Currency: wallet.Currency,
+		Expected: &expected,
+		Actual:   &actual,
+		Detail:   detail,
+	}
+	if t != nil {
+		id := t.ID
+		finding.TransactionID = &id
+		finding.ReferenceID = t.ReferenceID
+	}
+	return finding
+}
+
+func transactionFinding(findingType string, t walletModel.WalletTransaction, detail string) model.ReconciliationFinding {
+	id := t.ID
+	return model.ReconciliationFinding{
+		Type:          findingType,
+		WalletID:      t.WalletID,
+		Currency:      t.Currency,
+		TransactionID: &id,
+		ReferenceID:   t.ReferenceID,
+		Detail:        detail,
+	}
+}
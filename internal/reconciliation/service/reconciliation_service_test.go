@@ -0,0 +1,218 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/padapook/bestbit-core/internal/reconciliation/model"
+	walletModel "github.com/padapook/bestbit-core/internal/wallet/model"
+	walletRepository "github.com/padapook/bestbit-core/internal/wallet/repository"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockReconciliationRepository struct {
+	mock.Mock
+}
+
+func (m *MockReconciliationRepository) GetWalletSnapshot(walletID uint64) (*walletModel.Wallet, []walletModel.WalletTransaction, error) {
+	args := m.Called(walletID)
+	var wallet *walletModel.Wallet
+	if args.Get(0) != nil {
+		wallet = args.Get(0).(*walletModel.Wallet)
+	}
+	var transactions []walletModel.WalletTransaction
+	if args.Get(1) != nil {
+		transactions = args.Get(1).([]walletModel.WalletTransaction)
+	}
+	return wallet, transactions, args.Error(2)
+}
+
+func (m *MockReconciliationRepository) GetOrphanedTransactions(limit int) ([]walletModel.WalletTransaction, error) {
+	args := m.Called(limit)
+	if args.Get(0) != nil {
+		return args.Get(0).([]walletModel.WalletTransaction), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockReconciliationRepository) GetUnmatchedTransfers(limit int) ([]walletModel.WalletTransaction, error) {
+	args := m.Called(limit)
+	if args.Get(0) != nil {
+		return args.Get(0).([]walletModel.WalletTransaction), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockReconciliationRepository) CreateReport(report *model.ReconciliationReport) error {
+	args := m.Called(report)
+	return args.Error(0)
+}
+
+func (m *MockReconciliationRepository) GetReports(status string, limit int) ([]model.ReconciliationReport, error) {
+	args := m.Called(status, limit)
+	if args.Get(0) != nil {
+		return args.Get(0).([]model.ReconciliationReport), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockReconciliationRepository) GetReport(id uint64) (*model.ReconciliationReport, error) {
+	args := m.Called(id)
+	if args.Get(0) != nil {
+		return args.Get(0).(*model.ReconciliationReport), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockReconciliationRepository) PostOpeningBalances() (int, error) {
+	args := m.Called()
+	return args.Int(0), args.Error(1)
+}
+
+// ใช้แค่ GetAllWallets
+type MockWalletRepository struct {
+	walletRepository.WalletRepository
+	mock.Mock
+}
+
+func (m *MockWalletRepository) GetAllWallets(afterID uint64, limit int) ([]walletModel.Wallet, error) {
+	args := m.Called(afterID, limit)
+	if args.Get(0) != nil {
+		return args.Get(0).([]walletModel.Wallet), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func d(v int64) decimal.Decimal {
+	return decimal.NewFromInt(v)
+}
+
+var baseTime = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func walletTx(txType string, amount, before, after int64, offset time.Duration) walletModel.WalletTransaction {
+	return walletModel.WalletTransaction{
+		ID:              uuid.New(),
+		WalletID:        1,
+		ReferenceID:     "ref-" + txType,
+		TransactionType: txType,
+		Status:          walletModel.TxStatusCompleted,
+		Amount:          d(amount),
+		BalanceBefore:   d(before),
+		BalanceAfter:    d(after),
+		CreatedAt:       baseTime.Add(offset),
+	}
+}
+
+func findingTypes(findings []model.ReconciliationFinding) []string {
+	types := make([]string, 0, len(findings))
+	for _, f := range findings {
+		types = append(types, f.Type)
+	}
+	return types
+}
+
+func TestCheckWallet_Clean(t *testing.T) {
+	wallet := &walletModel.Wallet{ID: 1, UserID: "user-1", Currency: "THB", Balance: d(70), AmountLocked: d(10)}
+	transactions := []walletModel.WalletTransaction{
+		walletTx(walletModel.TxTypeDeposit, 100, 0, 100, 0),
+		walletTx(walletModel.TxTypeHold, 40, 100, 100, time.Second),
+		walletTx(walletModel.TxTypeHoldConsume, 30, 100, 70, 2*time.Second),
+	}
+
+	findings := CheckWallet(wallet, transactions)
+
+	assert.Empty(t, findings)
+}
+
+func TestCheckWallet_SameTimestampOutOfOrder(t *testing.T) {
+	wallet := &walletModel.Wallet{ID: 1, Balance: d(95)}
+	// credit กับ fee ของ trade เดียวกันเวลาเท่ากัน แต่ id สุ่มทำให้เรียงสลับ
+	transactions := []walletModel.WalletTransaction{
+		walletTx(walletModel.TxTypeTradeFee, 5, 100, 95, 0),
+		walletTx(walletModel.TxTypeTradeCredit, 100, 0, 100, 0),
+	}
+
+	findings := CheckWallet(wallet, transactions)
+
+	assert.Empty(t, findings)
+}
+
+func TestCheckWallet_DetectsDriftAndBrokenChain(t *testing.T) {
+	// balance ถูกแก้ตรงๆ และมีรายการหนึ่งที่ before ไม่ต่อจากก่อนหน้า
+	wallet := &walletModel.Wallet{ID: 1, Balance: d(500), AmountLocked: d(5)}
+	transactions := []walletModel.WalletTransaction{
+		walletTx(walletModel.TxTypeDeposit, 100, 0, 100, 0),
+		walletTx(walletModel.TxTypeWithdraw, 20, 90, 70, time.Second),
+	}
+
+	findings := CheckWallet(wallet, transactions)
+
+	assert.ElementsMatch(t, []string{
+		model.FindingBrokenChain,
+		model.FindingBalanceDrift,
+		model.FindingLockedDrift,
+		model.FindingLastBalanceMismatch,
+	}, findingTypes(findings))
+	assert.Equal(t, "ref-"+walletModel.TxTypeWithdraw, findings[0].ReferenceID)
+	assert.True(t, findings[0].Expected.Equal(d(100)))
+}
+
+func TestCheckWallet_SkipsPendingTransactions(t *testing.T) {
+	wallet := &walletModel.Wallet{ID: 1, Balance: d(100)}
+	pending := walletTx(walletModel.TxTypeWithdraw, 50, 100, 50, time.Second)
+	pending.Status = walletModel.TxStatusPending
+	transactions := []walletModel.WalletTransaction{
+		walletTx(walletModel.TxTypeDeposit, 100, 0, 100, 0),
+		pending,
+	}
+
+	assert.Empty(t, CheckWallet(wallet, transactions))
+}
+
+// wallet ที่มียอดก่อนเริ่มบันทึกรายการ ตรวจผ่านเมื่อมี OPENING_BALANCE ที่ migrate ลงไว้ก่อนรายการแรก
+func TestCheckWallet_LegacyWalletWithOpeningBalance(t *testing.T) {
+	wallet := &walletModel.Wallet{ID: 1, Balance: d(130)}
+	transactions := []walletModel.WalletTransaction{
+		walletTx(walletModel.TxTypeDeposit, 30, 100, 130, time.Second),
+	}
+	assert.Contains(t, findingTypes(CheckWallet(wallet, transactions)), model.FindingBalanceDrift)
+
+	opening := walletTx(walletModel.TxTypeOpening, 100, 0, 100, time.Second-time.Microsecond)
+	transactions = append([]walletModel.WalletTransaction{opening}, transactions...)
+
+	assert.Empty(t, CheckWallet(wallet, transactions))
+}
+
+func TestRun_PersistsReportWithFindings(t *testing.T) {
+	repo := new(MockReconciliationRepository)
+	walletRepo := new(MockWalletRepository)
+	service := NewReconciliationService(repo, walletRepo)
+
+	clean := &walletModel.Wallet{ID: 1, Balance: d(100)}
+	drifted := &walletModel.Wallet{ID: 2, Balance: d(1)}
+
+	walletRepo.On("GetAllWallets", uint64(0), reconcileWalletPageSize).Return([]walletModel.Wallet{*clean, *drifted}, nil)
+	repo.On("GetWalletSnapshot", uint64(1)).Return(clean, []walletModel.WalletTransaction{
+		walletTx(walletModel.TxTypeDeposit, 100, 0, 100, 0),
+	}, nil)
+	repo.On("GetWalletSnapshot", uint64(2)).Return(drifted, []walletModel.WalletTransaction{}, nil)
+
+	orphan := walletTx(walletModel.TxTypeDeposit, 10, 0, 10, 0)
+	orphan.WalletID = 99
+	repo.On("GetOrphanedTransactions", maxGlobalFindings).Return([]walletModel.WalletTransaction{orphan}, nil)
+	repo.On("GetUnmatchedTransfers", maxGlobalFindings).Return([]walletModel.WalletTransaction{}, nil)
+	repo.On("CreateReport", mock.AnythingOfType("*model.ReconciliationReport")).Return(nil)
+
+	report, err := service.Run("test")
+
+	require.NoError(t, err)
+	assert.Equal(t, model.ReportStatusDiscrepancies, report.Status)
+	assert.Equal(t, 2, report.WalletsChecked)
+	assert.Equal(t, 2, report.FindingsCount)
+	assert.ElementsMatch(t, []string{model.FindingBalanceDrift, model.FindingOrphanedTransaction}, findingTypes(report.Findings))
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,46 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	accountController "github.com/padapook/bestbit-core/internal/account/controller"
+	accountModel "github.com/padapook/bestbit-core/internal/account/model"
+	accountRepository "github.com/padapook/bestbit-core/internal/account/repository"
+	accountService "github.com/padapook/bestbit-core/internal/account/service"
+	currencyRepository "github.com/padapook/bestbit-core/internal/currency/repository"
+	currencyService "github.com/padapook/bestbit-core/internal/currency/service"
+	idempotencyRepository "github.com/padapook/bestbit-core/internal/idempotency/repository"
+	limitRepository "github.com/padapook/bestbit-core/internal/limit/repository"
+	limitService "github.com/padapook/bestbit-core/internal/limit/service"
+	"github.com/padapook/bestbit-core/internal/middleware"
+	"github.com/padapook/bestbit-core/internal/utils/auth"
+	walletController "github.com/padapook/bestbit-core/internal/wallet/controller"
+	walletRepository "github.com/padapook/bestbit-core/internal/wallet/repository"
+	walletService "github.com/padapook/bestbit-core/internal/wallet/service"
+	"gorm.io/gorm"
+)
+
+// admin คือ group /admin ที่ผ่าน authMiddleware มาแล้ว แต่ละ route ระบุสิทธิ์เอง
+func RegisterAdminRoutes(admin *gin.RouterGroup, db *gorm.DB, revocations auth.RevocationStore, loginGuard accountService.LoginGuard) {
+	userRepo := accountRepository.NewUserRepository(db)
+	userSvc := accountService.NewUserService(userRepo, db)
+	roleSvc := accountService.NewRoleService(accountRepository.NewRoleRepository(db), revocations)
+	tokenSvc := newTokenService(db, revocations, newOutboxService(db))
+	userCtrl := accountController.NewUserController(userSvc, tokenSvc, newMFAService(db), loginGuard)
+	roleCtrl := accountController.NewRoleController(roleSvc)
+
+	currencySvc := currencyService.NewCurrencyService(currencyRepository.NewCurrencyRepository(db))
+	limitSvc := limitService.NewLimitService(limitRepository.NewLimitRepository(db), currencySvc)
+	walletSvc := walletService.NewWalletService(walletRepository.NewWalletRepository(db), walletRepository.NewWalletTransactionRepository(db),
+		limitSvc, currencySvc, userRepo)
+	walletCtrl := walletController.NewWalletController(walletSvc)
+
+	idempotency := middleware.IdempotencyMiddleware(idempotencyRepository.NewIdempotencyRepository(db))
+
+	admin.GET("/users", middleware.RequirePermission(accountModel.PermUsersRead), userCtrl.SearchUsers)
+	admin.GET("/users/:account_id/roles", middleware.RequirePermission(accountModel.PermRolesManage), roleCtrl.GetRoles)
+	admin.PUT("/users/:account_id/roles", middleware.RequirePermission(accountModel.PermRolesManage), roleCtrl.SetRoles)
+
+	admin.GET("/users/:account_id/wallets", middleware.RequirePermission(accountModel.PermWalletsRead), walletCtrl.AdminGetWallets)
+	admin.GET("/users/:account_id/wallets/:currency/transactions", middleware.RequirePermission(accountModel.PermWalletsRead), walletCtrl.AdminGetTransactions)
+	admin.POST("/users/:account_id/wallets/:currency/adjustments", middleware.RequirePermission(accountModel.PermWalletsAdjust), idempotency, walletCtrl.AdjustBalance)
+}
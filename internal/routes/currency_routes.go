@@ -0,0 +1,29 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	accountModel "github.com/padapook/bestbit-core/internal/account/model"
+	"github.com/padapook/bestbit-core/internal/currency/controller"
+	"github.com/padapook/bestbit-core/internal/currency/repository"
+	"github.com/padapook/bestbit-core/internal/currency/service"
+	limitRepository "github.com/padapook/bestbit-core/internal/limit/repository"
+	limitService "github.com/padapook/bestbit-core/internal/limit/service"
+	"github.com/padapook/bestbit-core/internal/middleware"
+	"github.com/padapook/bestbit-core/internal/utils/auth"
+	"gorm.io/gorm"
+)
+
+func RegisterCurrencyRoutes(router *gin.RouterGroup, db *gorm.DB, revocations auth.RevocationStore) {
+	currencyRepo := repository.NewCurrencyRepository(db)
+	currencySvc := service.NewCurrencyService(currencyRepo, limitService.NewLimitSeeder(limitRepository.NewLimitRepository(db), defaultLimits()))
+	currencyCtrl := controller.NewCurrencyController(currencySvc)
+
+	router.GET("/currencies", currencyCtrl.GetCurrencies)
+
+	adminCurrencyRoutes := router.Group("/admin/currencies")
+	adminCurrencyRoutes.Use(authMiddleware(db, revocations), middleware.RequirePermission(accountModel.PermCurrenciesManage))
+	{
+		adminCurrencyRoutes.GET("", currencyCtrl.AdminGetCurrencies)
+		adminCurrencyRoutes.PUT("/:code", currencyCtrl.SaveCurrency)
+	}
+}
@@ -0,0 +1,31 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	accountModel "github.com/padapook/bestbit-core/internal/account/model"
+	accountService "github.com/padapook/bestbit-core/internal/account/service"
+	"github.com/padapook/bestbit-core/internal/freeze/controller"
+	"github.com/padapook/bestbit-core/internal/freeze/repository"
+	"github.com/padapook/bestbit-core/internal/freeze/service"
+	"github.com/padapook/bestbit-core/internal/middleware"
+	orderService "github.com/padapook/bestbit-core/internal/order/service"
+	"github.com/padapook/bestbit-core/internal/utils/auth"
+	"gorm.io/gorm"
+)
+
+func RegisterFreezeRoutes(router *gin.RouterGroup, db *gorm.DB, revocations auth.RevocationStore, orders orderService.OrderService, loginGuard accountService.LoginGuard) {
+	freezeRepo := repository.NewFreezeRepository(db)
+	freezeSvc := service.NewFreezeService(freezeRepo, revocations, orders, loginGuard)
+	freezeCtrl := controller.NewFreezeController(freezeSvc)
+
+	adminFreezeRoutes := router.Group("/admin/users/:account_id")
+	adminFreezeRoutes.Use(authMiddleware(db, revocations), middleware.RequirePermission(accountModel.PermUsersFreeze))
+	{
+		adminFreezeRoutes.POST("/freeze", freezeCtrl.FreezeAccount)
+		adminFreezeRoutes.POST("/unfreeze", freezeCtrl.UnfreezeAccount)
+		adminFreezeRoutes.POST("/unlock", freezeCtrl.UnlockLogin)
+		adminFreezeRoutes.POST("/wallets/:currency/freeze", freezeCtrl.FreezeWallet)
+		adminFreezeRoutes.POST("/wallets/:currency/unfreeze", freezeCtrl.UnfreezeWallet)
+		adminFreezeRoutes.GET("/freeze-events", freezeCtrl.GetEvents)
+	}
+}
@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	accountModel "github.com/padapook/bestbit-core/internal/account/model"
+	"github.com/padapook/bestbit-core/internal/ledger/controller"
+	"github.com/padapook/bestbit-core/internal/ledger/repository"
+	"github.com/padapook/bestbit-core/internal/ledger/service"
+	"github.com/padapook/bestbit-core/internal/middleware"
+	"github.com/padapook/bestbit-core/internal/utils/auth"
+	walletRepository "github.com/padapook/bestbit-core/internal/wallet/repository"
+	"gorm.io/gorm"
+)
+
+func RegisterLedgerRoutes(router *gin.RouterGroup, db *gorm.DB, revocations auth.RevocationStore) {
+	ledgerRepo := repository.NewLedgerRepository(db)
+	walletRepo := walletRepository.NewWalletRepository(db)
+	ledgerSvc := service.NewLedgerService(ledgerRepo, walletRepo)
+	ledgerCtrl := controller.NewLedgerController(ledgerSvc)
+
+	adminLedgerRoutes := router.Group("/admin/ledger")
+	adminLedgerRoutes.Use(authMiddleware(db, revocations), middleware.RequirePermission(accountModel.PermLedgerRead))
+	{
+		adminLedgerRoutes.GET("/verify", ledgerCtrl.Verify)
+	}
+}
@@ -0,0 +1,29 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	accountModel "github.com/padapook/bestbit-core/internal/account/model"
+	currencyRepository "github.com/padapook/bestbit-core/internal/currency/repository"
+	currencyService "github.com/padapook/bestbit-core/internal/currency/service"
+	"github.com/padapook/bestbit-core/internal/limit/controller"
+	"github.com/padapook/bestbit-core/internal/limit/repository"
+	"github.com/padapook/bestbit-core/internal/limit/service"
+	"github.com/padapook/bestbit-core/internal/middleware"
+	"github.com/padapook/bestbit-core/internal/utils/auth"
+	"gorm.io/gorm"
+)
+
+func RegisterLimitRoutes(router *gin.RouterGroup, db *gorm.DB, revocations auth.RevocationStore) {
+	limitRepo := repository.NewLimitRepository(db)
+	currencySvc := currencyService.NewCurrencyService(currencyRepository.NewCurrencyRepository(db))
+	limitSvc := service.NewLimitService(limitRepo, currencySvc)
+	limitCtrl := controller.NewLimitController(limitSvc)
+
+	adminRoutes := router.Group("/admin")
+	adminRoutes.Use(authMiddleware(db, revocations), middleware.RequirePermission(accountModel.PermLimitsManage))
+	{
+		adminRoutes.GET("/limits", limitCtrl.GetLimits)
+		adminRoutes.PUT("/limits", limitCtrl.SetLimit)
+		adminRoutes.PUT("/users/:account_id/kyc-tier", limitCtrl.SetUserTier)
+	}
+}
@@ -0,0 +1,35 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	accountModel "github.com/padapook/bestbit-core/internal/account/model"
+	currencyRepository "github.com/padapook/bestbit-core/internal/currency/repository"
+	currencyService "github.com/padapook/bestbit-core/internal/currency/service"
+	"github.com/padapook/bestbit-core/internal/market/controller"
+	"github.com/padapook/bestbit-core/internal/market/repository"
+	"github.com/padapook/bestbit-core/internal/market/service"
+	"github.com/padapook/bestbit-core/internal/middleware"
+	"github.com/padapook/bestbit-core/internal/utils/auth"
+	"gorm.io/gorm"
+)
+
+func RegisterMarketRoutes(router *gin.RouterGroup, db *gorm.DB, revocations auth.RevocationStore) {
+	marketRepo := repository.NewMarketRepository(db)
+	currencySvc := currencyService.NewCurrencyService(currencyRepository.NewCurrencyRepository(db))
+	marketSvc := service.NewMarketService(marketRepo, currencySvc)
+	marketCtrl := controller.NewMarketController(marketSvc)
+
+	publicMarketRoutes := router.Group("/markets")
+	{
+		publicMarketRoutes.GET("", marketCtrl.GetMarkets)
+		publicMarketRoutes.GET("/:symbol", marketCtrl.GetMarket)
+	}
+
+	adminMarketRoutes := router.Group("/admin/markets")
+	adminMarketRoutes.Use(authMiddleware(db, revocations), middleware.RequirePermission(accountModel.PermMarketsManage))
+	{
+		adminMarketRoutes.POST("", marketCtrl.CreateMarket)
+		adminMarketRoutes.PUT("/:symbol", marketCtrl.UpdateMarket)
+		adminMarketRoutes.DELETE("/:symbol", marketCtrl.DeleteMarket)
+	}
+}
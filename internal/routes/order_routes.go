@@ -0,0 +1,69 @@
+package routes
+
+import (
+	"log"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	accountModel "github.com/padapook/bestbit-core/internal/account/model"
+	accountService "github.com/padapook/bestbit-core/internal/account/service"
+	currencyRepository "github.com/padapook/bestbit-core/internal/currency/repository"
+	currencyService "github.com/padapook/bestbit-core/internal/currency/service"
+	marketRepository "github.com/padapook/bestbit-core/internal/market/repository"
+	"github.com/padapook/bestbit-core/internal/matching"
+	"github.com/padapook/bestbit-core/internal/middleware"
+	"github.com/padapook/bestbit-core/internal/order/controller"
+	"github.com/padapook/bestbit-core/internal/order/repository"
+	"github.com/padapook/bestbit-core/internal/order/service"
+	tradeRepository "github.com/padapook/bestbit-core/internal/trade/repository"
+	tradeService "github.com/padapook/bestbit-core/internal/trade/service"
+	"github.com/padapook/bestbit-core/internal/utils/auth"
+	walletRepository "github.com/padapook/bestbit-core/internal/wallet/repository"
+	"gorm.io/gorm"
+)
+
+func RegisterOrderRoutes(router *gin.RouterGroup, db *gorm.DB, revocations auth.RevocationStore, apiKeys accountService.APIKeyService, orderSvc service.OrderService) {
+	orderCtrl := controller.NewOrderController(orderSvc)
+
+	orderRoutes := router.Group("")
+	orderRoutes.Use(authOrAPIKey(db, revocations, apiKeys, accountModel.APIKeyPermRead))
+	{
+		requireTrade := middleware.RequireAPIKeyPermission(accountModel.APIKeyPermTrade)
+		orderRoutes.POST("/order", requireTrade, orderCtrl.PlaceOrder)
+		orderRoutes.GET("/order/:id", orderCtrl.GetOrder)
+		orderRoutes.DELETE("/order/:id", requireTrade, orderCtrl.CancelOrder)
+		orderRoutes.GET("/orders", orderCtrl.GetOrders)
+	}
+}
+
+// order book อยู่ใน memory ทุกที่ที่วาง/ยกเลิก order (รวม freeze) ต้องใช้ service ตัวเดียวกัน
+func newOrderService(db *gorm.DB) service.OrderService {
+	orderRepo := repository.NewOrderRepository(db)
+	walletRepo := walletRepository.NewWalletRepository(db)
+	tradeRepo := tradeRepository.NewTradeRepository(db)
+	settlementSvc := tradeService.NewSettlementService(tradeRepo, orderRepo, walletRepo, tradeService.LoadFeeScheduleFromEnv())
+	marketRepo := marketRepository.NewMarketRepository(db)
+	currencySvc := currencyService.NewCurrencyService(currencyRepository.NewCurrencyRepository(db))
+	orderSvc := service.NewOrderService(orderRepo, walletRepo, tradeRepo, settlementSvc, marketRepo, currencySvc, matching.NewManager(nil))
+
+	if err := orderSvc.RestoreOrderBooks(); err != nil {
+		log.Println("[matching] restore order books failed:", err)
+	}
+	startSettlementRetry(settlementSvc)
+	return orderSvc
+}
+
+// SETTLEMENT_RETRY_INTERVAL เช่น 1m, ไม่ตั้งไว้ = 30s
+func startSettlementRetry(settlement tradeService.SettlementService) {
+	interval := 30 * time.Second
+	if value := os.Getenv("SETTLEMENT_RETRY_INTERVAL"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			log.Println("[settlement] invalid SETTLEMENT_RETRY_INTERVAL:", value)
+		} else {
+			interval = parsed
+		}
+	}
+	settlement.Start(interval)
+}
@@ -0,0 +1,41 @@
+package routes
+
+import (
+	"log"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	accountModel "github.com/padapook/bestbit-core/internal/account/model"
+	"github.com/padapook/bestbit-core/internal/middleware"
+	"github.com/padapook/bestbit-core/internal/reconciliation/controller"
+	"github.com/padapook/bestbit-core/internal/reconciliation/repository"
+	"github.com/padapook/bestbit-core/internal/reconciliation/service"
+	"github.com/padapook/bestbit-core/internal/utils/auth"
+	walletRepository "github.com/padapook/bestbit-core/internal/wallet/repository"
+	"gorm.io/gorm"
+)
+
+func RegisterReconciliationRoutes(router *gin.RouterGroup, db *gorm.DB, revocations auth.RevocationStore) {
+	reconciliationRepo := repository.NewReconciliationRepository(db)
+	walletRepo := walletRepository.NewWalletRepository(db)
+	reconciliationSvc := service.NewReconciliationService(reconciliationRepo, walletRepo)
+	reconciliationCtrl := controller.NewReconciliationController(reconciliationSvc)
+
+	// RECONCILE_INTERVAL เช่น 1h, ไม่ตั้งไว้ = ไม่รันใน process นี้ (ใช้ cmd/reconcile แทน)
+	if value := os.Getenv("RECONCILE_INTERVAL"); value != "" {
+		interval, err := time.ParseDuration(value)
+		if err != nil || interval <= 0 {
+			log.Println("[reconcile] invalid RECONCILE_INTERVAL:", value)
+		} else {
+			reconciliationSvc.Start(interval)
+		}
+	}
+
+	adminReconciliationRoutes := router.Group("/admin/reconciliation")
+	adminReconciliationRoutes.Use(authMiddleware(db, revocations), middleware.RequirePermission(accountModel.PermReconciliationManage))
+	{
+		adminReconciliationRoutes.GET("/reports", reconciliationCtrl.GetReports)
+		adminReconciliationRoutes.GET("/reports/:id", reconciliationCtrl.GetReport)
+	}
+}
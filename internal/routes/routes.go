@@ -1,14 +1,255 @@
 package routes
 
 import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
 	"github.com/gin-gonic/gin"
+	accountController "github.com/padapook/bestbit-core/internal/account/controller"
+	accountRepository "github.com/padapook/bestbit-core/internal/account/repository"
+	accountService "github.com/padapook/bestbit-core/internal/account/service"
+	limitModel "github.com/padapook/bestbit-core/internal/limit/model"
+	"github.com/padapook/bestbit-core/internal/mail/mailer"
+	mailRepository "github.com/padapook/bestbit-core/internal/mail/repository"
+	mailService "github.com/padapook/bestbit-core/internal/mail/service"
+	"github.com/padapook/bestbit-core/internal/middleware"
+	"github.com/padapook/bestbit-core/internal/utils/auth"
+	"github.com/padapook/bestbit-core/internal/utils/crypto"
 	"gorm.io/gorm"
 )
 
 func Routes(r *gin.Engine, db *gorm.DB) {
+	auth.SetKeySet(newJWTKeySet())
+	if err := crypto.SetPasswordConfig(newPasswordConfig()); err != nil {
+		log.Fatalln("[password] invalid config:", err)
+	}
+	revocations := newRevocationStore(db)
+	outbox := newOutboxService(db)
+	startMailDispatcher(outbox)
+	// admin unlock (freeze routes) ต้องเห็น store เดียวกับ /login
+	loginGuard := newLoginGuard(db, outbox)
+	apiKeys := newAPIKeyService(db)
+	orders := newOrderService(db)
+
+	r.GET("/.well-known/jwks.json", accountController.NewJWKSController().GetJWKS)
+
 	v1 := r.Group("/api/v1")
 	{
-		RegisterUserRoutes(v1, db)
-		RegisterWalletRoutes(v1, db)
+		RegisterUserRoutes(v1, db, revocations, loginGuard, apiKeys)
+		RegisterWalletRoutes(v1, db, revocations, apiKeys)
+		RegisterMarketRoutes(v1, db, revocations)
+		RegisterOrderRoutes(v1, db, revocations, apiKeys, orders)
+		RegisterLedgerRoutes(v1, db, revocations)
+		RegisterReconciliationRoutes(v1, db, revocations)
+		RegisterWithdrawalRoutes(v1, db, revocations, apiKeys)
+		RegisterLimitRoutes(v1, db, revocations)
+		RegisterCurrencyRoutes(v1, db, revocations)
+		RegisterFreezeRoutes(v1, db, revocations, orders, loginGuard)
+
+		admin := v1.Group("/admin")
+		admin.Use(authMiddleware(db, revocations))
+		RegisterAdminRoutes(admin, db, revocations, loginGuard)
+	}
+}
+
+// JWT_SIGNING_KEY_FILE เป็น PEM ของ ES256 (P-256) หรือ EdDSA (ed25519), ไม่ตั้งไว้ = ใช้ HS256 กับ JWT_SECRET_KEY แบบเดิม
+// JWT_VERIFICATION_KEY_FILES (คั่นด้วย ,) คือ public key ที่ยังรับอยู่ระหว่าง rotate
+// JWT_HS256_ACCEPT_UNTIL (RFC3339) คือเวลาที่เลิกรับ token HS256 ที่ออกก่อนย้าย
+// ต้องตั้ง JWT_HS256_ISSUED_BEFORE (RFC3339 เวลาที่เริ่มใช้ key ใหม่) คู่กัน token HS256 ที่ iat หลังจากนั้นไม่รับ
+func newJWTKeySet() *auth.KeySet {
+	signingKeyFile := os.Getenv("JWT_SIGNING_KEY_FILE")
+	if signingKeyFile == "" {
+		return nil
+	}
+
+	cfg := auth.KeySetConfig{SigningKeyPEM: readKeyFile(signingKeyFile)}
+	for _, path := range strings.Split(os.Getenv("JWT_VERIFICATION_KEY_FILES"), ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			cfg.VerificationKeyPEMs = append(cfg.VerificationKeyPEMs, readKeyFile(path))
+		}
+	}
+
+	if value := os.Getenv("JWT_HS256_ACCEPT_UNTIL"); value != "" {
+		until, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			log.Fatalln("[jwt] invalid JWT_HS256_ACCEPT_UNTIL:", value)
+		}
+		issuedBefore, err := time.Parse(time.RFC3339, os.Getenv("JWT_HS256_ISSUED_BEFORE"))
+		if err != nil {
+			log.Fatalln("[jwt] JWT_HS256_ACCEPT_UNTIL requires JWT_HS256_ISSUED_BEFORE (RFC3339):", os.Getenv("JWT_HS256_ISSUED_BEFORE"))
+		}
+		cfg.LegacySecret = []byte(os.Getenv("JWT_SECRET_KEY"))
+		cfg.LegacyUntil = until
+		cfg.LegacyIssuedBefore = issuedBefore
+	}
+
+	keySet, err := auth.NewKeySet(cfg)
+	if err != nil {
+		log.Fatalln("[jwt] load keys:", err)
+	}
+	log.Println("[jwt] signing with kid", keySet.SigningKeyID())
+	return keySet
+}
+
+func readKeyFile(path string) []byte {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatalln("[jwt] read key file:", err)
+	}
+	return data
+}
+
+// PASSWORD_ARGON2_MEMORY (KiB) / PASSWORD_ARGON2_ITERATIONS / PASSWORD_ARGON2_PARALLELISM ไม่ตั้งไว้ = ค่าเดิม
+// เปลี่ยนค่าแล้ว hash เดิมยังใช้ได้ จะถูก hash ใหม่ตอน user login
+// PASSWORD_PEPPER_KEYS เป็น id:secret คั่นด้วย , ต้องเก็บ id เก่าไว้จนไม่มี hash อ้างถึงแล้ว, PASSWORD_PEPPER_ID คือตัวที่ใช้กับ hash ใหม่
+func newPasswordConfig() crypto.PasswordConfig {
+	cfg := crypto.PasswordConfig{Params: crypto.DefaultPasswordParams(), PepperID: os.Getenv("PASSWORD_PEPPER_ID")}
+	cfg.Params.Memory = uint32(envUint("PASSWORD_ARGON2_MEMORY", uint64(cfg.Params.Memory), 32))
+	cfg.Params.Iterations = uint32(envUint("PASSWORD_ARGON2_ITERATIONS", uint64(cfg.Params.Iterations), 32))
+	cfg.Params.Parallelism = uint8(envUint("PASSWORD_ARGON2_PARALLELISM", uint64(cfg.Params.Parallelism), 8))
+
+	for _, entry := range strings.Split(os.Getenv("PASSWORD_PEPPER_KEYS"), ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		id, secret, ok := strings.Cut(entry, ":")
+		if !ok || secret == "" {
+			log.Fatalln("[password] invalid PASSWORD_PEPPER_KEYS entry for id", id)
+		}
+		if cfg.Peppers == nil {
+			cfg.Peppers = map[string][]byte{}
+		}
+		cfg.Peppers[id] = []byte(secret)
+	}
+	return cfg
+}
+
+func envUint(name string, fallback uint64, bitSize int) uint64 {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.ParseUint(value, 10, bitSize)
+	if err != nil {
+		log.Fatalln("[password] invalid "+name+":", value)
+	}
+	return parsed
+}
+
+// TOKEN_REVOCATION_STORE=memory ใช้ตอน dev ที่รัน instance เดียว, ค่า default เก็บใน postgres
+func newRevocationStore(db *gorm.DB) auth.RevocationStore {
+	if os.Getenv("TOKEN_REVOCATION_STORE") == "memory" {
+		return auth.NewMemoryRevocationStore()
+	}
+	return accountRepository.NewRevocationStore(db)
+}
+
+// LIMIT_TIER0_DEFAULTS เช่น THB=50000/200000/1000000,BTC=0.1/0.5/2 (single/daily/monthly)
+// เป็นวงเงินตั้งต้นของ tier 0, สกุลที่ไม่ได้ตั้งไม่มีวงเงิน = ทำรายการไม่ได้จนกว่า admin จะตั้ง
+func defaultLimits() map[string]limitModel.LimitValues {
+	defaults, err := limitModel.ParseDefaultLimits(os.Getenv("LIMIT_TIER0_DEFAULTS"))
+	if err != nil {
+		log.Fatalln("[limit] invalid LIMIT_TIER0_DEFAULTS:", err)
+	}
+	return defaults
+}
+
+// memory store ต้องมีตัวเดียวทั้ง process ให้ login และ MFA ทุก route นับรวมกัน
+var memoryLoginAttempts = sync.OnceValue(auth.NewMemoryLoginAttemptStore)
+
+// LOGIN_ATTEMPT_STORE=memory ใช้ตอน dev ที่รัน instance เดียว, ค่า default เก็บใน postgres
+func newLoginAttemptStore(db *gorm.DB) auth.LoginAttemptStore {
+	if os.Getenv("LOGIN_ATTEMPT_STORE") == "memory" {
+		return memoryLoginAttempts()
+	}
+	return accountRepository.NewLoginAttemptStore(db)
+}
+
+func newLoginGuard(db *gorm.DB, outbox mailService.OutboxService) accountService.LoginGuard {
+	return accountService.NewLoginGuard(newLoginAttemptStore(db), accountRepository.NewUserRepository(db),
+		accountService.NewMailSecurityNotifier(outbox), accountService.DefaultLoginGuardPolicy())
+}
+
+// login จากเครื่องที่ไม่เคยเห็นแจ้ง user ทาง email
+func newTokenService(db *gorm.DB, revocations auth.RevocationStore, outbox mailService.OutboxService) accountService.TokenService {
+	return accountService.NewTokenService(accountRepository.NewRefreshTokenRepository(db), accountRepository.NewSessionRepository(db),
+		accountRepository.NewUserRepository(db), revocations, accountService.NewMailSecurityNotifier(outbox))
+}
+
+// ทุก group ที่ต้อง login ใช้ตัวนี้ ไม่เรียก middleware.AuthMiddleware ตรงๆ
+func authMiddleware(db *gorm.DB, revocations auth.RevocationStore) gin.HandlerFunc {
+	return middleware.AuthMiddleware(revocations, accountRepository.NewUserRepository(db))
+}
+
+// API_REPLAY_STORE=memory ใช้ตอน dev ที่รัน instance เดียว, ค่า default เก็บใน postgres
+// API_KEY_SECRET_KEY ใช้เข้ารหัส secret ของ API key ใน db, ไม่ตั้งไว้ = ปิด API key
+func newAPIKeyService(db *gorm.DB) accountService.APIKeyService {
+	var replays auth.ReplayStore = accountRepository.NewReplayStore(db)
+	if os.Getenv("API_REPLAY_STORE") == "memory" {
+		replays = auth.NewMemoryReplayStore()
+	}
+	secretKey := os.Getenv("API_KEY_SECRET_KEY")
+	if secretKey == "" {
+		log.Println("[api key] API_KEY_SECRET_KEY is empty, API keys are disabled")
+	}
+	return accountService.NewAPIKeyService(accountRepository.NewAPIKeyRepository(db), accountRepository.NewUserRepository(db), replays, []byte(secretKey))
+}
+
+// route ที่ bot เรียกด้วย API key ได้ใช้ตัวนี้แทน authMiddleware, key ต้องมีสิทธิ์ permission
+// route อื่นรับแค่ JWT ทำให้ API key จัดการ account/สร้าง key เพิ่มไม่ได้
+func authOrAPIKey(db *gorm.DB, revocations auth.RevocationStore, apiKeys accountService.APIKeyService, permission string) gin.HandlerFunc {
+	return middleware.AuthOrAPIKey(authMiddleware(db, revocations), middleware.APIKeyMiddleware(apiKeys, permission))
+}
+
+// MFA_SECRET_KEY ใช้เข้ารหัส TOTP secret ใน db, เปลี่ยน key แล้ว user ที่เปิด MFA ไว้ต้อง enroll ใหม่
+// ไม่ตั้งไว้ = ปิด MFA: enroll ไม่ได้ และ user ที่เปิดไว้แล้วทำรายการที่ต้องใช้ code ไม่ได้จนกว่าจะตั้ง key
+func newMFAService(db *gorm.DB) accountService.MFAService {
+	secretKey := os.Getenv("MFA_SECRET_KEY")
+	if secretKey == "" {
+		warnMFADisabled()
+	}
+	return accountService.NewMFAService(accountRepository.NewMFARepository(db), []byte(secretKey), newLoginAttemptStore(db))
+}
+
+var warnMFADisabled = sync.OnceFunc(func() {
+	log.Println("[mfa] MFA_SECRET_KEY is empty, MFA is disabled")
+})
+
+// MAILER=smtp ส่งจริงผ่าน SMTP_*, ค่า default เขียนลงไฟล์ MAIL_FILE_PATH (ว่าง = log แค่ผู้รับกับหัวเรื่อง) ใช้ตอน dev
+func newOutboxService(db *gorm.DB) mailService.OutboxService {
+	var sender mailer.Mailer
+	if os.Getenv("MAILER") == "smtp" {
+		port := os.Getenv("SMTP_PORT")
+		if port == "" {
+			port = "587"
+		}
+		sender = mailer.NewSMTPMailer(mailer.SMTPConfig{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     port,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     os.Getenv("MAIL_FROM"),
+		})
+	} else {
+		sender = mailer.NewFileMailer(os.Getenv("MAIL_FILE_PATH"))
+	}
+	return mailService.NewOutboxService(mailRepository.NewOutboxRepository(db), sender)
+}
+
+// MAIL_DISPATCH_INTERVAL เช่น 30s, ไม่ตั้งไว้ = 10s
+func startMailDispatcher(outbox mailService.OutboxService) {
+	interval := 10 * time.Second
+	if value := os.Getenv("MAIL_DISPATCH_INTERVAL"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			log.Println("[mail] invalid MAIL_DISPATCH_INTERVAL:", value)
+		} else {
+			interval = parsed
+		}
 	}
+	outbox.Start(interval)
 }
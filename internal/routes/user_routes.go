@@ -1,31 +1,62 @@
 package routes
 
 import (
+	"os"
+
 	"github.com/gin-gonic/gin"
 	"github.com/padapook/bestbit-core/internal/account/controller"
 	"github.com/padapook/bestbit-core/internal/account/repository"
 	"github.com/padapook/bestbit-core/internal/account/service"
 	"github.com/padapook/bestbit-core/internal/middleware"
+	"github.com/padapook/bestbit-core/internal/utils/auth"
 	"gorm.io/gorm"
 )
 
-func RegisterUserRoutes(router *gin.RouterGroup, db *gorm.DB) {
+func RegisterUserRoutes(router *gin.RouterGroup, db *gorm.DB, revocations auth.RevocationStore, loginGuard service.LoginGuard, apiKeys service.APIKeyService) {
 	userRepo := repository.NewUserRepository(db)
 	userSvc := service.NewUserService(userRepo, db)
-	userCtrl := controller.NewUserController(userSvc)
+	outbox := newOutboxService(db)
+	tokenSvc := newTokenService(db, revocations, outbox)
+	mfaSvc := newMFAService(db)
+	userCtrl := controller.NewUserController(userSvc, tokenSvc, mfaSvc, loginGuard)
+	mfaCtrl := controller.NewMFAController(mfaSvc)
+	passwordSvc := service.NewPasswordService(repository.NewPasswordRepository(db), userRepo, outbox,
+		revocations, os.Getenv("PASSWORD_RESET_URL"))
+	passwordCtrl := controller.NewPasswordController(passwordSvc, tokenSvc)
+	shareTokenSvc := service.NewShareTokenService(repository.NewShareTokenRepository(db), userRepo)
+	shareTokenCtrl := controller.NewShareTokenController(shareTokenSvc, tokenSvc)
+	apiKeyCtrl := controller.NewAPIKeyController(apiKeys)
+	sessionCtrl := controller.NewSessionController(tokenSvc)
 
 	publicUserRoutes := router.Group("")
 	{
 		publicUserRoutes.POST("/user/register", userCtrl.Register)
 		publicUserRoutes.POST("/login", userCtrl.Login)
-		publicUserRoutes.POST("/login/share-token", userCtrl.LoginByShareToken)
+		publicUserRoutes.POST("/login/mfa", userCtrl.LoginMFA)
+		publicUserRoutes.POST("/login/share-token", shareTokenCtrl.LoginByShareToken)
+		publicUserRoutes.POST("/token/refresh", userCtrl.RefreshToken)
+		publicUserRoutes.POST("/password/forgot", passwordCtrl.ForgotPassword)
+		publicUserRoutes.POST("/password/reset", passwordCtrl.ResetPassword)
 	}
 
 	userRoutes := router.Group("/user")
-	userRoutes.Use(middleware.AuthMiddleware())
+	userRoutes.Use(authMiddleware(db, revocations))
 	{
 		userRoutes.POST("/logout", userCtrl.Logout)
-		userRoutes.POST("/share-token", userCtrl.GenerateShareToken)
+		userRoutes.POST("/logout-all", userCtrl.LogoutAll)
+		userRoutes.GET("/sessions", sessionCtrl.GetSessions)
+		userRoutes.DELETE("/sessions/:id", sessionCtrl.RevokeSession)
+		userRoutes.POST("/share-token", shareTokenCtrl.Create)
+		userRoutes.GET("/share-tokens", shareTokenCtrl.GetOutstanding)
+		userRoutes.DELETE("/share-tokens/:id", shareTokenCtrl.Revoke)
+		userRoutes.POST("/api-keys", middleware.RequireMFA(mfaSvc), apiKeyCtrl.Create)
+		userRoutes.GET("/api-keys", apiKeyCtrl.GetAPIKeys)
+		userRoutes.DELETE("/api-keys/:id", apiKeyCtrl.Revoke)
+		userRoutes.POST("/password", passwordCtrl.ChangePassword)
+		userRoutes.GET("/mfa", mfaCtrl.GetStatus)
+		userRoutes.POST("/mfa/enroll", mfaCtrl.Enroll)
+		userRoutes.POST("/mfa/activate", mfaCtrl.Activate)
+		userRoutes.POST("/mfa/disable", mfaCtrl.Disable)
 		userRoutes.GET("/:username", userCtrl.GetProfile)
 	}
 }
@@ -2,25 +2,41 @@ package routes
 
 import (
 	"github.com/gin-gonic/gin"
+	accountModel "github.com/padapook/bestbit-core/internal/account/model"
+	accountRepository "github.com/padapook/bestbit-core/internal/account/repository"
+	accountService "github.com/padapook/bestbit-core/internal/account/service"
+	currencyRepository "github.com/padapook/bestbit-core/internal/currency/repository"
+	currencyService "github.com/padapook/bestbit-core/internal/currency/service"
+	idempotencyRepository "github.com/padapook/bestbit-core/internal/idempotency/repository"
+	limitRepository "github.com/padapook/bestbit-core/internal/limit/repository"
+	limitService "github.com/padapook/bestbit-core/internal/limit/service"
 	"github.com/padapook/bestbit-core/internal/middleware"
+	"github.com/padapook/bestbit-core/internal/utils/auth"
 	"github.com/padapook/bestbit-core/internal/wallet/controller"
 	"github.com/padapook/bestbit-core/internal/wallet/repository"
 	"github.com/padapook/bestbit-core/internal/wallet/service"
 	"gorm.io/gorm"
 )
 
-func RegisterWalletRoutes(router *gin.RouterGroup, db *gorm.DB) {
+func RegisterWalletRoutes(router *gin.RouterGroup, db *gorm.DB, revocations auth.RevocationStore, apiKeys accountService.APIKeyService) {
 	walletRepo := repository.NewWalletRepository(db)
-	walletSvc := service.NewWalletService(walletRepo)
+	walletTxRepo := repository.NewWalletTransactionRepository(db)
+	currencySvc := currencyService.NewCurrencyService(currencyRepository.NewCurrencyRepository(db))
+	limitSvc := limitService.NewLimitService(limitRepository.NewLimitRepository(db), currencySvc)
+	walletSvc := service.NewWalletService(walletRepo, walletTxRepo, limitSvc, currencySvc, accountRepository.NewUserRepository(db))
 	walletCtrl := controller.NewWalletController(walletSvc)
 
+	idempotency := middleware.IdempotencyMiddleware(idempotencyRepository.NewIdempotencyRepository(db))
+	requireMFA := middleware.RequireMFA(newMFAService(db))
+
 	walletRoutes := router.Group("/wallet")
-	walletRoutes.Use(middleware.AuthMiddleware())
+	walletRoutes.Use(authOrAPIKey(db, revocations, apiKeys, accountModel.APIKeyPermRead))
 	{
 		walletRoutes.GET("/", walletCtrl.GetWallets)
 		walletRoutes.GET("/:currency", walletCtrl.GetWalletByCurrency)
-		walletRoutes.POST("/deposit", walletCtrl.Deposit)
-		walletRoutes.POST("/withdraw", walletCtrl.Withdraw)
-		walletRoutes.POST("/transfer", walletCtrl.Transfer)
+		walletRoutes.GET("/:currency/transactions", walletCtrl.GetTransactions)
+		walletRoutes.GET("/:currency/transactions/:reference_id", walletCtrl.GetTransactionByReference)
+		walletRoutes.POST("/deposit", middleware.RequireAPIKeyPermission(accountModel.APIKeyPermTrade), idempotency, walletCtrl.Deposit)
+		walletRoutes.POST("/transfer", middleware.RequireAPIKeyPermission(accountModel.APIKeyPermWithdraw), requireMFA, idempotency, walletCtrl.Transfer)
 	}
 }
@@ -0,0 +1,74 @@
+package routes
+
+import (
+	"log"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	accountModel "github.com/padapook/bestbit-core/internal/account/model"
+	accountService "github.com/padapook/bestbit-core/internal/account/service"
+	currencyRepository "github.com/padapook/bestbit-core/internal/currency/repository"
+	currencyService "github.com/padapook/bestbit-core/internal/currency/service"
+	idempotencyRepository "github.com/padapook/bestbit-core/internal/idempotency/repository"
+	limitRepository "github.com/padapook/bestbit-core/internal/limit/repository"
+	limitService "github.com/padapook/bestbit-core/internal/limit/service"
+	"github.com/padapook/bestbit-core/internal/middleware"
+	"github.com/padapook/bestbit-core/internal/utils/auth"
+	walletRepository "github.com/padapook/bestbit-core/internal/wallet/repository"
+	"github.com/padapook/bestbit-core/internal/withdrawal/controller"
+	"github.com/padapook/bestbit-core/internal/withdrawal/provider"
+	"github.com/padapook/bestbit-core/internal/withdrawal/repository"
+	"github.com/padapook/bestbit-core/internal/withdrawal/service"
+	"gorm.io/gorm"
+)
+
+func RegisterWithdrawalRoutes(router *gin.RouterGroup, db *gorm.DB, revocations auth.RevocationStore, apiKeys accountService.APIKeyService) {
+	withdrawalRepo := repository.NewWithdrawalRepository(db)
+	walletRepo := walletRepository.NewWalletRepository(db)
+	currencySvc := currencyService.NewCurrencyService(currencyRepository.NewCurrencyRepository(db))
+	limitSvc := limitService.NewLimitService(limitRepository.NewLimitRepository(db), currencySvc)
+	// ยังไม่มี provider จริง ใช้ตัว local ไปก่อน
+	withdrawalSvc := service.NewWithdrawalService(withdrawalRepo, walletRepo, limitSvc, currencySvc, provider.NewLocalPayoutProvider())
+	withdrawalCtrl := controller.NewWithdrawalController(withdrawalSvc)
+
+	startWithdrawalRetry(withdrawalSvc)
+
+	idempotency := middleware.IdempotencyMiddleware(idempotencyRepository.NewIdempotencyRepository(db))
+	requireMFA := middleware.RequireMFA(newMFAService(db))
+
+	router.POST("/wallet/withdraw", authOrAPIKey(db, revocations, apiKeys, accountModel.APIKeyPermWithdraw), requireMFA, idempotency, withdrawalCtrl.RequestWithdrawal)
+
+	withdrawalRoutes := router.Group("/withdrawals")
+	withdrawalRoutes.Use(authOrAPIKey(db, revocations, apiKeys, accountModel.APIKeyPermRead))
+	{
+		withdrawalRoutes.GET("", withdrawalCtrl.GetWithdrawals)
+		withdrawalRoutes.GET("/:id", withdrawalCtrl.GetWithdrawal)
+	}
+
+	adminWithdrawalRoutes := router.Group("/admin/withdrawals")
+	adminWithdrawalRoutes.Use(authMiddleware(db, revocations), middleware.RequirePermission(accountModel.PermWithdrawalsManage))
+	{
+		adminWithdrawalRoutes.GET("", withdrawalCtrl.AdminGetWithdrawals)
+		adminWithdrawalRoutes.GET("/:id", withdrawalCtrl.AdminGetWithdrawal)
+		adminWithdrawalRoutes.POST("/:id/approve", withdrawalCtrl.Approve)
+		adminWithdrawalRoutes.POST("/:id/reject", withdrawalCtrl.Reject)
+		adminWithdrawalRoutes.POST("/:id/process", withdrawalCtrl.Process)
+		adminWithdrawalRoutes.POST("/:id/complete", withdrawalCtrl.Complete)
+		adminWithdrawalRoutes.POST("/:id/fail", withdrawalCtrl.Fail)
+	}
+}
+
+// WITHDRAWAL_RETRY_INTERVAL เช่น 5m, ไม่ตั้งไว้ = 1m
+func startWithdrawalRetry(withdrawals service.WithdrawalService) {
+	interval := time.Minute
+	if value := os.Getenv("WITHDRAWAL_RETRY_INTERVAL"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			log.Println("[withdrawal] invalid WITHDRAWAL_RETRY_INTERVAL:", value)
+		} else {
+			interval = parsed
+		}
+	}
+	withdrawals.Start(interval)
+}
@@ -1,15 +1,32 @@
 package model
 
 import (
+	"fmt"
 	"time"
+
+	"github.com/shopspring/decimal"
 )
 
 type Trade struct {
-	ID           uint64    `gorm:"primaryKey" json:"id"`
-	Symbol       string    `gorm:"size:20;index" json:"symbol" comment:""`
-	MakerOrderID uint64    `gorm:"index" json:"maker_order_id" comment:""`
-	TakerOrderID uint64    `gorm:"index" json:"taker_order_id" comment:""`
-	Price        float64   `gorm:"type:decimal(32,16)" json:"price"`
-	Amount       float64   `gorm:"type:decimal(32,16)" json:"amount"`
-	ExecutedAt   time.Time `gorm:"index" json:"executed_at"`
+	ID               uint64          `gorm:"primaryKey" json:"id"`
+	Symbol           string          `gorm:"size:20;index" json:"symbol" comment:""`
+	MakerOrderID     uint64          `gorm:"index" json:"maker_order_id" comment:""`
+	TakerOrderID     uint64          `gorm:"index" json:"taker_order_id" comment:""`
+	MakerUserID      string          `gorm:"size:100;index" json:"maker_user_id"`
+	TakerUserID      string          `gorm:"size:100;index" json:"taker_user_id"`
+	TakerSide        string          `gorm:"size:10" json:"taker_side" comment:"BUY, SELL"`
+	Price            decimal.Decimal `gorm:"type:decimal(32,16)" json:"price"`
+	Amount           decimal.Decimal `gorm:"type:decimal(32,16)" json:"amount" comment:"จำนวนฝั่ง base"`
+	QuoteAmount      decimal.Decimal `gorm:"type:decimal(32,16);default:0" json:"quote_amount" comment:"price * amount"`
+	MakerFee         decimal.Decimal `gorm:"type:decimal(32,16);default:0" json:"maker_fee"`
+	MakerFeeCurrency string          `gorm:"size:10" json:"maker_fee_currency" comment:"สกุลที่ maker ได้รับ"`
+	TakerFee         decimal.Decimal `gorm:"type:decimal(32,16);default:0" json:"taker_fee"`
+	TakerFeeCurrency string          `gorm:"size:10" json:"taker_fee_currency" comment:"สกุลที่ taker ได้รับ"`
+	ExecutedAt       time.Time       `gorm:"index" json:"executed_at"`
+	SettledAt        *time.Time      `gorm:"index" json:"settled_at" comment:"null = ยังไม่ได้โอนเงินระหว่าง wallet"`
+}
+
+// ใช้เป็น WalletTransaction.ReferenceID ของทุกแถวที่เกิดจาก trade นี้
+func (t *Trade) Reference() string {
+	return fmt.Sprintf("TRADE-%d", t.ID)
 }
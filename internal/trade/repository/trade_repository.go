@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/padapook/bestbit-core/internal/trade/model"
+	"github.com/padapook/bestbit-core/internal/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TradeRepository interface {
+	Transaction(fn func(tx *gorm.DB) error) error
+	CreateTrade(tx *gorm.DB, trade *model.Trade) error
+	GetTradeForUpdate(tx *gorm.DB, tradeID uint64) (*model.Trade, error)
+	MarkSettled(tx *gorm.DB, trade *model.Trade) error
+	CountUnsettledByOrderID(tx *gorm.DB, orderID uint64) (int64, error)
+	GetUnsettledTradeIDs(limit int) ([]uint64, error)
+}
+
+type tradeRepository struct {
+	db *gorm.DB
+}
+
+func NewTradeRepository(db *gorm.DB) TradeRepository {
+	return &tradeRepository{db: db}
+}
+
+func (r *tradeRepository) Transaction(fn func(tx *gorm.DB) error) error {
+	return r.db.Transaction(fn)
+}
+
+func (r *tradeRepository) CreateTrade(tx *gorm.DB, trade *model.Trade) error {
+	return tx.Create(trade).Error
+}
+
+func (r *tradeRepository) GetTradeForUpdate(tx *gorm.DB, tradeID uint64) (*model.Trade, error) {
+	var trade model.Trade
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", tradeID).
+		First(&trade).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.ErrTradeNotFound
+		}
+		return nil, err
+	}
+	return &trade, nil
+}
+
+// บันทึกค่าธรรมเนียมที่คำนวณตอน settle ไปพร้อมกัน
+func (r *tradeRepository) MarkSettled(tx *gorm.DB, trade *model.Trade) error {
+	now := time.Now()
+	trade.SettledAt = &now
+	return tx.Save(trade).Error
+}
+
+func (r *tradeRepository) CountUnsettledByOrderID(tx *gorm.DB, orderID uint64) (int64, error) {
+	var count int64
+	err := tx.Model(&model.Trade{}).
+		Where("(maker_order_id = ? OR taker_order_id = ?) AND settled_at IS NULL", orderID, orderID).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *tradeRepository) GetUnsettledTradeIDs(limit int) ([]uint64, error) {
+	var ids []uint64
+	err := r.db.Model(&model.Trade{}).
+		Where("settled_at IS NULL").
+		Order("id ASC").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
+}
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"log"
+	"os"
+
+	"github.com/shopspring/decimal"
+)
+
+// FeeSchedule อัตราค่าธรรมเนียมเป็นสัดส่วนของสิ่งที่แต่ละฝั่งได้รับ เช่น 0.0025 = 0.25%
+type FeeSchedule struct {
+	MakerRate decimal.Decimal
+	TakerRate decimal.Decimal
+}
+
+// อ่านจาก TRADE_MAKER_FEE_RATE / TRADE_TAKER_FEE_RATE ไม่ได้ตั้งค่าไว้ = ไม่เก็บค่าธรรมเนียม
+func LoadFeeScheduleFromEnv() FeeSchedule {
+	return FeeSchedule{
+		MakerRate: feeRateFromEnv("TRADE_MAKER_FEE_RATE"),
+		TakerRate: feeRateFromEnv("TRADE_TAKER_FEE_RATE"),
+	}
+}
+
+func feeRateFromEnv(key string) decimal.Decimal {
+	value := os.Getenv(key)
+	if value == "" {
+		return decimal.Zero
+	}
+
+	rate, err := decimal.NewFromString(value)
+	if err != nil || rate.IsNegative() || rate.GreaterThanOrEqual(decimal.NewFromInt(1)) {
+		log.Println("[settlement] invalid", key, "=", value, "using 0")
+		return decimal.Zero
+	}
+	return rate
+}
+
+// ปัดลงเพื่อไม่ให้เก็บเกินอัตรา
+func calculateFee(rate, received decimal.Decimal) decimal.Decimal {
+	return received.Mul(rate).Truncate(amountPrecision)
+}
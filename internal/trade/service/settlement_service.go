@@ -0,0 +1,230 @@
+package service
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	orderModel "github.com/padapook/bestbit-core/internal/order/model"
+	orderRepository "github.com/padapook/bestbit-core/internal/order/repository"
+	"github.com/padapook/bestbit-core/internal/trade/repository"
+	"github.com/padapook/bestbit-core/internal/utils"
+	walletRepository "github.com/padapook/bestbit-core/internal/wallet/repository"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// ทศนิยมสูงสุดตาม column decimal(32,16)
+const amountPrecision = 16
+
+const settlePendingBatchSize = 500
+
+// QuoteAmount ปัดราย trade ละไม่เกินครึ่งหน่วยสุดท้าย ผลรวมหลาย fill เลยอาจเกินยอด hold ได้นิดเดียว
+// เกินกว่านี้ถือว่าผิดจริง ให้ fail แทนที่จะตัดยอดเงียบๆ
+var maxQuoteRoundingExcess = decimal.New(1, -12)
+
+type SettlementService interface {
+	Settle(tradeID uint64) error
+	SettlePending() (int, error)
+	ReleaseRemainingHold(tx *gorm.DB, order *orderModel.Order) error
+	// retry trade ที่ settle ไม่สำเร็จตอน runtime (เช่น lock timeout) ทุก interval
+	Start(interval time.Duration) (stop func())
+}
+
+type settlementService struct {
+	tradeRepo  repository.TradeRepository
+	orderRepo  orderRepository.OrderRepository
+	walletRepo walletRepository.WalletRepository
+	fees       FeeSchedule
+}
+
+func NewSettlementService(tradeRepo repository.TradeRepository, orderRepo orderRepository.OrderRepository, walletRepo walletRepository.WalletRepository, fees FeeSchedule) SettlementService {
+	return &settlementService{tradeRepo: tradeRepo, orderRepo: orderRepo, walletRepo: walletRepo, fees: fees}
+}
+
+// Settle โอน base/quote ระหว่าง buyer กับ seller ของ trade ใน transaction เดียว
+// trade ที่ settle ไปแล้วจะไม่ทำซ้ำ
+func (s *settlementService) Settle(tradeID uint64) error {
+	return s.tradeRepo.Transaction(func(tx *gorm.DB) error {
+		trade, err := s.tradeRepo.GetTradeForUpdate(tx, tradeID)
+		if err != nil {
+			return err
+		}
+		if trade.SettledAt != nil {
+			return nil
+		}
+
+		// lock order ตาม id น้อยไปมากก่อน wallet ทุกครั้ง
+		firstID, secondID := trade.MakerOrderID, trade.TakerOrderID
+		if firstID > secondID {
+			firstID, secondID = secondID, firstID
+		}
+		first, err := s.orderRepo.GetOrderByIDForUpdate(tx, firstID)
+		if err != nil {
+			return err
+		}
+		second, err := s.orderRepo.GetOrderByIDForUpdate(tx, secondID)
+		if err != nil {
+			return err
+		}
+
+		buyOrder, sellOrder := first, second
+		if first.Side != orderModel.SideBuy {
+			buyOrder, sellOrder = second, first
+		}
+		if buyOrder.Side != orderModel.SideBuy || sellOrder.Side != orderModel.SideSell {
+			return utils.ErrInvalidTrade
+		}
+
+		base, quote, ok := orderModel.SplitSymbol(trade.Symbol)
+		if !ok {
+			return utils.ErrInvalidSymbol
+		}
+
+		// fill สุดท้ายจ่ายเท่าที่ hold เหลือ ไม่งั้น ConsumeHold ตอบ ErrInsufficientLocked แล้ว trade ค้างตลอดไป
+		if excess := trade.QuoteAmount.Sub(buyOrder.LockedAmount); excess.IsPositive() && excess.LessThanOrEqual(maxQuoteRoundingExcess) {
+			trade.QuoteAmount = buyOrder.LockedAmount
+		}
+
+		// maker/taker ได้รับคนละสกุล ค่าธรรมเนียมเก็บจากสิ่งที่ได้รับ
+		buyerFeeRate, sellerFeeRate := s.fees.TakerRate, s.fees.MakerRate
+		if trade.TakerSide == orderModel.SideSell {
+			buyerFeeRate, sellerFeeRate = s.fees.MakerRate, s.fees.TakerRate
+		}
+		buyerFee := calculateFee(buyerFeeRate, trade.Amount)
+		sellerFee := calculateFee(sellerFeeRate, trade.QuoteAmount)
+
+		if trade.TakerSide == orderModel.SideBuy {
+			trade.TakerFee, trade.TakerFeeCurrency = buyerFee, base
+			trade.MakerFee, trade.MakerFeeCurrency = sellerFee, quote
+		} else {
+			trade.TakerFee, trade.TakerFeeCurrency = sellerFee, quote
+			trade.MakerFee, trade.MakerFeeCurrency = buyerFee, base
+		}
+
+		err = s.walletRepo.LockWallets(tx, []walletRepository.WalletKey{
+			{UserID: buyOrder.UserID, Currency: base},
+			{UserID: buyOrder.UserID, Currency: quote},
+			{UserID: sellOrder.UserID, Currency: base},
+			{UserID: sellOrder.UserID, Currency: quote},
+		})
+		if err != nil {
+			return err
+		}
+
+		ref := trade.Reference()
+		if err := s.settleSide(tx, buyOrder.UserID, quote, trade.QuoteAmount, base, trade.Amount, buyerFee, ref); err != nil {
+			return err
+		}
+		if err := s.settleSide(tx, sellOrder.UserID, base, trade.Amount, quote, trade.QuoteAmount, sellerFee, ref); err != nil {
+			return err
+		}
+
+		buyOrder.LockedAmount = buyOrder.LockedAmount.Sub(trade.QuoteAmount)
+		sellOrder.LockedAmount = sellOrder.LockedAmount.Sub(trade.Amount)
+
+		if err := s.tradeRepo.MarkSettled(tx, trade); err != nil {
+			return err
+		}
+
+		for _, order := range []*orderModel.Order{first, second} {
+			if err := s.ReleaseRemainingHold(tx, order); err != nil {
+				return err
+			}
+			if err := s.orderRepo.UpdateOrder(tx, order); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// settleSide ตัดยอดที่ hold ของสกุลที่จ่าย แล้วเพิ่มสกุลที่ได้รับหักค่าธรรมเนียม
+func (s *settlementService) settleSide(tx *gorm.DB, userID, payCurrency string, payAmount decimal.Decimal, receiveCurrency string, receiveAmount, fee decimal.Decimal, ref string) error {
+	if _, err := s.walletRepo.ConsumeHold(tx, userID, payCurrency, payAmount, ref); err != nil {
+		return err
+	}
+	if _, err := s.walletRepo.CreditFunds(tx, userID, receiveCurrency, receiveAmount, ref); err != nil {
+		return err
+	}
+	if fee.IsPositive() {
+		if _, err := s.walletRepo.ChargeFee(tx, userID, receiveCurrency, fee, ref); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SettlePending ใช้ตอน start server หรือ retry trade ที่ settle ไม่สำเร็จ
+func (s *settlementService) SettlePending() (int, error) {
+	ids, err := s.tradeRepo.GetUnsettledTradeIDs(settlePendingBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	settled := 0
+	for _, id := range ids {
+		if err := s.Settle(id); err != nil {
+			log.Println("[settlement] trade", id, "failed:", err)
+			continue
+		}
+		settled++
+	}
+	return settled, nil
+}
+
+func (s *settlementService) Start(interval time.Duration) func() {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	var once sync.Once
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				settled, err := s.SettlePending()
+				if err != nil {
+					log.Println("[settlement] retry pending failed:", err)
+					continue
+				}
+				if settled > 0 {
+					log.Println("[settlement] settled", settled, "pending trades")
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		once.Do(func() {
+			ticker.Stop()
+			close(done)
+		})
+	}
+}
+
+// ReleaseRemainingHold คืนยอดที่ hold เกินไว้ (เช่น buy ได้ราคาดีกว่า limit หรือ market ที่ใช้งบไม่หมด)
+// ทำเฉพาะ order ที่จบแล้วและไม่มี trade ค้าง settle เพราะ trade ที่ค้างยังต้องใช้ยอดนี้
+// ผู้เรียกต้อง lock order ไว้แล้วและ save order เอง
+func (s *settlementService) ReleaseRemainingHold(tx *gorm.DB, order *orderModel.Order) error {
+	if !order.IsTerminal() || !order.LockedAmount.IsPositive() {
+		return nil
+	}
+
+	pending, err := s.tradeRepo.CountUnsettledByOrderID(tx, order.ID)
+	if err != nil {
+		return err
+	}
+	if pending > 0 {
+		return nil
+	}
+
+	if _, err := s.walletRepo.ReleaseFunds(tx, order.UserID, order.HoldCurrency(), order.LockedAmount, order.HoldReference()); err != nil {
+		return err
+	}
+
+	order.LockedAmount = decimal.Zero
+	return nil
+}
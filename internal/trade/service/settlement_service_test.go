@@ -0,0 +1,398 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	orderModel "github.com/padapook/bestbit-core/internal/order/model"
+	orderRepository "github.com/padapook/bestbit-core/internal/order/repository"
+	"github.com/padapook/bestbit-core/internal/trade/model"
+	walletModel "github.com/padapook/bestbit-core/internal/wallet/model"
+	walletRepository "github.com/padapook/bestbit-core/internal/wallet/repository"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+type MockTradeRepository struct {
+	mock.Mock
+}
+
+func (m *MockTradeRepository) Transaction(fn func(tx *gorm.DB) error) error {
+	return fn(nil)
+}
+
+func (m *MockTradeRepository) CreateTrade(tx *gorm.DB, trade *model.Trade) error {
+	args := m.Called(tx, trade)
+	return args.Error(0)
+}
+
+func (m *MockTradeRepository) GetTradeForUpdate(tx *gorm.DB, tradeID uint64) (*model.Trade, error) {
+	args := m.Called(tx, tradeID)
+	if args.Get(0) != nil {
+		return args.Get(0).(*model.Trade), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockTradeRepository) MarkSettled(tx *gorm.DB, trade *model.Trade) error {
+	args := m.Called(tx, trade)
+	return args.Error(0)
+}
+
+func (m *MockTradeRepository) CountUnsettledByOrderID(tx *gorm.DB, orderID uint64) (int64, error) {
+	args := m.Called(tx, orderID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockTradeRepository) GetUnsettledTradeIDs(limit int) ([]uint64, error) {
+	args := m.Called(limit)
+	if args.Get(0) != nil {
+		return args.Get(0).([]uint64), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+type MockOrderRepository struct {
+	mock.Mock
+}
+
+func (m *MockOrderRepository) Transaction(fn func(tx *gorm.DB) error) error {
+	return fn(nil)
+}
+
+func (m *MockOrderRepository) CreateOrder(tx *gorm.DB, order *orderModel.Order) error {
+	args := m.Called(tx, order)
+	return args.Error(0)
+}
+
+func (m *MockOrderRepository) UpdateOrder(tx *gorm.DB, order *orderModel.Order) error {
+	args := m.Called(tx, order)
+	return args.Error(0)
+}
+
+func (m *MockOrderRepository) GetOrderForUpdate(tx *gorm.DB, userID string, orderID uint64) (*orderModel.Order, error) {
+	args := m.Called(tx, userID, orderID)
+	if args.Get(0) != nil {
+		return args.Get(0).(*orderModel.Order), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockOrderRepository) GetOrderByIDForUpdate(tx *gorm.DB, orderID uint64) (*orderModel.Order, error) {
+	args := m.Called(tx, orderID)
+	if args.Get(0) != nil {
+		return args.Get(0).(*orderModel.Order), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockOrderRepository) GetOpenLimitOrders() ([]orderModel.Order, error) {
+	args := m.Called()
+	if args.Get(0) != nil {
+		return args.Get(0).([]orderModel.Order), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockOrderRepository) GetOpenOrdersByUserID(userID string) ([]orderModel.Order, error) {
+	args := m.Called(userID)
+	if args.Get(0) != nil {
+		return args.Get(0).([]orderModel.Order), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockOrderRepository) GetOrderByID(userID string, orderID uint64) (*orderModel.Order, error) {
+	args := m.Called(userID, orderID)
+	if args.Get(0) != nil {
+		return args.Get(0).(*orderModel.Order), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockOrderRepository) GetOrdersByUserID(userID string, filter orderRepository.OrderFilter) ([]orderModel.Order, error) {
+	args := m.Called(userID, filter)
+	if args.Get(0) != nil {
+		return args.Get(0).([]orderModel.Order), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+type MockWalletRepository struct {
+	mock.Mock
+}
+
+func (m *MockWalletRepository) GetWalletByUserID(userID string) ([]walletModel.Wallet, error) {
+	args := m.Called(userID)
+	if args.Get(0) != nil {
+		return args.Get(0).([]walletModel.Wallet), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockWalletRepository) GetWalletByUserIDAndCurrency(userID, currency string) (*walletModel.Wallet, error) {
+	args := m.Called(userID, currency)
+	if args.Get(0) != nil {
+		return args.Get(0).(*walletModel.Wallet), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockWalletRepository) GetAllWallets(afterID uint64, limit int) ([]walletModel.Wallet, error) {
+	args := m.Called(afterID, limit)
+	if args.Get(0) != nil {
+		return args.Get(0).([]walletModel.Wallet), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockWalletRepository) Transaction(fn func(tx *gorm.DB) error) error {
+	return fn(nil)
+}
+
+func (m *MockWalletRepository) EnsureWallet(tx *gorm.DB, userID, currency string) error {
+	args := m.Called(tx, userID, currency)
+	return args.Error(0)
+}
+
+func (m *MockWalletRepository) Deposit(tx *gorm.DB, userID, currency string, amount decimal.Decimal, referenceID string) (*walletModel.Wallet, error) {
+	args := m.Called(tx, userID, currency, amount, referenceID)
+	if args.Get(0) != nil {
+		return args.Get(0).(*walletModel.Wallet), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockWalletRepository) Transfer(tx *gorm.DB, fromUserID, toUserID, currency string, amount decimal.Decimal, referenceID string) error {
+	args := m.Called(tx, fromUserID, toUserID, currency, amount, referenceID)
+	return args.Error(0)
+}
+
+func (m *MockWalletRepository) GetWalletForUpdate(tx *gorm.DB, userID, currency string) (*walletModel.Wallet, error) {
+	args := m.Called(tx, userID, currency)
+	if args.Get(0) != nil {
+		return args.Get(0).(*walletModel.Wallet), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockWalletRepository) HoldFunds(tx *gorm.DB, userID, currency string, amount decimal.Decimal, referenceID string) (*walletModel.Wallet, error) {
+	args := m.Called(tx, userID, currency, amount, referenceID)
+	if args.Get(0) != nil {
+		return args.Get(0).(*walletModel.Wallet), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockWalletRepository) ReleaseFunds(tx *gorm.DB, userID, currency string, amount decimal.Decimal, referenceID string) (*walletModel.Wallet, error) {
+	args := m.Called(tx, userID, currency, amount, referenceID)
+	if args.Get(0) != nil {
+		return args.Get(0).(*walletModel.Wallet), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockWalletRepository) ConsumeHold(tx *gorm.DB, userID, currency string, amount decimal.Decimal, referenceID string) (*walletModel.Wallet, error) {
+	args := m.Called(tx, userID, currency, amount, referenceID)
+	if args.Get(0) != nil {
+		return args.Get(0).(*walletModel.Wallet), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockWalletRepository) CreditFunds(tx *gorm.DB, userID, currency string, amount decimal.Decimal, referenceID string) (*walletModel.Wallet, error) {
+	args := m.Called(tx, userID, currency, amount, referenceID)
+	if args.Get(0) != nil {
+		return args.Get(0).(*walletModel.Wallet), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockWalletRepository) ChargeFee(tx *gorm.DB, userID, currency string, amount decimal.Decimal, referenceID string) (*walletModel.Wallet, error) {
+	args := m.Called(tx, userID, currency, amount, referenceID)
+	if args.Get(0) != nil {
+		return args.Get(0).(*walletModel.Wallet), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockWalletRepository) CompleteWithdrawal(tx *gorm.DB, userID, currency string, amount decimal.Decimal, referenceID string) (*walletModel.Wallet, error) {
+	args := m.Called(tx, userID, currency, amount, referenceID)
+	if args.Get(0) != nil {
+		return args.Get(0).(*walletModel.Wallet), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockWalletRepository) Adjust(tx *gorm.DB, userID, currency string, amount decimal.Decimal, referenceID, reason, actor string) (*walletModel.Wallet, error) {
+	args := m.Called(tx, userID, currency, amount, referenceID, reason, actor)
+	if args.Get(0) != nil {
+		return args.Get(0).(*walletModel.Wallet), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockWalletRepository) LockWallets(tx *gorm.DB, keys []walletRepository.WalletKey) error {
+	args := m.Called(tx, keys)
+	return args.Error(0)
+}
+
+func decimalEq(value string) interface{} {
+	return mock.MatchedBy(func(d decimal.Decimal) bool {
+		return d.Equal(decimal.RequireFromString(value))
+	})
+}
+
+func TestSettle_MovesBaseAndQuoteAndReleasesLeftover(t *testing.T) {
+	trades := new(MockTradeRepository)
+	orders := new(MockOrderRepository)
+	wallets := new(MockWalletRepository)
+	service := NewSettlementService(trades, orders, wallets, FeeSchedule{})
+
+	trade := &model.Trade{ID: 5, Symbol: "BTC_THB", MakerOrderID: 10, TakerOrderID: 11, MakerUserID: "seller", TakerUserID: "buyer",
+		TakerSide: orderModel.SideBuy, Price: decimal.NewFromInt(100), Amount: decimal.RequireFromString("0.5"), QuoteAmount: decimal.NewFromInt(50)}
+	// maker ขายที่ 100, taker ซื้อ limit 120 hold ไว้ 60 THB ใช้จริง 50
+	sell := &orderModel.Order{ID: 10, UserID: "seller", Symbol: "BTC_THB", Side: orderModel.SideSell,
+		Status: orderModel.StatusPartialFilled, Price: decimal.NewFromInt(100), LockedAmount: decimal.NewFromInt(1)}
+	buy := &orderModel.Order{ID: 11, UserID: "buyer", Symbol: "BTC_THB", Side: orderModel.SideBuy,
+		Status: orderModel.StatusFilled, Price: decimal.NewFromInt(120), LockedAmount: decimal.NewFromInt(60)}
+
+	trades.On("GetTradeForUpdate", mock.Anything, uint64(5)).Return(trade, nil)
+	orders.On("GetOrderByIDForUpdate", mock.Anything, uint64(10)).Return(sell, nil)
+	orders.On("GetOrderByIDForUpdate", mock.Anything, uint64(11)).Return(buy, nil)
+	wallets.On("LockWallets", mock.Anything, mock.Anything).Return(nil)
+	wallets.On("ConsumeHold", mock.Anything, "buyer", "THB", decimalEq("50"), "TRADE-5").Return(&walletModel.Wallet{}, nil)
+	wallets.On("CreditFunds", mock.Anything, "buyer", "BTC", decimalEq("0.5"), "TRADE-5").Return(&walletModel.Wallet{}, nil)
+	wallets.On("ConsumeHold", mock.Anything, "seller", "BTC", decimalEq("0.5"), "TRADE-5").Return(&walletModel.Wallet{}, nil)
+	wallets.On("CreditFunds", mock.Anything, "seller", "THB", decimalEq("50"), "TRADE-5").Return(&walletModel.Wallet{}, nil)
+	trades.On("MarkSettled", mock.Anything, trade).Return(nil)
+	trades.On("CountUnsettledByOrderID", mock.Anything, uint64(11)).Return(int64(0), nil)
+	// buy order จบแล้ว คืนส่วนที่ hold เกิน 10 THB
+	wallets.On("ReleaseFunds", mock.Anything, "buyer", "THB", decimalEq("10"), "ORDER-11").Return(&walletModel.Wallet{}, nil)
+	orders.On("UpdateOrder", mock.Anything, mock.AnythingOfType("*model.Order")).Return(nil)
+
+	err := service.Settle(5)
+
+	assert.NoError(t, err)
+	assert.True(t, sell.LockedAmount.Equal(decimal.RequireFromString("0.5")))
+	assert.True(t, buy.LockedAmount.IsZero())
+	trades.AssertExpectations(t)
+	wallets.AssertExpectations(t)
+
+	keys := wallets.Calls[0].Arguments.Get(1).([]walletRepository.WalletKey)
+	assert.Len(t, keys, 4)
+}
+
+func TestSettle_ChargesFeesInReceivedCurrency(t *testing.T) {
+	trades := new(MockTradeRepository)
+	orders := new(MockOrderRepository)
+	wallets := new(MockWalletRepository)
+	// maker 0.1%, taker 0.2%
+	service := NewSettlementService(trades, orders, wallets, FeeSchedule{
+		MakerRate: decimal.RequireFromString("0.001"),
+		TakerRate: decimal.RequireFromString("0.002"),
+	})
+
+	// taker ขาย 2 BTC ให้ maker ที่ 1000
+	trade := &model.Trade{ID: 6, Symbol: "BTC_THB", MakerOrderID: 20, TakerOrderID: 21, MakerUserID: "buyer", TakerUserID: "seller",
+		TakerSide: orderModel.SideSell, Price: decimal.NewFromInt(1000), Amount: decimal.NewFromInt(2), QuoteAmount: decimal.NewFromInt(2000)}
+	buy := &orderModel.Order{ID: 20, UserID: "buyer", Symbol: "BTC_THB", Side: orderModel.SideBuy,
+		Status: orderModel.StatusPartialFilled, LockedAmount: decimal.NewFromInt(5000)}
+	sell := &orderModel.Order{ID: 21, UserID: "seller", Symbol: "BTC_THB", Side: orderModel.SideSell,
+		Status: orderModel.StatusPartialFilled, LockedAmount: decimal.NewFromInt(3)}
+
+	trades.On("GetTradeForUpdate", mock.Anything, uint64(6)).Return(trade, nil)
+	orders.On("GetOrderByIDForUpdate", mock.Anything, uint64(20)).Return(buy, nil)
+	orders.On("GetOrderByIDForUpdate", mock.Anything, uint64(21)).Return(sell, nil)
+	wallets.On("LockWallets", mock.Anything, mock.Anything).Return(nil)
+	wallets.On("ConsumeHold", mock.Anything, mock.Anything, mock.Anything, mock.Anything, "TRADE-6").Return(&walletModel.Wallet{}, nil)
+	wallets.On("CreditFunds", mock.Anything, mock.Anything, mock.Anything, mock.Anything, "TRADE-6").Return(&walletModel.Wallet{}, nil)
+	// buyer เป็น maker ได้ BTC เสีย 0.1% ของ 2 BTC, seller เป็น taker ได้ THB เสีย 0.2% ของ 2000
+	wallets.On("ChargeFee", mock.Anything, "buyer", "BTC", decimalEq("0.002"), "TRADE-6").Return(&walletModel.Wallet{}, nil)
+	wallets.On("ChargeFee", mock.Anything, "seller", "THB", decimalEq("4"), "TRADE-6").Return(&walletModel.Wallet{}, nil)
+	trades.On("MarkSettled", mock.Anything, trade).Return(nil)
+	orders.On("UpdateOrder", mock.Anything, mock.AnythingOfType("*model.Order")).Return(nil)
+
+	err := service.Settle(6)
+
+	assert.NoError(t, err)
+	assert.True(t, trade.MakerFee.Equal(decimal.RequireFromString("0.002")))
+	assert.Equal(t, "BTC", trade.MakerFeeCurrency)
+	assert.True(t, trade.TakerFee.Equal(decimal.NewFromInt(4)))
+	assert.Equal(t, "THB", trade.TakerFeeCurrency)
+	wallets.AssertExpectations(t)
+}
+
+// QuoteAmount ที่ปัดขึ้นเกิน hold ที่เหลือนิดเดียว fill สุดท้ายจ่ายเท่าที่เหลือแทนที่จะ fail
+func TestSettle_ClampsRoundedQuoteToRemainingHold(t *testing.T) {
+	trades := new(MockTradeRepository)
+	orders := new(MockOrderRepository)
+	wallets := new(MockWalletRepository)
+	service := NewSettlementService(trades, orders, wallets, FeeSchedule{})
+
+	held := decimal.RequireFromString("33.3333333333333333")
+	trade := &model.Trade{ID: 7, Symbol: "BTC_THB", MakerOrderID: 30, TakerOrderID: 31, MakerUserID: "seller", TakerUserID: "buyer",
+		TakerSide: orderModel.SideBuy, Price: decimal.NewFromInt(100), Amount: decimal.RequireFromString("0.3333333333333333"),
+		QuoteAmount: decimal.RequireFromString("33.3333333333333334")}
+	sell := &orderModel.Order{ID: 30, UserID: "seller", Symbol: "BTC_THB", Side: orderModel.SideSell,
+		Status: orderModel.StatusPartialFilled, LockedAmount: decimal.NewFromInt(1)}
+	buy := &orderModel.Order{ID: 31, UserID: "buyer", Symbol: "BTC_THB", Side: orderModel.SideBuy,
+		Status: orderModel.StatusFilled, LockedAmount: held}
+
+	trades.On("GetTradeForUpdate", mock.Anything, uint64(7)).Return(trade, nil)
+	orders.On("GetOrderByIDForUpdate", mock.Anything, uint64(30)).Return(sell, nil)
+	orders.On("GetOrderByIDForUpdate", mock.Anything, uint64(31)).Return(buy, nil)
+	wallets.On("LockWallets", mock.Anything, mock.Anything).Return(nil)
+	wallets.On("ConsumeHold", mock.Anything, "buyer", "THB", decimalEq(held.String()), "TRADE-7").Return(&walletModel.Wallet{}, nil)
+	wallets.On("CreditFunds", mock.Anything, "buyer", "BTC", mock.Anything, "TRADE-7").Return(&walletModel.Wallet{}, nil)
+	wallets.On("ConsumeHold", mock.Anything, "seller", "BTC", mock.Anything, "TRADE-7").Return(&walletModel.Wallet{}, nil)
+	wallets.On("CreditFunds", mock.Anything, "seller", "THB", decimalEq(held.String()), "TRADE-7").Return(&walletModel.Wallet{}, nil)
+	trades.On("MarkSettled", mock.Anything, trade).Return(nil)
+	trades.On("CountUnsettledByOrderID", mock.Anything, mock.Anything).Return(int64(0), nil)
+	orders.On("UpdateOrder", mock.Anything, mock.AnythingOfType("*model.Order")).Return(nil)
+
+	err := service.Settle(7)
+
+	assert.NoError(t, err)
+	assert.True(t, trade.QuoteAmount.Equal(held))
+	assert.True(t, buy.LockedAmount.IsZero())
+	wallets.AssertNotCalled(t, "ReleaseFunds", mock.Anything, "buyer", mock.Anything, mock.Anything, mock.Anything)
+	wallets.AssertExpectations(t)
+}
+
+func TestSettle_ReplayIsNoop(t *testing.T) {
+	trades := new(MockTradeRepository)
+	orders := new(MockOrderRepository)
+	wallets := new(MockWalletRepository)
+	service := NewSettlementService(trades, orders, wallets, FeeSchedule{})
+
+	settledAt := time.Now()
+	trades.On("GetTradeForUpdate", mock.Anything, uint64(5)).
+		Return(&model.Trade{ID: 5, Symbol: "BTC_THB", SettledAt: &settledAt}, nil)
+
+	err := service.Settle(5)
+
+	assert.NoError(t, err)
+	orders.AssertNotCalled(t, "GetOrderByIDForUpdate")
+	wallets.AssertNotCalled(t, "ConsumeHold")
+	wallets.AssertNotCalled(t, "CreditFunds")
+	trades.AssertNotCalled(t, "MarkSettled")
+}
+
+func TestReleaseRemainingHold_WaitsForPendingTrades(t *testing.T) {
+	trades := new(MockTradeRepository)
+	wallets := new(MockWalletRepository)
+	service := NewSettlementService(trades, new(MockOrderRepository), wallets, FeeSchedule{})
+
+	order := &orderModel.Order{ID: 3, UserID: "u", Symbol: "BTC_THB", Side: orderModel.SideSell,
+		Status: orderModel.StatusCanceled, LockedAmount: decimal.NewFromInt(2)}
+	trades.On("CountUnsettledByOrderID", mock.Anything, uint64(3)).Return(int64(1), nil)
+
+	err := service.ReleaseRemainingHold(nil, order)
+
+	assert.NoError(t, err)
+	assert.True(t, order.LockedAmount.Equal(decimal.NewFromInt(2)))
+	wallets.AssertNotCalled(t, "ReleaseFunds")
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/padapook/bestbit-core/internal/account/model"
 
 	"log"
@@ -27,26 +28,95 @@ func getShareTokenSecret() []byte {
 	return []byte(secret)
 }
 
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+	// รหัสผ่านถูกแล้วแต่ยังไม่ผ่าน MFA ใช้แลก token จริงที่ /login/mfa ได้อย่างเดียว
+	TokenTypeMFA = "mfa"
+)
+
+const (
+	AccessTokenTTL  = 1 * time.Hour
+	RefreshTokenTTL = 1 * 24 * time.Hour
+	MFATokenTTL     = 5 * time.Minute
+	ShareTokenTTL   = 5 * time.Minute
+)
+
+// scope ของ session, ว่าง = เต็มสิทธิ์
+const (
+	// session ที่ได้จาก share token ดูได้อย่างเดียว ถอน/โอน/แก้ไขอะไรไม่ได้
+	ScopeReadOnly = "read_only"
+)
+
 type TokenDetails struct {
-	AccessToken  string
-	RefreshToken string
+	AccessToken      string
+	AccessTokenID    string
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshTokenID   string
+	RefreshExpiresAt time.Time
 }
 
 type Claims struct {
 	AccountID string `json:"account_id"`
 	Username  string `json:"username"`
+	TokenType string `json:"token_type,omitempty"`
+	// ใส่เฉพาะ access token, เปลี่ยน role แล้วต้องได้ token ใหม่ถึงจะมีผล
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+	// ใส่ทั้ง access และ refresh token ให้ refresh แล้ว scope ไม่หลุด
+	Scope string `json:"scope,omitempty"`
+	// session ที่ token นี้สังกัด (= refresh token family), revoke session แล้ว token ทุกตัวใน session ใช้ไม่ได้
+	SessionID string `json:"sid,omitempty"`
 	jwt.RegisteredClaims
 }
 
+func (c *Claims) IsReadOnly() bool {
+	return c.Scope == ScopeReadOnly
+}
+
+type ShareTokenDetails struct {
+	Token     string
+	TokenID   string
+	ExpiresAt time.Time
+}
+
+func (c *Claims) HasPermission(permission string) bool {
+	for _, p := range c.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
 func GenerateTokens(user *model.User) (*TokenDetails, error) {
-	accessExpirationTime := time.Now().Add(1 * time.Hour)
+	return GenerateSessionTokens(user, "", "")
+}
+
+// session ที่มี scope ไม่ได้สิทธิ์ของ role ติดไปด้วย
+func GenerateSessionTokens(user *model.User, sessionID, scope string) (*TokenDetails, error) {
+	now := time.Now()
+	accessExpirationTime := now.Add(AccessTokenTTL)
+	accessTokenID := uuid.New().String()
+	var roles, permissions []string
+	if scope == "" {
+		roles = user.RoleNames()
+		permissions = model.PermissionsForRoles(roles)
+	}
 	accessClaims := &Claims{
-		AccountID: user.AccountId,
-		Username:  user.Username,
+		AccountID:   user.AccountId,
+		Username:    user.Username,
+		TokenType:   TokenTypeAccess,
+		Roles:       roles,
+		Permissions: permissions,
+		Scope:       scope,
+		SessionID:   sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        accessTokenID,
 			ExpiresAt: jwt.NewNumericDate(accessExpirationTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
 			Issuer:    "bestbit-core",
 			Subject:   user.AccountId,
 		},
@@ -54,45 +124,49 @@ func GenerateTokens(user *model.User) (*TokenDetails, error) {
 	log.Println("'jwtSecret")
 	log.Println("'shareTokenSecret")
 
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims)
-	accessTokenString, err := accessToken.SignedString(getJwtSecret())
+	accessTokenString, err := signClaims(accessClaims)
 	if err != nil {
 		return nil, err
 	}
 
-	// ให้ exp refresh tk 1 วัน
-	refreshExpirationTime := time.Now().Add(1 * 24 * time.Hour)
+	// ให้ exp refresh tk 1 วัน, jti ไว้ผูกกับ row ใน refresh_tokens
+	refreshExpirationTime := now.Add(RefreshTokenTTL)
+	refreshTokenID := uuid.New().String()
 	refreshClaims := &Claims{
 		AccountID: user.AccountId,
 		Username:  user.Username,
+		TokenType: TokenTypeRefresh,
+		Scope:     scope,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        refreshTokenID,
 			ExpiresAt: jwt.NewNumericDate(refreshExpirationTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
 			Issuer:    "bestbit-core",
 			Subject:   user.AccountId,
 		},
 	}
 
-	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims)
-	refreshTokenString, err := refreshToken.SignedString(getJwtSecret())
+	refreshTokenString, err := signClaims(refreshClaims)
 	if err != nil {
 		return nil, err
 	}
 
 	return &TokenDetails{
-		AccessToken:  accessTokenString,
-		RefreshToken: refreshTokenString,
+		AccessToken:      accessTokenString,
+		AccessTokenID:    accessTokenID,
+		AccessExpiresAt:  accessExpirationTime,
+		RefreshToken:     refreshTokenString,
+		RefreshTokenID:   refreshTokenID,
+		RefreshExpiresAt: refreshExpirationTime,
 	}, nil
 }
 
-func ValidateToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return getJwtSecret(), nil
-	})
+// HS256 รับเฉพาะช่วงย้ายไป key แบบ asymmetric (ดู KeySetConfig)
+func parseToken(tokenString string) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, verificationKey,
+		jwt.WithValidMethods([]string{"HS256", "ES256", "EdDSA"}))
 
 	if err != nil {
 		return nil, err
@@ -105,12 +179,74 @@ func ValidateToken(tokenString string) (*Claims, error) {
 	return nil, errors.New("invalid token")
 }
 
-func GenerateShareToken(user *model.User) (string, error) {
-	expirationTime := time.Now().Add(5 * time.Minute)
+// รับเฉพาะ access token, refresh token ใช้เรียก api ไม่ได้
+func ValidateToken(tokenString string) (*Claims, error) {
+	claims, err := parseToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.TokenType != TokenTypeAccess {
+		return nil, errors.New("invalid token type, expected access token")
+	}
+
+	return claims, nil
+}
+
+func ValidateRefreshToken(tokenString string) (*Claims, error) {
+	claims, err := parseToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.TokenType != TokenTypeRefresh || claims.ID == "" {
+		return nil, errors.New("invalid token type, expected refresh token")
+	}
+
+	return claims, nil
+}
+
+func GenerateMFAToken(user *model.User) (string, error) {
+	now := time.Now()
 	claims := &Claims{
 		AccountID: user.AccountId,
 		Username:  user.Username,
+		TokenType: TokenTypeMFA,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(MFATokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    "bestbit-core",
+			Subject:   user.AccountId,
+		},
+	}
+
+	return signClaims(claims)
+}
+
+func ValidateMFAToken(tokenString string) (*Claims, error) {
+	claims, err := parseToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.TokenType != TokenTypeMFA {
+		return nil, errors.New("invalid token type, expected mfa token")
+	}
+
+	return claims, nil
+}
+
+// jti ต้องถูกบันทึกไว้ฝั่ง server ก่อนส่งให้ใคร ตอน login จะถูกใช้ได้ครั้งเดียว
+func GenerateShareToken(user *model.User) (*ShareTokenDetails, error) {
+	expirationTime := time.Now().Add(ShareTokenTTL)
+	tokenID := uuid.New().String()
+	claims := &Claims{
+		AccountID: user.AccountId,
+		Username:  user.Username,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
@@ -123,10 +259,10 @@ func GenerateShareToken(user *model.User) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	tokenString, err := token.SignedString(getShareTokenSecret())
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	return tokenString, nil
+	return &ShareTokenDetails{Token: tokenString, TokenID: tokenID, ExpiresAt: expirationTime}, nil
 }
 
 func ValidateShareToken(tokenString string) (*Claims, error) {
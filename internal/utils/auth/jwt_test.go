@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/padapook/bestbit-core/internal/account/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateTokens_IncludesRolePermissions(t *testing.T) {
+	t.Setenv("JWT_SECRET_KEY", "test-secret")
+	user := &model.User{AccountId: "acc-1", Username: "pook", Roles: []model.UserRole{{Role: model.RoleSupport}}}
+
+	tokens, err := GenerateTokens(user)
+	require.NoError(t, err)
+
+	claims, err := ValidateToken(tokens.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, []string{model.RoleSupport}, claims.Roles)
+	assert.True(t, claims.HasPermission(model.PermWalletsRead))
+	assert.False(t, claims.HasPermission(model.PermWalletsAdjust))
+
+	// refresh token ไม่ต้องพกสิทธิ์ ได้ใหม่ทุกครั้งที่ refresh
+	refreshClaims, err := ValidateRefreshToken(tokens.RefreshToken)
+	require.NoError(t, err)
+	assert.Empty(t, refreshClaims.Permissions)
+}
+
+func TestGenerateTokens_NoRoles(t *testing.T) {
+	t.Setenv("JWT_SECRET_KEY", "test-secret")
+
+	tokens, err := GenerateTokens(&model.User{AccountId: "acc-1", Username: "pook"})
+	require.NoError(t, err)
+
+	claims, err := ValidateToken(tokens.AccessToken)
+	require.NoError(t, err)
+	assert.Empty(t, claims.Roles)
+	assert.Empty(t, claims.Permissions)
+}
+
+func TestGenerateMFAToken_OnlyValidForMFAStep(t *testing.T) {
+	t.Setenv("JWT_SECRET_KEY", "test-secret")
+
+	token, err := GenerateMFAToken(&model.User{AccountId: "acc-1", Username: "pook"})
+	require.NoError(t, err)
+
+	claims, err := ValidateMFAToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, "acc-1", claims.AccountID)
+
+	// ยังไม่ผ่าน MFA ห้ามใช้เรียก api
+	_, err = ValidateToken(token)
+	assert.Error(t, err)
+
+	tokens, err := GenerateTokens(&model.User{AccountId: "acc-1", Username: "pook"})
+	require.NoError(t, err)
+	_, err = ValidateMFAToken(tokens.AccessToken)
+	assert.Error(t, err)
+}
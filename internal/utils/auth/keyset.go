@@ -0,0 +1,263 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrUnsupportedKey     = errors.New("unsupported jwt key, expected P-256 ecdsa or ed25519")
+	ErrUnknownKeyID       = errors.New("unknown jwt key id")
+	ErrLegacyTokenExpired = errors.New("hs256 tokens are no longer accepted")
+)
+
+type KeySetConfig struct {
+	// private key ที่ใช้ sign token ใหม่ (ES256 หรือ EdDSA)
+	SigningKeyPEM []byte
+	// public key ที่ยังรับอยู่ระหว่าง rotate เช่น key เก่าที่ token ยังไม่หมดอายุ หรือ key ถัดไปที่จะใช้ sign
+	VerificationKeyPEMs [][]byte
+	// token HS256 ที่ออกก่อนย้ายยังใช้ได้ถึง LegacyUntil, ควรนานกว่า RefreshTokenTTL ไม่งั้น user ต้อง login ใหม่
+	LegacySecret []byte
+	LegacyUntil  time.Time
+	// เวลาที่เริ่ม sign ด้วย key ใหม่ HS256 ที่ iat ไม่ก่อนเวลานี้ไม่รับ แม้ยังอยู่ในช่วง LegacyUntil
+	// กันคนที่ได้ JWT_SECRET_KEY ไปปลอม token ใหม่ระหว่างย้าย
+	LegacyIssuedBefore time.Time
+}
+
+type jwtKey struct {
+	id     string
+	method jwt.SigningMethod
+	public crypto.PublicKey
+	jwk    JWK
+}
+
+type KeySet struct {
+	signing            *jwtKey
+	signingKey         crypto.PrivateKey
+	keys               map[string]*jwtKey
+	order              []string
+	legacySecret       []byte
+	legacyUntil        time.Time
+	legacyIssuedBefore time.Time
+}
+
+// เผยแพร่ที่ /.well-known/jwks.json ให้ service อื่นตรวจ token เองได้โดยไม่ต้องถือ secret
+type JWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y,omitempty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+var (
+	keySetMu      sync.RWMutex
+	currentKeySet *KeySet
+)
+
+// nil = โหมดเดิม sign และตรวจด้วย JWT_SECRET_KEY (HS256) อย่างเดียว
+func SetKeySet(ks *KeySet) {
+	keySetMu.Lock()
+	defer keySetMu.Unlock()
+	currentKeySet = ks
+}
+
+func getKeySet() *KeySet {
+	keySetMu.RLock()
+	defer keySetMu.RUnlock()
+	return currentKeySet
+}
+
+func NewKeySet(cfg KeySetConfig) (*KeySet, error) {
+	private, signing, err := parseSigningKey(cfg.SigningKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	ks := &KeySet{
+		signing:            signing,
+		signingKey:         private,
+		keys:               map[string]*jwtKey{},
+		legacySecret:       cfg.LegacySecret,
+		legacyUntil:        cfg.LegacyUntil,
+		legacyIssuedBefore: cfg.LegacyIssuedBefore,
+	}
+	ks.add(signing)
+
+	for _, data := range cfg.VerificationKeyPEMs {
+		key, err := parseVerificationKey(data)
+		if err != nil {
+			return nil, err
+		}
+		ks.add(key)
+	}
+
+	// key ที่ใช้ sign อยู่ขึ้นก่อน ที่เหลือเรียงตาม kid ให้ JWKS ออกมาเหมือนเดิมทุกครั้ง
+	sort.Strings(ks.order[1:])
+	return ks, nil
+}
+
+func (ks *KeySet) add(key *jwtKey) {
+	if _, ok := ks.keys[key.id]; ok {
+		return
+	}
+	ks.keys[key.id] = key
+	ks.order = append(ks.order, key.id)
+}
+
+func (ks *KeySet) SigningKeyID() string {
+	return ks.signing.id
+}
+
+func (ks *KeySet) JWKS() JWKS {
+	jwks := JWKS{Keys: make([]JWK, 0, len(ks.order))}
+	for _, id := range ks.order {
+		jwks.Keys = append(jwks.Keys, ks.keys[id].jwk)
+	}
+	return jwks
+}
+
+func (ks *KeySet) acceptsLegacy(now time.Time) bool {
+	return len(ks.legacySecret) > 0 && now.Before(ks.legacyUntil)
+}
+
+// ยังไม่ได้ตั้ง key set = ไม่มี public key ให้เผยแพร่
+func CurrentJWKS() JWKS {
+	ks := getKeySet()
+	if ks == nil {
+		return JWKS{Keys: []JWK{}}
+	}
+	return ks.JWKS()
+}
+
+func signClaims(claims jwt.Claims) (string, error) {
+	ks := getKeySet()
+	if ks == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(getJwtSecret())
+	}
+
+	token := jwt.NewWithClaims(ks.signing.method, claims)
+	token.Header["kid"] = ks.signing.id
+	return token.SignedString(ks.signingKey)
+}
+
+func verificationKey(token *jwt.Token) (interface{}, error) {
+	ks := getKeySet()
+
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		if ks == nil {
+			return getJwtSecret(), nil
+		}
+		if !ks.acceptsLegacy(time.Now()) {
+			return nil, ErrLegacyTokenExpired
+		}
+		// token ยังไม่ถูกตรวจลายเซ็น แต่ตรงนี้ใช้ iat แค่ปฏิเสธ ไม่ได้ใช้ยอมรับ
+		issuedAt, err := token.Claims.GetIssuedAt()
+		if err != nil || issuedAt == nil || !issuedAt.Before(ks.legacyIssuedBefore) {
+			return nil, ErrLegacyTokenExpired
+		}
+		return ks.legacySecret, nil
+	}
+
+	if ks == nil {
+		return nil, errors.New("unexpected signing method")
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, ErrUnknownKeyID
+	}
+	// กัน token ที่อ้าง kid ถูกแต่เปลี่ยน alg
+	if key.method.Alg() != token.Method.Alg() {
+		return nil, errors.New("unexpected signing method")
+	}
+	return key.public, nil
+}
+
+func parseSigningKey(data []byte) (crypto.PrivateKey, *jwtKey, error) {
+	if ecKey, err := jwt.ParseECPrivateKeyFromPEM(data); err == nil {
+		key, err := newJWTKey(&ecKey.PublicKey)
+		return ecKey, key, err
+	}
+	if edKey, err := jwt.ParseEdPrivateKeyFromPEM(data); err == nil {
+		private, ok := edKey.(ed25519.PrivateKey)
+		if !ok {
+			return nil, nil, ErrUnsupportedKey
+		}
+		key, err := newJWTKey(private.Public())
+		return private, key, err
+	}
+	return nil, nil, ErrUnsupportedKey
+}
+
+func parseVerificationKey(data []byte) (*jwtKey, error) {
+	if ecKey, err := jwt.ParseECPublicKeyFromPEM(data); err == nil {
+		return newJWTKey(ecKey)
+	}
+	if edKey, err := jwt.ParseEdPublicKeyFromPEM(data); err == nil {
+		return newJWTKey(edKey)
+	}
+	return nil, ErrUnsupportedKey
+}
+
+// kid คือ JWK thumbprint (RFC 7638) ได้ค่าเดียวกันทุก instance โดยไม่ต้องตั้งเอง
+func newJWTKey(public crypto.PublicKey) (*jwtKey, error) {
+	var key jwtKey
+	var thumbprint []byte
+	var err error
+
+	switch pub := public.(type) {
+	case *ecdsa.PublicKey:
+		if pub.Curve != elliptic.P256() {
+			return nil, ErrUnsupportedKey
+		}
+		x := base64.RawURLEncoding.EncodeToString(pub.X.FillBytes(make([]byte, 32)))
+		y := base64.RawURLEncoding.EncodeToString(pub.Y.FillBytes(make([]byte, 32)))
+		key = jwtKey{method: jwt.SigningMethodES256, public: pub,
+			jwk: JWK{Kty: "EC", Crv: "P-256", X: x, Y: y, Alg: jwt.SigningMethodES256.Alg(), Use: "sig"}}
+		// member ต้องเรียงตามตัวอักษรตาม RFC 7638
+		thumbprint, err = json.Marshal(struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		}{"P-256", "EC", x, y})
+	case ed25519.PublicKey:
+		x := base64.RawURLEncoding.EncodeToString(pub)
+		key = jwtKey{method: jwt.SigningMethodEdDSA, public: pub,
+			jwk: JWK{Kty: "OKP", Crv: "Ed25519", X: x, Alg: jwt.SigningMethodEdDSA.Alg(), Use: "sig"}}
+		thumbprint, err = json.Marshal(struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{"Ed25519", "OKP", x})
+	default:
+		return nil, ErrUnsupportedKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(thumbprint)
+	key.id = base64.RawURLEncoding.EncodeToString(sum[:])
+	key.jwk.Kid = key.id
+	return &key, nil
+}
@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/padapook/bestbit-core/internal/account/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newECKeyPEM(t *testing.T) (private []byte, public []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return encodeKeyPair(t, key, &key.PublicKey)
+}
+
+func newEdKeyPEM(t *testing.T) (private []byte, public []byte) {
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	return encodeKeyPair(t, key, pub)
+}
+
+func encodeKeyPair(t *testing.T, private crypto.PrivateKey, public crypto.PublicKey) ([]byte, []byte) {
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+	publicDER, err := x509.MarshalPKIXPublicKey(public)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}),
+		pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})
+}
+
+// key set เป็น global ต้องคืนค่าหลังจบ test
+func useKeySet(t *testing.T, cfg KeySetConfig) *KeySet {
+	ks, err := NewKeySet(cfg)
+	require.NoError(t, err)
+	SetKeySet(ks)
+	t.Cleanup(func() { SetKeySet(nil) })
+	return ks
+}
+
+func TestKeySet_SignsWithKid(t *testing.T) {
+	ecPrivate, _ := newECKeyPEM(t)
+	edPrivate, _ := newEdKeyPEM(t)
+
+	tests := []struct {
+		name string
+		pem  []byte
+		alg  string
+		kty  string
+	}{
+		{"ES256", ecPrivate, "ES256", "EC"},
+		{"EdDSA", edPrivate, "EdDSA", "OKP"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ks := useKeySet(t, KeySetConfig{SigningKeyPEM: tt.pem})
+
+			tokens, err := GenerateTokens(&model.User{AccountId: "acc-1", Username: "pook"})
+			require.NoError(t, err)
+
+			parsed, _, err := jwt.NewParser().ParseUnverified(tokens.AccessToken, &Claims{})
+			require.NoError(t, err)
+			assert.Equal(t, tt.alg, parsed.Method.Alg())
+			assert.Equal(t, ks.SigningKeyID(), parsed.Header["kid"])
+
+			claims, err := ValidateToken(tokens.AccessToken)
+			require.NoError(t, err)
+			assert.Equal(t, "acc-1", claims.AccountID)
+
+			jwks := CurrentJWKS()
+			require.Len(t, jwks.Keys, 1)
+			assert.Equal(t, ks.SigningKeyID(), jwks.Keys[0].Kid)
+			assert.Equal(t, tt.kty, jwks.Keys[0].Kty)
+			assert.Equal(t, tt.alg, jwks.Keys[0].Alg)
+		})
+	}
+}
+
+func TestKeySet_Rotation(t *testing.T) {
+	oldPrivate, oldPublic := newECKeyPEM(t)
+	newPrivate, _ := newEdKeyPEM(t)
+
+	useKeySet(t, KeySetConfig{SigningKeyPEM: oldPrivate})
+	tokens, err := GenerateTokens(&model.User{AccountId: "acc-1", Username: "pook"})
+	require.NoError(t, err)
+
+	// key ใหม่ขึ้นมา sign แต่ token ที่ออกด้วย key เก่ายังใช้ได้
+	useKeySet(t, KeySetConfig{SigningKeyPEM: newPrivate, VerificationKeyPEMs: [][]byte{oldPublic}})
+	_, err = ValidateToken(tokens.AccessToken)
+	require.NoError(t, err)
+	assert.Len(t, CurrentJWKS().Keys, 2)
+
+	// เอา key เก่าออกแล้วต้องใช้ไม่ได้
+	useKeySet(t, KeySetConfig{SigningKeyPEM: newPrivate})
+	_, err = ValidateToken(tokens.AccessToken)
+	assert.Error(t, err)
+}
+
+func TestKeySet_LegacyHS256Window(t *testing.T) {
+	t.Setenv("JWT_SECRET_KEY", "test-secret")
+	legacy, err := GenerateTokens(&model.User{AccountId: "acc-1", Username: "pook"})
+	require.NoError(t, err)
+
+	private, _ := newECKeyPEM(t)
+
+	migratedAt := time.Now().Add(time.Second)
+	useKeySet(t, KeySetConfig{SigningKeyPEM: private, LegacySecret: []byte("test-secret"), LegacyUntil: time.Now().Add(time.Hour), LegacyIssuedBefore: migratedAt})
+	_, err = ValidateToken(legacy.AccessToken)
+	require.NoError(t, err)
+	_, err = ValidateRefreshToken(legacy.RefreshToken)
+	require.NoError(t, err)
+
+	// ยังอยู่ในช่วงย้ายแต่ออกหลังย้าย = ถูกปลอมด้วย secret เดิม
+	useKeySet(t, KeySetConfig{SigningKeyPEM: private, LegacySecret: []byte("test-secret"), LegacyUntil: time.Now().Add(time.Hour), LegacyIssuedBefore: time.Now().Add(-time.Minute)})
+	_, err = ValidateToken(legacy.AccessToken)
+	assert.ErrorIs(t, err, ErrLegacyTokenExpired)
+
+	useKeySet(t, KeySetConfig{SigningKeyPEM: private, LegacySecret: []byte("test-secret"), LegacyUntil: time.Now().Add(-time.Second), LegacyIssuedBefore: migratedAt})
+	_, err = ValidateToken(legacy.AccessToken)
+	assert.Error(t, err)
+
+	// ไม่ได้ตั้งช่วงย้ายไว้ = ไม่รับ HS256 เลย
+	useKeySet(t, KeySetConfig{SigningKeyPEM: private})
+	_, err = ValidateToken(legacy.AccessToken)
+	assert.Error(t, err)
+}
+
+func TestNewKeySet_RejectsUnsupportedKey(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	private, _ := encodeKeyPair(t, key, &key.PublicKey)
+
+	_, err = NewKeySet(KeySetConfig{SigningKeyPEM: private})
+	assert.ErrorIs(t, err, ErrUnsupportedKey)
+
+	_, err = NewKeySet(KeySetConfig{SigningKeyPEM: []byte("not a key")})
+	assert.ErrorIs(t, err, ErrUnsupportedKey)
+}
@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"sync"
+	"time"
+
+	"github.com/padapook/bestbit-core/internal/account/model"
+)
+
+// ที่เก็บตัวนับ login ผิด ต้องแชร์กันทุก instance ไม่งั้นยิงสลับเครื่องก็หลบได้
+type LoginAttemptStore interface {
+	// ไม่เคยผิดคืน nil
+	Get(key string) (*model.LoginAttempt, error)
+	// +1 แบบ atomic, นับใหม่จาก 1 ถ้าครั้งล่าสุดเก่ากว่า window หรือ lock เดิมหมดเวลาแล้ว
+	RecordFailure(key string, now time.Time, window time.Duration) (*model.LoginAttempt, error)
+	Lock(key string, until time.Time) error
+	Reset(key string) error
+}
+
+type memoryLoginAttemptStore struct {
+	mu       sync.Mutex
+	attempts map[string]model.LoginAttempt
+}
+
+// ใช้ตอน dev/test หรือรัน instance เดียว
+func NewMemoryLoginAttemptStore() LoginAttemptStore {
+	return &memoryLoginAttemptStore{attempts: make(map[string]model.LoginAttempt)}
+}
+
+func (s *memoryLoginAttemptStore) Get(key string) (*model.LoginAttempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempt, ok := s.attempts[key]
+	if !ok {
+		return nil, nil
+	}
+	return &attempt, nil
+}
+
+func (s *memoryLoginAttemptStore) RecordFailure(key string, now time.Time, window time.Duration) (*model.LoginAttempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempt, ok := s.attempts[key]
+	lockExpired := attempt.LockedUntil != nil && !attempt.LockedUntil.After(now)
+	if !ok || attempt.LastFailureAt.Before(now.Add(-window)) || lockExpired {
+		attempt = model.LoginAttempt{Key: key}
+	}
+
+	attempt.Failures++
+	attempt.LastFailureAt = now
+	attempt.UpdatedAt = now
+	s.attempts[key] = attempt
+	return &attempt, nil
+}
+
+func (s *memoryLoginAttemptStore) Lock(key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempt := s.attempts[key]
+	attempt.Key = key
+	attempt.LockedUntil = &until
+	s.attempts[key] = attempt
+	return nil
+}
+
+func (s *memoryLoginAttemptStore) Reset(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.attempts, key)
+	return nil
+}
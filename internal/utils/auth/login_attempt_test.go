@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryLoginAttemptStore_RecordFailure(t *testing.T) {
+	store := NewMemoryLoginAttemptStore()
+	now := time.Now()
+	window := 15 * time.Minute
+
+	attempt, _ := store.RecordFailure("user:pook", now, window)
+	assert.Equal(t, 1, attempt.Failures)
+	attempt, _ = store.RecordFailure("user:pook", now.Add(time.Minute), window)
+	assert.Equal(t, 2, attempt.Failures)
+
+	// เว้นนานเกิน window เริ่มนับใหม่
+	attempt, _ = store.RecordFailure("user:pook", now.Add(time.Hour), window)
+	assert.Equal(t, 1, attempt.Failures)
+
+	// lock หมดเวลาแล้วเริ่มนับใหม่
+	assert.NoError(t, store.Lock("user:pook", now.Add(time.Hour+time.Minute)))
+	attempt, _ = store.RecordFailure("user:pook", now.Add(time.Hour+2*time.Minute), window)
+	assert.Equal(t, 1, attempt.Failures)
+	assert.Nil(t, attempt.LockedUntil)
+
+	assert.NoError(t, store.Reset("user:pook"))
+	attempt, _ = store.Get("user:pook")
+	assert.Nil(t, attempt)
+}
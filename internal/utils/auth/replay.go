@@ -0,0 +1,40 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// กันคำขอที่ sign แล้วถูกส่งซ้ำภายในช่วงที่ timestamp ยังรับอยู่
+// Use คืน false ถ้า key นี้ถูกใช้ไปแล้วและยังไม่หมดอายุ
+type ReplayStore interface {
+	Use(key string, expiresAt time.Time) (bool, error)
+}
+
+type memoryReplayStore struct {
+	mu   sync.Mutex
+	keys map[string]time.Time
+	now  func() time.Time
+}
+
+// ใช้ตอน dev/test หรือรัน instance เดียว, restart แล้วหาย
+func NewMemoryReplayStore() ReplayStore {
+	return &memoryReplayStore{keys: make(map[string]time.Time), now: time.Now}
+}
+
+func (s *memoryReplayStore) Use(key string, expiresAt time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for k, exp := range s.keys {
+		if exp.Before(now) {
+			delete(s.keys, k)
+		}
+	}
+	if _, ok := s.keys[key]; ok {
+		return false, nil
+	}
+	s.keys[key] = expiresAt
+	return true, nil
+}
@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// ที่เก็บ token ที่ถูก revoke ก่อนหมดอายุ
+// RevokeToken = revoke ราย jti, RevokeSession = revoke ทุก token ที่มี sid นี้
+// RevokeAllBefore = revoke ทุก token ของ account ที่ออกก่อนเวลาที่กำหนด
+type RevocationStore interface {
+	RevokeToken(tokenID, accountID string, expiresAt time.Time) error
+	RevokeSession(sessionID, accountID string, expiresAt time.Time) error
+	RevokeAllBefore(accountID string, before time.Time) error
+	IsRevoked(claims *Claims) (bool, error)
+}
+
+// iat ใน jwt ละเอียดแค่วินาที ปัด cutoff ลงก่อนเก็บ
+// ไม่งั้น token ที่ออกให้ในวินาทีเดียวกันหลัง revoke (เช่น login ใหม่ทันที) ก็โดนไปด้วย
+func RevocationCutoff(before time.Time) time.Time {
+	return before.Truncate(time.Second)
+}
+
+// token ที่ออกก่อน cutoff ถือว่าถูก revoke, token ที่ไม่มี iat ถือว่าออกก่อนเสมอ
+func IssuedBefore(claims *Claims, cutoff time.Time) bool {
+	if claims.IssuedAt == nil {
+		return true
+	}
+	return claims.IssuedAt.Time.Before(cutoff)
+}
+
+type memoryRevocationStore struct {
+	mu      sync.RWMutex
+	tokens  map[string]time.Time
+	cutoffs map[string]time.Time
+	now     func() time.Time
+}
+
+// ใช้ตอน dev/test หรือรัน instance เดียว, restart แล้วหาย
+func NewMemoryRevocationStore() RevocationStore {
+	return &memoryRevocationStore{
+		tokens:  make(map[string]time.Time),
+		cutoffs: make(map[string]time.Time),
+		now:     time.Now,
+	}
+}
+
+func (s *memoryRevocationStore) RevokeToken(tokenID, accountID string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for id, exp := range s.tokens {
+		if exp.Before(now) {
+			delete(s.tokens, id)
+		}
+	}
+	s.tokens[tokenID] = expiresAt
+	return nil
+}
+
+// jti กับ sid เป็น uuid คนละชุดกัน เก็บรวมกันได้
+func (s *memoryRevocationStore) RevokeSession(sessionID, accountID string, expiresAt time.Time) error {
+	return s.RevokeToken(sessionID, accountID, expiresAt)
+}
+
+func (s *memoryRevocationStore) RevokeAllBefore(accountID string, before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	before = RevocationCutoff(before)
+	if current, ok := s.cutoffs[accountID]; !ok || before.After(current) {
+		s.cutoffs[accountID] = before
+	}
+	return nil
+}
+
+func (s *memoryRevocationStore) IsRevoked(claims *Claims) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, id := range []string{claims.ID, claims.SessionID} {
+		if id == "" {
+			continue
+		}
+		if _, ok := s.tokens[id]; ok {
+			return true, nil
+		}
+	}
+	if cutoff, ok := s.cutoffs[claims.AccountID]; ok && IssuedBefore(claims, cutoff) {
+		return true, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func testClaims(id, accountID string, issuedAt time.Time) *Claims {
+	return &Claims{
+		AccountID: accountID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:       id,
+			IssuedAt: jwt.NewNumericDate(issuedAt),
+		},
+	}
+}
+
+func TestMemoryRevocationStore_RevokeToken(t *testing.T) {
+	store := NewMemoryRevocationStore()
+	now := time.Now()
+
+	assert.NoError(t, store.RevokeToken("jti-1", "acc-1", now.Add(time.Hour)))
+
+	revoked, _ := store.IsRevoked(testClaims("jti-1", "acc-1", now))
+	assert.True(t, revoked)
+
+	revoked, _ = store.IsRevoked(testClaims("jti-2", "acc-1", now))
+	assert.False(t, revoked)
+}
+
+func TestMemoryRevocationStore_RevokeAllBefore(t *testing.T) {
+	store := NewMemoryRevocationStore()
+	cutoff := time.Now()
+
+	assert.NoError(t, store.RevokeAllBefore("acc-1", cutoff))
+	// cutoff ไม่ถอยหลัง
+	assert.NoError(t, store.RevokeAllBefore("acc-1", cutoff.Add(-time.Hour)))
+
+	revoked, _ := store.IsRevoked(testClaims("jti-1", "acc-1", cutoff.Add(-time.Minute)))
+	assert.True(t, revoked)
+
+	revoked, _ = store.IsRevoked(testClaims("jti-2", "acc-1", cutoff.Add(time.Minute)))
+	assert.False(t, revoked)
+
+	revoked, _ = store.IsRevoked(testClaims("jti-3", "acc-2", cutoff.Add(-time.Minute)))
+	assert.False(t, revoked)
+}
+
+// token ที่ออกในวินาทีเดียวกันหลัง revoke มี iat เท่ากับ cutoff ที่ปัดแล้ว ต้องยังใช้ได้
+func TestMemoryRevocationStore_RevokeAllBeforeTruncatesToSeconds(t *testing.T) {
+	store := NewMemoryRevocationStore()
+	cutoff := time.Date(2024, 1, 1, 12, 0, 0, 900_000_000, time.UTC)
+
+	assert.NoError(t, store.RevokeAllBefore("acc-1", cutoff))
+
+	revoked, _ := store.IsRevoked(testClaims("jti-1", "acc-1", cutoff.Truncate(time.Second)))
+	assert.False(t, revoked)
+
+	revoked, _ = store.IsRevoked(testClaims("jti-2", "acc-1", cutoff.Add(-time.Second)))
+	assert.True(t, revoked)
+}
@@ -1,95 +1,245 @@
 package crypto
 
 import (
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"crypto/subtle"
 	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 
 	"golang.org/x/crypto/argon2"
 )
 
-type params struct {
-	memory      uint32
-	iterations  uint32
-	parallelism uint8
-	saltLength  uint32
-	keyLength   uint32
+type PasswordParams struct {
+	Memory      uint32 // KiB
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
 }
 
-var argon2Params = &params{
-	memory:      64 * 1024,
-	iterations:  3,
-	parallelism: 2,
-	saltLength:  16,
-	keyLength:   32,
+// ค่าเดิมก่อนมี config, hash ที่มีอยู่ใน db ทั้งหมดใช้ค่านี้
+func DefaultPasswordParams() PasswordParams {
+	return PasswordParams{
+		Memory:      64 * 1024,
+		Iterations:  3,
+		Parallelism: 2,
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+}
+
+// pepper คือ key ฝั่ง server ที่ HMAC รหัสผ่านก่อนเข้า argon2, db หลุดอย่างเดียวเดารหัสไม่ได้
+// PepperID ว่าง = hash ใหม่ไม่ใส่ pepper
+// Peppers ต้องมี key เก่าทุกตัวที่ยังมี hash อ้างถึงอยู่ ไม่งั้น user เหล่านั้น login ไม่ได้
+type PasswordConfig struct {
+	Params   PasswordParams
+	PepperID string
+	Peppers  map[string][]byte
 }
 
 var (
-	ErrInvalidHash         = errors.New("the encoded hash is not in the correct format")
-	ErrIncompatibleVersion = errors.New("incompatible version of argon2")
+	ErrInvalidHash           = errors.New("the encoded hash is not in the correct format")
+	ErrIncompatibleVersion   = errors.New("incompatible version of argon2")
+	ErrUnknownPepper         = errors.New("password hash references an unknown pepper id")
+	ErrInvalidPasswordConfig = errors.New("invalid password hashing config")
+)
+
+// อยู่ใน params ของ hash เป็น keyid=<id> ตาม PHC string format เลยจำกัดตัวอักษรให้เป็น base64 ที่ใช้ได้
+var pepperIDPattern = regexp.MustCompile(`^[A-Za-z0-9]{1,16}$`)
+
+var (
+	passwordConfigMu sync.RWMutex
+	passwordConfig   = PasswordConfig{Params: DefaultPasswordParams()}
 )
 
+func SetPasswordConfig(cfg PasswordConfig) error {
+	p := cfg.Params
+	if p.Iterations < 1 || p.Parallelism < 1 || p.Memory < 8*uint32(p.Parallelism) || p.SaltLength < 16 || p.KeyLength < 16 {
+		return ErrInvalidPasswordConfig
+	}
+	for id, key := range cfg.Peppers {
+		if !pepperIDPattern.MatchString(id) || len(key) == 0 {
+			return ErrInvalidPasswordConfig
+		}
+	}
+	if cfg.PepperID != "" {
+		if _, ok := cfg.Peppers[cfg.PepperID]; !ok {
+			return ErrInvalidPasswordConfig
+		}
+	}
+
+	passwordConfigMu.Lock()
+	defer passwordConfigMu.Unlock()
+	passwordConfig = cfg
+	return nil
+}
+
+func getPasswordConfig() PasswordConfig {
+	passwordConfigMu.RLock()
+	defer passwordConfigMu.RUnlock()
+	return passwordConfig
+}
+
+type decodedHash struct {
+	params   PasswordParams
+	pepperID string
+	salt     []byte
+	hash     []byte
+}
+
 func HashPassword(password string) (string, error) {
-	// สร้างค่า sault จาก rand 16 bytes
-	salt := make([]byte, argon2Params.saltLength)
+	cfg := getPasswordConfig()
+	p := cfg.Params
+
+	// สร้างค่า sault จาก rand ตาม SaltLength
+	salt := make([]byte, p.SaltLength)
 	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
 		return "", err
 	}
 
-	hash := argon2.IDKey([]byte(password), salt, argon2Params.iterations, argon2Params.memory, argon2Params.parallelism, argon2Params.keyLength)
+	input := []byte(password)
+	keyID := ""
+	if cfg.PepperID != "" {
+		input = pepper(cfg.Peppers[cfg.PepperID], password)
+		keyID = ",keyid=" + cfg.PepperID
+	}
+
+	hash := argon2.IDKey(input, salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
 
 	b64Salt := base64.RawStdEncoding.EncodeToString(salt)
 	b64Hash := base64.RawStdEncoding.EncodeToString(hash)
 
 	encodedHash := fmt.Sprintf(
-		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
-		argon2.Version, argon2Params.memory, argon2Params.iterations, argon2Params.parallelism, b64Salt, b64Hash,
+		"$argon2id$v=%d$m=%d,t=%d,p=%d%s$%s$%s",
+		argon2.Version, p.Memory, p.Iterations, p.Parallelism, keyID, b64Salt, b64Hash,
 	)
 
 	return encodedHash, nil
 }
 
-// decode pw
+// decode pw, ใช้ params และ pepper ตามที่บันทึกไว้ใน hash ไม่ใช่ config ปัจจุบัน
 func ComparePasswordAndHash(password, encodedHash string) (bool, error) {
-	vals := strings.Split(encodedHash, "$")
-	if len(vals) != 6 {
-		return false, ErrInvalidHash
-	}
-
-	var version int
-	_, err := fmt.Sscanf(vals[2], "v=%d", &version)
+	decoded, err := decodeHash(encodedHash)
 	if err != nil {
 		return false, err
 	}
-	if version != argon2.Version {
-		return false, ErrIncompatibleVersion
+
+	input := []byte(password)
+	if decoded.pepperID != "" {
+		key, ok := getPasswordConfig().Peppers[decoded.pepperID]
+		if !ok {
+			return false, ErrUnknownPepper
+		}
+		input = pepper(key, password)
 	}
 
-	p := &params{}
-	_, err = fmt.Sscanf(vals[3], "m=%d,t=%d,p=%d", &p.memory, &p.iterations, &p.parallelism)
-	if err != nil {
-		return false, err
+	p := decoded.params
+	otherHash := argon2.IDKey(input, decoded.salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+
+	if subtle.ConstantTimeCompare(decoded.hash, otherHash) == 1 {
+		return true, nil
 	}
+	return false, nil
+}
 
-	salt, err := base64.RawStdEncoding.DecodeString(vals[4])
+// hash ที่ params หรือ pepper ไม่ตรงกับ config ปัจจุบัน ควร hash ใหม่ตอนที่มีรหัสผ่านจริงอยู่ในมือ (login)
+// hash ที่อ่านไม่ออกตอบ false เพราะยังไงก็เทียบรหัสผ่านไม่ผ่านอยู่แล้ว
+func NeedsRehash(encodedHash string) bool {
+	decoded, err := decodeHash(encodedHash)
 	if err != nil {
-		return false, err
+		return false
 	}
 
-	hash, err := base64.RawStdEncoding.DecodeString(vals[5])
-	if err != nil {
-		return false, err
+	cfg := getPasswordConfig()
+	p := decoded.params
+	return p.Memory != cfg.Params.Memory ||
+		p.Iterations != cfg.Params.Iterations ||
+		p.Parallelism != cfg.Params.Parallelism ||
+		p.SaltLength != cfg.Params.SaltLength ||
+		p.KeyLength != cfg.Params.KeyLength ||
+		decoded.pepperID != cfg.PepperID
+}
+
+// $argon2id$v=19$m=65536,t=3,p=2[,keyid=<id>]$<salt>$<hash>
+func decodeHash(encodedHash string) (*decodedHash, error) {
+	vals := strings.Split(encodedHash, "$")
+	if len(vals) != 6 || vals[1] != "argon2id" {
+		return nil, ErrInvalidHash
 	}
-	p.keyLength = uint32(len(hash))
 
-	otherHash := argon2.IDKey([]byte(password), salt, p.iterations, p.memory, p.parallelism, p.keyLength)
+	var version int
+	if _, err := fmt.Sscanf(vals[2], "v=%d", &version); err != nil {
+		return nil, ErrInvalidHash
+	}
+	if version != argon2.Version {
+		return nil, ErrIncompatibleVersion
+	}
 
-	if subtle.ConstantTimeCompare(hash, otherHash) == 1 {
-		return true, nil
+	decoded := &decodedHash{}
+	seen := map[string]bool{}
+	for _, field := range strings.Split(vals[3], ",") {
+		name, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return nil, ErrInvalidHash
+		}
+		if seen[name] {
+			return nil, ErrInvalidHash
+		}
+		seen[name] = true
+
+		if name == "keyid" {
+			if !pepperIDPattern.MatchString(value) {
+				return nil, ErrInvalidHash
+			}
+			decoded.pepperID = value
+			continue
+		}
+
+		n, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return nil, ErrInvalidHash
+		}
+		switch name {
+		case "m":
+			decoded.params.Memory = uint32(n)
+		case "t":
+			decoded.params.Iterations = uint32(n)
+		case "p":
+			if n == 0 || n > 255 {
+				return nil, ErrInvalidHash
+			}
+			decoded.params.Parallelism = uint8(n)
+		default:
+			return nil, ErrInvalidHash
+		}
 	}
-	return false, nil
+	if !seen["m"] || !seen["t"] || !seen["p"] {
+		return nil, ErrInvalidHash
+	}
+
+	var err error
+	if decoded.salt, err = base64.RawStdEncoding.DecodeString(vals[4]); err != nil {
+		return nil, ErrInvalidHash
+	}
+	if decoded.hash, err = base64.RawStdEncoding.DecodeString(vals[5]); err != nil {
+		return nil, ErrInvalidHash
+	}
+	decoded.params.SaltLength = uint32(len(decoded.salt))
+	decoded.params.KeyLength = uint32(len(decoded.hash))
+
+	return decoded, nil
+}
+
+func pepper(key []byte, password string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(password))
+	return mac.Sum(nil)
 }
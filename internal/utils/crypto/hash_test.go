@@ -0,0 +1,139 @@
+package crypto
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// params เล็กให้ test เร็ว
+func testPasswordParams() PasswordParams {
+	return PasswordParams{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+}
+
+func setTestPasswordConfig(t *testing.T, cfg PasswordConfig) {
+	t.Helper()
+	require.NoError(t, SetPasswordConfig(cfg))
+	t.Cleanup(func() {
+		require.NoError(t, SetPasswordConfig(PasswordConfig{Params: DefaultPasswordParams()}))
+	})
+}
+
+func TestComparePasswordAndHash_LegacyHashStillVerifies(t *testing.T) {
+	legacy, err := HashPassword("secret123")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(legacy, "$argon2id$v=19$m=65536,t=3,p=2$"))
+
+	// เปลี่ยน params และเปิด pepper แล้ว hash เดิมต้องยัง login ได้
+	setTestPasswordConfig(t, PasswordConfig{
+		Params:   testPasswordParams(),
+		PepperID: "k1",
+		Peppers:  map[string][]byte{"k1": []byte("pepper-one")},
+	})
+
+	match, err := ComparePasswordAndHash("secret123", legacy)
+	require.NoError(t, err)
+	assert.True(t, match)
+	assert.True(t, NeedsRehash(legacy))
+}
+
+func TestHashPassword_EmbedsPepperID(t *testing.T) {
+	setTestPasswordConfig(t, PasswordConfig{
+		Params:   testPasswordParams(),
+		PepperID: "k1",
+		Peppers:  map[string][]byte{"k1": []byte("pepper-one")},
+	})
+
+	encoded, err := HashPassword("secret123")
+	require.NoError(t, err)
+	assert.Contains(t, encoded, "$m=1024,t=1,p=1,keyid=k1$")
+	assert.False(t, NeedsRehash(encoded))
+
+	match, err := ComparePasswordAndHash("secret123", encoded)
+	require.NoError(t, err)
+	assert.True(t, match)
+
+	match, err = ComparePasswordAndHash("wrong", encoded)
+	require.NoError(t, err)
+	assert.False(t, match)
+
+	// หมุนไปใช้ key ใหม่ hash ที่ใช้ key เก่ายังตรวจได้แต่ควร hash ใหม่
+	setTestPasswordConfig(t, PasswordConfig{
+		Params:   testPasswordParams(),
+		PepperID: "k2",
+		Peppers:  map[string][]byte{"k1": []byte("pepper-one"), "k2": []byte("pepper-two")},
+	})
+	match, err = ComparePasswordAndHash("secret123", encoded)
+	require.NoError(t, err)
+	assert.True(t, match)
+	assert.True(t, NeedsRehash(encoded))
+}
+
+func TestComparePasswordAndHash_UnknownPepper(t *testing.T) {
+	setTestPasswordConfig(t, PasswordConfig{
+		Params:   testPasswordParams(),
+		PepperID: "k1",
+		Peppers:  map[string][]byte{"k1": []byte("pepper-one")},
+	})
+	encoded, err := HashPassword("secret123")
+	require.NoError(t, err)
+
+	setTestPasswordConfig(t, PasswordConfig{Params: testPasswordParams()})
+
+	match, err := ComparePasswordAndHash("secret123", encoded)
+	assert.ErrorIs(t, err, ErrUnknownPepper)
+	assert.False(t, match)
+}
+
+func TestNeedsRehash_ParamsChanged(t *testing.T) {
+	setTestPasswordConfig(t, PasswordConfig{Params: testPasswordParams()})
+	encoded, err := HashPassword("secret123")
+	require.NoError(t, err)
+	assert.False(t, NeedsRehash(encoded))
+
+	stronger := testPasswordParams()
+	stronger.Iterations = 2
+	setTestPasswordConfig(t, PasswordConfig{Params: stronger})
+	assert.True(t, NeedsRehash(encoded))
+
+	assert.False(t, NeedsRehash("not-a-hash"))
+}
+
+func TestDecodeHash_RejectsMalformedParams(t *testing.T) {
+	salt := "c2FsdHNhbHRzYWx0c2FsdA"
+	hash := "aGFzaGhhc2hoYXNoaGFzaGhhc2hoYXNoaGFzaGhhc2g"
+
+	for _, params := range []string{
+		"m=1024,t=1",
+		"m=1024,m=1024,p=1",
+		"m=1024,t=1,p=0",
+		"m=1024,t=1,p=1,keyid=bad-id",
+		"m=1024,t=1,p=1,x=1",
+	} {
+		_, err := decodeHash("$argon2id$v=19$" + params + "$" + salt + "$" + hash)
+		assert.ErrorIs(t, err, ErrInvalidHash, params)
+	}
+
+	_, err := decodeHash("$argon2id$v=16$m=1024,t=1,p=1$" + salt + "$" + hash)
+	assert.ErrorIs(t, err, ErrIncompatibleVersion)
+}
+
+func TestSetPasswordConfig_RejectsInvalid(t *testing.T) {
+	weakSalt := testPasswordParams()
+	weakSalt.SaltLength = 8
+
+	tests := []PasswordConfig{
+		{Params: PasswordParams{Memory: 1024, Iterations: 0, Parallelism: 1, SaltLength: 16, KeyLength: 32}},
+		{Params: weakSalt},
+		{Params: testPasswordParams(), PepperID: "missing"},
+		{Params: testPasswordParams(), Peppers: map[string][]byte{"bad-id": []byte("x")}},
+		{Params: testPasswordParams(), Peppers: map[string][]byte{"k1": nil}},
+	}
+
+	for _, cfg := range tests {
+		assert.ErrorIs(t, SetPasswordConfig(cfg), ErrInvalidPasswordConfig)
+	}
+	assert.Equal(t, DefaultPasswordParams(), getPasswordConfig().Params)
+}
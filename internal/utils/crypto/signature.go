@@ -0,0 +1,20 @@
+package crypto
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// HMAC-SHA256 ของ "timestamp\nMETHOD\npath\nbody" เป็น hex, path รวม query string
+func SignRequest(key, timestamp, method, path string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(timestamp + "\n" + method + "\n" + path + "\n"))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func VerifyRequestSignature(key, timestamp, method, path string, body []byte, signature string) bool {
+	expected := SignRequest(key, timestamp, method, path, body)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
@@ -0,0 +1,24 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+)
+
+// token สุ่มสำหรับส่งให้ user ถือ (reset password ฯลฯ) เก็บใน db เป็น HashToken เท่านั้น
+func GenerateToken(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// token สุ่มยาวพอแล้ว sha256 ก็พอ ไม่ต้องใช้ argon2 และยังค้นจาก hash ตรงๆ ได้
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,75 @@
+package crypto
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ค่าทดสอบ SHA1 จาก RFC 6238 appendix B ตัดเหลือ 6 หลักท้าย
+func TestTOTPCode_RFC6238Vectors(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+	tests := []struct {
+		unix     int64
+		expected string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tt := range tests {
+		code, err := TOTPCode(secret, TOTPStep(time.Unix(tt.unix, 0)))
+		require.NoError(t, err)
+		assert.Equal(t, tt.expected, code)
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	require.NoError(t, err)
+
+	now := time.Unix(1700000000, 0)
+	code, err := TOTPCode(secret, TOTPStep(now))
+	require.NoError(t, err)
+
+	step, ok := ValidateTOTP(secret, code, now, 0)
+	assert.True(t, ok)
+	assert.Equal(t, TOTPStep(now), step)
+
+	// นาฬิกาคลาดไป 1 ช่วงยังผ่าน
+	_, ok = ValidateTOTP(secret, code, now.Add(TOTPPeriod*time.Second), 0)
+	assert.True(t, ok)
+
+	// code เดิมใช้ซ้ำไม่ได้
+	_, ok = ValidateTOTP(secret, code, now, step)
+	assert.False(t, ok)
+
+	// เก่าเกิน skew
+	_, ok = ValidateTOTP(secret, code, now.Add(3*TOTPPeriod*time.Second), 0)
+	assert.False(t, ok)
+
+	_, ok = ValidateTOTP(secret, "12345", now, 0)
+	assert.False(t, ok)
+}
+
+func TestEncryptSecret_RoundTrip(t *testing.T) {
+	key := []byte("test-key")
+
+	encrypted, err := EncryptSecret(key, "JBSWY3DPEHPK3PXP")
+	require.NoError(t, err)
+	assert.NotContains(t, encrypted, "JBSWY3DPEHPK3PXP")
+
+	plaintext, err := DecryptSecret(key, encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", plaintext)
+
+	_, err = DecryptSecret([]byte("other-key"), encrypted)
+	assert.Equal(t, ErrInvalidCiphertext, err)
+}
@@ -0,0 +1,20 @@
+package utils
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// แปลง unique violation ของ driver เฉพาะจุดที่ต้องการ ไม่เปิด TranslateError ทั้ง db
+// เพราะ repository เดิมกับ HandleServiceError เห็น error ของ driver ตรงๆ อยู่
+func IsDuplicateKey(db *gorm.DB, err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return true
+	}
+	translator, ok := db.Dialector.(gorm.ErrorTranslator)
+	return ok && errors.Is(translator.Translate(err), gorm.ErrDuplicatedKey)
+}
@@ -3,18 +3,131 @@ package utils
 import "net/http"
 
 type AppError struct {
-	StatusCode	int
-	Message		string
-	ErrorCode	string
+	StatusCode int
+	Message    string
+	ErrorCode  string
+}
+
+func (e AppError) Error() string {
+	return e.Message
 }
 
 var (
 	//400
 	ErrInvalidRequest = AppError{http.StatusBadRequest, "INVALID_REQUEST", "ERR_4000"}
-	ErrUnauthorized = AppError{http.StatusUnauthorized, "UNAUTHORIZED", "ERR_4010"}
-	ErrUserNotFound = AppError{http.StatusNotFound, "USER_NOT_FOUND", "ERR_4040"}
-	ErrUserConflict = AppError{http.StatusConflict, "USER_ALREADY_EXIST", "ERR_4090"}
+	ErrUnauthorized   = AppError{http.StatusUnauthorized, "UNAUTHORIZED", "ERR_4010"}
+	ErrUserNotFound   = AppError{http.StatusNotFound, "USER_NOT_FOUND", "ERR_4040"}
+	ErrUserConflict   = AppError{http.StatusConflict, "USER_ALREADY_EXIST", "ERR_4090"}
+	ErrForbidden      = AppError{http.StatusForbidden, "FORBIDDEN", "ERR_4030"}
+
+	// token
+	ErrInvalidRefreshToken = AppError{http.StatusUnauthorized, "INVALID_REFRESH_TOKEN", "ERR_4011"}
+	ErrRefreshTokenReused  = AppError{http.StatusUnauthorized, "REFRESH_TOKEN_REUSED", "ERR_4012"}
+
+	// idempotency
+	ErrInvalidIdempotencyKey    = AppError{http.StatusBadRequest, "INVALID_IDEMPOTENCY_KEY", "ERR_4009"}
+	ErrIdempotencyKeyConflict   = AppError{http.StatusConflict, "IDEMPOTENCY_KEY_REUSED", "ERR_4095"}
+	ErrIdempotencyKeyInProgress = AppError{http.StatusConflict, "IDEMPOTENCY_KEY_IN_PROGRESS", "ERR_4096"}
+
+	// order
+	ErrInvalidOrderSide   = AppError{http.StatusBadRequest, "INVALID_ORDER_SIDE", "ERR_4001"}
+	ErrInvalidOrderType   = AppError{http.StatusBadRequest, "INVALID_ORDER_TYPE", "ERR_4002"}
+	ErrInvalidOrderStatus = AppError{http.StatusBadRequest, "INVALID_ORDER_STATUS", "ERR_4003"}
+	ErrInvalidOrderPrice  = AppError{http.StatusBadRequest, "INVALID_ORDER_PRICE", "ERR_4004"}
+	ErrInvalidOrderAmount = AppError{http.StatusBadRequest, "INVALID_ORDER_AMOUNT", "ERR_4005"}
+	ErrInvalidSymbol      = AppError{http.StatusBadRequest, "INVALID_SYMBOL", "ERR_4007"}
+	ErrOrderNotFound      = AppError{http.StatusNotFound, "ORDER_NOT_FOUND", "ERR_4041"}
+	ErrOrderNotCancelable = AppError{http.StatusConflict, "ORDER_NOT_CANCELABLE", "ERR_4091"}
+	ErrOrderNotAccepted   = AppError{http.StatusServiceUnavailable, "ORDER_NOT_ACCEPTED_BY_MATCHING", "ERR_5032"}
+
+	// market
+	ErrInvalidMarket       = AppError{http.StatusBadRequest, "INVALID_MARKET_DEFINITION", "ERR_4008"}
+	ErrInvalidPriceTick    = AppError{http.StatusUnprocessableEntity, "PRICE_NOT_MULTIPLE_OF_TICK", "ERR_4220"}
+	ErrInvalidQuantityStep = AppError{http.StatusUnprocessableEntity, "QUANTITY_NOT_MULTIPLE_OF_STEP", "ERR_4221"}
+	ErrQuantityTooSmall    = AppError{http.StatusUnprocessableEntity, "QUANTITY_BELOW_MINIMUM", "ERR_4222"}
+	ErrQuantityTooLarge    = AppError{http.StatusUnprocessableEntity, "QUANTITY_ABOVE_MAXIMUM", "ERR_4223"}
+	ErrNotionalTooSmall    = AppError{http.StatusUnprocessableEntity, "NOTIONAL_BELOW_MINIMUM", "ERR_4224"}
+	ErrNoLiquidity         = AppError{http.StatusUnprocessableEntity, "NO_OPPOSITE_LIQUIDITY", "ERR_42212"}
+	ErrMarketNotFound      = AppError{http.StatusNotFound, "MARKET_NOT_FOUND", "ERR_4044"}
+	ErrMarketConflict      = AppError{http.StatusConflict, "MARKET_ALREADY_EXIST", "ERR_4093"}
+	ErrMarketNotTrading    = AppError{http.StatusConflict, "MARKET_NOT_TRADING", "ERR_4094"}
+
+	// trade
+	ErrTradeNotFound = AppError{http.StatusNotFound, "TRADE_NOT_FOUND", "ERR_4043"}
+	ErrInvalidTrade  = AppError{http.StatusInternalServerError, "INVALID_TRADE", "ERR_5001"}
+
+	// wallet
+	ErrInsufficientBalance = AppError{http.StatusBadRequest, "INSUFFICIENT_BALANCE", "ERR_4006"}
+	ErrInsufficientLocked  = AppError{http.StatusConflict, "INSUFFICIENT_LOCKED_AMOUNT", "ERR_4092"}
+	ErrWalletNotFound      = AppError{http.StatusNotFound, "WALLET_NOT_FOUND", "ERR_4042"}
+	ErrTransactionNotFound = AppError{http.StatusNotFound, "TRANSACTION_NOT_FOUND", "ERR_4045"}
+
+	// withdrawal
+	ErrInvalidWithdrawal           = AppError{http.StatusUnprocessableEntity, "INVALID_WITHDRAWAL", "ERR_4225"}
+	ErrWithdrawalNotFound          = AppError{http.StatusNotFound, "WITHDRAWAL_NOT_FOUND", "ERR_4047"}
+	ErrInvalidWithdrawalTransition = AppError{http.StatusConflict, "INVALID_WITHDRAWAL_TRANSITION", "ERR_4098"}
+	ErrPayoutFailed                = AppError{http.StatusBadGateway, "PAYOUT_PROVIDER_ERROR", "ERR_5020"}
+
+	// currency
+	ErrCurrencyNotFound       = AppError{http.StatusNotFound, "CURRENCY_NOT_SUPPORTED", "ERR_4048"}
+	ErrCurrencyDisabled       = AppError{http.StatusConflict, "CURRENCY_DISABLED", "ERR_4099"}
+	ErrDepositDisabled        = AppError{http.StatusForbidden, "DEPOSIT_DISABLED_FOR_CURRENCY", "ERR_4032"}
+	ErrWithdrawDisabled       = AppError{http.StatusForbidden, "WITHDRAW_DISABLED_FOR_CURRENCY", "ERR_4033"}
+	ErrInvalidAmountPrecision = AppError{http.StatusUnprocessableEntity, "AMOUNT_EXCEEDS_CURRENCY_PRECISION", "ERR_42210"}
+
+	// limit
+	ErrLimitNotConfigured   = AppError{http.StatusForbidden, "LIMIT_NOT_CONFIGURED_FOR_TIER", "ERR_4031"}
+	ErrSingleLimitExceeded  = AppError{http.StatusUnprocessableEntity, "SINGLE_TRANSACTION_LIMIT_EXCEEDED", "ERR_4226"}
+	ErrDailyLimitExceeded   = AppError{http.StatusUnprocessableEntity, "DAILY_LIMIT_EXCEEDED", "ERR_4227"}
+	ErrMonthlyLimitExceeded = AppError{http.StatusUnprocessableEntity, "MONTHLY_LIMIT_EXCEEDED", "ERR_4228"}
+	ErrInvalidLimit         = AppError{http.StatusUnprocessableEntity, "INVALID_LIMIT_DEFINITION", "ERR_4229"}
+
+	// freeze
+	ErrAccountFrozen = AppError{http.StatusForbidden, "ACCOUNT_FROZEN", "ERR_4034"}
+	ErrWalletFrozen  = AppError{http.StatusForbidden, "WALLET_FROZEN", "ERR_4035"}
+
+	// admin
+	ErrInvalidAdjustment = AppError{http.StatusUnprocessableEntity, "INVALID_ADJUSTMENT", "ERR_42211"}
+
+	// mfa
+	ErrMFARequired       = AppError{http.StatusUnauthorized, "MFA_CODE_REQUIRED", "ERR_4013"}
+	ErrInvalidMFACode    = AppError{http.StatusUnauthorized, "INVALID_MFA_CODE", "ERR_4014"}
+	ErrInvalidMFAToken   = AppError{http.StatusUnauthorized, "INVALID_MFA_TOKEN", "ERR_4015"}
+	ErrMFANotEnrolled    = AppError{http.StatusNotFound, "MFA_NOT_ENROLLED", "ERR_4049"}
+	ErrMFAAlreadyEnabled = AppError{http.StatusConflict, "MFA_ALREADY_ENABLED", "ERR_40910"}
+	ErrMFALocked         = AppError{http.StatusTooManyRequests, "MFA_TEMPORARILY_LOCKED", "ERR_4292"}
+	ErrMFAUnavailable    = AppError{http.StatusServiceUnavailable, "MFA_UNAVAILABLE", "ERR_5030"}
+
+	// share token
+	ErrInvalidShareToken      = AppError{http.StatusUnauthorized, "INVALID_SHARE_TOKEN", "ERR_4017"}
+	ErrShareTokenNotFound     = AppError{http.StatusNotFound, "SHARE_TOKEN_NOT_FOUND", "ERR_40410"}
+	ErrSessionScopeRestricted = AppError{http.StatusForbidden, "SESSION_SCOPE_RESTRICTED", "ERR_4036"}
+
+	// api key
+	ErrInvalidAPIKey          = AppError{http.StatusUnauthorized, "INVALID_API_KEY_OR_SIGNATURE", "ERR_4018"}
+	ErrAPIRequestExpired      = AppError{http.StatusUnauthorized, "API_REQUEST_TIMESTAMP_OUT_OF_WINDOW", "ERR_4019"}
+	ErrAPIRequestReplayed     = AppError{http.StatusUnauthorized, "API_REQUEST_REPLAYED", "ERR_40110"}
+	ErrAPIKeyPermissionDenied = AppError{http.StatusForbidden, "API_KEY_PERMISSION_DENIED", "ERR_4037"}
+	ErrAPIKeyIPNotAllowed     = AppError{http.StatusForbidden, "API_KEY_IP_NOT_ALLOWED", "ERR_4038"}
+	ErrAPIKeyNotFound         = AppError{http.StatusNotFound, "API_KEY_NOT_FOUND", "ERR_40411"}
+	ErrInvalidAPIKeyRequest   = AppError{http.StatusBadRequest, "INVALID_API_KEY_REQUEST", "ERR_40011"}
+	ErrAPIKeysUnavailable     = AppError{http.StatusServiceUnavailable, "API_KEYS_UNAVAILABLE", "ERR_5031"}
+
+	// session
+	ErrSessionNotFound = AppError{http.StatusNotFound, "SESSION_NOT_FOUND", "ERR_40412"}
+
+	// password
+	ErrInvalidCurrentPassword = AppError{http.StatusUnauthorized, "INVALID_CURRENT_PASSWORD", "ERR_4016"}
+	ErrInvalidResetToken      = AppError{http.StatusBadRequest, "INVALID_OR_EXPIRED_RESET_TOKEN", "ERR_40010"}
+
+	// login throttle
+	ErrTooManyLoginAttempts = AppError{http.StatusTooManyRequests, "TOO_MANY_LOGIN_ATTEMPTS", "ERR_4290"}
+	ErrLoginLocked          = AppError{http.StatusTooManyRequests, "LOGIN_TEMPORARILY_LOCKED", "ERR_4291"}
+
+	// reconciliation
+	ErrReconciliationReportNotFound = AppError{http.StatusNotFound, "RECONCILIATION_REPORT_NOT_FOUND", "ERR_4046"}
+	ErrReconciliationInProgress     = AppError{http.StatusConflict, "RECONCILIATION_IN_PROGRESS", "ERR_4097"}
 
 	//500
 	ErrInternalServer = AppError{http.StatusInternalServerError, "INTERNAL_SERVER_ERROR", "ERR_5000"}
-)
\ No newline at end of file
+)
@@ -1,16 +1,16 @@
 package utils
 
 import (
-	"github.com/gin-gonic/gin"
 	"errors"
+	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
 type Response struct {
-	Success    bool        `json:"success"`
-	Message    string      `json:"message"`
-	Data       interface{} `json:"data,omitempty"`
-	ErrorCode  string      `json:"error_code,omitempty"`
+	Success   bool        `json:"success"`
+	Message   string      `json:"message"`
+	Data      interface{} `json:"data,omitempty"`
+	ErrorCode string      `json:"error_code,omitempty"`
 }
 
 func HandleError(c *gin.Context, appErr AppError) {
@@ -30,21 +30,21 @@ func HandleSuccess(c *gin.Context, statusCode int, message string, data interfac
 }
 
 func HandleServiceError(c *gin.Context, err error) {
-    var appErr AppError
-    if errors.As(err, &appErr) {
-        HandleError(c, appErr)
-        return
-    }
+	var appErr AppError
+	if errors.As(err, &appErr) {
+		HandleError(c, appErr)
+		return
+	}
 
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		HandleError(c, ErrUserNotFound)
 		return
 	}
-	
+
 	if errors.Is(err, gorm.ErrDuplicatedKey) {
 		HandleError(c, ErrUserConflict)
 		return
 	}
 
-    HandleError(c, ErrInternalServer)
-}
\ No newline at end of file
+	HandleError(c, ErrInternalServer)
+}
@@ -1,10 +1,15 @@
 package controller
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/padapook/bestbit-core/internal/utils"
 	"github.com/padapook/bestbit-core/internal/wallet/service"
 	"github.com/shopspring/decimal"
 )
@@ -13,8 +18,14 @@ type WalletController interface {
 	GetWallets(c *gin.Context)
 	GetWalletByCurrency(c *gin.Context)
 	Deposit(c *gin.Context)
-	Withdraw(c *gin.Context)
 	Transfer(c *gin.Context)
+	GetTransactions(c *gin.Context)
+	GetTransactionByReference(c *gin.Context)
+
+	// admin ดู/ปรับ wallet ของ user ใดก็ได้ จาก :account_id
+	AdminGetWallets(c *gin.Context)
+	AdminGetTransactions(c *gin.Context)
+	AdjustBalance(c *gin.Context)
 }
 
 type walletController struct {
@@ -58,7 +69,7 @@ func (ctrl *walletController) GetWalletByCurrency(c *gin.Context) {
 
 	wallet, err := ctrl.walletService.GetWalletBalance(accountID.(string), currency)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		utils.HandleServiceError(c, err)
 		return
 	}
 
@@ -85,80 +96,195 @@ func (ctrl *walletController) Deposit(c *gin.Context) {
 		return
 	}
 
-	refID := uuid.New().String()
+	refID := referenceID(c, "DEPOSIT")
 
 	wallet, err := ctrl.walletService.DepositMoney(accountID.(string), req.Currency, req.Amount, refID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		handleMutationError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Deposit successful",
-		"data":    wallet,
+		"message":      "Deposit successful",
+		"reference_id": refID,
+		"data":         wallet,
 	})
 }
 
-type WithdrawRequest struct {
-	Currency string          `json:"currency" binding:"required"`
-	Amount   decimal.Decimal `json:"amount" binding:"required"`
-}
-
 type TransferRequest struct {
 	ToUserID string          `json:"to_user_id" binding:"required"`
 	Currency string          `json:"currency" binding:"required"`
 	Amount   decimal.Decimal `json:"amount" binding:"required"`
 }
 
-func (ctrl *walletController) Withdraw(c *gin.Context) {
+func (ctrl *walletController) Transfer(c *gin.Context) {
 	accountID, exists := c.Get("account_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
 		return
 	}
 
-	var req WithdrawRequest
+	var req TransferRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: amount and currency are required"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
 		return
 	}
 
-	refID := uuid.New().String()
+	refID := referenceID(c, "TRANSFER")
 
-	wallet, err := ctrl.walletService.WithdrawMoney(accountID.(string), req.Currency, req.Amount, refID)
+	err := ctrl.walletService.TransferMoney(accountID.(string), req.ToUserID, req.Currency, req.Amount, refID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		handleMutationError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Withdraw successful",
-		"data":    wallet,
+		"message":      "Transfer successful",
+		"reference_id": refID,
 	})
 }
 
-func (ctrl *walletController) Transfer(c *gin.Context) {
+func (ctrl *walletController) GetTransactions(c *gin.Context) {
 	accountID, exists := c.Get("account_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		utils.HandleError(c, utils.ErrUnauthorized)
 		return
 	}
 
-	var req TransferRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+	ctrl.listTransactions(c, accountID.(string))
+}
+
+func (ctrl *walletController) GetTransactionByReference(c *gin.Context) {
+	accountID, exists := c.Get("account_id")
+	if !exists {
+		utils.HandleError(c, utils.ErrUnauthorized)
 		return
 	}
 
-	refID := uuid.New().String()
+	transactions, err := ctrl.walletService.GetTransactionByReference(accountID.(string), c.Param("currency"),
+		referenceCandidates(accountID.(string), c.Param("reference_id"))...)
+	if err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
 
-	err := ctrl.walletService.TransferMoney(accountID.(string), req.ToUserID, req.Currency, req.Amount, refID)
+	c.JSON(http.StatusOK, gin.H{
+		"data": transactions,
+	})
+}
+
+func (ctrl *walletController) listTransactions(c *gin.Context, accountID string) {
+	from, err := parseTimeQuery(c, "from")
+	if err != nil {
+		utils.HandleError(c, utils.ErrInvalidRequest)
+		return
+	}
+	to, err := parseTimeQuery(c, "to")
+	if err != nil {
+		utils.HandleError(c, utils.ErrInvalidRequest)
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	page, err := ctrl.walletService.GetTransactions(accountID, c.Param("currency"), service.TransactionQuery{
+		Type:   c.Query("type"),
+		Status: c.Query("status"),
+		From:   from,
+		To:     to,
+		Cursor: c.Query("cursor"),
+		Limit:  limit,
+	})
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		utils.HandleServiceError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Transfer successful",
+		"data":        page.Transactions,
+		"next_cursor": page.NextCursor,
 	})
 }
+
+func (ctrl *walletController) AdminGetWallets(c *gin.Context) {
+	wallets, err := ctrl.walletService.GetUserWallets(c.Param("account_id"))
+	if err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": wallets,
+	})
+}
+
+func (ctrl *walletController) AdminGetTransactions(c *gin.Context) {
+	ctrl.listTransactions(c, c.Param("account_id"))
+}
+
+// amount ติดลบได้ = หักออก
+type AdjustBalanceRequest struct {
+	Amount decimal.Decimal `json:"amount" binding:"required"`
+	Reason string          `json:"reason" binding:"required"`
+}
+
+func (ctrl *walletController) AdjustBalance(c *gin.Context) {
+	var req AdjustBalanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleError(c, utils.ErrInvalidRequest)
+		return
+	}
+
+	wallet, err := ctrl.walletService.AdjustBalance(c.Param("account_id"), c.Param("currency"), req.Amount, req.Reason,
+		c.GetString("account_id"), referenceID(c, "ADJUSTMENT"))
+	if err != nil {
+		handleMutationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Balance adjusted",
+		"data":    wallet,
+	})
+}
+
+// รับ RFC3339 เช่น 2024-01-31T00:00:00Z, ไม่ส่งมาได้ nil
+func parseTimeQuery(c *gin.Context, key string) (*time.Time, error) {
+	value := c.Query(key)
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// AppError (เช่นเกินวงเงิน) ตอบตาม code ของมัน ที่เหลือคง 400 แบบเดิม
+func handleMutationError(c *gin.Context, err error) {
+	var appErr utils.AppError
+	if errors.As(err, &appErr) {
+		utils.HandleError(c, appErr)
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
+
+// ใช้ Idempotency-Key เป็น reference ถ้ามี ไม่งั้นสุ่มใหม่ (retry แล้วจะได้รายการใหม่)
+// key มาจาก client ซ้ำข้าม user ได้ เลยต่อประเภทรายการกับ account ไว้หน้า key กันชนกับของคนอื่นและ reference ที่ระบบสร้าง
+func referenceID(c *gin.Context, operation string) string {
+	if key := c.GetString("idempotency_key"); key != "" {
+		return fmt.Sprintf("%s-%s-%s", operation, c.GetString("account_id"), key)
+	}
+	return uuid.New().String()
+}
+
+// รับได้ทั้ง reference_id ที่ตอบกลับไปและ Idempotency-Key ตัวเดิมที่ client ส่งมา
+func referenceCandidates(accountID, reference string) []string {
+	candidates := []string{reference}
+	for _, operation := range []string{"DEPOSIT", "TRANSFER"} {
+		candidates = append(candidates, fmt.Sprintf("%s-%s-%s", operation, accountID, reference))
+	}
+	return candidates
+}
@@ -15,3 +15,8 @@ type Wallet struct {
 	UpdatedAt    time.Time       `json:"updated_at"`
 	IsActive     bool            `json:"is_active"`
 }
+
+// ยอดที่ใช้ได้จริง = balance - ยอดที่ถูก hold ไว้กับ order
+func (w *Wallet) AvailableBalance() decimal.Decimal {
+	return w.Balance.Sub(w.AmountLocked)
+}
@@ -1,27 +1,59 @@
 package model
 
 import (
-	"github.com/shopspring/decimal"
-	"time"
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
+	"time"
+)
+
+const (
+	TxTypeDeposit     = "DEPOSIT"
+	TxTypeWithdraw    = "WITHDRAW"
+	TxTypeWithdrawal  = "WITHDRAWAL" // ถอนผ่าน withdrawal request ตัดจากเงินที่ hold ไว้ (WITHDRAW เดิมตัดจาก available ตรงๆ)
+	TxTypeTransferOut = "TRANSFER_OUT"
+	TxTypeTransferIn  = "TRANSFER_IN"
+	TxTypeHold        = "HOLD"
+	TxTypeRelease     = "RELEASE"
+	TxTypeHoldConsume = "HOLD_CONSUME"
+	TxTypeTradeCredit = "TRADE_CREDIT"
+	TxTypeTradeFee    = "TRADE_FEE"
+	TxTypeAdjustment  = "ADJUSTMENT"      // admin ปรับยอดมือ Amount มีเครื่องหมาย ลบ = หักออก, เหตุผลอยู่ใน Remark
+	TxTypeOpening     = "OPENING_BALANCE" // ยอดยกมาของ wallet ที่มียอดก่อนเริ่มบันทึกรายการ ลงตอน migrate ครั้งเดียว
+
+	TxStatusPending   = "PENDING"
+	TxStatusCompleted = "COMPLETED"
 )
 
+func IsValidTxType(txType string) bool {
+	switch txType {
+	case TxTypeDeposit, TxTypeWithdraw, TxTypeWithdrawal, TxTypeTransferOut, TxTypeTransferIn,
+		TxTypeHold, TxTypeRelease, TxTypeHoldConsume, TxTypeTradeCredit, TxTypeTradeFee, TxTypeAdjustment, TxTypeOpening:
+		return true
+	}
+	return false
+}
+
+func IsValidTxStatus(status string) bool {
+	return status == TxStatusPending || status == TxStatusCompleted
+}
+
+// reference id ซ้ำกันได้ข้าม wallet/type เช่น transfer ที่เขียน 2 แถวด้วย reference เดียวกัน
 type WalletTransaction struct {
-	ID              uuid.UUID       `gorm:"primaryKey" json:"id"`
-	WalletID        uuid.UUID       `gorm:"type:uuid;index;not null" json:"wallet_id"`
-	TargetWalletID	*string 		`gorm:"index" json:"target_wallet_id,omitempty"`
-	ReferenceID     string          `gorm:"uniqueIndex;not null" json:"reference_id"`
-	TransactionType	string 			`gorm:"type:varchar(20);not null" json:"transaction_type"`
+	ID              uuid.UUID       `gorm:"primaryKey;index:idx_wallet_tx_history,priority:3" json:"id"`
+	WalletID        uint64          `gorm:"index;uniqueIndex:idx_wallet_tx_reference;index:idx_wallet_tx_history,priority:1;not null" json:"wallet_id"`
+	TargetWalletID  *string         `gorm:"index" json:"target_wallet_id,omitempty"`
+	ReferenceID     string          `gorm:"uniqueIndex:idx_wallet_tx_reference,priority:1;not null" json:"reference_id"`
+	TransactionType string          `gorm:"type:varchar(20);uniqueIndex:idx_wallet_tx_reference;not null" json:"transaction_type"`
 	Status          string          `gorm:"type:varchar(20);not null;default:'PENDING'" json:"status"`
 	Amount          decimal.Decimal `gorm:"type:decimal(32,16); default:0" json:"amount"`
-	Currency 		string 			`gorm:"type:varchar(20);not null;default:'THB'" json:"currency"`
+	Currency        string          `gorm:"type:varchar(20);not null;default:'THB'" json:"currency"`
 	BalanceBefore   decimal.Decimal `gorm:"type:decimal(32,16);not null" json:"balance_before"`
-    BalanceAfter    decimal.Decimal `gorm:"type:decimal(32,16);not null" json:"balance_after"`
+	BalanceAfter    decimal.Decimal `gorm:"type:decimal(32,16);not null" json:"balance_after"`
 	Description     string          `gorm:"type:text" json:"description"`
-	Remark			string			`gorm:"type:text" json:"remark"`
-    CreatedAt       time.Time       `gorm:"index" json:"created_at"`
-    CreatedBy       string          `gorm:"size:100" json:"created_by"`
+	Remark          string          `gorm:"type:text" json:"remark"`
+	CreatedAt       time.Time       `gorm:"index;index:idx_wallet_tx_history,priority:2" json:"created_at"`
+	CreatedBy       string          `gorm:"size:100" json:"created_by"`
 }
 
 func (m *WalletTransaction) BeforeCreate(tx *gorm.DB) error {
@@ -29,4 +61,26 @@ func (m *WalletTransaction) BeforeCreate(tx *gorm.DB) error {
 		m.ID = uuid.New()
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+// ผลต่อ Wallet.Balance ของรายการนี้ ใช้ตรวจยอดย้อนหลัง (hold/release ไม่เปลี่ยน balance)
+func (m *WalletTransaction) BalanceEffect() decimal.Decimal {
+	switch m.TransactionType {
+	case TxTypeDeposit, TxTypeTransferIn, TxTypeTradeCredit, TxTypeAdjustment, TxTypeOpening:
+		return m.Amount
+	case TxTypeWithdraw, TxTypeWithdrawal, TxTypeTransferOut, TxTypeHoldConsume, TxTypeTradeFee:
+		return m.Amount.Neg()
+	}
+	return decimal.Zero
+}
+
+// ผลต่อ Wallet.AmountLocked ของรายการนี้
+func (m *WalletTransaction) LockedEffect() decimal.Decimal {
+	switch m.TransactionType {
+	case TxTypeHold:
+		return m.Amount
+	case TxTypeRelease, TxTypeHoldConsume, TxTypeWithdrawal:
+		return m.Amount.Neg()
+	}
+	return decimal.Zero
+}
@@ -2,28 +2,72 @@ package repository
 
 import (
 	"errors"
+	"sort"
 	"time"
 
+	ledgerModel "github.com/padapook/bestbit-core/internal/ledger/model"
+	ledgerRepository "github.com/padapook/bestbit-core/internal/ledger/repository"
+	"github.com/padapook/bestbit-core/internal/utils"
 	"github.com/padapook/bestbit-core/internal/wallet/model"
 	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
+type WalletKey struct {
+	UserID   string
+	Currency string
+}
+
 type WalletRepository interface {
 	GetWalletByUserID(userID string) ([]model.Wallet, error)
 	GetWalletByUserIDAndCurrency(userID, currency string) (*model.Wallet, error)
-	Deposit(userID, currency string, amount decimal.Decimal, referenceID string) (*model.Wallet, error)
-	Withdraw(userID, currency string, amount decimal.Decimal, referenceID string) (*model.Wallet, error)
-	Transfer(fromUserID, toUserID, currency string, amount decimal.Decimal, referenceID string) error
+	// ไล่ทุก wallet ทีละหน้าตาม id ใช้กับงานตรวจยอด
+	GetAllWallets(afterID uint64, limit int) ([]model.Wallet, error)
+
+	// ใช้ภายใน transaction ของผู้เรียก (เช่น order) เพื่อให้ hold กับการสร้าง order commit พร้อมกัน
+	Transaction(fn func(tx *gorm.DB) error) error
+	// สร้าง wallet ยอด 0 ถ้ายังไม่มี ใช้ก่อนรับเงินเข้าสกุลที่ user ยังไม่เคยถือ
+	EnsureWallet(tx *gorm.DB, userID, currency string) error
+	// wallet ที่ถูก freeze ได้ ErrWalletFrozen
+	Deposit(tx *gorm.DB, userID, currency string, amount decimal.Decimal, referenceID string) (*model.Wallet, error)
+	Transfer(tx *gorm.DB, fromUserID, toUserID, currency string, amount decimal.Decimal, referenceID string) error
+	GetWalletForUpdate(tx *gorm.DB, userID, currency string) (*model.Wallet, error)
+	HoldFunds(tx *gorm.DB, userID, currency string, amount decimal.Decimal, referenceID string) (*model.Wallet, error)
+	ReleaseFunds(tx *gorm.DB, userID, currency string, amount decimal.Decimal, referenceID string) (*model.Wallet, error)
+	ConsumeHold(tx *gorm.DB, userID, currency string, amount decimal.Decimal, referenceID string) (*model.Wallet, error)
+	// ข้าม freeze โดยตั้งใจ: เป็นขาที่สองของ trade ที่ match ไปแล้ว
+	CreditFunds(tx *gorm.DB, userID, currency string, amount decimal.Decimal, referenceID string) (*model.Wallet, error)
+	ChargeFee(tx *gorm.DB, userID, currency string, amount decimal.Decimal, referenceID string) (*model.Wallet, error)
+	CompleteWithdrawal(tx *gorm.DB, userID, currency string, amount decimal.Decimal, referenceID string) (*model.Wallet, error)
+	// amount บวก = เพิ่ม, ลบ = หัก (หักได้ไม่เกิน available)
+	// ข้าม freeze โดยตั้งใจ: admin ต้องแก้ยอดของ wallet ที่ถูก freeze ได้ และมี reason/actor บันทึกไว้แล้ว
+	Adjust(tx *gorm.DB, userID, currency string, amount decimal.Decimal, referenceID, reason, actor string) (*model.Wallet, error)
+	LockWallets(tx *gorm.DB, keys []WalletKey) error
 }
 
 type walletRepository struct {
-	db *gorm.DB
+	db     *gorm.DB
+	ledger ledgerRepository.LedgerRepository
 }
 
 func NewWalletRepository(db *gorm.DB) WalletRepository {
-	return &walletRepository{db: db}
+	return &walletRepository{db: db, ledger: ledgerRepository.NewLedgerRepository(db)}
+}
+
+func (r *walletRepository) Transaction(fn func(tx *gorm.DB) error) error {
+	return r.db.Transaction(fn)
+}
+
+func (r *walletRepository) EnsureWallet(tx *gorm.DB, userID, currency string) error {
+	wallet := model.Wallet{
+		UserID:       userID,
+		Currency:     currency,
+		Balance:      decimal.Zero,
+		AmountLocked: decimal.Zero,
+		IsActive:     true,
+	}
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&wallet).Error
 }
 
 func (r *walletRepository) GetWalletByUserID(userID string) ([]model.Wallet, error) {
@@ -37,194 +81,425 @@ func (r *walletRepository) GetWalletByUserIDAndCurrency(userID, currency string)
 	err := r.db.Where("user_id = ? AND currency = ?", userID, currency).First(&wallet).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("wallet not found")
+			return nil, utils.ErrWalletNotFound
 		}
 		return nil, err
 	}
 	return &wallet, nil
 }
 
-func (r *walletRepository) Deposit(userID, currency string, amount decimal.Decimal, referenceID string) (*model.Wallet, error) {
-	var wallet model.Wallet
+func (r *walletRepository) GetAllWallets(afterID uint64, limit int) ([]model.Wallet, error) {
+	var wallets []model.Wallet
+	err := r.db.Where("id > ?", afterID).Order("id ASC").Limit(limit).Find(&wallets).Error
+	return wallets, err
+}
 
-	err := r.db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
-			Where("user_id = ? AND currency = ?", userID, currency).
-			First(&wallet).Error; err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				return errors.New("wallet not found")
-			}
-			return err
-		}
+func (r *walletRepository) Deposit(tx *gorm.DB, userID, currency string, amount decimal.Decimal, referenceID string) (*model.Wallet, error) {
+	wallet, err := r.getActiveWalletForUpdate(tx, userID, currency)
+	if err != nil {
+		return nil, err
+	}
 
-		balanceBefore := wallet.Balance
-		wallet.Balance = wallet.Balance.Add(amount)
-		balanceAfter := wallet.Balance
+	balanceBefore := wallet.Balance
+	wallet.Balance = wallet.Balance.Add(amount)
+	balanceAfter := wallet.Balance
 
-		if err := tx.Save(&wallet).Error; err != nil {
-			return err
-		}
+	if err := tx.Save(wallet).Error; err != nil {
+		return nil, err
+	}
 
-		trx := model.WalletTransaction{
-			WalletID:        wallet.ID,
-			ReferenceID:     referenceID,
-			TransactionType: "DEPOSIT",
-			Amount:          amount,
-			BalanceBefore:   balanceBefore,
-			BalanceAfter:    balanceAfter,
-			Status:          "COMPLETED",
-			Description:     "Deposit via API",
-			CreatedAt:       time.Now(),
-			CreatedBy:       userID,
-		}
+	trx := model.WalletTransaction{
+		WalletID:        wallet.ID,
+		ReferenceID:     referenceID,
+		TransactionType: model.TxTypeDeposit,
+		Amount:          amount,
+		Currency:        wallet.Currency,
+		BalanceBefore:   balanceBefore,
+		BalanceAfter:    balanceAfter,
+		Status:          model.TxStatusCompleted,
+		Description:     "Deposit via API",
+		CreatedAt:       time.Now(),
+		CreatedBy:       userID,
+	}
 
-		if err := tx.Create(&trx).Error; err != nil {
-			return err
+	if err := tx.Create(&trx).Error; err != nil {
+		return nil, err
+	}
+
+	if err := r.post(tx, model.TxTypeDeposit, referenceID, userID, trx.Description,
+		ledgerModel.SystemOmnibus(currency), ledgerModel.UserWallet(userID, currency), amount); err != nil {
+		return nil, err
+	}
+
+	return wallet, nil
+}
+
+func (r *walletRepository) Transfer(tx *gorm.DB, fromUserID, toUserID, currency string, amount decimal.Decimal, referenceID string) error {
+	firstID, secondID := fromUserID, toUserID
+	if firstID > secondID {
+		firstID, secondID = secondID, firstID
+	}
+
+	var firstWallet model.Wallet
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ? AND currency = ?", firstID, currency).First(&firstWallet).Error; err != nil {
+		return errors.New("wallet not found for user: " + firstID)
+	}
+
+	var secondWallet model.Wallet
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ? AND currency = ?", secondID, currency).First(&secondWallet).Error; err != nil {
+		return errors.New("wallet not found for user: " + secondID)
+	}
+
+	var senderWallet, receiverWallet *model.Wallet
+	if fromUserID == firstID {
+		senderWallet = &firstWallet
+		receiverWallet = &secondWallet
+	} else {
+		senderWallet = &secondWallet
+		receiverWallet = &firstWallet
+	}
+
+	if !senderWallet.IsActive || !receiverWallet.IsActive {
+		return utils.ErrWalletFrozen
+	}
+	if senderWallet.AvailableBalance().LessThan(amount) {
+		return utils.ErrInsufficientBalance
+	}
+
+	senderBalBefore := senderWallet.Balance
+	senderWallet.Balance = senderWallet.Balance.Sub(amount)
+	senderBalAfter := senderWallet.Balance
+
+	recvBalBefore := receiverWallet.Balance
+	receiverWallet.Balance = receiverWallet.Balance.Add(amount)
+	recvBalAfter := receiverWallet.Balance
+
+	if err := tx.Save(senderWallet).Error; err != nil {
+		return err
+	}
+	if err := tx.Save(receiverWallet).Error; err != nil {
+		return err
+	}
+
+	// tx ฝั่ง sender
+	txSender := model.WalletTransaction{
+		WalletID:        senderWallet.ID,
+		ReferenceID:     referenceID,
+		TransactionType: model.TxTypeTransferOut,
+		Amount:          amount,
+		Currency:        currency,
+		BalanceBefore:   senderBalBefore,
+		BalanceAfter:    senderBalAfter,
+		Status:          model.TxStatusCompleted,
+		Description:     "Transfer to " + toUserID,
+		CreatedAt:       time.Now(),
+		CreatedBy:       fromUserID,
+	}
+	if err := tx.Create(&txSender).Error; err != nil {
+		return err
+	}
+
+	// tx ฝั่ง receive
+	txReceiver := model.WalletTransaction{
+		WalletID:        receiverWallet.ID,
+		ReferenceID:     referenceID,
+		TransactionType: model.TxTypeTransferIn,
+		Amount:          amount,
+		Currency:        currency,
+		BalanceBefore:   recvBalBefore,
+		BalanceAfter:    recvBalAfter,
+		Status:          model.TxStatusCompleted,
+		Description:     "Transfer from " + fromUserID,
+		CreatedAt:       time.Now(),
+		CreatedBy:       fromUserID,
+	}
+	if err := tx.Create(&txReceiver).Error; err != nil {
+		return err
+	}
+
+	return r.post(tx, model.TxTypeTransferOut, referenceID, fromUserID, "Transfer to "+toUserID,
+		ledgerModel.UserWallet(fromUserID, currency), ledgerModel.UserWallet(toUserID, currency), amount)
+}
+
+func (r *walletRepository) GetWalletForUpdate(tx *gorm.DB, userID, currency string) (*model.Wallet, error) {
+	var wallet model.Wallet
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ? AND currency = ?", userID, currency).
+		First(&wallet).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.ErrWalletNotFound
 		}
+		return nil, err
+	}
+	return &wallet, nil
+}
 
-		return nil
-	})
+// wallet ที่ถูก freeze ห้ามเจ้าของขยับเงินเอง (ฝาก, hold ให้ order/ถอน, โอนเข้า/ออก)
+func (r *walletRepository) getActiveWalletForUpdate(tx *gorm.DB, userID, currency string) (*model.Wallet, error) {
+	wallet, err := r.GetWalletForUpdate(tx, userID, currency)
+	if err != nil {
+		return nil, err
+	}
+	if !wallet.IsActive {
+		return nil, utils.ErrWalletFrozen
+	}
+	return wallet, nil
+}
 
+// ข้าม freeze โดยตั้งใจ ใช้เฉพาะงานที่ระบบทำต่อจากรายการที่รับไว้ก่อน freeze (settle, คืน hold, ปิดการถอน)
+// และ admin adjust ถ้าบล็อกตรงนี้ trade/withdrawal ที่ค้างอยู่จะปิดไม่ได้
+func (r *walletRepository) getWalletForUpdateBypassFreeze(tx *gorm.DB, userID, currency string) (*model.Wallet, error) {
+	return r.GetWalletForUpdate(tx, userID, currency)
+}
+
+// ย้ายเงินจาก available ไปอยู่ใน AmountLocked balance ไม่เปลี่ยน
+func (r *walletRepository) HoldFunds(tx *gorm.DB, userID, currency string, amount decimal.Decimal, referenceID string) (*model.Wallet, error) {
+	wallet, err := r.getActiveWalletForUpdate(tx, userID, currency)
 	if err != nil {
 		return nil, err
 	}
 
-	return &wallet, nil
+	if wallet.AvailableBalance().LessThan(amount) {
+		return nil, utils.ErrInsufficientBalance
+	}
+
+	wallet.AmountLocked = wallet.AmountLocked.Add(amount)
+
+	if err := r.saveWithTransaction(tx, wallet, model.TxTypeHold, amount, wallet.Balance, referenceID, userID, "Hold for "+referenceID); err != nil {
+		return nil, err
+	}
+
+	return wallet, nil
 }
 
-func (r *walletRepository) Withdraw(userID, currency string, amount decimal.Decimal, referenceID string) (*model.Wallet, error) {
-	var wallet model.Wallet
+// คืนเงินที่ hold ไว้กลับเป็น available เช่นตอน cancel order
+func (r *walletRepository) ReleaseFunds(tx *gorm.DB, userID, currency string, amount decimal.Decimal, referenceID string) (*model.Wallet, error) {
+	wallet, err := r.getWalletForUpdateBypassFreeze(tx, userID, currency)
+	if err != nil {
+		return nil, err
+	}
 
-	err := r.db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
-			Where("user_id = ? AND currency = ?", userID, currency).
-			First(&wallet).Error; err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				return errors.New("wallet not found")
-			}
-			return err
-		}
+	if wallet.AmountLocked.LessThan(amount) {
+		return nil, utils.ErrInsufficientLocked
+	}
 
-		if wallet.Balance.LessThan(amount) {
-			return errors.New("insufficient balance")
-		}
+	wallet.AmountLocked = wallet.AmountLocked.Sub(amount)
 
-		balanceBefore := wallet.Balance
-		wallet.Balance = wallet.Balance.Sub(amount)
-		balanceAfter := wallet.Balance
+	if err := r.saveWithTransaction(tx, wallet, model.TxTypeRelease, amount, wallet.Balance, referenceID, userID, "Release hold for "+referenceID); err != nil {
+		return nil, err
+	}
 
-		if err := tx.Save(&wallet).Error; err != nil {
-			return err
-		}
+	return wallet, nil
+}
 
-		trx := model.WalletTransaction{
-			WalletID:        wallet.ID,
-			ReferenceID:     referenceID,
-			TransactionType: "WITHDRAW",
-			Amount:          amount,
-			BalanceBefore:   balanceBefore,
-			BalanceAfter:    balanceAfter,
-			Status:          "COMPLETED",
-			Description:     "Withdraw via API",
-			CreatedAt:       time.Now(),
-			CreatedBy:       userID,
-		}
+// ตัดเงินที่ hold ไว้ออกจาก wallet จริง (เช่นตอน order ถูก match)
+func (r *walletRepository) ConsumeHold(tx *gorm.DB, userID, currency string, amount decimal.Decimal, referenceID string) (*model.Wallet, error) {
+	wallet, err := r.getWalletForUpdateBypassFreeze(tx, userID, currency)
+	if err != nil {
+		return nil, err
+	}
 
-		if err := tx.Create(&trx).Error; err != nil {
-			return err
-		}
+	if wallet.AmountLocked.LessThan(amount) || wallet.Balance.LessThan(amount) {
+		return nil, utils.ErrInsufficientLocked
+	}
 
-		return nil
-	})
+	balanceBefore := wallet.Balance
+	wallet.AmountLocked = wallet.AmountLocked.Sub(amount)
+	wallet.Balance = wallet.Balance.Sub(amount)
+
+	if err := r.saveWithTransaction(tx, wallet, model.TxTypeHoldConsume, amount, balanceBefore, referenceID, userID, "Consume hold for "+referenceID); err != nil {
+		return nil, err
+	}
 
+	// ขาจ่ายของ trade พักไว้ที่ clearing จนกว่าอีกฝั่งจะ CreditFunds
+	if err := r.post(tx, model.TxTypeHoldConsume, referenceID, userID, "Consume hold for "+referenceID,
+		ledgerModel.UserWallet(userID, currency), ledgerModel.TradeClearing(currency), amount); err != nil {
+		return nil, err
+	}
+
+	return wallet, nil
+}
+
+// เพิ่มเงินเข้า wallet จากการ match เช่นฝั่ง buyer ได้รับ base
+func (r *walletRepository) CreditFunds(tx *gorm.DB, userID, currency string, amount decimal.Decimal, referenceID string) (*model.Wallet, error) {
+	wallet, err := r.getWalletForUpdateBypassFreeze(tx, userID, currency)
 	if err != nil {
 		return nil, err
 	}
 
-	return &wallet, nil
+	balanceBefore := wallet.Balance
+	wallet.Balance = wallet.Balance.Add(amount)
+
+	if err := r.saveWithTransaction(tx, wallet, model.TxTypeTradeCredit, amount, balanceBefore, referenceID, userID, "Trade credit for "+referenceID); err != nil {
+		return nil, err
+	}
+
+	if err := r.post(tx, model.TxTypeTradeCredit, referenceID, userID, "Trade credit for "+referenceID,
+		ledgerModel.TradeClearing(currency), ledgerModel.UserWallet(userID, currency), amount); err != nil {
+		return nil, err
+	}
+
+	return wallet, nil
 }
 
-func (r *walletRepository) Transfer(fromUserID, toUserID, currency string, amount decimal.Decimal, referenceID string) error {
-	return r.db.Transaction(func(tx *gorm.DB) error {
-		firstID, secondID := fromUserID, toUserID
-		if firstID > secondID {
-			firstID, secondID = secondID, firstID
-		}
+// หักค่าธรรมเนียมจาก available ต้องเรียกหลัง CreditFunds ของ trade เดียวกัน
+func (r *walletRepository) ChargeFee(tx *gorm.DB, userID, currency string, amount decimal.Decimal, referenceID string) (*model.Wallet, error) {
+	wallet, err := r.getWalletForUpdateBypassFreeze(tx, userID, currency)
+	if err != nil {
+		return nil, err
+	}
 
-		var firstWallet model.Wallet
-		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
-			Where("user_id = ? AND currency = ?", firstID, currency).First(&firstWallet).Error; err != nil {
-			return errors.New("wallet not found for user: " + firstID)
-		}
+	if wallet.AvailableBalance().LessThan(amount) {
+		return nil, utils.ErrInsufficientBalance
+	}
 
-		var secondWallet model.Wallet
-		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
-			Where("user_id = ? AND currency = ?", secondID, currency).First(&secondWallet).Error; err != nil {
-			return errors.New("wallet not found for user: " + secondID)
-		}
+	balanceBefore := wallet.Balance
+	wallet.Balance = wallet.Balance.Sub(amount)
 
-		var senderWallet, receiverWallet *model.Wallet
-		if fromUserID == firstID {
-			senderWallet = &firstWallet
-			receiverWallet = &secondWallet
-		} else {
-			senderWallet = &secondWallet
-			receiverWallet = &firstWallet
-		}
+	if err := r.saveWithTransaction(tx, wallet, model.TxTypeTradeFee, amount, balanceBefore, referenceID, userID, "Trade fee for "+referenceID); err != nil {
+		return nil, err
+	}
 
-		if senderWallet.Balance.LessThan(amount) {
-			return errors.New("insufficient balance")
-		}
+	if err := r.post(tx, model.TxTypeTradeFee, referenceID, userID, "Trade fee for "+referenceID,
+		ledgerModel.UserWallet(userID, currency), ledgerModel.FeeRevenue(currency), amount); err != nil {
+		return nil, err
+	}
 
-		senderBalBefore := senderWallet.Balance
-		senderWallet.Balance = senderWallet.Balance.Sub(amount)
-		senderBalAfter := senderWallet.Balance
+	return wallet, nil
+}
 
-		recvBalBefore := receiverWallet.Balance
-		receiverWallet.Balance = receiverWallet.Balance.Add(amount)
-		recvBalAfter := receiverWallet.Balance
+// ตัดเงินที่ hold ไว้กับ withdrawal ออกจาก wallet หลัง provider จ่ายสำเร็จ
+// ลงผ่าน pending withdrawal แล้วล้างออกจาก omnibus ทันที เพราะเงินออกจากระบบไปแล้ว
+func (r *walletRepository) CompleteWithdrawal(tx *gorm.DB, userID, currency string, amount decimal.Decimal, referenceID string) (*model.Wallet, error) {
+	wallet, err := r.getWalletForUpdateBypassFreeze(tx, userID, currency)
+	if err != nil {
+		return nil, err
+	}
 
-		if err := tx.Save(senderWallet).Error; err != nil {
-			return err
-		}
-		if err := tx.Save(receiverWallet).Error; err != nil {
-			return err
-		}
+	if wallet.AmountLocked.LessThan(amount) || wallet.Balance.LessThan(amount) {
+		return nil, utils.ErrInsufficientLocked
+	}
 
-		// tx ฝั่ง sender
-		txSender := model.WalletTransaction{
-			WalletID:        senderWallet.ID,
-			ReferenceID:     referenceID,
-			TransactionType: "TRANSFER_OUT",
-			Amount:          amount,
-			BalanceBefore:   senderBalBefore,
-			BalanceAfter:    senderBalAfter,
-			Status:          "COMPLETED",
-			Description:     "Transfer to " + toUserID,
-			CreatedAt:       time.Now(),
-			CreatedBy:       fromUserID,
-		}
-		if err := tx.Create(&txSender).Error; err != nil {
-			return err
+	balanceBefore := wallet.Balance
+	wallet.AmountLocked = wallet.AmountLocked.Sub(amount)
+	wallet.Balance = wallet.Balance.Sub(amount)
+
+	if err := r.saveWithTransaction(tx, wallet, model.TxTypeWithdrawal, amount, balanceBefore, referenceID, userID, "Withdrawal "+referenceID); err != nil {
+		return nil, err
+	}
+
+	if err := r.post(tx, model.TxTypeWithdrawal, referenceID, userID, "Withdrawal "+referenceID,
+		ledgerModel.UserWallet(userID, currency), ledgerModel.PendingWithdrawals(currency), amount); err != nil {
+		return nil, err
+	}
+	if err := r.post(tx, ledgerModel.EntryTypeWithdrawalPayout, referenceID, userID, "Withdrawal payout "+referenceID,
+		ledgerModel.PendingWithdrawals(currency), ledgerModel.SystemOmnibus(currency), amount); err != nil {
+		return nil, err
+	}
+
+	return wallet, nil
+}
+
+func (r *walletRepository) Adjust(tx *gorm.DB, userID, currency string, amount decimal.Decimal, referenceID, reason, actor string) (*model.Wallet, error) {
+	wallet, err := r.getWalletForUpdateBypassFreeze(tx, userID, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	if amount.IsNegative() && wallet.AvailableBalance().LessThan(amount.Neg()) {
+		return nil, utils.ErrInsufficientBalance
+	}
+
+	balanceBefore := wallet.Balance
+	wallet.Balance = wallet.Balance.Add(amount)
+
+	if err := tx.Save(wallet).Error; err != nil {
+		return nil, err
+	}
+
+	trx := model.WalletTransaction{
+		WalletID:        wallet.ID,
+		ReferenceID:     referenceID,
+		TransactionType: model.TxTypeAdjustment,
+		Amount:          amount,
+		Currency:        wallet.Currency,
+		BalanceBefore:   balanceBefore,
+		BalanceAfter:    wallet.Balance,
+		Status:          model.TxStatusCompleted,
+		Description:     "Manual adjustment",
+		Remark:          reason,
+		CreatedAt:       time.Now(),
+		CreatedBy:       actor,
+	}
+	if err := tx.Create(&trx).Error; err != nil {
+		return nil, err
+	}
+
+	debit, credit := ledgerModel.ManualAdjustment(currency), ledgerModel.UserWallet(userID, currency)
+	if amount.IsNegative() {
+		debit, credit = credit, debit
+	}
+	if err := r.post(tx, model.TxTypeAdjustment, referenceID, actor, "Manual adjustment: "+reason,
+		debit, credit, amount.Abs()); err != nil {
+		return nil, err
+	}
+
+	return wallet, nil
+}
+
+// lock หลาย wallet ตามลำดับ user id แล้ว currency แบบเดียวกับ Transfer กัน deadlock
+// หลังจากนี้เรียก HoldFunds/ConsumeHold กับ wallet เหล่านี้ใน tx เดียวกันได้โดยไม่ต้องรอ lock ซ้ำ
+func (r *walletRepository) LockWallets(tx *gorm.DB, keys []WalletKey) error {
+	sorted := make([]WalletKey, 0, len(keys))
+	seen := make(map[WalletKey]bool, len(keys))
+	for _, key := range keys {
+		if !seen[key] {
+			seen[key] = true
+			sorted = append(sorted, key)
 		}
+	}
 
-		// tx ฝั่ง receive
-		txReceiver := model.WalletTransaction{
-			WalletID:        receiverWallet.ID,
-			ReferenceID:     referenceID,
-			TransactionType: "TRANSFER_IN",
-			Amount:          amount,
-			BalanceBefore:   recvBalBefore,
-			BalanceAfter:    recvBalAfter,
-			Status:          "COMPLETED",
-			Description:     "Transfer from " + fromUserID,
-			CreatedAt:       time.Now(),
-			CreatedBy:       fromUserID,
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].UserID != sorted[j].UserID {
+			return sorted[i].UserID < sorted[j].UserID
 		}
-		if err := tx.Create(&txReceiver).Error; err != nil {
+		return sorted[i].Currency < sorted[j].Currency
+	})
+
+	for _, key := range sorted {
+		if _, err := r.GetWalletForUpdate(tx, key.UserID, key.Currency); err != nil {
 			return err
 		}
+	}
+	return nil
+}
 
-		return nil
-	})
+// ลงบัญชีคู่ทุกครั้งที่ Wallet.Balance เปลี่ยน, hold/release ไม่ต้องลงเพราะ balance ไม่เปลี่ยน
+func (r *walletRepository) post(tx *gorm.DB, entryType, referenceID, createdBy, description string, debit, credit ledgerModel.AccountRef, amount decimal.Decimal) error {
+	entry := ledgerModel.NewEntry(entryType, referenceID, createdBy, description, debit, credit, amount)
+	return r.ledger.PostEntry(tx, entry)
+}
+
+func (r *walletRepository) saveWithTransaction(tx *gorm.DB, wallet *model.Wallet, txType string, amount, balanceBefore decimal.Decimal, referenceID, createdBy, description string) error {
+	if err := tx.Save(wallet).Error; err != nil {
+		return err
+	}
+
+	trx := model.WalletTransaction{
+		WalletID:        wallet.ID,
+		ReferenceID:     referenceID,
+		TransactionType: txType,
+		Amount:          amount,
+		Currency:        wallet.Currency,
+		BalanceBefore:   balanceBefore,
+		BalanceAfter:    wallet.Balance,
+		Status:          model.TxStatusCompleted,
+		Description:     description,
+		CreatedAt:       time.Now(),
+		CreatedBy:       createdBy,
+	}
+
+	return tx.Create(&trx).Error
 }
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/padapook/bestbit-core/internal/wallet/model"
+	"gorm.io/gorm"
+)
+
+// ตำแหน่งของแถวสุดท้ายในหน้าก่อน เรียงตาม (created_at, id) จากใหม่ไปเก่า
+type TransactionCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+type TransactionFilter struct {
+	Type   string
+	Status string
+	From   *time.Time
+	To     *time.Time
+	Cursor *TransactionCursor
+	Limit  int
+}
+
+type WalletTransactionRepository interface {
+	GetTransactions(walletID uint64, filter TransactionFilter) ([]model.WalletTransaction, error)
+	GetTransactionsByReference(walletID uint64, referenceIDs []string) ([]model.WalletTransaction, error)
+}
+
+type walletTransactionRepository struct {
+	db *gorm.DB
+}
+
+func NewWalletTransactionRepository(db *gorm.DB) WalletTransactionRepository {
+	return &walletTransactionRepository{db: db}
+}
+
+func (r *walletTransactionRepository) GetTransactions(walletID uint64, filter TransactionFilter) ([]model.WalletTransaction, error) {
+	var transactions []model.WalletTransaction
+
+	query := r.db.Where("wallet_id = ?", walletID)
+	if filter.Type != "" {
+		query = query.Where("transaction_type = ?", filter.Type)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+	if filter.Cursor != nil {
+		query = query.Where("(created_at, id) < (?, ?)", filter.Cursor.CreatedAt, filter.Cursor.ID)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	err := query.Order("created_at DESC, id DESC").Find(&transactions).Error
+	return transactions, err
+}
+
+func (r *walletTransactionRepository) GetTransactionsByReference(walletID uint64, referenceIDs []string) ([]model.WalletTransaction, error) {
+	var transactions []model.WalletTransaction
+	err := r.db.Where("wallet_id = ? AND reference_id IN ?", walletID, referenceIDs).
+		Order("created_at ASC, id ASC").
+		Find(&transactions).Error
+	return transactions, err
+}
@@ -1,27 +1,73 @@
 package service
 
 import (
+	"encoding/base64"
 	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/google/uuid"
+	accountRepository "github.com/padapook/bestbit-core/internal/account/repository"
+	currencyModel "github.com/padapook/bestbit-core/internal/currency/model"
+	currencyService "github.com/padapook/bestbit-core/internal/currency/service"
+	limitModel "github.com/padapook/bestbit-core/internal/limit/model"
+	limitService "github.com/padapook/bestbit-core/internal/limit/service"
+	"github.com/padapook/bestbit-core/internal/utils"
 	"github.com/padapook/bestbit-core/internal/wallet/model"
 	"github.com/padapook/bestbit-core/internal/wallet/repository"
 	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
 )
 
 type WalletService interface {
 	GetUserWallets(userID string) ([]model.Wallet, error)
 	GetWalletBalance(userID, currency string) (*model.Wallet, error)
 	DepositMoney(userID, currency string, amount decimal.Decimal, referenceID string) (*model.Wallet, error)
-	WithdrawMoney(userID, currency string, amount decimal.Decimal, referenceID string) (*model.Wallet, error)
 	TransferMoney(fromUserID, toUserID, currency string, amount decimal.Decimal, referenceID string) error
+	GetTransactions(userID, currency string, query TransactionQuery) (*TransactionPage, error)
+	GetTransactionByReference(userID, currency string, referenceIDs ...string) ([]model.WalletTransaction, error)
+
+	// admin ปรับยอดมือ ต้องมีเหตุผลเสมอ ไม่ผ่านวงเงิน KYC
+	AdjustBalance(userID, currency string, amount decimal.Decimal, reason, actor, referenceID string) (*model.Wallet, error)
+}
+
+const (
+	defaultTransactionListLimit = 50
+	maxTransactionListLimit     = 200
+)
+
+type TransactionQuery struct {
+	Type   string
+	Status string
+	From   *time.Time
+	To     *time.Time
+	Cursor string
+	Limit  int
+}
+
+type TransactionPage struct {
+	Transactions []model.WalletTransaction `json:"transactions"`
+	NextCursor   string                    `json:"next_cursor,omitempty"`
 }
 
 type walletService struct {
-	repo repository.WalletRepository
+	repo       repository.WalletRepository
+	txRepo     repository.WalletTransactionRepository
+	limits     limitService.LimitService
+	currencies currencyService.CurrencyService
+	userRepo   accountRepository.UserRepository
 }
 
-func NewWalletService(repo repository.WalletRepository) WalletService {
-	return &walletService{repo: repo}
+func NewWalletService(
+	repo repository.WalletRepository,
+	txRepo repository.WalletTransactionRepository,
+	limits limitService.LimitService,
+	currencies currencyService.CurrencyService,
+	userRepo accountRepository.UserRepository,
+) WalletService {
+	return &walletService{repo: repo, txRepo: txRepo, limits: limits, currencies: currencies, userRepo: userRepo}
 }
 
 func (s *walletService) GetUserWallets(userID string) ([]model.Wallet, error) {
@@ -29,7 +75,11 @@ func (s *walletService) GetUserWallets(userID string) ([]model.Wallet, error) {
 }
 
 func (s *walletService) GetWalletBalance(userID, currency string) (*model.Wallet, error) {
-	return s.repo.GetWalletByUserIDAndCurrency(userID, currency)
+	cur, err := s.currencies.Validate(currency, "")
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetWalletByUserIDAndCurrency(userID, cur.Code)
 }
 
 func (s *walletService) DepositMoney(userID, currency string, amount decimal.Decimal, referenceID string) (*model.Wallet, error) {
@@ -37,15 +87,47 @@ func (s *walletService) DepositMoney(userID, currency string, amount decimal.Dec
 		return nil, errors.New("deposit amount must be greater than zero")
 	}
 
-	return s.repo.Deposit(userID, currency, amount, referenceID)
-}
+	cur, err := s.currencies.ValidateAmount(currency, currencyModel.OperationDeposit, amount)
+	if err != nil {
+		return nil, err
+	}
+	currency = cur.Code
 
-func (s *walletService) WithdrawMoney(userID, currency string, amount decimal.Decimal, referenceID string) (*model.Wallet, error) {
-	if amount.LessThanOrEqual(decimal.Zero) {
-		return nil, errors.New("withdraw amount must be greater than zero")
+	// account ที่ถูก freeze ห้ามฝากเข้า แม้จะเรียกผ่านช่องทางที่ไม่ผ่าน middleware
+	user, err := s.userRepo.GetByAccountID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.ErrUserNotFound
+		}
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, utils.ErrAccountFrozen
 	}
 
-	return s.repo.Withdraw(userID, currency, amount, referenceID)
+	var wallet *model.Wallet
+	err = s.repo.Transaction(func(tx *gorm.DB) error {
+		// ฝากครั้งแรกของสกุลนี้ สร้าง wallet ให้ก่อน
+		if err := s.repo.EnsureWallet(tx, userID, currency); err != nil {
+			return err
+		}
+		// lock ก่อนเช็ควงเงิน รายการที่เข้ามาพร้อมกันจะต้องรอและเห็นยอดรวมล่าสุด
+		if _, err := s.repo.GetWalletForUpdate(tx, userID, currency); err != nil {
+			return err
+		}
+		if err := s.limits.Check(tx, userID, currency, limitModel.OperationDeposit, amount); err != nil {
+			return err
+		}
+
+		var err error
+		wallet, err = s.repo.Deposit(tx, userID, currency, amount, referenceID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return wallet, nil
 }
 
 func (s *walletService) TransferMoney(fromUserID, toUserID, currency string, amount decimal.Decimal, referenceID string) error {
@@ -57,5 +139,189 @@ func (s *walletService) TransferMoney(fromUserID, toUserID, currency string, amo
 		return errors.New("transfer amount must be greater than zero")
 	}
 
-	return s.repo.Transfer(fromUserID, toUserID, currency, amount, referenceID)
+	cur, err := s.currencies.ValidateAmount(currency, "", amount)
+	if err != nil {
+		return err
+	}
+	currency = cur.Code
+
+	// ผู้รับอาจยังไม่มี wallet สกุลนี้ ต้องเป็น user จริงก่อนถึงจะสร้างให้
+	recipient, err := s.userRepo.GetByAccountID(toUserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return utils.ErrUserNotFound
+		}
+		return err
+	}
+	if !recipient.IsActive {
+		return utils.ErrAccountFrozen
+	}
+
+	return s.repo.Transaction(func(tx *gorm.DB) error {
+		if err := s.repo.EnsureWallet(tx, toUserID, currency); err != nil {
+			return err
+		}
+		if err := s.repo.LockWallets(tx, []repository.WalletKey{
+			{UserID: fromUserID, Currency: currency},
+			{UserID: toUserID, Currency: currency},
+		}); err != nil {
+			return err
+		}
+		// วงเงินโอนนับเฉพาะฝั่งผู้โอน
+		if err := s.limits.Check(tx, fromUserID, currency, limitModel.OperationTransfer, amount); err != nil {
+			return err
+		}
+
+		return s.repo.Transfer(tx, fromUserID, toUserID, currency, amount, referenceID)
+	})
+}
+
+// ดูได้เฉพาะ wallet ของตัวเอง หา wallet จาก userID ก่อนเสมอ
+func (s *walletService) GetTransactions(userID, currency string, query TransactionQuery) (*TransactionPage, error) {
+	filter := repository.TransactionFilter{
+		Type:   strings.ToUpper(query.Type),
+		Status: strings.ToUpper(query.Status),
+		From:   query.From,
+		To:     query.To,
+		Limit:  query.Limit,
+	}
+
+	if filter.Type != "" && !model.IsValidTxType(filter.Type) {
+		return nil, utils.ErrInvalidRequest
+	}
+	if filter.Status != "" && !model.IsValidTxStatus(filter.Status) {
+		return nil, utils.ErrInvalidRequest
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, utils.ErrInvalidRequest
+	}
+
+	if query.Cursor != "" {
+		cursor, err := decodeTransactionCursor(query.Cursor)
+		if err != nil {
+			return nil, utils.ErrInvalidRequest
+		}
+		filter.Cursor = cursor
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = defaultTransactionListLimit
+	}
+	if filter.Limit > maxTransactionListLimit {
+		filter.Limit = maxTransactionListLimit
+	}
+
+	cur, err := s.currencies.Validate(currency, "")
+	if err != nil {
+		return nil, err
+	}
+
+	wallet, err := s.repo.GetWalletByUserIDAndCurrency(userID, cur.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	// ดึงเกินมา 1 แถวเพื่อรู้ว่ามีหน้าถัดไปไหม
+	limit := filter.Limit
+	filter.Limit = limit + 1
+	transactions, err := s.txRepo.GetTransactions(wallet.ID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &TransactionPage{Transactions: transactions}
+	if len(transactions) > limit {
+		page.Transactions = transactions[:limit]
+		last := page.Transactions[limit-1]
+		page.NextCursor = encodeTransactionCursor(last.CreatedAt, last.ID)
+	}
+	if page.Transactions == nil {
+		page.Transactions = []model.WalletTransaction{}
+	}
+
+	return page, nil
+}
+
+func (s *walletService) GetTransactionByReference(userID, currency string, referenceIDs ...string) ([]model.WalletTransaction, error) {
+	cur, err := s.currencies.Validate(currency, "")
+	if err != nil {
+		return nil, err
+	}
+
+	wallet, err := s.repo.GetWalletByUserIDAndCurrency(userID, cur.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions, err := s.txRepo.GetTransactionsByReference(wallet.ID, referenceIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(transactions) == 0 {
+		return nil, utils.ErrTransactionNotFound
+	}
+
+	return transactions, nil
+}
+
+func (s *walletService) AdjustBalance(userID, currency string, amount decimal.Decimal, reason, actor, referenceID string) (*model.Wallet, error) {
+	reason = strings.TrimSpace(reason)
+	if amount.IsZero() || reason == "" {
+		return nil, utils.ErrInvalidAdjustment
+	}
+
+	cur, err := s.currencies.ValidateAmount(currency, "", amount.Abs())
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.userRepo.GetByAccountID(userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	var wallet *model.Wallet
+	err = s.repo.Transaction(func(tx *gorm.DB) error {
+		if err := s.repo.EnsureWallet(tx, userID, cur.Code); err != nil {
+			return err
+		}
+		var err error
+		wallet, err = s.repo.Adjust(tx, userID, cur.Code, amount, referenceID, reason, actor)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return wallet, nil
+}
+
+// cursor = base64("<created_at unix nano>_<id>") ให้ client ส่งกลับมาตรงๆ
+func encodeTransactionCursor(createdAt time.Time, id uuid.UUID) string {
+	raw := fmt.Sprintf("%d_%s", createdAt.UnixNano(), id.String())
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeTransactionCursor(cursor string) (*repository.TransactionCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := strings.SplitN(string(raw), "_", 2)
+	if len(parts) != 2 {
+		return nil, errors.New("invalid cursor")
+	}
+
+	nanos, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	id, err := uuid.Parse(parts[1])
+	if err != nil {
+		return nil, err
+	}
+
+	return &repository.TransactionCursor{CreatedAt: time.Unix(0, nanos), ID: id}, nil
 }
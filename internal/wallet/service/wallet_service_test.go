@@ -1,13 +1,24 @@
 package service
 
 import (
-	"errors"
 	"testing"
+	"time"
 
+	"github.com/google/uuid"
+	accountModel "github.com/padapook/bestbit-core/internal/account/model"
+	accountRepository "github.com/padapook/bestbit-core/internal/account/repository"
+	currencyModel "github.com/padapook/bestbit-core/internal/currency/model"
+	currencyRepository "github.com/padapook/bestbit-core/internal/currency/repository"
+	currencyService "github.com/padapook/bestbit-core/internal/currency/service"
+	limitModel "github.com/padapook/bestbit-core/internal/limit/model"
+	limitService "github.com/padapook/bestbit-core/internal/limit/service"
+	"github.com/padapook/bestbit-core/internal/utils"
 	"github.com/padapook/bestbit-core/internal/wallet/model"
+	"github.com/padapook/bestbit-core/internal/wallet/repository"
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
 )
 
 type MockWalletRepository struct {
@@ -30,35 +41,178 @@ func (m *MockWalletRepository) GetWalletByUserIDAndCurrency(userID, currency str
 	return nil, args.Error(1)
 }
 
-func (m *MockWalletRepository) Deposit(userID, currency string, amount decimal.Decimal, referenceID string) (*model.Wallet, error) {
-	args := m.Called(userID, currency, amount, referenceID)
+func (m *MockWalletRepository) GetAllWallets(afterID uint64, limit int) ([]model.Wallet, error) {
+	args := m.Called(afterID, limit)
+	if args.Get(0) != nil {
+		return args.Get(0).([]model.Wallet), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockWalletRepository) Transaction(fn func(tx *gorm.DB) error) error {
+	return fn(nil)
+}
+
+func (m *MockWalletRepository) EnsureWallet(tx *gorm.DB, userID, currency string) error {
+	args := m.Called(tx, userID, currency)
+	return args.Error(0)
+}
+
+func (m *MockWalletRepository) Deposit(tx *gorm.DB, userID, currency string, amount decimal.Decimal, referenceID string) (*model.Wallet, error) {
+	args := m.Called(tx, userID, currency, amount, referenceID)
+	if args.Get(0) != nil {
+		return args.Get(0).(*model.Wallet), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockWalletRepository) Transfer(tx *gorm.DB, fromUserID, toUserID, currency string, amount decimal.Decimal, referenceID string) error {
+	args := m.Called(tx, fromUserID, toUserID, currency, amount, referenceID)
+	return args.Error(0)
+}
+
+func (m *MockWalletRepository) GetWalletForUpdate(tx *gorm.DB, userID, currency string) (*model.Wallet, error) {
+	args := m.Called(tx, userID, currency)
+	if args.Get(0) != nil {
+		return args.Get(0).(*model.Wallet), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockWalletRepository) HoldFunds(tx *gorm.DB, userID, currency string, amount decimal.Decimal, referenceID string) (*model.Wallet, error) {
+	args := m.Called(tx, userID, currency, amount, referenceID)
 	if args.Get(0) != nil {
 		return args.Get(0).(*model.Wallet), args.Error(1)
 	}
 	return nil, args.Error(1)
 }
 
-func (m *MockWalletRepository) Withdraw(userID, currency string, amount decimal.Decimal, referenceID string) (*model.Wallet, error) {
-	args := m.Called(userID, currency, amount, referenceID)
+func (m *MockWalletRepository) ReleaseFunds(tx *gorm.DB, userID, currency string, amount decimal.Decimal, referenceID string) (*model.Wallet, error) {
+	args := m.Called(tx, userID, currency, amount, referenceID)
 	if args.Get(0) != nil {
 		return args.Get(0).(*model.Wallet), args.Error(1)
 	}
 	return nil, args.Error(1)
 }
 
-func (m *MockWalletRepository) Transfer(fromUserID, toUserID, currency string, amount decimal.Decimal, referenceID string) error {
-	args := m.Called(fromUserID, toUserID, currency, amount, referenceID)
+func (m *MockWalletRepository) ConsumeHold(tx *gorm.DB, userID, currency string, amount decimal.Decimal, referenceID string) (*model.Wallet, error) {
+	args := m.Called(tx, userID, currency, amount, referenceID)
+	if args.Get(0) != nil {
+		return args.Get(0).(*model.Wallet), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockWalletRepository) CreditFunds(tx *gorm.DB, userID, currency string, amount decimal.Decimal, referenceID string) (*model.Wallet, error) {
+	args := m.Called(tx, userID, currency, amount, referenceID)
+	if args.Get(0) != nil {
+		return args.Get(0).(*model.Wallet), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockWalletRepository) ChargeFee(tx *gorm.DB, userID, currency string, amount decimal.Decimal, referenceID string) (*model.Wallet, error) {
+	args := m.Called(tx, userID, currency, amount, referenceID)
+	if args.Get(0) != nil {
+		return args.Get(0).(*model.Wallet), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockWalletRepository) CompleteWithdrawal(tx *gorm.DB, userID, currency string, amount decimal.Decimal, referenceID string) (*model.Wallet, error) {
+	args := m.Called(tx, userID, currency, amount, referenceID)
+	if args.Get(0) != nil {
+		return args.Get(0).(*model.Wallet), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockWalletRepository) Adjust(tx *gorm.DB, userID, currency string, amount decimal.Decimal, referenceID, reason, actor string) (*model.Wallet, error) {
+	args := m.Called(tx, userID, currency, amount, referenceID, reason, actor)
+	if args.Get(0) != nil {
+		return args.Get(0).(*model.Wallet), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockWalletRepository) LockWallets(tx *gorm.DB, keys []repository.WalletKey) error {
+	args := m.Called(tx, keys)
+	return args.Error(0)
+}
+
+type MockWalletTransactionRepository struct {
+	mock.Mock
+}
+
+func (m *MockWalletTransactionRepository) GetTransactions(walletID uint64, filter repository.TransactionFilter) ([]model.WalletTransaction, error) {
+	args := m.Called(walletID, filter)
+	if args.Get(0) != nil {
+		return args.Get(0).([]model.WalletTransaction), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockWalletTransactionRepository) GetTransactionsByReference(walletID uint64, referenceIDs []string) ([]model.WalletTransaction, error) {
+	args := m.Called(walletID, referenceIDs)
+	if args.Get(0) != nil {
+		return args.Get(0).([]model.WalletTransaction), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+// registry จริงที่มีแค่สกุลตั้งต้น
+type StubCurrencyRepository struct {
+	currencyRepository.CurrencyRepository
+}
+
+func (r *StubCurrencyRepository) GetByCode(code string) (*currencyModel.Currency, error) {
+	for _, currency := range currencyModel.DefaultCurrencies {
+		if currency.Code == code {
+			return &currency, nil
+		}
+	}
+	return nil, utils.ErrCurrencyNotFound
+}
+
+func testCurrencies() currencyService.CurrencyService {
+	return currencyService.NewCurrencyService(new(StubCurrencyRepository))
+}
+
+type MockUserRepository struct {
+	accountRepository.UserRepository
+	mock.Mock
+}
+
+func (m *MockUserRepository) GetByAccountID(accountID string) (*accountModel.User, error) {
+	args := m.Called(accountID)
+	if args.Get(0) != nil {
+		return args.Get(0).(*accountModel.User), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+// วงเงินทดสอบแยกใน limit service ที่นี่ดูแค่ว่าเรียกถูกจังหวะ
+type MockLimitService struct {
+	limitService.LimitService
+	mock.Mock
+}
+
+func (m *MockLimitService) Check(tx *gorm.DB, userID, currency, operation string, amount decimal.Decimal) error {
+	args := m.Called(tx, userID, currency, operation, amount)
 	return args.Error(0)
 }
 
 func TestDepositMoney_Success(t *testing.T) {
 	mockRepo := new(MockWalletRepository)
-	service := NewWalletService(mockRepo)
+	mockLimits := new(MockLimitService)
+	mockUsers := new(MockUserRepository)
+	service := NewWalletService(mockRepo, new(MockWalletTransactionRepository), mockLimits, testCurrencies(), mockUsers)
 
 	userID := "user-123"
 	currency := "THB"
 	amount := decimal.NewFromInt(1000)
 	refID := "ref-123"
+	mockUsers.On("GetByAccountID", userID).Return(&accountModel.User{AccountId: userID, IsActive: true}, nil)
 
 	expectedWallet := &model.Wallet{
 		UserID:   userID,
@@ -67,7 +221,10 @@ func TestDepositMoney_Success(t *testing.T) {
 	}
 
 	// เมื่อ Service เรียก Deposit ไปยัง Repo, ให้returnค่า expectedWallet
-	mockRepo.On("Deposit", userID, currency, amount, refID).Return(expectedWallet, nil)
+	mockRepo.On("EnsureWallet", mock.Anything, userID, currency).Return(nil)
+	mockRepo.On("GetWalletForUpdate", mock.Anything, userID, currency).Return(&model.Wallet{UserID: userID, Currency: currency}, nil)
+	mockLimits.On("Check", mock.Anything, userID, currency, limitModel.OperationDeposit, amount).Return(nil)
+	mockRepo.On("Deposit", mock.Anything, userID, currency, amount, refID).Return(expectedWallet, nil)
 
 	wallet, err := service.DepositMoney(userID, currency, amount, refID)
 
@@ -76,11 +233,67 @@ func TestDepositMoney_Success(t *testing.T) {
 	assert.Equal(t, amount, wallet.Balance)
 
 	mockRepo.AssertExpectations(t)
+	mockLimits.AssertExpectations(t)
+}
+
+func TestDepositMoney_Fail_LimitExceeded(t *testing.T) {
+	mockRepo := new(MockWalletRepository)
+	mockLimits := new(MockLimitService)
+	mockUsers := new(MockUserRepository)
+	service := NewWalletService(mockRepo, new(MockWalletTransactionRepository), mockLimits, testCurrencies(), mockUsers)
+
+	amount := decimal.NewFromInt(1000)
+
+	mockUsers.On("GetByAccountID", "user-123").Return(&accountModel.User{AccountId: "user-123", IsActive: true}, nil)
+	mockRepo.On("EnsureWallet", mock.Anything, "user-123", "THB").Return(nil)
+	mockRepo.On("GetWalletForUpdate", mock.Anything, "user-123", "THB").Return(&model.Wallet{}, nil)
+	mockLimits.On("Check", mock.Anything, "user-123", "THB", limitModel.OperationDeposit, amount).Return(utils.ErrDailyLimitExceeded)
+
+	wallet, err := service.DepositMoney("user-123", "THB", amount, "ref-123")
+
+	assert.Nil(t, wallet)
+	assert.Equal(t, utils.ErrDailyLimitExceeded, err)
+	mockRepo.AssertNotCalled(t, "Deposit")
+}
+
+func TestDepositMoney_Fail_AccountFrozen(t *testing.T) {
+	mockRepo := new(MockWalletRepository)
+	mockUsers := new(MockUserRepository)
+	service := NewWalletService(mockRepo, new(MockWalletTransactionRepository), new(MockLimitService), testCurrencies(), mockUsers)
+
+	mockUsers.On("GetByAccountID", "user-123").Return(&accountModel.User{AccountId: "user-123", IsActive: false}, nil)
+
+	wallet, err := service.DepositMoney("user-123", "THB", decimal.NewFromInt(1000), "ref-123")
+
+	assert.Nil(t, wallet)
+	assert.Equal(t, utils.ErrAccountFrozen, err)
+	mockRepo.AssertNotCalled(t, "EnsureWallet")
+	mockRepo.AssertNotCalled(t, "Deposit")
+}
+
+func TestDepositMoney_Fail_WalletFrozen(t *testing.T) {
+	mockRepo := new(MockWalletRepository)
+	mockLimits := new(MockLimitService)
+	mockUsers := new(MockUserRepository)
+	service := NewWalletService(mockRepo, new(MockWalletTransactionRepository), mockLimits, testCurrencies(), mockUsers)
+
+	amount := decimal.NewFromInt(1000)
+
+	mockUsers.On("GetByAccountID", "user-123").Return(&accountModel.User{AccountId: "user-123", IsActive: true}, nil)
+	mockRepo.On("EnsureWallet", mock.Anything, "user-123", "THB").Return(nil)
+	mockRepo.On("GetWalletForUpdate", mock.Anything, "user-123", "THB").Return(&model.Wallet{}, nil)
+	mockLimits.On("Check", mock.Anything, "user-123", "THB", limitModel.OperationDeposit, amount).Return(nil)
+	mockRepo.On("Deposit", mock.Anything, "user-123", "THB", amount, "ref-123").Return(nil, utils.ErrWalletFrozen)
+
+	wallet, err := service.DepositMoney("user-123", "THB", amount, "ref-123")
+
+	assert.Nil(t, wallet)
+	assert.Equal(t, utils.ErrWalletFrozen, err)
 }
 
 func TestDepositMoney_Fail_InvalidAmount(t *testing.T) {
 	mockRepo := new(MockWalletRepository)
-	service := NewWalletService(mockRepo)
+	service := NewWalletService(mockRepo, new(MockWalletTransactionRepository), new(MockLimitService), testCurrencies(), new(MockUserRepository))
 
 	userID := "user-123"
 	currency := "THB"
@@ -97,31 +310,34 @@ func TestDepositMoney_Fail_InvalidAmount(t *testing.T) {
 	mockRepo.AssertNotCalled(t, "Deposit")
 }
 
-func TestWithdrawMoney_Fail_InsufficientBalance(t *testing.T) {
-	mockRepo := new(MockWalletRepository)
-	service := NewWalletService(mockRepo)
-
-	userID := "user-123"
-	currency := "THB"
-	amount := decimal.NewFromInt(2000)
-	refID := "ref-123"
-
-	expectedError := errors.New("insufficient balance")
+func TestDepositMoney_Fail_Currency(t *testing.T) {
+	tests := []struct {
+		name     string
+		currency string
+		amount   string
+		expected error
+	}{
+		{"not in registry", "DOGE", "1", utils.ErrCurrencyNotFound},
+		{"too many decimals", "THB", "10.005", utils.ErrInvalidAmountPrecision},
+	}
 
-	// จำลองว่ายอดเงินไม่พอ
-	mockRepo.On("Withdraw", userID, currency, amount, refID).Return(nil, expectedError)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockWalletRepository)
+			service := NewWalletService(mockRepo, new(MockWalletTransactionRepository), new(MockLimitService), testCurrencies(), new(MockUserRepository))
 
-	wallet, err := service.WithdrawMoney(userID, currency, amount, refID)
+			wallet, err := service.DepositMoney("user-123", tt.currency, decimal.RequireFromString(tt.amount), "ref-123")
 
-	assert.Error(t, err)
-	assert.Nil(t, wallet)
-	assert.Equal(t, expectedError, err)
-	mockRepo.AssertExpectations(t)
+			assert.Nil(t, wallet)
+			assert.Equal(t, tt.expected, err)
+			mockRepo.AssertNotCalled(t, "EnsureWallet")
+		})
+	}
 }
 
 func TestTransferMoney_Fail_SelfTransfer(t *testing.T) {
 	mockRepo := new(MockWalletRepository)
-	service := NewWalletService(mockRepo)
+	service := NewWalletService(mockRepo, new(MockWalletTransactionRepository), new(MockLimitService), testCurrencies(), new(MockUserRepository))
 
 	userID := "user-123"
 	amount := decimal.NewFromInt(500)
@@ -134,3 +350,186 @@ func TestTransferMoney_Fail_SelfTransfer(t *testing.T) {
 	assert.Equal(t, "cannot transfer to yourself", err.Error())
 	mockRepo.AssertNotCalled(t, "Transfer")
 }
+
+func TestTransferMoney_ChecksSenderLimit(t *testing.T) {
+	mockRepo := new(MockWalletRepository)
+	mockLimits := new(MockLimitService)
+	mockUsers := new(MockUserRepository)
+	service := NewWalletService(mockRepo, new(MockWalletTransactionRepository), mockLimits, testCurrencies(), mockUsers)
+
+	amount := decimal.NewFromInt(500)
+
+	mockUsers.On("GetByAccountID", "user-b").Return(&accountModel.User{AccountId: "user-b", IsActive: true}, nil)
+	// ผู้รับยังไม่เคยถือ THB
+	mockRepo.On("EnsureWallet", mock.Anything, "user-b", "THB").Return(nil)
+	mockRepo.On("LockWallets", mock.Anything, []repository.WalletKey{
+		{UserID: "user-a", Currency: "THB"},
+		{UserID: "user-b", Currency: "THB"},
+	}).Return(nil)
+	mockLimits.On("Check", mock.Anything, "user-a", "THB", limitModel.OperationTransfer, amount).Return(utils.ErrSingleLimitExceeded)
+
+	err := service.TransferMoney("user-a", "user-b", "THB", amount, "ref-123")
+
+	assert.Equal(t, utils.ErrSingleLimitExceeded, err)
+	mockRepo.AssertNotCalled(t, "Transfer")
+	mockRepo.AssertExpectations(t)
+	mockLimits.AssertExpectations(t)
+}
+
+func TestTransferMoney_Fail_RecipientFrozen(t *testing.T) {
+	mockRepo := new(MockWalletRepository)
+	mockUsers := new(MockUserRepository)
+	service := NewWalletService(mockRepo, new(MockWalletTransactionRepository), new(MockLimitService), testCurrencies(), mockUsers)
+
+	mockUsers.On("GetByAccountID", "user-b").Return(&accountModel.User{AccountId: "user-b", IsActive: false}, nil)
+
+	err := service.TransferMoney("user-a", "user-b", "THB", decimal.NewFromInt(500), "ref-123")
+
+	assert.Equal(t, utils.ErrAccountFrozen, err)
+	mockRepo.AssertNotCalled(t, "Transfer", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestTransferMoney_Fail_UnknownRecipient(t *testing.T) {
+	mockRepo := new(MockWalletRepository)
+	mockUsers := new(MockUserRepository)
+	service := NewWalletService(mockRepo, new(MockWalletTransactionRepository), new(MockLimitService), testCurrencies(), mockUsers)
+
+	mockUsers.On("GetByAccountID", "ghost").Return(nil, gorm.ErrRecordNotFound)
+
+	err := service.TransferMoney("user-a", "ghost", "thb", decimal.NewFromInt(500), "ref-123")
+
+	assert.Equal(t, utils.ErrUserNotFound, err)
+	mockRepo.AssertNotCalled(t, "EnsureWallet")
+}
+
+func TestGetTransactions_Success_ReturnsNextCursor(t *testing.T) {
+	mockRepo := new(MockWalletRepository)
+	mockTxRepo := new(MockWalletTransactionRepository)
+	service := NewWalletService(mockRepo, mockTxRepo, new(MockLimitService), testCurrencies(), new(MockUserRepository))
+
+	now := time.Now()
+	rows := []model.WalletTransaction{
+		{ID: uuid.New(), CreatedAt: now},
+		{ID: uuid.New(), CreatedAt: now.Add(-time.Minute)},
+		{ID: uuid.New(), CreatedAt: now.Add(-2 * time.Minute)},
+	}
+
+	mockRepo.On("GetWalletByUserIDAndCurrency", "user-123", "THB").Return(&model.Wallet{ID: 7}, nil)
+	// ขอ 2 แถว repo ต้องถูกถาม 3 แถว
+	mockTxRepo.On("GetTransactions", uint64(7), mock.MatchedBy(func(f repository.TransactionFilter) bool {
+		return f.Limit == 3 && f.Type == model.TxTypeDeposit && f.Cursor == nil
+	})).Return(rows, nil)
+
+	page, err := service.GetTransactions("user-123", "thb", TransactionQuery{Type: "deposit", Limit: 2})
+
+	assert.NoError(t, err)
+	assert.Len(t, page.Transactions, 2)
+	assert.NotEmpty(t, page.NextCursor)
+
+	// cursor ชี้ไปที่แถวสุดท้ายของหน้านี้
+	cursor, err := decodeTransactionCursor(page.NextCursor)
+	assert.NoError(t, err)
+	assert.Equal(t, rows[1].ID, cursor.ID)
+	assert.True(t, rows[1].CreatedAt.Equal(cursor.CreatedAt))
+}
+
+func TestGetTransactions_Fail_InvalidFilter(t *testing.T) {
+	mockRepo := new(MockWalletRepository)
+	mockTxRepo := new(MockWalletTransactionRepository)
+	service := NewWalletService(mockRepo, mockTxRepo, new(MockLimitService), testCurrencies(), new(MockUserRepository))
+
+	_, err := service.GetTransactions("user-123", "THB", TransactionQuery{Type: "UNKNOWN"})
+	assert.Equal(t, utils.ErrInvalidRequest, err)
+
+	_, err = service.GetTransactions("user-123", "THB", TransactionQuery{Cursor: "not-a-cursor"})
+	assert.Equal(t, utils.ErrInvalidRequest, err)
+
+	mockRepo.AssertNotCalled(t, "GetWalletByUserIDAndCurrency")
+}
+
+func TestGetTransactions_Fail_NotOwner(t *testing.T) {
+	mockRepo := new(MockWalletRepository)
+	mockTxRepo := new(MockWalletTransactionRepository)
+	service := NewWalletService(mockRepo, mockTxRepo, new(MockLimitService), testCurrencies(), new(MockUserRepository))
+
+	mockRepo.On("GetWalletByUserIDAndCurrency", "user-123", "BTC").Return(nil, utils.ErrWalletNotFound)
+
+	_, err := service.GetTransactions("user-123", "BTC", TransactionQuery{})
+
+	assert.Equal(t, utils.ErrWalletNotFound, err)
+	mockTxRepo.AssertNotCalled(t, "GetTransactions")
+}
+
+func TestGetTransactionByReference_Success_AnyCandidate(t *testing.T) {
+	mockRepo := new(MockWalletRepository)
+	mockTxRepo := new(MockWalletTransactionRepository)
+	service := NewWalletService(mockRepo, mockTxRepo, new(MockLimitService), testCurrencies(), new(MockUserRepository))
+
+	candidates := []string{"key-1", "DEPOSIT-user-123-key-1"}
+	mockRepo.On("GetWalletByUserIDAndCurrency", "user-123", "THB").Return(&model.Wallet{ID: 7}, nil)
+	mockTxRepo.On("GetTransactionsByReference", uint64(7), candidates).
+		Return([]model.WalletTransaction{{WalletID: 7, ReferenceID: "DEPOSIT-user-123-key-1"}}, nil)
+
+	transactions, err := service.GetTransactionByReference("user-123", "THB", candidates...)
+
+	assert.NoError(t, err)
+	assert.Len(t, transactions, 1)
+	assert.Equal(t, "DEPOSIT-user-123-key-1", transactions[0].ReferenceID)
+}
+
+func TestGetTransactionByReference_Fail_NotFound(t *testing.T) {
+	mockRepo := new(MockWalletRepository)
+	mockTxRepo := new(MockWalletTransactionRepository)
+	service := NewWalletService(mockRepo, mockTxRepo, new(MockLimitService), testCurrencies(), new(MockUserRepository))
+
+	mockRepo.On("GetWalletByUserIDAndCurrency", "user-123", "THB").Return(&model.Wallet{ID: 7}, nil)
+	mockTxRepo.On("GetTransactionsByReference", uint64(7), []string{"ref-1"}).Return([]model.WalletTransaction{}, nil)
+
+	transactions, err := service.GetTransactionByReference("user-123", "THB", "ref-1")
+
+	assert.Nil(t, transactions)
+	assert.Equal(t, utils.ErrTransactionNotFound, err)
+}
+
+func TestAdjustBalance_Success(t *testing.T) {
+	mockRepo := new(MockWalletRepository)
+	mockUsers := new(MockUserRepository)
+	service := NewWalletService(mockRepo, new(MockWalletTransactionRepository), new(MockLimitService), testCurrencies(), mockUsers)
+
+	amount := decimal.RequireFromString("-25.50")
+	mockUsers.On("GetByAccountID", "user-1").Return(&accountModel.User{AccountId: "user-1", IsActive: true}, nil)
+	mockRepo.On("EnsureWallet", mock.Anything, "user-1", "THB").Return(nil)
+	mockRepo.On("Adjust", mock.Anything, "user-1", "THB", amount, "ref-adj", "duplicate deposit", "admin-1").
+		Return(&model.Wallet{UserID: "user-1", Currency: "THB"}, nil)
+
+	wallet, err := service.AdjustBalance("user-1", "thb", amount, "  duplicate deposit ", "admin-1", "ref-adj")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "THB", wallet.Currency)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAdjustBalance_Fail_InvalidInput(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   string
+		reason   string
+		expected error
+	}{
+		{"zero amount", "0", "fix", utils.ErrInvalidAdjustment},
+		{"blank reason", "10", "   ", utils.ErrInvalidAdjustment},
+		{"too many decimals", "-0.001", "fix", utils.ErrInvalidAmountPrecision},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockWalletRepository)
+			service := NewWalletService(mockRepo, new(MockWalletTransactionRepository), new(MockLimitService), testCurrencies(), new(MockUserRepository))
+
+			_, err := service.AdjustBalance("user-1", "THB", decimal.RequireFromString(tt.amount), tt.reason, "admin-1", "ref-adj")
+
+			assert.Equal(t, tt.expected, err)
+			mockRepo.AssertNotCalled(t, "Adjust", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/padapook/bestbit-core/internal/utils"
+	"github.com/padapook/bestbit-core/internal/withdrawal/model"
+	"github.com/padapook/bestbit-core/internal/withdrawal/service"
+	"github.com/shopspring/decimal"
+)
+
+type WithdrawalController interface {
+	RequestWithdrawal(c *gin.Context)
+	GetWithdrawal(c *gin.Context)
+	GetWithdrawals(c *gin.Context)
+
+	// admin
+	AdminGetWithdrawals(c *gin.Context)
+	AdminGetWithdrawal(c *gin.Context)
+	Approve(c *gin.Context)
+	Reject(c *gin.Context)
+	Process(c *gin.Context)
+	Complete(c *gin.Context)
+	Fail(c *gin.Context)
+}
+
+type withdrawalController struct {
+	withdrawalService service.WithdrawalService
+}
+
+func NewWithdrawalController(withdrawalService service.WithdrawalService) WithdrawalController {
+	return &withdrawalController{withdrawalService: withdrawalService}
+}
+
+type WithdrawRequest struct {
+	Currency    string          `json:"currency" binding:"required"`
+	Amount      decimal.Decimal `json:"amount" binding:"required"`
+	Destination string          `json:"destination" binding:"required"`
+}
+
+type ReasonRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+type CompleteRequest struct {
+	ProviderReference string `json:"provider_reference"`
+}
+
+func (ctrl *withdrawalController) RequestWithdrawal(c *gin.Context) {
+	accountID, exists := c.Get("account_id")
+	if !exists {
+		utils.HandleError(c, utils.ErrUnauthorized)
+		return
+	}
+
+	var req WithdrawRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleError(c, utils.ErrInvalidRequest)
+		return
+	}
+
+	// ใช้ Idempotency-Key เป็น reference ให้ยิงซ้ำแล้วได้คำขอเดิม
+	refID := c.GetString("idempotency_key")
+	if refID == "" {
+		refID = uuid.New().String()
+	}
+
+	withdrawal, err := ctrl.withdrawalService.RequestWithdrawal(accountID.(string), req.Currency, req.Amount, req.Destination, refID)
+	if err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Withdrawal requested",
+		"data":    withdrawal,
+	})
+}
+
+func (ctrl *withdrawalController) GetWithdrawal(c *gin.Context) {
+	accountID, exists := c.Get("account_id")
+	if !exists {
+		utils.HandleError(c, utils.ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.HandleError(c, utils.ErrInvalidRequest)
+		return
+	}
+
+	withdrawal, err := ctrl.withdrawalService.GetWithdrawal(accountID.(string), id)
+	if err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": withdrawal,
+	})
+}
+
+func (ctrl *withdrawalController) GetWithdrawals(c *gin.Context) {
+	accountID, exists := c.Get("account_id")
+	if !exists {
+		utils.HandleError(c, utils.ErrUnauthorized)
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	withdrawals, err := ctrl.withdrawalService.GetUserWithdrawals(accountID.(string), strings.ToUpper(c.Query("status")), limit)
+	if err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": withdrawals,
+	})
+}
+
+func (ctrl *withdrawalController) AdminGetWithdrawals(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	withdrawals, err := ctrl.withdrawalService.GetWithdrawals(strings.ToUpper(c.Query("status")), limit)
+	if err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": withdrawals,
+	})
+}
+
+func (ctrl *withdrawalController) AdminGetWithdrawal(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.HandleError(c, utils.ErrInvalidRequest)
+		return
+	}
+
+	detail, err := ctrl.withdrawalService.GetWithdrawalDetail(id)
+	if err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": detail,
+	})
+}
+
+func (ctrl *withdrawalController) Approve(c *gin.Context) {
+	ctrl.handleTransition(c, func(id uint64, actor string) (*model.Withdrawal, error) {
+		return ctrl.withdrawalService.Approve(id, actor)
+	})
+}
+
+func (ctrl *withdrawalController) Reject(c *gin.Context) {
+	var req ReasonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleError(c, utils.ErrInvalidRequest)
+		return
+	}
+
+	ctrl.handleTransition(c, func(id uint64, actor string) (*model.Withdrawal, error) {
+		return ctrl.withdrawalService.Reject(id, actor, req.Reason)
+	})
+}
+
+func (ctrl *withdrawalController) Process(c *gin.Context) {
+	ctrl.handleTransition(c, func(id uint64, actor string) (*model.Withdrawal, error) {
+		return ctrl.withdrawalService.Process(id, actor)
+	})
+}
+
+// ปิดงานเองเมื่อ provider ยืนยันนอกระบบ
+func (ctrl *withdrawalController) Complete(c *gin.Context) {
+	var req CompleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil && c.Request.ContentLength > 0 {
+		utils.HandleError(c, utils.ErrInvalidRequest)
+		return
+	}
+
+	ctrl.handleTransition(c, func(id uint64, actor string) (*model.Withdrawal, error) {
+		return ctrl.withdrawalService.Complete(id, actor, req.ProviderReference)
+	})
+}
+
+func (ctrl *withdrawalController) Fail(c *gin.Context) {
+	var req ReasonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.HandleError(c, utils.ErrInvalidRequest)
+		return
+	}
+
+	ctrl.handleTransition(c, func(id uint64, actor string) (*model.Withdrawal, error) {
+		return ctrl.withdrawalService.Fail(id, actor, req.Reason)
+	})
+}
+
+func (ctrl *withdrawalController) handleTransition(c *gin.Context, fn func(id uint64, actor string) (*model.Withdrawal, error)) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.HandleError(c, utils.ErrInvalidRequest)
+		return
+	}
+
+	withdrawal, err := fn(id, c.GetString("account_id"))
+	if err != nil {
+		utils.HandleServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Withdrawal " + strings.ToLower(withdrawal.Status),
+		"data":    withdrawal,
+	})
+}
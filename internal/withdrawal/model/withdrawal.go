@@ -0,0 +1,80 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	StatusRequested  = "REQUESTED"
+	StatusApproved   = "APPROVED"
+	StatusProcessing = "PROCESSING"
+	StatusCompleted  = "COMPLETED"
+	StatusRejected   = "REJECTED"
+	StatusFailed     = "FAILED"
+)
+
+// reference ของ hold ที่ withdrawal ถือไว้ใน wallet
+const HoldReferencePrefix = "WITHDRAWAL-"
+
+// เส้นทางที่อนุญาต, REQUESTED ถือเงินไว้ใน AmountLocked จนจบ
+// COMPLETED ตัดเงินจริง REJECTED/FAILED คืน hold
+var transitions = map[string][]string{
+	StatusRequested:  {StatusApproved, StatusRejected},
+	StatusApproved:   {StatusProcessing, StatusRejected},
+	StatusProcessing: {StatusCompleted, StatusFailed},
+}
+
+type Withdrawal struct {
+	ID                uint64          `gorm:"primaryKey" json:"id"`
+	UserID            string          `gorm:"size:100;not null;index;uniqueIndex:idx_withdrawal_reference" json:"user_id"`
+	ReferenceID       string          `gorm:"size:255;not null;uniqueIndex:idx_withdrawal_reference" json:"reference_id"`
+	Currency          string          `gorm:"type:varchar(20);not null" json:"currency"`
+	Amount            decimal.Decimal `gorm:"type:decimal(32,16);not null" json:"amount"`
+	Destination       string          `gorm:"size:255;not null" json:"destination"`
+	Status            string          `gorm:"type:varchar(20);not null;index" json:"status"`
+	Provider          string          `gorm:"size:50" json:"provider,omitempty"`
+	ProviderReference string          `gorm:"size:255" json:"provider_reference,omitempty"`
+	FailureReason     string          `gorm:"type:text" json:"failure_reason,omitempty"`
+	CreatedAt         time.Time       `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt         time.Time       `gorm:"autoUpdateTime" json:"updated_at"`
+	UpdatedBy         string          `gorm:"size:100" json:"updated_by"`
+}
+
+// ทุกการเปลี่ยนสถานะ, เข้าแต่ละสถานะได้ครั้งเดียวต่อ withdrawal
+type WithdrawalTransition struct {
+	ID           uint64    `gorm:"primaryKey" json:"id"`
+	WithdrawalID uint64    `gorm:"not null;uniqueIndex:idx_withdrawal_transition" json:"withdrawal_id"`
+	FromStatus   string    `gorm:"type:varchar(20)" json:"from_status"`
+	ToStatus     string    `gorm:"type:varchar(20);not null;uniqueIndex:idx_withdrawal_transition" json:"to_status"`
+	Actor        string    `gorm:"size:100;not null" json:"actor"`
+	Reason       string    `gorm:"type:text" json:"reason,omitempty"`
+	CreatedAt    time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func IsValidStatus(status string) bool {
+	switch status {
+	case StatusRequested, StatusApproved, StatusProcessing, StatusCompleted, StatusRejected, StatusFailed:
+		return true
+	}
+	return false
+}
+
+func (w *Withdrawal) CanTransitionTo(status string) bool {
+	for _, next := range transitions[w.Status] {
+		if next == status {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *Withdrawal) IsTerminal() bool {
+	return w.Status == StatusCompleted || w.Status == StatusRejected || w.Status == StatusFailed
+}
+
+func (w *Withdrawal) HoldReference() string {
+	return fmt.Sprintf("%s%d", HoldReferencePrefix, w.ID)
+}
@@ -0,0 +1,24 @@
+package provider
+
+import (
+	"github.com/padapook/bestbit-core/internal/withdrawal/model"
+)
+
+// provider ปลอมสำหรับ dev/test จ่ายสำเร็จทันที
+// ผลคำนวณจาก withdrawal ล้วนๆ ไม่เก็บ state ใน memory เรียกซ้ำหลัง restart ได้ผลเดิม
+type localPayoutProvider struct{}
+
+func NewLocalPayoutProvider() PayoutProvider {
+	return &localPayoutProvider{}
+}
+
+func (p *localPayoutProvider) Name() string {
+	return "LOCAL"
+}
+
+func (p *localPayoutProvider) Payout(w *model.Withdrawal) (*PayoutResult, error) {
+	return &PayoutResult{
+		Status:            PayoutCompleted,
+		ProviderReference: "LOCAL-" + w.HoldReference(),
+	}, nil
+}
@@ -0,0 +1,23 @@
+package provider
+
+import "github.com/padapook/bestbit-core/internal/withdrawal/model"
+
+const (
+	PayoutCompleted = "COMPLETED"
+	// provider รับเรื่องแล้วแต่ยังไม่ยืนยัน รอ callback หรือ admin มาปิด
+	PayoutPending = "PENDING"
+	PayoutFailed  = "FAILED"
+)
+
+type PayoutResult struct {
+	Status            string
+	ProviderReference string
+	FailureReason     string
+}
+
+// ช่องทางจ่ายเงินออก, ต้องใช้ w.ReferenceID เป็น idempotency key ฝั่ง provider
+// เพราะ Process อาจถูกเรียกซ้ำกับ withdrawal เดิมหลัง timeout
+type PayoutProvider interface {
+	Name() string
+	Payout(w *model.Withdrawal) (*PayoutResult, error)
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/padapook/bestbit-core/internal/utils"
+	"github.com/padapook/bestbit-core/internal/withdrawal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WithdrawalFilter struct {
+	UserID string
+	Status string
+	// ไม่ว่าง = เฉพาะแถวที่ไม่ได้ขยับมาตั้งแต่ก่อนเวลานี้ ใช้หา PROCESSING ที่ค้าง
+	UpdatedBefore time.Time
+	Limit         int
+}
+
+type WithdrawalRepository interface {
+	Transaction(fn func(tx *gorm.DB) error) error
+	CreateWithdrawal(tx *gorm.DB, w *model.Withdrawal) error
+	UpdateWithdrawal(tx *gorm.DB, w *model.Withdrawal) error
+	CreateTransition(tx *gorm.DB, t *model.WithdrawalTransition) error
+	GetWithdrawalForUpdate(tx *gorm.DB, id uint64) (*model.Withdrawal, error)
+	GetByReference(userID, referenceID string) (*model.Withdrawal, error)
+	GetWithdrawal(id uint64) (*model.Withdrawal, error)
+	GetWithdrawals(filter WithdrawalFilter) ([]model.Withdrawal, error)
+	GetTransitions(withdrawalID uint64) ([]model.WithdrawalTransition, error)
+}
+
+type withdrawalRepository struct {
+	db *gorm.DB
+}
+
+func NewWithdrawalRepository(db *gorm.DB) WithdrawalRepository {
+	return &withdrawalRepository{db: db}
+}
+
+func (r *withdrawalRepository) Transaction(fn func(tx *gorm.DB) error) error {
+	return r.db.Transaction(fn)
+}
+
+// reference ซ้ำตอบ gorm.ErrDuplicatedKey ให้ service ไปหาตัวที่สร้างไปก่อน
+func (r *withdrawalRepository) CreateWithdrawal(tx *gorm.DB, w *model.Withdrawal) error {
+	err := tx.Create(w).Error
+	if utils.IsDuplicateKey(tx, err) {
+		return gorm.ErrDuplicatedKey
+	}
+	return err
+}
+
+func (r *withdrawalRepository) UpdateWithdrawal(tx *gorm.DB, w *model.Withdrawal) error {
+	return tx.Save(w).Error
+}
+
+func (r *withdrawalRepository) CreateTransition(tx *gorm.DB, t *model.WithdrawalTransition) error {
+	return tx.Create(t).Error
+}
+
+func (r *withdrawalRepository) GetWithdrawalForUpdate(tx *gorm.DB, id uint64) (*model.Withdrawal, error) {
+	var w model.Withdrawal
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&w, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.ErrWithdrawalNotFound
+		}
+		return nil, err
+	}
+	return &w, nil
+}
+
+func (r *withdrawalRepository) GetByReference(userID, referenceID string) (*model.Withdrawal, error) {
+	var w model.Withdrawal
+	if err := r.db.Where("user_id = ? AND reference_id = ?", userID, referenceID).First(&w).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.ErrWithdrawalNotFound
+		}
+		return nil, err
+	}
+	return &w, nil
+}
+
+func (r *withdrawalRepository) GetWithdrawal(id uint64) (*model.Withdrawal, error) {
+	var w model.Withdrawal
+	if err := r.db.First(&w, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.ErrWithdrawalNotFound
+		}
+		return nil, err
+	}
+	return &w, nil
+}
+
+func (r *withdrawalRepository) GetWithdrawals(filter WithdrawalFilter) ([]model.Withdrawal, error) {
+	var withdrawals []model.Withdrawal
+
+	query := r.db.Model(&model.Withdrawal{})
+	if filter.UserID != "" {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if !filter.UpdatedBefore.IsZero() {
+		query = query.Where("updated_at < ?", filter.UpdatedBefore)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	err := query.Order("created_at DESC, id DESC").Find(&withdrawals).Error
+	return withdrawals, err
+}
+
+func (r *withdrawalRepository) GetTransitions(withdrawalID uint64) ([]model.WithdrawalTransition, error) {
+	var transitions []model.WithdrawalTransition
+	err := r.db.Where("withdrawal_id = ?", withdrawalID).Order("id ASC").Find(&transitions).Error
+	return transitions, err
+}
@@ -0,0 +1,375 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	currencyModel "github.com/padapook/bestbit-core/internal/currency/model"
+	currencyService "github.com/padapook/bestbit-core/internal/currency/service"
+	limitModel "github.com/padapook/bestbit-core/internal/limit/model"
+	limitService "github.com/padapook/bestbit-core/internal/limit/service"
+	"github.com/padapook/bestbit-core/internal/utils"
+	walletRepository "github.com/padapook/bestbit-core/internal/wallet/repository"
+	"github.com/padapook/bestbit-core/internal/withdrawal/model"
+	"github.com/padapook/bestbit-core/internal/withdrawal/provider"
+	"github.com/padapook/bestbit-core/internal/withdrawal/repository"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultWithdrawalListLimit = 50
+	maxWithdrawalListLimit     = 200
+
+	// PROCESSING ที่ไม่ขยับนานเท่านี้ถือว่าค้าง (process ตายกลางทางหรือ provider ยังไม่ยืนยัน) ส่งถาม provider ใหม่
+	stuckProcessingAfter = 5 * time.Minute
+	retryProcessingBatch = 50
+	retryProcessingActor = "SYSTEM"
+)
+
+type WithdrawalDetail struct {
+	model.Withdrawal
+	Transitions []model.WithdrawalTransition `json:"transitions"`
+}
+
+type WithdrawalService interface {
+	RequestWithdrawal(userID, currency string, amount decimal.Decimal, destination, referenceID string) (*model.Withdrawal, error)
+	GetWithdrawal(userID string, id uint64) (*model.Withdrawal, error)
+	GetUserWithdrawals(userID, status string, limit int) ([]model.Withdrawal, error)
+
+	// admin
+	GetWithdrawals(status string, limit int) ([]model.Withdrawal, error)
+	GetWithdrawalDetail(id uint64) (*WithdrawalDetail, error)
+	Approve(id uint64, actor string) (*model.Withdrawal, error)
+	Reject(id uint64, actor, reason string) (*model.Withdrawal, error)
+	// ส่งให้ provider จ่าย, ถ้า provider ยังไม่ยืนยันจะค้างที่ PROCESSING
+	Process(id uint64, actor string) (*model.Withdrawal, error)
+	Complete(id uint64, actor, providerReference string) (*model.Withdrawal, error)
+	Fail(id uint64, actor, reason string) (*model.Withdrawal, error)
+	// ส่ง PROCESSING ที่ค้างให้ provider ซ้ำด้วย reference เดิม คืนจำนวนที่ปิดได้
+	RetryProcessing() (int, error)
+	// เรียก RetryProcessing ทุก interval, เรียก stop เพื่อหยุด
+	Start(interval time.Duration) (stop func())
+}
+
+type withdrawalService struct {
+	repo       repository.WithdrawalRepository
+	walletRepo walletRepository.WalletRepository
+	limits     limitService.LimitService
+	currencies currencyService.CurrencyService
+	provider   provider.PayoutProvider
+	now        func() time.Time
+}
+
+func NewWithdrawalService(
+	repo repository.WithdrawalRepository,
+	walletRepo walletRepository.WalletRepository,
+	limits limitService.LimitService,
+	currencies currencyService.CurrencyService,
+	payoutProvider provider.PayoutProvider,
+) WithdrawalService {
+	return &withdrawalService{repo: repo, walletRepo: walletRepo, limits: limits, currencies: currencies, provider: payoutProvider, now: time.Now}
+}
+
+func (s *withdrawalService) RequestWithdrawal(userID, currency string, amount decimal.Decimal, destination, referenceID string) (*model.Withdrawal, error) {
+	destination = strings.TrimSpace(destination)
+
+	if destination == "" || amount.LessThanOrEqual(decimal.Zero) {
+		return nil, utils.ErrInvalidWithdrawal
+	}
+
+	cur, err := s.currencies.ValidateAmount(currency, currencyModel.OperationWithdraw, amount)
+	if err != nil {
+		return nil, err
+	}
+	currency = cur.Code
+
+	// reference เดิมคือคำขอเดิม ไม่ hold ซ้ำ
+	if existing, err := s.repo.GetByReference(userID, referenceID); err == nil {
+		return existing, nil
+	} else if !errors.Is(err, utils.ErrWithdrawalNotFound) {
+		return nil, err
+	}
+
+	withdrawal := model.Withdrawal{
+		UserID:      userID,
+		ReferenceID: referenceID,
+		Currency:    currency,
+		Amount:      amount,
+		Destination: destination,
+		Status:      model.StatusRequested,
+		UpdatedBy:   userID,
+	}
+
+	err = s.repo.Transaction(func(tx *gorm.DB) error {
+		if err := s.repo.CreateWithdrawal(tx, &withdrawal); err != nil {
+			return err
+		}
+
+		// นับวงเงินตอนขอถอน hold ของคำขอที่ยังไม่ถูก reject/fail ถือว่าใช้วงเงินไปแล้ว
+		if _, err := s.walletRepo.GetWalletForUpdate(tx, userID, currency); err != nil {
+			return err
+		}
+		if err := s.limits.Check(tx, userID, currency, limitModel.OperationWithdraw, amount); err != nil {
+			return err
+		}
+
+		if _, err := s.walletRepo.HoldFunds(tx, userID, currency, amount, withdrawal.HoldReference()); err != nil {
+			return err
+		}
+
+		return s.repo.CreateTransition(tx, &model.WithdrawalTransition{
+			WithdrawalID: withdrawal.ID,
+			ToStatus:     model.StatusRequested,
+			Actor:        userID,
+		})
+	})
+	if err != nil {
+		// request เดียวกันยิงพร้อมกันแล้วอีกตัวสร้างไปก่อน
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return s.repo.GetByReference(userID, referenceID)
+		}
+		return nil, err
+	}
+
+	return &withdrawal, nil
+}
+
+func (s *withdrawalService) GetWithdrawal(userID string, id uint64) (*model.Withdrawal, error) {
+	withdrawal, err := s.repo.GetWithdrawal(id)
+	if err != nil {
+		return nil, err
+	}
+	if withdrawal.UserID != userID {
+		return nil, utils.ErrWithdrawalNotFound
+	}
+	return withdrawal, nil
+}
+
+func (s *withdrawalService) GetUserWithdrawals(userID, status string, limit int) ([]model.Withdrawal, error) {
+	return s.list(repository.WithdrawalFilter{UserID: userID, Status: status, Limit: limit})
+}
+
+func (s *withdrawalService) GetWithdrawals(status string, limit int) ([]model.Withdrawal, error) {
+	return s.list(repository.WithdrawalFilter{Status: status, Limit: limit})
+}
+
+func (s *withdrawalService) list(filter repository.WithdrawalFilter) ([]model.Withdrawal, error) {
+	if filter.Status != "" && !model.IsValidStatus(filter.Status) {
+		return nil, utils.ErrInvalidRequest
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultWithdrawalListLimit
+	}
+	if filter.Limit > maxWithdrawalListLimit {
+		filter.Limit = maxWithdrawalListLimit
+	}
+	return s.repo.GetWithdrawals(filter)
+}
+
+func (s *withdrawalService) GetWithdrawalDetail(id uint64) (*WithdrawalDetail, error) {
+	withdrawal, err := s.repo.GetWithdrawal(id)
+	if err != nil {
+		return nil, err
+	}
+
+	transitions, err := s.repo.GetTransitions(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WithdrawalDetail{Withdrawal: *withdrawal, Transitions: transitions}, nil
+}
+
+func (s *withdrawalService) Approve(id uint64, actor string) (*model.Withdrawal, error) {
+	return s.transition(id, model.StatusApproved, actor, "", nil)
+}
+
+func (s *withdrawalService) Reject(id uint64, actor, reason string) (*model.Withdrawal, error) {
+	return s.transition(id, model.StatusRejected, actor, reason, func(tx *gorm.DB, w *model.Withdrawal) error {
+		w.FailureReason = reason
+		_, err := s.walletRepo.ReleaseFunds(tx, w.UserID, w.Currency, w.Amount, w.HoldReference())
+		return err
+	})
+}
+
+func (s *withdrawalService) Process(id uint64, actor string) (*model.Withdrawal, error) {
+	withdrawal, err := s.repo.GetWithdrawal(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// PROCESSING อยู่แล้วคือ retry หลัง provider timeout ส่งซ้ำได้เพราะ provider ใช้ reference เดิม
+	if withdrawal.Status != model.StatusProcessing {
+		withdrawal, err = s.transition(id, model.StatusProcessing, actor, "", func(tx *gorm.DB, w *model.Withdrawal) error {
+			w.Provider = s.provider.Name()
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// เรียก provider นอก transaction ไม่ถือ lock ระหว่างรอ network
+	result, err := s.provider.Payout(withdrawal)
+	if err != nil {
+		log.Println("[withdrawal] payout error:", withdrawal.ID, err)
+		return nil, utils.ErrPayoutFailed
+	}
+
+	switch result.Status {
+	case provider.PayoutCompleted:
+		return s.Complete(id, actor, result.ProviderReference)
+	case provider.PayoutFailed:
+		return s.Fail(id, actor, result.FailureReason)
+	default:
+		return s.recordProviderReference(id, result.ProviderReference)
+	}
+}
+
+// provider รับเรื่องแต่ยังไม่ยืนยัน เก็บ reference ไว้ที่แถว ไม่ให้หายไปกับ process
+func (s *withdrawalService) recordProviderReference(id uint64, providerReference string) (*model.Withdrawal, error) {
+	var withdrawal *model.Withdrawal
+
+	err := s.repo.Transaction(func(tx *gorm.DB) error {
+		w, err := s.repo.GetWithdrawalForUpdate(tx, id)
+		if err != nil {
+			return err
+		}
+		withdrawal = w
+
+		if w.Status != model.StatusProcessing || providerReference == "" || w.ProviderReference == providerReference {
+			return nil
+		}
+		w.ProviderReference = providerReference
+		return s.repo.UpdateWithdrawal(tx, w)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return withdrawal, nil
+}
+
+func (s *withdrawalService) RetryProcessing() (int, error) {
+	stuck, err := s.repo.GetWithdrawals(repository.WithdrawalFilter{
+		Status:        model.StatusProcessing,
+		UpdatedBefore: s.now().Add(-stuckProcessingAfter),
+		Limit:         retryProcessingBatch,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	resolved := 0
+	for _, w := range stuck {
+		result, err := s.Process(w.ID, retryProcessingActor)
+		if err != nil {
+			log.Println("[withdrawal] retry processing", w.ID, "failed:", err)
+			continue
+		}
+		if result.Status != model.StatusProcessing {
+			resolved++
+		}
+	}
+	return resolved, nil
+}
+
+func (s *withdrawalService) Start(interval time.Duration) func() {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	var once sync.Once
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				resolved, err := s.RetryProcessing()
+				if err != nil {
+					log.Println("[withdrawal] retry processing failed:", err)
+					continue
+				}
+				if resolved > 0 {
+					log.Println("[withdrawal] resolved", resolved, "stuck withdrawals")
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		once.Do(func() {
+			ticker.Stop()
+			close(done)
+		})
+	}
+}
+
+func (s *withdrawalService) Complete(id uint64, actor, providerReference string) (*model.Withdrawal, error) {
+	return s.transition(id, model.StatusCompleted, actor, "", func(tx *gorm.DB, w *model.Withdrawal) error {
+		if providerReference != "" {
+			w.ProviderReference = providerReference
+		}
+		_, err := s.walletRepo.CompleteWithdrawal(tx, w.UserID, w.Currency, w.Amount, w.HoldReference())
+		return err
+	})
+}
+
+func (s *withdrawalService) Fail(id uint64, actor, reason string) (*model.Withdrawal, error) {
+	return s.transition(id, model.StatusFailed, actor, reason, func(tx *gorm.DB, w *model.Withdrawal) error {
+		w.FailureReason = reason
+		_, err := s.walletRepo.ReleaseFunds(tx, w.UserID, w.Currency, w.Amount, w.HoldReference())
+		return err
+	})
+}
+
+// lock แถว, เช็คเส้นทาง, ทำ side effect กับ wallet แล้วบันทึก transition ใน tx เดียวกัน
+// สั่งไปสถานะที่เป็นอยู่แล้วถือว่าสำเร็จ (เรียกซ้ำได้) โดยไม่ทำ side effect ซ้ำ
+func (s *withdrawalService) transition(id uint64, to, actor, reason string, apply func(tx *gorm.DB, w *model.Withdrawal) error) (*model.Withdrawal, error) {
+	var withdrawal *model.Withdrawal
+
+	err := s.repo.Transaction(func(tx *gorm.DB) error {
+		w, err := s.repo.GetWithdrawalForUpdate(tx, id)
+		if err != nil {
+			return err
+		}
+		withdrawal = w
+
+		if w.Status == to {
+			return nil
+		}
+		if !w.CanTransitionTo(to) {
+			return utils.ErrInvalidWithdrawalTransition
+		}
+
+		from := w.Status
+		if apply != nil {
+			if err := apply(tx, w); err != nil {
+				return err
+			}
+		}
+
+		w.Status = to
+		w.UpdatedBy = actor
+		if err := s.repo.UpdateWithdrawal(tx, w); err != nil {
+			return err
+		}
+
+		return s.repo.CreateTransition(tx, &model.WithdrawalTransition{
+			WithdrawalID: w.ID,
+			FromStatus:   from,
+			ToStatus:     to,
+			Actor:        actor,
+			Reason:       reason,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return withdrawal, nil
+}
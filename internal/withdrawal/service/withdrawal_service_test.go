@@ -0,0 +1,465 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	currencyModel "github.com/padapook/bestbit-core/internal/currency/model"
+	currencyRepository "github.com/padapook/bestbit-core/internal/currency/repository"
+	currencyService "github.com/padapook/bestbit-core/internal/currency/service"
+	limitModel "github.com/padapook/bestbit-core/internal/limit/model"
+	limitService "github.com/padapook/bestbit-core/internal/limit/service"
+	"github.com/padapook/bestbit-core/internal/utils"
+	walletModel "github.com/padapook/bestbit-core/internal/wallet/model"
+	walletRepository "github.com/padapook/bestbit-core/internal/wallet/repository"
+	"github.com/padapook/bestbit-core/internal/withdrawal/model"
+	"github.com/padapook/bestbit-core/internal/withdrawal/provider"
+	"github.com/padapook/bestbit-core/internal/withdrawal/repository"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+type MockWithdrawalRepository struct {
+	mock.Mock
+}
+
+// ไม่มี DB จริง เรียก fn ตรงๆ ด้วย tx = nil
+func (m *MockWithdrawalRepository) Transaction(fn func(tx *gorm.DB) error) error {
+	return fn(nil)
+}
+
+func (m *MockWithdrawalRepository) CreateWithdrawal(tx *gorm.DB, w *model.Withdrawal) error {
+	args := m.Called(tx, w)
+	return args.Error(0)
+}
+
+func (m *MockWithdrawalRepository) UpdateWithdrawal(tx *gorm.DB, w *model.Withdrawal) error {
+	args := m.Called(tx, w)
+	return args.Error(0)
+}
+
+func (m *MockWithdrawalRepository) CreateTransition(tx *gorm.DB, t *model.WithdrawalTransition) error {
+	args := m.Called(tx, t)
+	return args.Error(0)
+}
+
+func (m *MockWithdrawalRepository) GetWithdrawalForUpdate(tx *gorm.DB, id uint64) (*model.Withdrawal, error) {
+	args := m.Called(tx, id)
+	if args.Get(0) != nil {
+		return args.Get(0).(*model.Withdrawal), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockWithdrawalRepository) GetByReference(userID, referenceID string) (*model.Withdrawal, error) {
+	args := m.Called(userID, referenceID)
+	if args.Get(0) != nil {
+		return args.Get(0).(*model.Withdrawal), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockWithdrawalRepository) GetWithdrawal(id uint64) (*model.Withdrawal, error) {
+	args := m.Called(id)
+	if args.Get(0) != nil {
+		return args.Get(0).(*model.Withdrawal), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockWithdrawalRepository) GetWithdrawals(filter repository.WithdrawalFilter) ([]model.Withdrawal, error) {
+	args := m.Called(filter)
+	if args.Get(0) != nil {
+		return args.Get(0).([]model.Withdrawal), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockWithdrawalRepository) GetTransitions(withdrawalID uint64) ([]model.WithdrawalTransition, error) {
+	args := m.Called(withdrawalID)
+	if args.Get(0) != nil {
+		return args.Get(0).([]model.WithdrawalTransition), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+// withdrawal ใช้แค่ lock/hold/release/complete ที่เหลือไม่ควรถูกเรียก
+type MockWalletRepository struct {
+	walletRepository.WalletRepository
+	mock.Mock
+}
+
+func (m *MockWalletRepository) GetWalletForUpdate(tx *gorm.DB, userID, currency string) (*walletModel.Wallet, error) {
+	args := m.Called(tx, userID, currency)
+	if args.Get(0) != nil {
+		return args.Get(0).(*walletModel.Wallet), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockWalletRepository) HoldFunds(tx *gorm.DB, userID, currency string, amount decimal.Decimal, referenceID string) (*walletModel.Wallet, error) {
+	args := m.Called(tx, userID, currency, amount, referenceID)
+	if args.Get(0) != nil {
+		return args.Get(0).(*walletModel.Wallet), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockWalletRepository) ReleaseFunds(tx *gorm.DB, userID, currency string, amount decimal.Decimal, referenceID string) (*walletModel.Wallet, error) {
+	args := m.Called(tx, userID, currency, amount, referenceID)
+	if args.Get(0) != nil {
+		return args.Get(0).(*walletModel.Wallet), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockWalletRepository) CompleteWithdrawal(tx *gorm.DB, userID, currency string, amount decimal.Decimal, referenceID string) (*walletModel.Wallet, error) {
+	args := m.Called(tx, userID, currency, amount, referenceID)
+	if args.Get(0) != nil {
+		return args.Get(0).(*walletModel.Wallet), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+type MockLimitService struct {
+	limitService.LimitService
+	mock.Mock
+}
+
+func (m *MockLimitService) Check(tx *gorm.DB, userID, currency, operation string, amount decimal.Decimal) error {
+	args := m.Called(tx, userID, currency, operation, amount)
+	return args.Error(0)
+}
+
+type StubCurrencyRepository struct {
+	currencyRepository.CurrencyRepository
+}
+
+func (r *StubCurrencyRepository) GetByCode(code string) (*currencyModel.Currency, error) {
+	for _, currency := range currencyModel.DefaultCurrencies {
+		if currency.Code == code {
+			return &currency, nil
+		}
+	}
+	return nil, utils.ErrCurrencyNotFound
+}
+
+func testCurrencies() currencyService.CurrencyService {
+	return currencyService.NewCurrencyService(new(StubCurrencyRepository))
+}
+
+type MockPayoutProvider struct {
+	mock.Mock
+}
+
+func (m *MockPayoutProvider) Name() string {
+	return "MOCK"
+}
+
+func (m *MockPayoutProvider) Payout(w *model.Withdrawal) (*provider.PayoutResult, error) {
+	args := m.Called(w)
+	if args.Get(0) != nil {
+		return args.Get(0).(*provider.PayoutResult), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func newTestWithdrawal(status string) *model.Withdrawal {
+	return &model.Withdrawal{
+		ID:          7,
+		UserID:      "user-1",
+		ReferenceID: "ref-1",
+		Currency:    "THB",
+		Amount:      decimal.NewFromInt(500),
+		Destination: "bank:123",
+		Status:      status,
+	}
+}
+
+func isTransition(from, to string) interface{} {
+	return mock.MatchedBy(func(t *model.WithdrawalTransition) bool {
+		return t.FromStatus == from && t.ToStatus == to
+	})
+}
+
+func TestRequestWithdrawal_Success_HoldsFunds(t *testing.T) {
+	mockRepo := new(MockWithdrawalRepository)
+	mockWallet := new(MockWalletRepository)
+	mockLimits := new(MockLimitService)
+	service := NewWithdrawalService(mockRepo, mockWallet, mockLimits, testCurrencies(), new(MockPayoutProvider))
+
+	amount := decimal.NewFromInt(500)
+
+	mockRepo.On("GetByReference", "user-1", "ref-1").Return(nil, utils.ErrWithdrawalNotFound)
+	mockRepo.On("CreateWithdrawal", mock.Anything, mock.AnythingOfType("*model.Withdrawal")).Run(func(args mock.Arguments) {
+		args.Get(1).(*model.Withdrawal).ID = 7
+	}).Return(nil)
+	mockWallet.On("GetWalletForUpdate", mock.Anything, "user-1", "THB").Return(&walletModel.Wallet{}, nil)
+	mockLimits.On("Check", mock.Anything, "user-1", "THB", limitModel.OperationWithdraw, amount).Return(nil)
+	mockWallet.On("HoldFunds", mock.Anything, "user-1", "THB", amount, "WITHDRAWAL-7").Return(&walletModel.Wallet{}, nil)
+	mockRepo.On("CreateTransition", mock.Anything, isTransition("", model.StatusRequested)).Return(nil)
+
+	withdrawal, err := service.RequestWithdrawal("user-1", "thb", amount, "bank:123", "ref-1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, model.StatusRequested, withdrawal.Status)
+	assert.Equal(t, "THB", withdrawal.Currency)
+	mockRepo.AssertExpectations(t)
+	mockWallet.AssertExpectations(t)
+	mockLimits.AssertExpectations(t)
+}
+
+func TestRequestWithdrawal_Fail_LimitExceeded(t *testing.T) {
+	mockRepo := new(MockWithdrawalRepository)
+	mockWallet := new(MockWalletRepository)
+	mockLimits := new(MockLimitService)
+	service := NewWithdrawalService(mockRepo, mockWallet, mockLimits, testCurrencies(), new(MockPayoutProvider))
+
+	amount := decimal.NewFromInt(500)
+
+	mockRepo.On("GetByReference", "user-1", "ref-1").Return(nil, utils.ErrWithdrawalNotFound)
+	mockRepo.On("CreateWithdrawal", mock.Anything, mock.Anything).Return(nil)
+	mockWallet.On("GetWalletForUpdate", mock.Anything, "user-1", "THB").Return(&walletModel.Wallet{}, nil)
+	mockLimits.On("Check", mock.Anything, "user-1", "THB", limitModel.OperationWithdraw, amount).Return(utils.ErrMonthlyLimitExceeded)
+
+	withdrawal, err := service.RequestWithdrawal("user-1", "THB", amount, "bank:123", "ref-1")
+
+	assert.Nil(t, withdrawal)
+	assert.Equal(t, utils.ErrMonthlyLimitExceeded, err)
+	mockWallet.AssertNotCalled(t, "HoldFunds")
+}
+
+func TestRequestWithdrawal_Fail_InsufficientBalance(t *testing.T) {
+	mockRepo := new(MockWithdrawalRepository)
+	mockWallet := new(MockWalletRepository)
+	mockLimits := new(MockLimitService)
+	service := NewWithdrawalService(mockRepo, mockWallet, mockLimits, testCurrencies(), new(MockPayoutProvider))
+
+	amount := decimal.NewFromInt(2000)
+
+	mockRepo.On("GetByReference", "user-1", "ref-1").Return(nil, utils.ErrWithdrawalNotFound)
+	mockRepo.On("CreateWithdrawal", mock.Anything, mock.Anything).Return(nil)
+	mockWallet.On("GetWalletForUpdate", mock.Anything, "user-1", "THB").Return(&walletModel.Wallet{}, nil)
+	mockLimits.On("Check", mock.Anything, "user-1", "THB", limitModel.OperationWithdraw, amount).Return(nil)
+	// จำลองว่ายอดเงินไม่พอ
+	mockWallet.On("HoldFunds", mock.Anything, "user-1", "THB", amount, mock.Anything).Return(nil, utils.ErrInsufficientBalance)
+
+	withdrawal, err := service.RequestWithdrawal("user-1", "THB", amount, "bank:123", "ref-1")
+
+	assert.Nil(t, withdrawal)
+	assert.Equal(t, utils.ErrInsufficientBalance, err)
+	mockRepo.AssertNotCalled(t, "CreateTransition")
+}
+
+func TestRequestWithdrawal_Idempotent_SameReference(t *testing.T) {
+	mockRepo := new(MockWithdrawalRepository)
+	mockWallet := new(MockWalletRepository)
+	service := NewWithdrawalService(mockRepo, mockWallet, new(MockLimitService), testCurrencies(), new(MockPayoutProvider))
+
+	existing := newTestWithdrawal(model.StatusApproved)
+	mockRepo.On("GetByReference", "user-1", "ref-1").Return(existing, nil)
+
+	withdrawal, err := service.RequestWithdrawal("user-1", "THB", decimal.NewFromInt(500), "bank:123", "ref-1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, existing, withdrawal)
+	mockRepo.AssertNotCalled(t, "CreateWithdrawal")
+	mockWallet.AssertNotCalled(t, "HoldFunds")
+}
+
+func TestRequestWithdrawal_Fail_InvalidInput(t *testing.T) {
+	service := NewWithdrawalService(new(MockWithdrawalRepository), new(MockWalletRepository), new(MockLimitService), testCurrencies(), new(MockPayoutProvider))
+
+	_, err := service.RequestWithdrawal("user-1", "THB", decimal.Zero, "bank:123", "ref-1")
+	assert.Equal(t, utils.ErrInvalidWithdrawal, err)
+
+	_, err = service.RequestWithdrawal("user-1", "THB", decimal.NewFromInt(1), " ", "ref-1")
+	assert.Equal(t, utils.ErrInvalidWithdrawal, err)
+}
+
+func TestApprove_RecordsTransition(t *testing.T) {
+	mockRepo := new(MockWithdrawalRepository)
+	service := NewWithdrawalService(mockRepo, new(MockWalletRepository), new(MockLimitService), testCurrencies(), new(MockPayoutProvider))
+
+	mockRepo.On("GetWithdrawalForUpdate", mock.Anything, uint64(7)).Return(newTestWithdrawal(model.StatusRequested), nil)
+	mockRepo.On("UpdateWithdrawal", mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("CreateTransition", mock.Anything, isTransition(model.StatusRequested, model.StatusApproved)).Return(nil)
+
+	withdrawal, err := service.Approve(7, "admin-1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, model.StatusApproved, withdrawal.Status)
+	assert.Equal(t, "admin-1", withdrawal.UpdatedBy)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestApprove_Idempotent_AlreadyApproved(t *testing.T) {
+	mockRepo := new(MockWithdrawalRepository)
+	service := NewWithdrawalService(mockRepo, new(MockWalletRepository), new(MockLimitService), testCurrencies(), new(MockPayoutProvider))
+
+	mockRepo.On("GetWithdrawalForUpdate", mock.Anything, uint64(7)).Return(newTestWithdrawal(model.StatusApproved), nil)
+
+	withdrawal, err := service.Approve(7, "admin-1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, model.StatusApproved, withdrawal.Status)
+	mockRepo.AssertNotCalled(t, "UpdateWithdrawal")
+	mockRepo.AssertNotCalled(t, "CreateTransition")
+}
+
+func TestReject_ReleasesHold(t *testing.T) {
+	mockRepo := new(MockWithdrawalRepository)
+	mockWallet := new(MockWalletRepository)
+	service := NewWithdrawalService(mockRepo, mockWallet, new(MockLimitService), testCurrencies(), new(MockPayoutProvider))
+
+	mockRepo.On("GetWithdrawalForUpdate", mock.Anything, uint64(7)).Return(newTestWithdrawal(model.StatusApproved), nil)
+	mockWallet.On("ReleaseFunds", mock.Anything, "user-1", "THB", decimal.NewFromInt(500), "WITHDRAWAL-7").Return(&walletModel.Wallet{}, nil)
+	mockRepo.On("UpdateWithdrawal", mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("CreateTransition", mock.Anything, isTransition(model.StatusApproved, model.StatusRejected)).Return(nil)
+
+	withdrawal, err := service.Reject(7, "admin-1", "suspicious destination")
+
+	assert.NoError(t, err)
+	assert.Equal(t, model.StatusRejected, withdrawal.Status)
+	assert.Equal(t, "suspicious destination", withdrawal.FailureReason)
+	mockRepo.AssertExpectations(t)
+	mockWallet.AssertExpectations(t)
+}
+
+func TestComplete_Fail_InvalidTransition(t *testing.T) {
+	mockRepo := new(MockWithdrawalRepository)
+	mockWallet := new(MockWalletRepository)
+	service := NewWithdrawalService(mockRepo, mockWallet, new(MockLimitService), testCurrencies(), new(MockPayoutProvider))
+
+	// ยังไม่ได้ approve ข้ามไป COMPLETED ไม่ได้
+	mockRepo.On("GetWithdrawalForUpdate", mock.Anything, uint64(7)).Return(newTestWithdrawal(model.StatusRequested), nil)
+
+	withdrawal, err := service.Complete(7, "admin-1", "")
+
+	assert.Nil(t, withdrawal)
+	assert.Equal(t, utils.ErrInvalidWithdrawalTransition, err)
+	mockWallet.AssertNotCalled(t, "CompleteWithdrawal")
+}
+
+func TestProcess_ProviderCompleted_ConsumesHold(t *testing.T) {
+	mockRepo := new(MockWithdrawalRepository)
+	mockWallet := new(MockWalletRepository)
+	mockProvider := new(MockPayoutProvider)
+	service := NewWithdrawalService(mockRepo, mockWallet, new(MockLimitService), testCurrencies(), mockProvider)
+
+	withdrawal := newTestWithdrawal(model.StatusApproved)
+	mockRepo.On("GetWithdrawal", uint64(7)).Return(withdrawal, nil)
+	mockRepo.On("GetWithdrawalForUpdate", mock.Anything, uint64(7)).Return(withdrawal, nil)
+	mockRepo.On("UpdateWithdrawal", mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("CreateTransition", mock.Anything, isTransition(model.StatusApproved, model.StatusProcessing)).Return(nil)
+	mockRepo.On("CreateTransition", mock.Anything, isTransition(model.StatusProcessing, model.StatusCompleted)).Return(nil)
+	mockProvider.On("Payout", withdrawal).Return(&provider.PayoutResult{Status: provider.PayoutCompleted, ProviderReference: "TX-1"}, nil)
+	mockWallet.On("CompleteWithdrawal", mock.Anything, "user-1", "THB", decimal.NewFromInt(500), "WITHDRAWAL-7").Return(&walletModel.Wallet{}, nil)
+
+	result, err := service.Process(7, "admin-1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, model.StatusCompleted, result.Status)
+	assert.Equal(t, "MOCK", result.Provider)
+	assert.Equal(t, "TX-1", result.ProviderReference)
+	mockRepo.AssertExpectations(t)
+	mockWallet.AssertExpectations(t)
+}
+
+func TestProcess_ProviderFailed_ReleasesHold(t *testing.T) {
+	mockRepo := new(MockWithdrawalRepository)
+	mockWallet := new(MockWalletRepository)
+	mockProvider := new(MockPayoutProvider)
+	service := NewWithdrawalService(mockRepo, mockWallet, new(MockLimitService), testCurrencies(), mockProvider)
+
+	withdrawal := newTestWithdrawal(model.StatusProcessing)
+	mockRepo.On("GetWithdrawal", uint64(7)).Return(withdrawal, nil)
+	mockRepo.On("GetWithdrawalForUpdate", mock.Anything, uint64(7)).Return(withdrawal, nil)
+	mockRepo.On("UpdateWithdrawal", mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("CreateTransition", mock.Anything, isTransition(model.StatusProcessing, model.StatusFailed)).Return(nil)
+	mockProvider.On("Payout", withdrawal).Return(&provider.PayoutResult{Status: provider.PayoutFailed, FailureReason: "account closed"}, nil)
+	mockWallet.On("ReleaseFunds", mock.Anything, "user-1", "THB", decimal.NewFromInt(500), "WITHDRAWAL-7").Return(&walletModel.Wallet{}, nil)
+
+	result, err := service.Process(7, "admin-1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, model.StatusFailed, result.Status)
+	assert.Equal(t, "account closed", result.FailureReason)
+	mockWallet.AssertNotCalled(t, "CompleteWithdrawal")
+	mockWallet.AssertExpectations(t)
+}
+
+func TestProcess_ProviderError_StaysProcessing(t *testing.T) {
+	mockRepo := new(MockWithdrawalRepository)
+	mockWallet := new(MockWalletRepository)
+	mockProvider := new(MockPayoutProvider)
+	service := NewWithdrawalService(mockRepo, mockWallet, new(MockLimitService), testCurrencies(), mockProvider)
+
+	withdrawal := newTestWithdrawal(model.StatusApproved)
+	mockRepo.On("GetWithdrawal", uint64(7)).Return(withdrawal, nil)
+	mockRepo.On("GetWithdrawalForUpdate", mock.Anything, uint64(7)).Return(withdrawal, nil)
+	mockRepo.On("UpdateWithdrawal", mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("CreateTransition", mock.Anything, isTransition(model.StatusApproved, model.StatusProcessing)).Return(nil)
+	mockProvider.On("Payout", withdrawal).Return(nil, errors.New("timeout"))
+
+	result, err := service.Process(7, "admin-1")
+
+	assert.Nil(t, result)
+	assert.Equal(t, utils.ErrPayoutFailed, err)
+	assert.Equal(t, model.StatusProcessing, withdrawal.Status)
+	mockWallet.AssertNotCalled(t, "ReleaseFunds")
+	mockWallet.AssertNotCalled(t, "CompleteWithdrawal")
+}
+
+func TestProcess_ProviderPending_PersistsReference(t *testing.T) {
+	mockRepo := new(MockWithdrawalRepository)
+	mockProvider := new(MockPayoutProvider)
+	service := NewWithdrawalService(mockRepo, new(MockWalletRepository), new(MockLimitService), testCurrencies(), mockProvider)
+
+	withdrawal := newTestWithdrawal(model.StatusProcessing)
+	mockRepo.On("GetWithdrawal", uint64(7)).Return(withdrawal, nil)
+	mockRepo.On("GetWithdrawalForUpdate", mock.Anything, uint64(7)).Return(withdrawal, nil)
+	mockRepo.On("UpdateWithdrawal", mock.Anything, withdrawal).Return(nil)
+	mockProvider.On("Payout", withdrawal).Return(&provider.PayoutResult{Status: provider.PayoutPending, ProviderReference: "TX-9"}, nil)
+
+	result, err := service.Process(7, "admin-1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, model.StatusProcessing, result.Status)
+	assert.Equal(t, "TX-9", result.ProviderReference)
+	mockRepo.AssertExpectations(t)
+}
+
+// หลัง restart แถวที่ค้าง PROCESSING ต้องถูกส่งให้ provider ซ้ำจนปิดได้
+func TestRetryProcessing_ResolvesStuckWithdrawals(t *testing.T) {
+	mockRepo := new(MockWithdrawalRepository)
+	mockWallet := new(MockWalletRepository)
+	mockProvider := new(MockPayoutProvider)
+	service := NewWithdrawalService(mockRepo, mockWallet, new(MockLimitService), testCurrencies(), mockProvider)
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	service.(*withdrawalService).now = func() time.Time { return now }
+
+	withdrawal := newTestWithdrawal(model.StatusProcessing)
+	mockRepo.On("GetWithdrawals", repository.WithdrawalFilter{
+		Status:        model.StatusProcessing,
+		UpdatedBefore: now.Add(-stuckProcessingAfter),
+		Limit:         retryProcessingBatch,
+	}).Return([]model.Withdrawal{*withdrawal}, nil)
+	mockRepo.On("GetWithdrawal", uint64(7)).Return(withdrawal, nil)
+	mockRepo.On("GetWithdrawalForUpdate", mock.Anything, uint64(7)).Return(withdrawal, nil)
+	mockRepo.On("UpdateWithdrawal", mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("CreateTransition", mock.Anything, isTransition(model.StatusProcessing, model.StatusCompleted)).Return(nil)
+	mockProvider.On("Payout", withdrawal).Return(&provider.PayoutResult{Status: provider.PayoutCompleted, ProviderReference: "TX-1"}, nil)
+	mockWallet.On("CompleteWithdrawal", mock.Anything, "user-1", "THB", decimal.NewFromInt(500), "WITHDRAWAL-7").Return(&walletModel.Wallet{}, nil)
+
+	resolved, err := service.RetryProcessing()
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, resolved)
+	assert.Equal(t, model.StatusCompleted, withdrawal.Status)
+	mockWallet.AssertExpectations(t)
+}